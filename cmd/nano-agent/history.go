@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent/history"
+	"github.com/spf13/cobra"
+)
+
+// History command flags
+var (
+	historySerial string
+	historyOLTID  string
+	historySince  time.Duration
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the ONU history kept by the agent",
+	Long: `Show the optical, traffic and status history of an ONU recorded by the
+agent daemon. History is kept on disk in the config directory, so it is
+available even while the control plane is unreachable. Samples older than a
+day are downsampled to 15 minute averages.
+
+Examples:
+  # Last 24 hours of an ONU
+  nano-agent history --serial HWTC12345678
+
+  # Last week on a specific OLT, as JSON
+  nano-agent history --serial HWTC12345678 --olt olt-1 --since 168h --json`,
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historySerial, "serial", "", "ONU serial number [required]")
+	historyCmd.Flags().StringVar(&historyOLTID, "olt", "", "Only show samples from this OLT ID")
+	historyCmd.Flags().DurationVar(&historySince, "since", 24*time.Hour, "How far back to show")
+	historyCmd.Flags().BoolVar(&outputJSON, "json", false, "Output as JSON")
+	historyCmd.MarkFlagRequired("serial")
+
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	store, err := history.OpenReadOnly(filepath.Join(configDir, history.DefaultDir))
+	if err != nil {
+		return err
+	}
+
+	samples, err := store.Query(history.Query{
+		Serial: historySerial,
+		OLTID:  historyOLTID,
+		From:   time.Now().Add(-historySince),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	if outputJSON {
+		if samples == nil {
+			samples = []history.Sample{}
+		}
+		data, _ := json.MarshalIndent(samples, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(samples) == 0 {
+		fmt.Printf("No history for %s in the last %s.\n", historySerial, historySince)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tOLT\tStatus\tRx dBm\tTx dBm\tTemp °C\tDown Mbps\tUp Mbps")
+	fmt.Fprintln(w, "----\t---\t------\t------\t------\t-------\t---------\t-------")
+	for _, s := range samples {
+		status := s.Status
+		if status == "" {
+			status = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Time.Local().Format("2006-01-02 15:04"),
+			s.OLTID,
+			status,
+			formatReading(s.RxPower),
+			formatReading(s.TxPower),
+			formatReading(s.Temperature),
+			formatMbps(s.InputRateBps),
+			formatMbps(s.OutputRateBps))
+	}
+	w.Flush()
+	return nil
+}
+
+// formatReading formats an optical or temperature reading; zero means no
+// reading.
+func formatReading(v float64) string {
+	if v == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// formatMbps formats a byte rate in megabits per second.
+func formatMbps(bytesPerSecond uint64) string {
+	if bytesPerSecond == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", float64(bytesPerSecond)*8/1e6)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -14,9 +15,12 @@ import (
 
 	"github.com/nanoncore/nano-agent/pkg/agent"
 	"github.com/nanoncore/nano-agent/pkg/agent/command"
+	"github.com/nanoncore/nano-agent/pkg/agent/history"
 	"github.com/nanoncore/nano-agent/pkg/agent/poller"
 	"github.com/nanoncore/nano-agent/pkg/agent/resilience"
+	"github.com/nanoncore/nano-agent/pkg/agent/sink"
 	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/nanoncore/nano-southbound/types"
 	"github.com/spf13/cobra"
 
 	// Import vendor drivers to register them with the CLI factory
@@ -168,6 +172,12 @@ var (
 	configSyncInterval time.Duration
 	enableOLTPolling   bool
 	pollerWorkers      int
+	pollerStaleAfter   int
+	historyRetention   time.Duration
+	auditLogPath       string
+	oltCommandsPerMin  int
+	oltMaxCLISessions  int
+	maxImpactONUs      int
 )
 
 // Login flags
@@ -199,6 +209,18 @@ func init() {
 		"Enable OLT polling for ONU discovery")
 	runCmd.Flags().IntVar(&pollerWorkers, "poller-workers", 5,
 		"Number of concurrent OLT polling workers")
+	runCmd.Flags().IntVar(&pollerStaleAfter, "poller-stale-after", 3,
+		"Missed polling intervals after which an OLT's telemetry is reported stale")
+	runCmd.Flags().DurationVar(&historyRetention, "history-retention", history.DefaultRetention,
+		"How long ONU history is kept on disk (0 disables history)")
+	runCmd.Flags().StringVar(&auditLogPath, "audit-log", "",
+		"Audit log for mutating commands (default <config-dir>/audit.log)")
+	runCmd.Flags().IntVar(&oltCommandsPerMin, "olt-commands-per-minute", command.DefaultGuardConfig().CommandsPerMinute,
+		"Maximum commands per minute per OLT (0 disables)")
+	runCmd.Flags().IntVar(&oltMaxCLISessions, "olt-max-cli-sessions", command.DefaultGuardConfig().MaxCLISessions,
+		"Maximum concurrent command CLI sessions per OLT (0 disables)")
+	runCmd.Flags().IntVar(&maxImpactONUs, "max-impact-onus", command.DefaultGuardConfig().MaxImpactONUs,
+		"Online ONUs a destructive command may affect without confirmImpact (0 disables)")
 
 	// Login flags
 	loginCmd.Flags().StringVar(&loginAPIURL, "api", defaultAPIURL, "Nanoncore API URL")
@@ -847,13 +869,15 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 	// Create OLT poller if enabled
 	var oltPoller *poller.Poller
 	var resilientPusher *resilience.ResilientMetricsPusher
+	var backfillPusher *history.BackfillPusher
 	if enableOLTPolling {
 		pollerCfg := &poller.Config{
-			WorkerCount:    pollerWorkers,
-			CheckInterval:  10 * time.Second,
-			MaxBackoff:     5 * time.Minute,
-			ConnectTimeout: 30 * time.Second,
-			LogPrefix:      "[olt-poller]",
+			WorkerCount:         pollerWorkers,
+			CheckInterval:       10 * time.Second,
+			MaxBackoff:          5 * time.Minute,
+			ConnectTimeout:      30 * time.Second,
+			StaleAfterIntervals: pollerStaleAfter,
+			LogPrefix:           "[olt-poller]",
 		}
 		adapter := poller.NewClientAdapter(client)
 
@@ -865,8 +889,43 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 			resilience.DefaultMetricsBufferConfig(),
 		)
 
+		// Keep ONU history on disk and backfill it after metric push outages
+		var metricsPusher poller.MetricsPusher = resilientPusher
+		var historyStore *history.Store
+		if historyRetention > 0 {
+			historyStore, err = history.Open(filepath.Join(configDir, history.DefaultDir), history.Options{Retention: historyRetention})
+			if err != nil {
+				fmt.Printf("Warning: ONU history disabled: %v\n", err)
+			} else {
+				defer historyStore.Close()
+				backfillPusher = history.NewBackfillPusher(resilientPusher, adapter, historyStore)
+				metricsPusher = backfillPusher
+			}
+		}
+
 		// Use adapter for ONU/telemetry, resilient pusher for metrics
-		oltPoller = poller.New(adapter, adapter, resilientPusher, pollerCfg)
+		oltPoller = poller.New(adapter, adapter, metricsPusher, pollerCfg)
+		oltPoller.SetAutofindPusher(adapter)
+		oltPoller.SetPortPusher(adapter)
+		if historyStore != nil {
+			oltPoller.AddSink(historyStore)
+		}
+
+		// Also write poll results to the sinks in the daemon config
+		for _, sinkCfg := range cfg.Sinks {
+			if sinkCfg.Disabled {
+				continue
+			}
+			resultSink, err := sink.New(sinkCfg)
+			if err != nil {
+				fmt.Printf("Warning: Sink %q disabled: %v\n", sinkCfg.Name, err)
+				continue
+			}
+			defer resultSink.Close()
+			oltPoller.AddSink(resultSink)
+			fmt.Printf("[%s] Poll results also written to %s sink %q\n", time.Now().Format("15:04:05"), sinkCfg.Type, resultSink.Name())
+		}
+
 		oltPoller.Start(ctx)
 		fmt.Printf("[%s] OLT poller started with %d workers (metrics resilience enabled)\n", time.Now().Format("15:04:05"), pollerWorkers)
 	}
@@ -883,6 +942,101 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 			return err
 		})
 	}
+
+	// Record every mutating command with its pre/post state diff
+	if auditLogPath == "" {
+		auditLogPath = filepath.Join(configDir, command.DefaultAuditLogFile)
+	}
+	auditLog, err := command.OpenAuditLog(auditLogPath)
+	if err != nil {
+		fmt.Printf("Warning: Command audit log disabled: %v\n", err)
+	} else {
+		defer auditLog.Close()
+		cmdExecutor.SetAuditLog(auditLog)
+		fmt.Printf("[%s] Command audit log: %s\n", time.Now().Format("15:04:05"), auditLogPath)
+	}
+
+	// Restrict cli_exec to the operator-configured policy (read-only by default)
+	cliPolicyPath := filepath.Join(configDir, command.DefaultCLIPolicyFile)
+	cliPolicy, err := command.LoadCLIPolicy(cliPolicyPath)
+	if err != nil {
+		fmt.Printf("Warning: Invalid CLI policy %s, using read-only default: %v\n", cliPolicyPath, err)
+		cliPolicy = command.DefaultCLIPolicy()
+	}
+	cmdExecutor.SetCLIPolicy(cliPolicy)
+
+	// Cache service templates so onu_provision can use them while the control
+	// plane is unreachable
+	templates, err := command.OpenServiceTemplates(filepath.Join(configDir, command.DefaultServiceTemplateFile))
+	if err != nil {
+		fmt.Printf("Warning: Failed to load cached service templates, starting empty: %v\n", err)
+		templates = command.NewServiceTemplates()
+	}
+	cmdExecutor.SetServiceTemplates(templates)
+
+	// Persist scheduled commands so they survive restarts
+	schedule, err := command.OpenSchedule(filepath.Join(configDir, command.DefaultScheduleFile))
+	if err != nil {
+		fmt.Printf("Warning: Failed to load command schedule, starting empty: %v\n", err)
+		schedule = command.NewSchedule()
+	} else if pending := len(schedule.List()); pending > 0 {
+		fmt.Printf("[%s] Loaded %d scheduled commands\n", time.Now().Format("15:04:05"), pending)
+	}
+	cmdExecutor.SetSchedule(schedule)
+	scheduleTicker := time.NewTicker(30 * time.Second)
+	defer scheduleTicker.Stop()
+
+	// Protect OLTs from command floods and large-impact destructive commands
+	guardCfg := command.DefaultGuardConfig()
+	guardCfg.CommandsPerMinute = oltCommandsPerMin
+	guardCfg.MaxCLISessions = oltMaxCLISessions
+	guardCfg.MaxImpactONUs = maxImpactONUs
+	cmdExecutor.SetGuardConfig(guardCfg)
+	if oltPoller != nil {
+		cmdExecutor.SetONUSnapshot(func(oltID string) ([]command.ONUSnapshot, time.Time, bool) {
+			onus, at, ok := oltPoller.ONUSnapshot(oltID)
+			snapshot := make([]command.ONUSnapshot, 0, len(onus))
+			for _, onu := range onus {
+				snapshot = append(snapshot, command.ONUSnapshot{
+					PONPort: onu.PONPort,
+					ONUID:   onu.ONUID,
+					Serial:  onu.Serial,
+					Status:  onu.Status,
+					VLAN:    onu.VLAN,
+				})
+			}
+			return snapshot, at, ok
+		})
+	}
+
+	// Report command-driven events such as auto-provisioning results
+	cmdExecutor.SetEventEmitter(func(event *agent.EmitEventRequest) error {
+		event.NodeID = cfg.NodeID
+		_, err := client.EmitEvent(event)
+		return err
+	})
+
+	// Apply the auto-provisioning policy to ONUs appearing in autofind on
+	// OLTs with discovery enabled
+	autoProvisionPath := filepath.Join(configDir, command.DefaultAutoProvisionFile)
+	autoProvisionPolicy, err := command.LoadAutoProvisionPolicy(autoProvisionPath)
+	if err != nil {
+		fmt.Printf("Warning: Auto-provisioning disabled, invalid policy %s: %v\n", autoProvisionPath, err)
+	} else if autoProvisionPolicy.Enabled() {
+		cmdExecutor.SetAutoProvisionPolicy(autoProvisionPolicy)
+		if oltPoller != nil {
+			oltPoller.SetDiscoveryHandler(func(oltID string, discoveries []types.ONUDiscovery) {
+				go func() {
+					runCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+					defer cancel()
+					cmdExecutor.AutoProvision(runCtx, oltID, discoveries)
+				}()
+			})
+			fmt.Printf("[%s] Auto-provisioning policy: %s\n", time.Now().Format("15:04:05"), autoProvisionPath)
+		} else {
+			fmt.Printf("Warning: Auto-provisioning needs OLT polling enabled\n")
+		}
+	}
 	fmt.Printf("[%s] Command executor initialized\n", time.Now().Format("15:04:05"))
 
 	// Send initial heartbeat
@@ -899,6 +1053,9 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 			if oltPoller != nil {
 				oltPoller.Stop()
 			}
+			if backfillPusher != nil {
+				backfillPusher.Stop()
+			}
 			if resilientPusher != nil {
 				resilientPusher.Stop()
 			}
@@ -911,6 +1068,10 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 			if oltPoller != nil {
 				oltPoller.Stop()
 			}
+			// Stop history backfill before the pushers it uses
+			if backfillPusher != nil {
+				backfillPusher.Stop()
+			}
 			// Stop resilient pusher (flushes buffered metrics)
 			if resilientPusher != nil {
 				resilientPusher.Stop()
@@ -929,6 +1090,13 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 
 		case <-configSyncTicker.C:
 			syncConfigWithPoller(ctx, client, cfg.NodeID, state, cfg, oltPoller, cmdExecutor)
+
+		case <-scheduleTicker.C:
+			go func() {
+				runCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+				defer cancel()
+				_ = cmdExecutor.RunScheduled(runCtx)
+			}()
 		}
 	}
 }
@@ -998,6 +1166,23 @@ func syncConfigWithPoller(ctx context.Context, client *agent.Client, nodeID stri
 	// Update command executor with OLT configs
 	if cmdExecutor != nil && len(oltConfig.OLTs) > 0 {
 		cmdExecutor.UpdateOLTConfigs(oltConfig.OLTs)
+
+		// Publish per-OLT command capabilities so the UI can hide unsupported actions
+		capsReq := &agent.CapabilitiesRequest{
+			NodeID:       nodeID,
+			CommandTypes: cmdExecutor.CommandTypes(),
+			OLTs:         cmdExecutor.Capabilities(),
+		}
+		if err := client.PushCapabilities(nodeID, capsReq); err != nil {
+			fmt.Printf("[%s] Failed to publish capabilities: %v\n", time.Now().Format("15:04:05"), err)
+		}
+	}
+
+	// Refresh cached service templates; an absent list keeps the cache
+	if cmdExecutor != nil && oltConfig.ServiceTemplates != nil {
+		if err := cmdExecutor.UpdateServiceTemplates(oltConfig.ServiceTemplates); err != nil {
+			fmt.Printf("[%s] Failed to update service templates: %v\n", time.Now().Format("15:04:05"), err)
+		}
 	}
 
 	// Process pending commands
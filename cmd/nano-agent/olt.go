@@ -34,6 +34,15 @@ var (
 	outputJSON     bool
 )
 
+// SNMPv3 flags
+var (
+	oltSNMPUser         string
+	oltSNMPAuthProtocol string
+	oltSNMPAuthPassword string
+	oltSNMPPrivProtocol string
+	oltSNMPPrivPassword string
+)
+
 // Discover flags
 var (
 	discoverPONPorts []string
@@ -245,6 +254,28 @@ var (
 	onuUpdateForce          bool
 )
 
+// ONU move flags
+var (
+	onuMovePONPort        string
+	onuMoveONUID          int
+	onuMoveTargetPONPort  string
+	onuMoveTargetONUID    int
+	onuMoveLineProfile    string
+	onuMoveServiceProfile string
+	onuMoveDescription    string
+	onuMoveForce          bool
+)
+
+// ONU replace flags
+var (
+	onuReplacePONPort    string
+	onuReplaceONUID      int
+	onuReplaceNewSerial  string
+	onuReplaceOldSerial  string
+	onuReplaceONUProfile string
+	onuReplaceForce      bool
+)
+
 // Port management flags
 var (
 	portPONPort string
@@ -428,6 +459,56 @@ Examples:
 	RunE: runONUUpdate,
 }
 
+var onuMoveCmd = &cobra.Command{
+	Use:   "onu-move",
+	Short: "Move an ONU to another PON port",
+	Long: `Move a provisioned ONU to another PON port on the same OLT.
+
+The current profiles, VLAN and bandwidth are read from the OLT and
+re-provisioned on the target port. The serial number cannot be registered
+twice, so the ONU is deleted from its current port first; if it does not come
+online on the target it is provisioned back where it was.
+
+To move an ONU to a different OLT, send an onu_move command with
+targetEquipmentId through the control plane.
+
+WARNING: The ONU is out of service during the move. Use --force to confirm.
+
+Examples:
+  # Move ONU 5 from PON 0/1 to PON 0/2, keeping its ONU ID
+  nano-agent onu-move --pon-port 0/1 --onu-id 5 --target-pon-port 0/2 --force \
+    --vendor vsol --address 10.0.0.254 --username admin --password admin
+
+  # Move and change the line profile
+  nano-agent onu-move --pon-port 0/0/1 --onu-id 5 --target-pon-port 0/0/3 \
+    --target-onu-id 12 --line-profile line_vlan_200 --force \
+    --vendor huawei --address 192.168.1.1`,
+	RunE: runONUMove,
+}
+
+var onuReplaceCmd = &cobra.Command{
+	Use:   "onu-replace",
+	Short: "Bind an ONU entry to a new serial number",
+	Long: `Replace the ONU behind a PON port/ONU ID with a new one, keeping the ONU ID,
+profiles, VLAN and bandwidth.
+
+On Huawei OLTs that accept raw CLI commands the serial is changed in place
+with "ont modify ... sn". Otherwise the entry is deleted and re-provisioned
+with the same configuration for the new serial; if that fails the old serial
+is provisioned back. V-SOL needs the ONU hardware profile to re-provision an
+explicit ONU ID; pass --onu-profile if the OLT does not report it.
+
+WARNING: The ONU entry is briefly removed on vendors without in-place
+replacement. Use --force to confirm.
+
+Examples:
+  # Replace a dead ONT
+  nano-agent onu-replace --pon-port 0/0/1 --onu-id 5 \
+    --old-serial HWTC12345678 --new-serial HWTC87654321 --force \
+    --vendor huawei --address 192.168.1.1`,
+	RunE: runONUReplace,
+}
+
 var profileONUCmd = &cobra.Command{
 	Use:   "profile-onu",
 	Short: "Manage ONU hardware profiles",
@@ -608,7 +689,7 @@ func init() {
 	// Common OLT connection flags for all OLT commands
 	oltCommands := []*cobra.Command{
 		discoverCmd, diagnoseCmd, oltStatusCmd, oltAlarmsCmd, oltHealthCheckCmd, onuListCmd,
-		onuInfoCmd, onuProvisionCmd, onuDeleteCmd, onuSuspendCmd, onuResumeCmd, onuBulkProvisionCmd, onuRebootCmd, onuUpdateCmd,
+		onuInfoCmd, onuProvisionCmd, onuDeleteCmd, onuSuspendCmd, onuResumeCmd, onuBulkProvisionCmd, onuRebootCmd, onuUpdateCmd, onuMoveCmd, onuReplaceCmd,
 		profileONUCmd, profileONUListCmd, profileONUGetCmd, profileONUCreateCmd, profileONUDeleteCmd,
 		profileLineCmd, profileLineListCmd, profileLineGetCmd, profileLineCreateCmd, profileLineDeleteCmd,
 		portListCmd, portEnableCmd, portDisableCmd, portPowerCmd, servicePortListCmd,
@@ -622,6 +703,11 @@ func init() {
 		cmd.Flags().StringVar(&oltPassword, "password", "", "OLT password (required for CLI/NETCONF)")
 		cmd.Flags().StringVar(&oltCommunity, "community", "", "SNMP community string (required for SNMP)")
 		cmd.Flags().StringVar(&oltSNMPVersion, "snmp-version", "2c", "SNMP version (1, 2c, 3)")
+		cmd.Flags().StringVar(&oltSNMPUser, "snmp-user", "", "SNMPv3 username (required for SNMPv3)")
+		cmd.Flags().StringVar(&oltSNMPAuthProtocol, "snmp-auth-protocol", "", "SNMPv3 auth protocol (MD5, SHA, SHA224, SHA256, SHA384, SHA512)")
+		cmd.Flags().StringVar(&oltSNMPAuthPassword, "snmp-auth-password", "", "SNMPv3 auth password")
+		cmd.Flags().StringVar(&oltSNMPPrivProtocol, "snmp-priv-protocol", "", "SNMPv3 privacy protocol (DES, AES, AES192, AES256)")
+		cmd.Flags().StringVar(&oltSNMPPrivPassword, "snmp-priv-password", "", "SNMPv3 privacy password")
 		cmd.Flags().BoolVar(&oltTLS, "tls", false, "Enable TLS")
 		cmd.Flags().BoolVar(&oltTLSSkipVe, "tls-skip-verify", false, "Skip TLS verification (insecure)")
 		cmd.Flags().BoolVar(&outputJSON, "json", false, "Output as JSON")
@@ -700,6 +786,30 @@ func init() {
 	onuUpdateCmd.MarkFlagRequired("pon-port")
 	onuUpdateCmd.MarkFlagRequired("onu-id")
 
+	// ONU move flags
+	onuMoveCmd.Flags().StringVar(&onuMovePONPort, "pon-port", "", "Current PON port [required]")
+	onuMoveCmd.Flags().IntVar(&onuMoveONUID, "onu-id", 0, "Current ONU ID [required]")
+	onuMoveCmd.Flags().StringVar(&onuMoveTargetPONPort, "target-pon-port", "", "Target PON port [required]")
+	onuMoveCmd.Flags().IntVar(&onuMoveTargetONUID, "target-onu-id", 0, "Target ONU ID (default: current ONU ID)")
+	onuMoveCmd.Flags().StringVar(&onuMoveLineProfile, "line-profile", "", "Line profile on the target (default: current)")
+	onuMoveCmd.Flags().StringVar(&onuMoveServiceProfile, "service-profile", "", "Service profile on the target (default: current)")
+	onuMoveCmd.Flags().StringVar(&onuMoveDescription, "description", "", "Description on the target (optional)")
+	onuMoveCmd.Flags().BoolVar(&onuMoveForce, "force", false, "Confirm the move")
+	onuMoveCmd.MarkFlagRequired("pon-port")
+	onuMoveCmd.MarkFlagRequired("onu-id")
+	onuMoveCmd.MarkFlagRequired("target-pon-port")
+
+	// ONU replace flags
+	onuReplaceCmd.Flags().StringVar(&onuReplacePONPort, "pon-port", "", "PON port [required]")
+	onuReplaceCmd.Flags().IntVar(&onuReplaceONUID, "onu-id", 0, "ONU ID [required]")
+	onuReplaceCmd.Flags().StringVar(&onuReplaceNewSerial, "new-serial", "", "Serial number of the replacement ONU [required]")
+	onuReplaceCmd.Flags().StringVar(&onuReplaceOldSerial, "old-serial", "", "Expected current serial number (optional safety check)")
+	onuReplaceCmd.Flags().StringVar(&onuReplaceONUProfile, "onu-profile", "", "ONU hardware profile (default: read from the OLT)")
+	onuReplaceCmd.Flags().BoolVar(&onuReplaceForce, "force", false, "Confirm the replacement")
+	onuReplaceCmd.MarkFlagRequired("pon-port")
+	onuReplaceCmd.MarkFlagRequired("onu-id")
+	onuReplaceCmd.MarkFlagRequired("new-serial")
+
 	// Profile ONU create flags
 	profileONUCreateCmd.Flags().IntVar(&profileONUPortEth, "port-eth", 0, "Number of Ethernet ports (1-255)")
 	profileONUCreateCmd.Flags().IntVar(&profileONUPortPots, "port-pots", 0, "Number of POTS ports (1-255)")
@@ -755,6 +865,8 @@ func init() {
 	rootCmd.AddCommand(onuBulkProvisionCmd)
 	rootCmd.AddCommand(onuRebootCmd)
 	rootCmd.AddCommand(onuUpdateCmd)
+	rootCmd.AddCommand(onuMoveCmd)
+	rootCmd.AddCommand(onuReplaceCmd)
 	rootCmd.AddCommand(profileONUCmd)
 	profileONUCmd.AddCommand(profileONUListCmd)
 	profileONUCmd.AddCommand(profileONUGetCmd)
@@ -779,8 +891,8 @@ func createOLTDriver() (types.Driver, error) {
 
 	// Validate credentials based on protocol
 	if protocol == types.ProtocolSNMP {
-		if oltCommunity == "" {
-			return nil, fmt.Errorf("--community is required for SNMP protocol")
+		if oltCommunity == "" && !isSNMPv3Flags() {
+			return nil, fmt.Errorf("--community is required for SNMP protocol (or --snmp-user for SNMPv3)")
 		}
 	} else {
 		// CLI, NETCONF, GNMI require username/password
@@ -805,10 +917,9 @@ func createOLTDriver() (types.Driver, error) {
 		Metadata:      make(map[string]string),
 	}
 
-	// Add SNMP community to metadata for drivers that read it from there
-	if protocol == types.ProtocolSNMP {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if _, err := applySNMPMetadata(config); err != nil {
+		return nil, err
 	}
 	if protocol == types.ProtocolCLI {
 		// Prefer CLI execution when explicitly using CLI protocol (avoid slow SNMP walks).
@@ -859,10 +970,9 @@ func runDiscover(cmd *cobra.Command, args []string) error {
 		Timeout:       60 * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	// Add SNMP metadata for drivers that read it from there
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		return err
 	}
 
 	if !outputJSON {
@@ -986,10 +1096,9 @@ func runDiagnose(cmd *cobra.Command, args []string) error {
 		Timeout:       60 * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	// Add SNMP metadata for drivers that read it from there
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		return err
 	}
 
 	if !outputJSON {
@@ -1163,10 +1272,9 @@ func runOLTStatus(cmd *cobra.Command, args []string) error {
 		Timeout:       60 * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	// Add SNMP metadata for drivers that read it from there
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		return err
 	}
 
 	if !outputJSON {
@@ -1306,9 +1414,9 @@ func runOLTAlarms(cmd *cobra.Command, args []string) error {
 		Timeout:       60 * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		return err
 	}
 
 	if !outputJSON {
@@ -1409,9 +1517,9 @@ func runOLTHealthCheck(cmd *cobra.Command, args []string) error {
 		Timeout:       60 * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		return err
 	}
 
 	if !outputJSON {
@@ -1591,10 +1699,9 @@ func runONUList(cmd *cobra.Command, args []string) error {
 		Timeout:       60 * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	// Add SNMP metadata for drivers that read it from there
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		return err
 	}
 
 	if !outputJSON {
@@ -2622,6 +2729,215 @@ func runONUUpdate(cmd *cobra.Command, args []string) error {
 	return outputUpdateResult(preONU, postONU, onuUpdateVLAN, onuUpdateTrafficProfile)
 }
 
+func runONUReplace(cmd *cobra.Command, args []string) error {
+	newSerial := strings.ToUpper(onuReplaceNewSerial)
+	if err := validateSerialNumber(newSerial); err != nil {
+		return err
+	}
+	if !onuReplaceForce {
+		return fmt.Errorf("this replaces the ONU bound to %s ONU %d; use --force to confirm", onuReplacePONPort, onuReplaceONUID)
+	}
+
+	printReplaceHeader(onuReplacePONPort, onuReplaceONUID, newSerial)
+
+	conn, err := connectToOLT(180)
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	driverV2, err := conn.getDriverV2()
+	if err != nil {
+		return err
+	}
+
+	preONU, err := lookupONUByPortID(conn.ctx, driverV2, onuReplacePONPort, onuReplaceONUID)
+	if err != nil {
+		return err
+	}
+	if onuReplaceOldSerial != "" && !strings.EqualFold(preONU.Serial, onuReplaceOldSerial) {
+		return fmt.Errorf("%s ONU %d has serial %s, not %s", onuReplacePONPort, onuReplaceONUID, preONU.Serial, onuReplaceOldSerial)
+	}
+	if strings.EqualFold(preONU.Serial, newSerial) {
+		return fmt.Errorf("%s ONU %d already has serial %s", onuReplacePONPort, onuReplaceONUID, newSerial)
+	}
+	if !outputJSON {
+		printONUSummary(preONU)
+		printServiceConfig(preONU)
+	}
+
+	method := "in_place"
+	if executor, ok := conn.driver.(types.CLIExecutor); ok && strings.EqualFold(oltVendor, "huawei") {
+		err = executeReplaceInPlace(conn.ctx, executor, onuReplacePONPort, onuReplaceONUID, newSerial)
+	} else {
+		method = "reprovision"
+		onuProfile := onuReplaceONUProfile
+		if onuProfile == "" {
+			onuProfile = preONU.ONUProfile
+		}
+		// The entry is deleted before re-provisioning, which V-SOL rejects
+		// for an explicit ONU ID without a hardware profile
+		if onuProfile == "" && strings.EqualFold(oltVendor, "vsol") {
+			return fmt.Errorf("the OLT did not report the ONU hardware profile; pass --onu-profile")
+		}
+		err = executeReplaceReprovision(conn.ctx, conn.driver, driverV2, preONU, newSerial, onuProfile)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Verify the entry is bound to the new serial (NAN-257)
+	if !outputJSON {
+		fmt.Printf("Verifying serial... ")
+	}
+	verifyFunc := func() (bool, error) {
+		onu, lookupErr := lookupONUByPortID(conn.ctx, driverV2, onuReplacePONPort, onuReplaceONUID)
+		if lookupErr != nil {
+			return false, nil
+		}
+		return strings.EqualFold(onu.Serial, newSerial), nil
+	}
+	if err := verifyONUChange(conn.ctx, verifyFunc, 3, 2*time.Second); err != nil {
+		if !outputJSON {
+			fmt.Printf("FAILED\n")
+		}
+		return fmt.Errorf("serial verification failed: %w", err)
+	}
+	if !outputJSON {
+		fmt.Printf("OK\n")
+	}
+
+	return outputReplaceResult(preONU, newSerial, method)
+}
+
+// executeReplaceReprovision deletes the ONU entry and provisions the same
+// configuration for the new serial, restoring the old serial on failure.
+func executeReplaceReprovision(ctx context.Context, driver types.Driver, driverV2 types.DriverV2, preONU *types.ONUInfo, newSerial, onuProfile string) error {
+	if err := executeDelete(ctx, driver, preONU.Serial, preONU.PONPort, preONU.ONUID); err != nil {
+		return err
+	}
+	if err := verifyONUDeletion(ctx, driverV2, preONU.PONPort, preONU.ONUID); err != nil {
+		return fmt.Errorf("failed to verify ONU deletion: %w", err)
+	}
+
+	// Wait for OLT to process deletion
+	time.Sleep(2 * time.Second)
+
+	subscriber, tier := buildProvisionModelsFromUpdate(
+		preONU, newSerial, preONU.PONPort, preONU.ONUID,
+		preONU.LineProfile, preONU.ServiceProfile, preONU.VLAN, 0, "")
+	setONUProfile(subscriber, onuProfile)
+	_, provisionErr := executeProvision(ctx, driver, subscriber, tier, preONU.LineProfile != "")
+	if provisionErr == nil {
+		return nil
+	}
+
+	if !outputJSON {
+		fmt.Printf("\nReplacement failed, restoring serial %s...\n", preONU.Serial)
+	}
+	if _, lookupErr := lookupONUByPortID(ctx, driverV2, preONU.PONPort, preONU.ONUID); lookupErr == nil {
+		if err := executeDelete(ctx, driver, newSerial, preONU.PONPort, preONU.ONUID); err != nil {
+			return fmt.Errorf("replacement failed: %w; removing the new entry also failed: %v", provisionErr, err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+	restoreSubscriber, restoreTier := buildProvisionModelsFromUpdate(
+		preONU, preONU.Serial, preONU.PONPort, preONU.ONUID,
+		preONU.LineProfile, preONU.ServiceProfile, preONU.VLAN, 0, "")
+	setONUProfile(restoreSubscriber, onuProfile)
+	if _, err := executeProvision(ctx, driver, restoreSubscriber, restoreTier, preONU.LineProfile != ""); err != nil {
+		return fmt.Errorf("replacement failed: %w; restoring serial %s also failed: %v", provisionErr, preONU.Serial, err)
+	}
+	return fmt.Errorf("replacement failed, serial %s restored: %w", preONU.Serial, provisionErr)
+}
+
+func runONUMove(cmd *cobra.Command, args []string) error {
+	targetONUID := onuMoveTargetONUID
+	if targetONUID == 0 {
+		targetONUID = onuMoveONUID
+	}
+	if onuMoveTargetPONPort == onuMovePONPort && targetONUID == onuMoveONUID {
+		return fmt.Errorf("target is the ONU's current location")
+	}
+	if !onuMoveForce {
+		return fmt.Errorf("the ONU is out of service during the move; use --force to confirm")
+	}
+
+	printMoveHeader(onuMovePONPort, onuMoveONUID, onuMoveTargetPONPort, targetONUID)
+
+	conn, err := connectToOLT(180)
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	driverV2, err := conn.getDriverV2()
+	if err != nil {
+		return err
+	}
+
+	// Read the current configuration before anything is removed
+	preONU, err := lookupONUByPortID(conn.ctx, driverV2, onuMovePONPort, onuMoveONUID)
+	if err != nil {
+		return err
+	}
+	if preONU.Serial == "" {
+		return fmt.Errorf("cannot move: ONU serial number not found")
+	}
+	if !outputJSON {
+		printONUSummary(preONU)
+		printServiceConfig(preONU)
+	}
+
+	lineProfile := onuMoveLineProfile
+	if lineProfile == "" {
+		lineProfile = preONU.LineProfile
+	}
+	serviceProfile := onuMoveServiceProfile
+	if serviceProfile == "" {
+		serviceProfile = preONU.ServiceProfile
+	}
+
+	// Step 1: Remove the ONU from its current port (serials are unique per OLT)
+	if err := executeDelete(conn.ctx, conn.driver, preONU.Serial, onuMovePONPort, onuMoveONUID); err != nil {
+		return err
+	}
+	if err := verifyONUDeletion(conn.ctx, driverV2, onuMovePONPort, onuMoveONUID); err != nil {
+		return fmt.Errorf("failed to verify ONU deletion: %w", err)
+	}
+
+	// Wait for OLT to process deletion
+	time.Sleep(2 * time.Second)
+
+	// Step 2: Provision on the target port and wait for it to come online
+	subscriber, tier := buildProvisionModelsFromUpdate(
+		preONU, preONU.Serial, onuMoveTargetPONPort, targetONUID,
+		lineProfile, serviceProfile, preONU.VLAN, 0, onuMoveDescription)
+	_, provisionErr := executeProvision(conn.ctx, conn.driver, subscriber, tier, lineProfile != "")
+	if provisionErr == nil {
+		return outputMoveResult(preONU, onuMoveTargetPONPort, targetONUID)
+	}
+
+	// Step 3: Roll back - remove whatever reached the target and restore the source
+	if !outputJSON {
+		fmt.Printf("\nMove failed, restoring ONU on %s ONU %d...\n", onuMovePONPort, onuMoveONUID)
+	}
+	if _, lookupErr := lookupONUByPortID(conn.ctx, driverV2, onuMoveTargetPONPort, targetONUID); lookupErr == nil {
+		if err := executeDelete(conn.ctx, conn.driver, preONU.Serial, onuMoveTargetPONPort, targetONUID); err != nil {
+			return fmt.Errorf("move failed: %w; removing target entry also failed: %v", provisionErr, err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+	restoreSubscriber, restoreTier := buildProvisionModelsFromUpdate(
+		preONU, preONU.Serial, onuMovePONPort, onuMoveONUID,
+		preONU.LineProfile, preONU.ServiceProfile, preONU.VLAN, 0, "")
+	if _, err := executeProvision(conn.ctx, conn.driver, restoreSubscriber, restoreTier, preONU.LineProfile != ""); err != nil {
+		return fmt.Errorf("move failed: %w; restoring the ONU on %s ONU %d also failed: %v",
+			provisionErr, onuMovePONPort, onuMoveONUID, err)
+	}
+	return fmt.Errorf("move failed, ONU restored on %s ONU %d: %w", onuMovePONPort, onuMoveONUID, provisionErr)
+}
+
 func runPortList(cmd *cobra.Command, args []string) error {
 	if !outputJSON {
 		fmt.Printf("Port List\n")
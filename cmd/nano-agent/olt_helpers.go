@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nanoncore/nano-agent/pkg/snmp"
 	"github.com/nanoncore/nano-southbound/model"
 	"github.com/nanoncore/nano-southbound/types"
 )
@@ -49,10 +50,10 @@ func connectToOLT(timeoutSecs int) (*oltConnection, error) {
 		Timeout:       time.Duration(timeoutSecs) * time.Second,
 		Metadata:      make(map[string]string),
 	}
-	// Add SNMP metadata for drivers that read it from there
-	if oltProtocol == "snmp" {
-		config.Metadata["snmp_community"] = oltCommunity
-		config.Metadata["snmp_version"] = oltSNMPVersion
+	// Add SNMP settings to metadata for drivers that read them from there
+	if err := applySNMPFlags(config); err != nil {
+		cancel()
+		return nil, err
 	}
 	if strings.ToLower(oltProtocol) == "cli" {
 		config.Metadata["prefer_cli"] = "true"
@@ -105,6 +106,72 @@ func (c *oltConnection) close() {
 	}
 }
 
+// cliSNMPConfig returns the SNMP settings given by the connection flags,
+// rejecting bad SNMPv3 credentials before connecting.
+func cliSNMPConfig() (snmp.DeviceConfig, error) {
+	version, err := snmp.ParseVersion(oltSNMPVersion)
+	if err != nil {
+		return snmp.DeviceConfig{}, fmt.Errorf("invalid --snmp-version: %w", err)
+	}
+	device := snmp.DeviceConfig{
+		Host:         oltAddress,
+		Community:    oltCommunity,
+		Version:      version,
+		Username:     oltSNMPUser,
+		AuthProtocol: oltSNMPAuthProtocol,
+		AuthPassword: oltSNMPAuthPassword,
+		PrivProtocol: oltSNMPPrivProtocol,
+		PrivPassword: oltSNMPPrivPassword,
+	}
+	if err := device.Validate(); err != nil {
+		return snmp.DeviceConfig{}, fmt.Errorf("invalid SNMPv3 flags: %w", err)
+	}
+	return device, nil
+}
+
+// isSNMPv3Flags reports whether the connection flags select SNMPv3.
+func isSNMPv3Flags() bool {
+	version, err := snmp.ParseVersion(oltSNMPVersion)
+	return err == nil && version == snmp.SNMPv3
+}
+
+// applySNMPMetadata sets the SNMP settings of an SNMP session for drivers
+// that read them from metadata. It leaves the login alone, so it is used for
+// the config a driver is created with: vendor adapters open secondary CLI
+// sessions with that login.
+func applySNMPMetadata(config *types.EquipmentConfig) (snmp.DeviceConfig, error) {
+	if config.Protocol != types.ProtocolSNMP {
+		return snmp.DeviceConfig{}, nil
+	}
+	device, err := cliSNMPConfig()
+	if err != nil {
+		return snmp.DeviceConfig{}, err
+	}
+	metadata := device.DriverMetadata()
+	for k, v := range metadata {
+		config.Metadata[k] = v
+	}
+	config.SNMPVersion = metadata["snmp_version"]
+	return device, nil
+}
+
+// applySNMPFlags sets the SNMP settings of the config an SNMP session is
+// connected with. The SNMP driver authenticates SNMPv3 with the equipment
+// credentials, so they are replaced by the SNMPv3 user.
+func applySNMPFlags(config *types.EquipmentConfig) error {
+	device, err := applySNMPMetadata(config)
+	if err != nil {
+		return err
+	}
+	if device.Version == snmp.SNMPv3 {
+		config.Username, config.Password, err = device.DriverCredentials()
+		if err != nil {
+			return fmt.Errorf("invalid SNMPv3 flags: %w", err)
+		}
+	}
+	return nil
+}
+
 // =============================================================================
 // ONU Lookup Helpers
 // =============================================================================
@@ -976,6 +1043,152 @@ func extractVLANFromProfileName(profileName string) (int, error) {
 	return 0, fmt.Errorf("VLAN not found in profile name %q (expected format: line_vlan_XXX or vlan_XXX)", profileName)
 }
 
+// =============================================================================
+// Move Helpers
+// =============================================================================
+
+// printMoveHeader prints the move command header
+func printMoveHeader(ponPort string, onuID int, targetPONPort string, targetONUID int) {
+	if outputJSON {
+		return
+	}
+	fmt.Printf("ONU Move\n")
+	fmt.Printf("========\n\n")
+	fmt.Printf("OLT:  %s (%s)\n", oltAddress, oltVendor)
+	fmt.Printf("From: %s ONU %d\n", ponPort, onuID)
+	fmt.Printf("To:   %s ONU %d\n\n", targetPONPort, targetONUID)
+}
+
+// outputMoveResult outputs move results
+func outputMoveResult(preONU *types.ONUInfo, targetPONPort string, targetONUID int) error {
+	if outputJSON {
+		output := struct {
+			Status        string `json:"status"`
+			Serial        string `json:"serial"`
+			FromPONPort   string `json:"from_pon_port"`
+			FromONUID     int    `json:"from_onu_id"`
+			TargetPONPort string `json:"pon_port"`
+			TargetONUID   int    `json:"onu_id"`
+		}{
+			Status:        "moved",
+			Serial:        preONU.Serial,
+			FromPONPort:   preONU.PONPort,
+			FromONUID:     preONU.ONUID,
+			TargetPONPort: targetPONPort,
+			TargetONUID:   targetONUID,
+		}
+		data, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("\nMove Complete\n")
+	fmt.Printf("-------------\n")
+	fmt.Printf("  Serial:          %s\n", preONU.Serial)
+	fmt.Printf("  From:            %s ONU %d\n", preONU.PONPort, preONU.ONUID)
+	fmt.Printf("  To:              %s ONU %d\n", targetPONPort, targetONUID)
+	fmt.Printf("\nONU is online on the target port.\n")
+	return nil
+}
+
+// =============================================================================
+// Replace Helpers
+// =============================================================================
+
+// setONUProfile sets the ONU hardware profile annotation of a subscriber built
+// by buildProvisionModelsFromUpdate.
+func setONUProfile(subscriber *model.Subscriber, onuProfile string) {
+	if onuProfile != "" {
+		subscriber.Annotations["nano.io/onu-profile"] = onuProfile
+	}
+}
+
+// printReplaceHeader prints the replace command header
+func printReplaceHeader(ponPort string, onuID int, newSerial string) {
+	if outputJSON {
+		return
+	}
+	fmt.Printf("ONU Replacement\n")
+	fmt.Printf("===============\n\n")
+	fmt.Printf("OLT:        %s (%s)\n", oltAddress, oltVendor)
+	fmt.Printf("ONU:        %s ONU %d\n", ponPort, onuID)
+	fmt.Printf("New serial: %s\n\n", newSerial)
+}
+
+// executeReplaceInPlace re-binds a Huawei ONT to a new serial with ont modify
+func executeReplaceInPlace(ctx context.Context, executor types.CLIExecutor, ponPort string, onuID int, newSerial string) error {
+	if !outputJSON {
+		fmt.Printf("Changing serial in place... ")
+	}
+	parts := strings.Split(ponPort, "/")
+	if len(parts) != 3 {
+		if !outputJSON {
+			fmt.Printf("FAILED\n")
+		}
+		return fmt.Errorf("invalid PON port %q (expected frame/slot/port)", ponPort)
+	}
+	commands := []string{
+		"enable",
+		"config",
+		fmt.Sprintf("interface gpon %s/%s", parts[0], parts[1]),
+		fmt.Sprintf("ont modify %s %d sn %s", parts[2], onuID, newSerial),
+		"quit",
+		"quit",
+	}
+	outputs, err := executor.ExecCommands(ctx, commands)
+	if err == nil {
+		for _, output := range outputs {
+			lower := strings.ToLower(output)
+			if strings.Contains(lower, "error") || strings.Contains(lower, "failure") {
+				err = fmt.Errorf("%s", strings.TrimSpace(output))
+				break
+			}
+		}
+	}
+	if err != nil {
+		if !outputJSON {
+			fmt.Printf("FAILED\n")
+		}
+		return fmt.Errorf("serial replacement failed: %w", err)
+	}
+	if !outputJSON {
+		fmt.Printf("OK\n\n")
+	}
+	return nil
+}
+
+// outputReplaceResult outputs replacement results
+func outputReplaceResult(preONU *types.ONUInfo, newSerial, method string) error {
+	if outputJSON {
+		output := struct {
+			Status    string `json:"status"`
+			Method    string `json:"method"`
+			PONPort   string `json:"pon_port"`
+			ONUID     int    `json:"onu_id"`
+			OldSerial string `json:"old_serial"`
+			NewSerial string `json:"new_serial"`
+		}{
+			Status:    "replaced",
+			Method:    method,
+			PONPort:   preONU.PONPort,
+			ONUID:     preONU.ONUID,
+			OldSerial: preONU.Serial,
+			NewSerial: newSerial,
+		}
+		data, _ := json.MarshalIndent(output, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("\nReplacement Complete\n")
+	fmt.Printf("--------------------\n")
+	fmt.Printf("  PON Port:        %s\n", preONU.PONPort)
+	fmt.Printf("  ONU ID:          %d\n", preONU.ONUID)
+	fmt.Printf("  Serial:          %s → %s\n", preONU.Serial, newSerial)
+	fmt.Printf("  Method:          %s\n", method)
+	return nil
+}
+
 // =============================================================================
 // Verification & Retry Logic (NAN-257)
 // =============================================================================
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -1285,3 +1286,107 @@ func TestONUUpdateErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestApplySNMPFlags(t *testing.T) {
+	setFlags := func(version, user, authProto, authPass, privProto, privPass string) {
+		oltSNMPVersion = version
+		oltCommunity = "public"
+		oltSNMPUser = user
+		oltSNMPAuthProtocol = authProto
+		oltSNMPAuthPassword = authPass
+		oltSNMPPrivProtocol = privProto
+		oltSNMPPrivPassword = privPass
+	}
+	defer setFlags("2c", "", "", "", "", "")
+
+	tests := []struct {
+		name     string
+		flags    [6]string
+		wantErr  string
+		wantUser string
+	}{
+		{name: "v2c", flags: [6]string{"2c"}},
+		{name: "v3 authPriv", flags: [6]string{"3", "nms", "SHA", "secretpass", "AES", "secretpass"}, wantUser: "nms"},
+		{name: "v3 without user", flags: [6]string{"3", "", "SHA", "secretpass", "AES", "secretpass"}, wantErr: "requires a username"},
+		{name: "v3 short password", flags: [6]string{"3", "nms", "SHA", "short", "AES", "short"}, wantErr: "at least 8 characters"},
+		{name: "v3 unsupported by driver", flags: [6]string{"3", "nms", "SHA256", "secretpass", "AES", "secretpass"}, wantErr: "SHA256/AES"},
+		{name: "unknown version", flags: [6]string{"5"}, wantErr: "invalid --snmp-version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.flags
+			setFlags(f[0], f[1], f[2], f[3], f[4], f[5])
+			config := &types.EquipmentConfig{
+				Protocol: types.ProtocolSNMP,
+				Username: "admin",
+				Metadata: make(map[string]string),
+			}
+			err := applySNMPFlags(config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applySNMPFlags() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applySNMPFlags() error = %v", err)
+			}
+			if config.Metadata["snmp_community"] != "public" {
+				t.Errorf("snmp_community = %q, want public", config.Metadata["snmp_community"])
+			}
+			if tt.wantUser != "" {
+				if config.Metadata["snmp_version"] != "3" || config.Username != tt.wantUser || config.Password != "secretpass" {
+					t.Errorf("SNMPv3 settings not applied: version %q, user %q", config.Metadata["snmp_version"], config.Username)
+				}
+			} else if config.Username != "admin" {
+				t.Errorf("Username = %q, want the CLI username kept", config.Username)
+			}
+		})
+	}
+
+	// Other protocols are left alone
+	setFlags("3", "", "", "", "", "")
+	config := &types.EquipmentConfig{Protocol: types.ProtocolCLI, Metadata: make(map[string]string)}
+	if err := applySNMPFlags(config); err != nil || len(config.Metadata) != 0 {
+		t.Errorf("applySNMPFlags(cli) = %v, metadata %v", err, config.Metadata)
+	}
+}
+
+func TestSetONUProfile(t *testing.T) {
+	preONU := &types.ONUInfo{ONUID: 5, PONPort: "0/1", Serial: "GPON00000001"}
+	subscriber, _ := buildProvisionModelsFromUpdate(preONU, "GPON00000002", "0/1", 5, "", "", 100, 0, "")
+
+	setONUProfile(subscriber, "")
+	if _, ok := subscriber.Annotations["nano.io/onu-profile"]; ok {
+		t.Error("empty profile should not be annotated")
+	}
+	setONUProfile(subscriber, "HG8010H")
+	if got := subscriber.Annotations["nano.io/onu-profile"]; got != "HG8010H" {
+		t.Errorf("nano.io/onu-profile = %q, want HG8010H", got)
+	}
+}
+
+func TestApplySNMPMetadataKeepsLogin(t *testing.T) {
+	oltSNMPVersion, oltCommunity = "3", "public"
+	oltSNMPUser, oltSNMPAuthProtocol, oltSNMPAuthPassword = "nms", "SHA", "secretpass"
+	oltSNMPPrivProtocol, oltSNMPPrivPassword = "AES", "secretpass"
+	t.Cleanup(func() {
+		oltSNMPVersion, oltCommunity = "2c", ""
+		oltSNMPUser, oltSNMPAuthProtocol, oltSNMPAuthPassword = "", "", ""
+		oltSNMPPrivProtocol, oltSNMPPrivPassword = "", ""
+	})
+
+	// Vendor adapters log in to the CLI with the login of the config the
+	// driver is created with, so it must not carry the SNMPv3 user
+	config := &types.EquipmentConfig{Protocol: types.ProtocolSNMP, Username: "admin", Password: "clipass", Metadata: make(map[string]string)}
+	if _, err := applySNMPMetadata(config); err != nil {
+		t.Fatalf("applySNMPMetadata() error = %v", err)
+	}
+	if config.Username != "admin" || config.Password != "clipass" {
+		t.Errorf("login = %q/%q, want the CLI login kept", config.Username, config.Password)
+	}
+	if config.Metadata["snmp_username"] != "nms" {
+		t.Errorf("snmp_username = %q, want nms", config.Metadata["snmp_username"])
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
 )
 
 // Client communicates with the Nanoncore control plane API.
@@ -526,20 +528,23 @@ func (c *Client) EmitEvent(req *EmitEventRequest) (*EmitEventResponse, error) {
 
 // Common event types for convenience
 const (
-	EventTypeAgentConnected       = "agent_connected"
-	EventTypeAgentDisconnected    = "agent_disconnected"
-	EventTypeAgentHeartbeatMissed = "agent_heartbeat_missed"
-	EventTypeEntityStatusChanged  = "entity_status_changed"
-	EventTypeEntityOffline        = "entity_offline"
-	EventTypeEntityOnline         = "entity_online"
-	EventTypeEntityDegraded       = "entity_degraded"
-	EventTypeHighCPUUsage         = "high_cpu_usage"
-	EventTypeHighMemoryUsage      = "high_memory_usage"
-	EventTypeHighBandwidthUsage   = "high_bandwidth_usage"
-	EventTypePacketLossDetected   = "packet_loss_detected"
-	EventTypeConfigChanged        = "config_changed"
-	EventTypeConfigApplied        = "config_applied"
-	EventTypeConfigFailed         = "config_failed"
+	EventTypeAgentConnected         = "agent_connected"
+	EventTypeAgentDisconnected      = "agent_disconnected"
+	EventTypeAgentHeartbeatMissed   = "agent_heartbeat_missed"
+	EventTypeEntityStatusChanged    = "entity_status_changed"
+	EventTypeEntityOffline          = "entity_offline"
+	EventTypeEntityOnline           = "entity_online"
+	EventTypeEntityDegraded         = "entity_degraded"
+	EventTypeHighCPUUsage           = "high_cpu_usage"
+	EventTypeHighMemoryUsage        = "high_memory_usage"
+	EventTypeHighBandwidthUsage     = "high_bandwidth_usage"
+	EventTypePacketLossDetected     = "packet_loss_detected"
+	EventTypeConfigChanged          = "config_changed"
+	EventTypeConfigApplied          = "config_applied"
+	EventTypeConfigFailed           = "config_failed"
+	EventTypeONUAutoProvisioned     = "onu_auto_provisioned"
+	EventTypeONUAutoProvisionFailed = "onu_auto_provision_failed"
+	EventTypeONUPendingApproval     = "onu_pending_approval"
 )
 
 // KeyRotateResponse is returned from the key rotation endpoint.
@@ -651,6 +656,9 @@ type OLTConfig struct {
 	Protocols OLTProtocols       `json:"protocols"`
 	Polling   OLTPollingConfig   `json:"polling"`
 	Discovery OLTDiscoveryConfig `json:"discovery"`
+
+	// BlackoutWindows are periods in which mutating commands must not run.
+	BlackoutWindows []MaintenanceWindow `json:"blackoutWindows,omitempty"`
 }
 
 // OLTProtocols contains protocol configurations for OLT access.
@@ -665,6 +673,13 @@ type SNMPConfig struct {
 	Port      int    `json:"port"`
 	Community string `json:"community"`
 	Version   string `json:"version"`
+
+	// SNMPv3 user-based security, used when Version is "3".
+	Username     string `json:"username,omitempty"`
+	AuthProtocol string `json:"authProtocol,omitempty"` // MD5, SHA, SHA224, SHA256, SHA384, SHA512
+	AuthPassword string `json:"authPassword,omitempty"`
+	PrivProtocol string `json:"privProtocol,omitempty"` // DES, AES, AES192, AES256
+	PrivPassword string `json:"privPassword,omitempty"`
 }
 
 // SSHConfig contains SSH configuration.
@@ -677,9 +692,22 @@ type SSHConfig struct {
 
 // OLTPollingConfig contains polling configuration.
 type OLTPollingConfig struct {
-	Enabled  bool     `json:"enabled"`
-	Interval int      `json:"interval"` // seconds
-	Metrics  []string `json:"metrics"`
+	Enabled          bool     `json:"enabled"`
+	Interval         int      `json:"interval"`         // seconds
+	DetailedInterval int      `json:"detailedInterval"` // seconds
+	Metrics          []string `json:"metrics"`          // metric groups to collect
+
+	// MetricIntervals overrides the interval of individual metric groups, in seconds.
+	MetricIntervals map[string]int `json:"metricIntervals,omitempty"`
+
+	// UplinkPorts names the interfaces reported as uplinks (default: detected by name).
+	UplinkPorts []string `json:"uplinkPorts,omitempty"`
+
+	// DetailConcurrency is how many PON ports are read in parallel during detailed polls (default: 2).
+	DetailConcurrency int `json:"detailConcurrency,omitempty"`
+
+	// DetailTimeout bounds each detailed poll, in seconds (default: 80% of interval).
+	DetailTimeout int `json:"detailTimeout,omitempty"`
 }
 
 // OLTDiscoveryConfig contains discovery configuration.
@@ -697,6 +725,46 @@ type AgentConfigResponse struct {
 	OLTs            []OLTConfig      `json:"olts"`
 	PendingProbes   []PendingProbe   `json:"pendingProbes,omitempty"`
 	PendingCommands []PendingCommand `json:"pendingCommands,omitempty"`
+
+	// ServiceTemplates are the named provisioning bundles onu_provision can
+	// reference instead of spelling out profiles and VLANs.
+	ServiceTemplates []ServiceTemplate `json:"serviceTemplates,omitempty"`
+}
+
+// ServiceTemplate is a named provisioning bundle such as "residential-100M".
+// Vendor settings override the defaults for OLTs of that vendor.
+type ServiceTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ServiceTemplateSettings
+	Vendors map[string]ServiceTemplateSettings `json:"vendors,omitempty"`
+}
+
+// ServiceTemplateSettings is what a service template provisions on an ONU.
+type ServiceTemplateSettings struct {
+	LineProfile      string                       `json:"lineProfile,omitempty"`
+	ServiceProfile   string                       `json:"serviceProfile,omitempty"`
+	ONUProfile       string                       `json:"onuProfile,omitempty"`
+	TrafficProfile   int                          `json:"trafficProfile,omitempty"`
+	VLAN             int                          `json:"vlan,omitempty"`
+	AllowedVLANs     []int                        `json:"allowedVlans,omitempty"`
+	ServicePorts     []ServiceTemplatePort        `json:"servicePorts,omitempty"`
+	VLANTranslations []ServiceTemplateTranslation `json:"vlanTranslations,omitempty"`
+}
+
+// ServiceTemplatePort is an additional service port created for the ONU.
+type ServiceTemplatePort struct {
+	VLAN     int    `json:"vlan"`
+	GemPort  int    `json:"gemPort,omitempty"`
+	UserVLAN int    `json:"userVlan,omitempty"`
+	Mode     string `json:"mode,omitempty"` // tag, translate, transparent
+}
+
+// ServiceTemplateTranslation is a C-VLAN to S-VLAN translation rule.
+type ServiceTemplateTranslation struct {
+	CustomerVLAN int    `json:"customerVlan"`
+	ServiceVLAN  int    `json:"serviceVlan"`
+	Mode         string `json:"mode,omitempty"`
 }
 
 // PendingCommand represents a command queued by the control plane for execution.
@@ -705,6 +773,20 @@ type PendingCommand struct {
 	EquipmentID string                 `json:"equipmentId"`
 	Type        string                 `json:"type"`
 	Payload     map[string]interface{} `json:"payload"`
+
+	// NotBefore defers execution until the given time.
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	// MaintenanceWindow restricts execution to a recurring time window.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+}
+
+// MaintenanceWindow is a recurring daily time window, e.g. 01:00-05:00.
+// Windows whose end is before their start wrap past midnight.
+type MaintenanceWindow struct {
+	Start    string   `json:"start"`              // HH:MM
+	End      string   `json:"end"`                // HH:MM
+	Timezone string   `json:"timezone,omitempty"` // IANA name, defaults to agent local time
+	Days     []string `json:"days,omitempty"`     // mon..sun the window starts on; empty means every day
 }
 
 // PendingProbe represents a probe request queued by the control plane.
@@ -752,6 +834,67 @@ func (c *Client) AckProbe(nodeID string, req *AckProbeRequest) error {
 	return nil
 }
 
+// CapabilitiesRequest publishes the command capabilities of this agent and its OLTs.
+type CapabilitiesRequest struct {
+	NodeID       string            `json:"nodeId"`
+	CommandTypes []string          `json:"commandTypes"`
+	OLTs         []OLTCapabilities `json:"olts"`
+}
+
+// OLTCapabilities reports which command types an OLT supports through this agent.
+type OLTCapabilities struct {
+	OLTID               string                  `json:"oltId"`
+	Vendor              string                  `json:"vendor"`
+	Model               string                  `json:"model,omitempty"`
+	SupportedCommands   []string                `json:"supportedCommands"`
+	UnsupportedCommands []string                `json:"unsupportedCommands,omitempty"`
+	CLI                 *cli.VendorCapabilities `json:"cli,omitempty"`
+	Southbound          *SouthboundCapabilities `json:"southbound,omitempty"`
+	Error               string                  `json:"error,omitempty"`
+}
+
+// SouthboundCapabilities mirrors the southbound vendor capability matrix.
+type SouthboundCapabilities struct {
+	PrimaryProtocol    string   `json:"primaryProtocol"`
+	SupportedProtocols []string `json:"supportedProtocols"`
+	ConfigMethod       string   `json:"configMethod"`
+	TelemetryMethod    string   `json:"telemetryMethod"`
+	SupportsStreaming  bool     `json:"supportsStreaming"`
+}
+
+// PushCapabilities publishes the per-OLT command capability matrix to the control plane.
+func (c *Client) PushCapabilities(nodeID string, req *CapabilitiesRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/nodes/"+nodeID+"/capabilities", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	c.checkResponseHeaders(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("push capabilities failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
 // GetOLTConfig retrieves the typed OLT configuration from the control plane.
 func (c *Client) GetOLTConfig(nodeID string) (*AgentConfigResponse, error) {
 	httpReq, err := http.NewRequest("GET", c.baseURL+"/api/v1/nodes/"+nodeID+"/config", nil)
@@ -903,6 +1046,14 @@ type TelemetryData struct {
 	IsHealthy     bool    `json:"isHealthy"`
 	Firmware      string  `json:"firmware,omitempty"`
 	SerialNumber  string  `json:"serialNumber,omitempty"`
+
+	// Staleness, reported when the agent has had no fresh data from the OLT
+	// for several polling intervals
+	Stale               bool       `json:"stale,omitempty"`
+	StaleGroups         []string   `json:"staleGroups,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"`
 }
 
 // PushTelemetryResponse is the response from pushing telemetry.
@@ -956,6 +1107,165 @@ func (c *Client) PushTelemetry(oltID string, telemetry *TelemetryData) (*PushTel
 	return &pushResp, nil
 }
 
+// AutofindEntry is an unprovisioned ONU seen in an OLT's autofind list.
+type AutofindEntry struct {
+	Serial    string    `json:"serialNumber"`
+	PONPort   string    `json:"ponPort"`
+	Model     string    `json:"model,omitempty"`
+	Vendor    string    `json:"vendor,omitempty"`
+	MAC       string    `json:"mac,omitempty"`
+	Distance  int       `json:"distance,omitempty"`
+	RxPower   float64   `json:"rxPower,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// PushAutofindRequest reports an OLT's autofind list compared with the
+// previous discovery: entries that appeared, are still there, or are gone.
+type PushAutofindRequest struct {
+	New          []AutofindEntry `json:"new"`
+	Persisting   []AutofindEntry `json:"persisting"`
+	Vanished     []AutofindEntry `json:"vanished"`
+	DiscoveredAt time.Time       `json:"discoveredAt"`
+}
+
+// PushAutofindResponse is the response from pushing autofind entries.
+type PushAutofindResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// PushAutofind sends the result of an autofind discovery to the control plane.
+// This calls POST /api/v1/equipment/{oltId}/autofind
+func (c *Client) PushAutofind(oltID string, req *PushAutofindRequest) (*PushAutofindResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/equipment/"+oltID+"/autofind", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	// Use agent API key (na_) for per-agent rate limiting
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for server signals
+	c.checkResponseHeaders(resp)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("push autofind failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var pushResp PushAutofindResponse
+	if err := json.Unmarshal(respBody, &pushResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &pushResp, nil
+}
+
+// PortData represents a PON or uplink port reported by the poller.
+type PortData struct {
+	Port        string `json:"port"`
+	Type        string `json:"type"` // pon, uplink
+	AdminState  string `json:"adminState,omitempty"`
+	OperState   string `json:"operState,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// PON ports
+	ONUCount int     `json:"onuCount,omitempty"`
+	MaxONUs  int     `json:"maxOnus,omitempty"`
+	TxPower  float64 `json:"txPower,omitempty"` // dBm
+	RxPower  float64 `json:"rxPower,omitempty"` // dBm
+
+	// Uplink ports (cumulative counters)
+	SpeedMbps   uint64 `json:"speedMbps,omitempty"`
+	BytesIn     uint64 `json:"bytesIn,omitempty"`
+	BytesOut    uint64 `json:"bytesOut,omitempty"`
+	PacketsIn   uint64 `json:"packetsIn,omitempty"`
+	PacketsOut  uint64 `json:"packetsOut,omitempty"`
+	ErrorsIn    uint64 `json:"errorsIn,omitempty"`
+	ErrorsOut   uint64 `json:"errorsOut,omitempty"`
+	DiscardsIn  uint64 `json:"discardsIn,omitempty"`
+	DiscardsOut uint64 `json:"discardsOut,omitempty"`
+
+	// Uplink ports (computed from counter deltas, bytes per second)
+	InputRateBps  uint64 `json:"inputRateBps,omitempty"`
+	OutputRateBps uint64 `json:"outputRateBps,omitempty"`
+}
+
+// PushPortsRequest is the request body for pushing port data.
+type PushPortsRequest struct {
+	Ports []PortData `json:"ports"`
+}
+
+// PushPortsResponse is the response from pushing port data.
+type PushPortsResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// PushPorts sends PON and uplink port state and counters to the control plane.
+// This calls POST /api/v1/equipment/{oltId}/ports
+func (c *Client) PushPorts(oltID string, ports []PortData) (*PushPortsResponse, error) {
+	body, err := json.Marshal(PushPortsRequest{Ports: ports})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/equipment/"+oltID+"/ports", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	// Use agent API key (na_) for per-agent rate limiting
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for server signals
+	c.checkResponseHeaders(resp)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("push ports failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var pushResp PushPortsResponse
+	if err := json.Unmarshal(respBody, &pushResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &pushResp, nil
+}
+
 // MetricSample represents a single metric data point for time-series storage.
 type MetricSample struct {
 	Name      string            `json:"name"`
@@ -1075,10 +1385,20 @@ type CommandResultRequest struct {
 	Error      string                 `json:"error,omitempty"`
 	PreState   map[string]interface{} `json:"preState,omitempty"`
 	PostState  map[string]interface{} `json:"postState,omitempty"`
+	Diff       []StateChange          `json:"diff,omitempty"`
 	Verified   bool                   `json:"verified,omitempty"`
 	DurationMs int64                  `json:"durationMs,omitempty"`
 }
 
+// StateChange describes one field that differs between a command's pre and
+// post state. Path uses dot notation for nested fields (e.g. "vlan.name").
+type StateChange struct {
+	Path   string      `json:"path"`
+	Op     string      `json:"op"` // added, removed, changed
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
 // CommandResultResponse is the response from pushing command results.
 type CommandResultResponse struct {
 	Success   bool   `json:"success"`
@@ -1128,3 +1448,141 @@ func (c *Client) PushCommandResult(commandID string, req *CommandResultRequest)
 
 	return &resultResp, nil
 }
+
+// BatchItemResult is the outcome of one operation in a batch command.
+type BatchItemResult struct {
+	Index       int                    `json:"index"`
+	EquipmentID string                 `json:"equipmentId"`
+	Type        string                 `json:"type"`
+	Status      string                 `json:"status"` // succeeded, failed, skipped
+	Result      map[string]interface{} `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	DurationMs  int64                  `json:"durationMs,omitempty"`
+}
+
+// CommandProgressRequest reports incremental progress of a long-running command.
+type CommandProgressRequest struct {
+	Total     int              `json:"total"`
+	Completed int              `json:"completed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Skipped   int              `json:"skipped"`
+	Item      *BatchItemResult `json:"item,omitempty"` // the item that just finished
+}
+
+// PushCommandProgress sends incremental progress for a command that is still running.
+func (c *Client) PushCommandProgress(commandID string, req *CommandProgressRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/commands/"+commandID+"/progress", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	c.checkResponseHeaders(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("push command progress failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// CommandPartialResultRequest is one intermediate step of a running command.
+type CommandPartialResultRequest struct {
+	Sequence  int                    `json:"sequence"` // 1-based, in report order
+	Step      string                 `json:"step"`
+	Message   string                 `json:"message,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// PushCommandPartialResult sends an intermediate step (log line and optional
+// partial data) for a command that is still running.
+func (c *Client) PushCommandPartialResult(commandID string, req *CommandPartialResultRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/commands/"+commandID+"/partial", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	c.checkResponseHeaders(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("push partial result failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// CommandScheduledRequest reports that a command is held for later execution.
+type CommandScheduledRequest struct {
+	Status       string    `json:"status"` // always "scheduled"
+	ScheduledFor time.Time `json:"scheduledFor"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// ReportCommandScheduled tells the control plane a command is scheduled rather
+// than in progress, so it is shown as scheduled until the agent runs it.
+func (c *Client) ReportCommandScheduled(commandID string, req *CommandScheduledRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest("POST", c.baseURL+"/api/v1/commands/"+commandID+"/schedule", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	c.checkResponseHeaders(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("report command scheduled failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
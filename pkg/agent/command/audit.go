@@ -0,0 +1,127 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+)
+
+// DefaultAuditLogFile is the audit log file name inside the config directory.
+const DefaultAuditLogFile = "audit.log"
+
+// AuditEntry records one executed mutating command for compliance.
+type AuditEntry struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	CommandID   string                 `json:"commandId"`
+	CommandType string                 `json:"commandType"`
+	EquipmentID string                 `json:"equipmentId"`
+	Vendor      string                 `json:"vendor,omitempty"`
+	Payload     map[string]interface{} `json:"payload,omitempty"`
+	Success     bool                   `json:"success"`
+	Error       string                 `json:"error,omitempty"`
+	DurationMs  int64                  `json:"durationMs"`
+	PreState    map[string]interface{} `json:"preState,omitempty"`
+	PostState   map[string]interface{} `json:"postState,omitempty"`
+	Diff        []agent.StateChange    `json:"diff,omitempty"`
+}
+
+// AuditLog is an append-only JSON lines file of AuditEntry records.
+type AuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenAuditLog opens (or creates) the audit log at path for appending.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return &AuditLog{file: file}, nil
+}
+
+// Append writes an entry as a single JSON line.
+func (l *AuditLog) Append(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file.
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// ReadAuditLog reads all entries from an audit log file.
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	var entries []AuditEntry
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var entry AuditEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return entries, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// audit appends an entry for a mutating command to the audit log, if one is set.
+func (e *Executor) audit(cmd agent.PendingCommand, oltConfig agent.OLTConfig, startTime time.Time, result map[string]interface{}, cmdErr error) {
+	if e.auditLog == nil {
+		return
+	}
+
+	preState, postState := resultState(result)
+	entry := AuditEntry{
+		Timestamp:   startTime.UTC(),
+		CommandID:   cmd.ID,
+		CommandType: cmd.Type,
+		EquipmentID: cmd.EquipmentID,
+		Vendor:      oltConfig.Vendor,
+		Payload:     cmd.Payload,
+		Success:     cmdErr == nil,
+		DurationMs:  time.Since(startTime).Milliseconds(),
+		PreState:    preState,
+		PostState:   postState,
+	}
+	if cmdErr != nil {
+		entry.Error = cmdErr.Error()
+	}
+	if preState != nil || postState != nil {
+		entry.Diff = diffState(preState, postState)
+	}
+
+	if err := e.auditLog.Append(entry); err != nil {
+		log.Printf("[command] Failed to write audit entry for command %s: %v", cmd.ID, err)
+	}
+}
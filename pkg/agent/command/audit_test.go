@@ -0,0 +1,57 @@
+package command
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutorAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", DefaultAuditLogFile)
+	auditLog, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	e := newTestExecutor()
+	e.SetAuditLog(auditLog)
+
+	oltConfig := agent.OLTConfig{ID: "olt-1", Vendor: "huawei"}
+	cmd := agent.PendingCommand{
+		ID:          "cmd-1",
+		Type:        "vlan_create",
+		EquipmentID: "olt-1",
+		Payload:     map[string]interface{}{"vlanId": float64(100)},
+	}
+	result := map[string]interface{}{
+		"success":   true,
+		"preState":  map[string]interface{}{"vlanId": 100, "exists": false},
+		"postState": map[string]interface{}{"vlanId": 100, "exists": true},
+	}
+	e.audit(cmd, oltConfig, time.Now(), result, nil)
+
+	failed := cmd
+	failed.ID = "cmd-2"
+	e.audit(failed, oltConfig, time.Now(), nil, errors.New("failed to create VLAN"))
+	require.NoError(t, auditLog.Close())
+
+	entries, err := ReadAuditLog(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "cmd-1", entries[0].CommandID)
+	assert.Equal(t, "vlan_create", entries[0].CommandType)
+	assert.Equal(t, "huawei", entries[0].Vendor)
+	assert.True(t, entries[0].Success)
+	require.Len(t, entries[0].Diff, 1)
+	assert.Equal(t, "exists", entries[0].Diff[0].Path)
+	assert.Equal(t, true, entries[0].Diff[0].After)
+
+	assert.Equal(t, "cmd-2", entries[1].CommandID)
+	assert.False(t, entries[1].Success)
+	assert.Equal(t, "failed to create VLAN", entries[1].Error)
+	assert.Empty(t, entries[1].Diff)
+}
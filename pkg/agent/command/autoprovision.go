@@ -0,0 +1,620 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-southbound/types"
+)
+
+// DefaultAutoProvisionFile is the auto-provisioning policy file name inside
+// the config directory.
+const DefaultAutoProvisionFile = "auto_provision.json"
+
+// Auto-provisioning modes. In approval mode matched ONUs are held until an
+// auto_provision_approve command arrives.
+const (
+	AutoProvisionAuto     = "auto"
+	AutoProvisionApproval = "approval"
+)
+
+// maxONUsPerPort bounds the search for a free ONU ID on a PON port.
+const maxONUsPerPort = 128
+
+// pendingApprovalTTL is how long a matched ONU waits for approval before it
+// is dropped. It is held again if discovery still reports it.
+const pendingApprovalTTL = 24 * time.Hour
+
+// EventFunc emits a network event to the control plane.
+type EventFunc func(event *agent.EmitEventRequest) error
+
+// AutoProvisionMatch selects discovered ONUs. Empty fields match anything.
+type AutoProvisionMatch struct {
+	SerialPrefix string   `json:"serialPrefix,omitempty"`
+	Vendor       string   `json:"vendor,omitempty"` // ONU vendor, e.g. "HWTC"
+	Model        string   `json:"model,omitempty"`
+	PONPorts     []string `json:"ponPorts,omitempty"`
+	EquipmentIDs []string `json:"equipmentIds,omitempty"`
+}
+
+// AutoProvisionTemplate is the provisioning applied to a matched ONU.
+// ServiceTemplate names a cached service template; the other fields
+// override it.
+type AutoProvisionTemplate struct {
+	ServiceTemplate string `json:"serviceTemplate,omitempty"`
+	LineProfile     string `json:"lineProfile,omitempty"`
+	ServiceProfile  string `json:"serviceProfile,omitempty"`
+	ONUProfile      string `json:"onuProfile,omitempty"`
+	VLAN            int    `json:"vlan,omitempty"`
+	TrafficProfile  int    `json:"trafficProfile,omitempty"`
+	Description     string `json:"description,omitempty"`
+}
+
+// AutoProvisionRule maps matching ONUs to a provisioning template.
+type AutoProvisionRule struct {
+	Name     string                `json:"name"`
+	Match    AutoProvisionMatch    `json:"match"`
+	Template AutoProvisionTemplate `json:"template"`
+	// Mode overrides the policy mode for this rule.
+	Mode string `json:"mode,omitempty"`
+}
+
+// AutoProvisionConfig is the on-disk auto-provisioning policy. Rules are
+// evaluated in order and the first match wins.
+type AutoProvisionConfig struct {
+	Enabled bool                `json:"enabled"`
+	Mode    string              `json:"mode,omitempty"` // default "approval"
+	Rules   []AutoProvisionRule `json:"rules,omitempty"`
+}
+
+// AutoProvisionPolicy decides how discovered ONUs are provisioned.
+type AutoProvisionPolicy struct {
+	config AutoProvisionConfig
+}
+
+// NewAutoProvisionPolicy validates a policy config.
+func NewAutoProvisionPolicy(config AutoProvisionConfig) (*AutoProvisionPolicy, error) {
+	if config.Mode == "" {
+		config.Mode = AutoProvisionApproval
+	}
+	if err := validateAutoProvisionMode(config.Mode); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range config.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Mode != "" {
+			if err := validateAutoProvisionMode(rule.Mode); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+		}
+		if rule.Template.VLAN < 0 || rule.Template.VLAN > 4094 {
+			return nil, fmt.Errorf("rule %s: invalid VLAN %d", rule.Name, rule.Template.VLAN)
+		}
+	}
+
+	return &AutoProvisionPolicy{config: config}, nil
+}
+
+// LoadAutoProvisionPolicy reads a policy from a JSON file. A missing file
+// yields a disabled policy.
+func LoadAutoProvisionPolicy(path string) (*AutoProvisionPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewAutoProvisionPolicy(AutoProvisionConfig{})
+		}
+		return nil, fmt.Errorf("failed to read auto-provisioning policy: %w", err)
+	}
+
+	var config AutoProvisionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse auto-provisioning policy: %w", err)
+	}
+
+	return NewAutoProvisionPolicy(config)
+}
+
+func validateAutoProvisionMode(mode string) error {
+	if mode != AutoProvisionAuto && mode != AutoProvisionApproval {
+		return fmt.Errorf("invalid mode %q (expected %s or %s)", mode, AutoProvisionAuto, AutoProvisionApproval)
+	}
+	return nil
+}
+
+// Enabled reports whether discovered ONUs should be evaluated at all.
+func (p *AutoProvisionPolicy) Enabled() bool {
+	return p != nil && p.config.Enabled && len(p.config.Rules) > 0
+}
+
+// Match returns the first rule matching an ONU discovered on an OLT and the
+// mode it runs in.
+func (p *AutoProvisionPolicy) Match(equipmentID string, d types.ONUDiscovery) (*AutoProvisionRule, string, bool) {
+	if !p.Enabled() {
+		return nil, "", false
+	}
+
+	for i := range p.config.Rules {
+		rule := &p.config.Rules[i]
+		if rule.Match.matches(equipmentID, d) {
+			mode := rule.Mode
+			if mode == "" {
+				mode = p.config.Mode
+			}
+			return rule, mode, true
+		}
+	}
+	return nil, "", false
+}
+
+func (m AutoProvisionMatch) matches(equipmentID string, d types.ONUDiscovery) bool {
+	serial := strings.ToUpper(d.Serial)
+	if m.SerialPrefix != "" && !strings.HasPrefix(serial, strings.ToUpper(m.SerialPrefix)) {
+		return false
+	}
+	if m.Vendor != "" {
+		// Discovery does not always report the vendor; GPON serials start
+		// with the four-letter vendor ID
+		vendor := d.Vendor
+		if vendor == "" && len(serial) >= 4 {
+			vendor = serial[:4]
+		}
+		if !strings.EqualFold(vendor, m.Vendor) {
+			return false
+		}
+	}
+	if m.Model != "" && !strings.EqualFold(d.Model, m.Model) {
+		return false
+	}
+	if len(m.PONPorts) > 0 && !containsString(m.PONPorts, d.PONPort) {
+		return false
+	}
+	if len(m.EquipmentIDs) > 0 && !containsString(m.EquipmentIDs, equipmentID) {
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingApproval is a discovered ONU waiting for an operator to approve its
+// provisioning.
+type PendingApproval struct {
+	EquipmentID  string    `json:"equipmentId"`
+	Serial       string    `json:"serial"`
+	PONPort      string    `json:"ponPort"`
+	Model        string    `json:"model,omitempty"`
+	Vendor       string    `json:"vendor,omitempty"`
+	Rule         string    `json:"rule"`
+	Reason       string    `json:"reason,omitempty"`
+	DiscoveredAt time.Time `json:"discoveredAt"`
+
+	rule   AutoProvisionRule
+	heldAt time.Time
+}
+
+// AutoProvisionResult reports what happened to one discovered ONU.
+type AutoProvisionResult struct {
+	Serial  string `json:"serial"`
+	PONPort string `json:"ponPort"`
+	Rule    string `json:"rule,omitempty"`
+	// Action is "provisioned", "pending_approval", "failed" or "unmatched".
+	Action string `json:"action"`
+	ONUID  int    `json:"onuId,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// autoProvisioner holds the policy, the ONUs waiting for approval and the
+// ONU IDs handed out since the last poll.
+type autoProvisioner struct {
+	mu      sync.Mutex
+	policy  *AutoProvisionPolicy
+	pending map[string]PendingApproval // serial -> approval
+	// reserved maps OLT and PON port to the ONU IDs allocated by
+	// auto-provisioning and when they were provisioned; the time is zero
+	// while provisioning is in flight
+	reserved map[string]map[int]time.Time
+}
+
+// SetAutoProvisionPolicy sets the policy applied to newly discovered ONUs.
+func (e *Executor) SetAutoProvisionPolicy(policy *AutoProvisionPolicy) {
+	e.autoProvision.mu.Lock()
+	defer e.autoProvision.mu.Unlock()
+	e.autoProvision.policy = policy
+}
+
+// SetEventEmitter sets the callback used to report events to the control plane.
+func (e *Executor) SetEventEmitter(emit EventFunc) {
+	e.emitEvent = emit
+}
+
+// AutoProvision applies the auto-provisioning policy to ONUs newly found in
+// an OLT's autofind list. Matched ONUs are provisioned immediately or held
+// for approval depending on the rule's mode; every outcome is reported as an
+// event.
+func (e *Executor) AutoProvision(ctx context.Context, equipmentID string, discoveries []types.ONUDiscovery) []AutoProvisionResult {
+	e.autoProvision.mu.Lock()
+	policy := e.autoProvision.policy
+	e.autoProvision.mu.Unlock()
+	if !policy.Enabled() {
+		return nil
+	}
+
+	results := make([]AutoProvisionResult, 0, len(discoveries))
+	for _, d := range discoveries {
+		rule, mode, ok := policy.Match(equipmentID, d)
+		if !ok {
+			results = append(results, AutoProvisionResult{Serial: d.Serial, PONPort: d.PONPort, Action: "unmatched"})
+			continue
+		}
+
+		approval := PendingApproval{
+			EquipmentID:  equipmentID,
+			Serial:       d.Serial,
+			PONPort:      d.PONPort,
+			Model:        d.Model,
+			Vendor:       d.Vendor,
+			Rule:         rule.Name,
+			DiscoveredAt: d.DiscoveredAt,
+			rule:         *rule,
+		}
+		if approval.DiscoveredAt.IsZero() {
+			approval.DiscoveredAt = time.Now().UTC()
+		}
+
+		if mode == AutoProvisionApproval {
+			results = append(results, e.holdForApproval(approval))
+			continue
+		}
+
+		// Auto-provisioning must not touch an OLT in a blackout window;
+		// hold the ONU so an operator can approve it later
+		if oltConfig, ok := e.oltConfig(equipmentID); ok {
+			now := time.Now()
+			runAt, reason, err := scheduledRunTime(agent.PendingCommand{}, true, oltConfig.BlackoutWindows, now)
+			if err == nil && runAt.After(now) {
+				approval.Reason = fmt.Sprintf("blocked by %s until %s", reason, runAt.Format(time.RFC3339))
+				results = append(results, e.holdForApproval(approval))
+				continue
+			}
+		}
+
+		results = append(results, e.provisionDiscovered(ctx, approval))
+	}
+	return results
+}
+
+// PendingApprovals returns the ONUs waiting for approval, oldest first.
+func (e *Executor) PendingApprovals() []PendingApproval {
+	e.autoProvision.mu.Lock()
+	defer e.autoProvision.mu.Unlock()
+
+	e.prunePendingLocked(time.Now())
+	pending := make([]PendingApproval, 0, len(e.autoProvision.pending))
+	for _, approval := range e.autoProvision.pending {
+		pending = append(pending, approval)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].DiscoveredAt.Before(pending[j].DiscoveredAt)
+	})
+	return pending
+}
+
+// holdForApproval queues a matched ONU until it is approved or rejected.
+func (e *Executor) holdForApproval(approval PendingApproval) AutoProvisionResult {
+	approval.heldAt = time.Now()
+	e.autoProvision.mu.Lock()
+	e.prunePendingLocked(approval.heldAt)
+	if e.autoProvision.pending == nil {
+		e.autoProvision.pending = make(map[string]PendingApproval)
+	}
+	e.autoProvision.pending[approval.Serial] = approval
+	e.autoProvision.mu.Unlock()
+
+	log.Printf("[command] ONU %s on %s %s matched rule %s, waiting for approval",
+		approval.Serial, approval.EquipmentID, approval.PONPort, approval.Rule)
+
+	content := fmt.Sprintf("ONU %s discovered on %s matches rule %s and is waiting for approval", approval.Serial, approval.PONPort, approval.Rule)
+	if approval.Reason != "" {
+		content += " (" + approval.Reason + ")"
+	}
+	e.emit(agent.EventTypeONUPendingApproval, agent.SeverityInfo, content, approval.EquipmentID, map[string]interface{}{
+		"serial":  approval.Serial,
+		"ponPort": approval.PONPort,
+		"model":   approval.Model,
+		"rule":    approval.Rule,
+		"reason":  approval.Reason,
+	})
+
+	return AutoProvisionResult{Serial: approval.Serial, PONPort: approval.PONPort, Rule: approval.Rule, Action: "pending_approval"}
+}
+
+// takeApproval removes and returns the pending approval for a serial.
+func (e *Executor) takeApproval(serial string) (PendingApproval, bool) {
+	e.autoProvision.mu.Lock()
+	defer e.autoProvision.mu.Unlock()
+
+	e.prunePendingLocked(time.Now())
+	approval, ok := e.autoProvision.pending[serial]
+	if ok {
+		delete(e.autoProvision.pending, serial)
+	}
+	return approval, ok
+}
+
+// restoreApproval puts back an approval taken for provisioning that failed,
+// unless the ONU was held again meanwhile.
+func (e *Executor) restoreApproval(approval PendingApproval) {
+	e.autoProvision.mu.Lock()
+	defer e.autoProvision.mu.Unlock()
+
+	if _, ok := e.autoProvision.pending[approval.Serial]; ok {
+		return
+	}
+	if e.autoProvision.pending == nil {
+		e.autoProvision.pending = make(map[string]PendingApproval)
+	}
+	e.autoProvision.pending[approval.Serial] = approval
+}
+
+// prunePendingLocked drops approvals held longer than pendingApprovalTTL.
+// Callers must hold e.autoProvision.mu.
+func (e *Executor) prunePendingLocked(now time.Time) {
+	for serial, approval := range e.autoProvision.pending {
+		if now.Sub(approval.heldAt) > pendingApprovalTTL {
+			log.Printf("[command] Dropping ONU %s on %s, not approved within %s", serial, approval.EquipmentID, pendingApprovalTTL)
+			delete(e.autoProvision.pending, serial)
+		}
+	}
+}
+
+// provisionDiscovered provisions a discovered ONU with its rule's template
+// through the regular onu_provision command, so rate limits, session limits
+// and the audit log apply.
+func (e *Executor) provisionDiscovered(ctx context.Context, approval PendingApproval) AutoProvisionResult {
+	result := AutoProvisionResult{Serial: approval.Serial, PONPort: approval.PONPort, Rule: approval.Rule}
+	fail := func(err error) AutoProvisionResult {
+		result.Action = "failed"
+		result.Error = err.Error()
+		log.Printf("[command] Auto-provisioning ONU %s on %s failed: %v", approval.Serial, approval.EquipmentID, err)
+		e.emit(agent.EventTypeONUAutoProvisionFailed, agent.SeverityWarning,
+			fmt.Sprintf("Auto-provisioning ONU %s on %s failed: %v", approval.Serial, approval.PONPort, err),
+			approval.EquipmentID, map[string]interface{}{
+				"serial":  approval.Serial,
+				"ponPort": approval.PONPort,
+				"rule":    approval.Rule,
+				"error":   err.Error(),
+			})
+		return result
+	}
+
+	oltConfig, ok := e.oltConfig(approval.EquipmentID)
+	if !ok {
+		return fail(fmt.Errorf("OLT configuration not found for equipment %s", approval.EquipmentID))
+	}
+	spec, ok := e.commands().Lookup("onu_provision")
+	if !ok {
+		return fail(fmt.Errorf("unsupported command type: onu_provision"))
+	}
+
+	onuID, err := e.reserveONUID(approval.EquipmentID, approval.PONPort)
+	if err != nil {
+		return fail(err)
+	}
+	result.ONUID = onuID
+
+	template := approval.rule.Template
+	payload := map[string]interface{}{
+		"serial":         approval.Serial,
+		"ponPort":        approval.PONPort,
+		"onuId":          float64(onuID),
+		"lineProfile":    template.LineProfile,
+		"serviceProfile": template.ServiceProfile,
+		"onuProfile":     template.ONUProfile,
+		"description":    template.Description,
+	}
+	if template.VLAN > 0 {
+		payload["vlan"] = float64(template.VLAN)
+	}
+	if template.TrafficProfile > 0 {
+		payload["trafficProfile"] = float64(template.TrafficProfile)
+	}
+	if template.ServiceTemplate != "" {
+		payload["template"] = template.ServiceTemplate
+	}
+	cmd := agent.PendingCommand{
+		ID:          fmt.Sprintf("auto-provision-%s-%d", approval.Serial, time.Now().UnixNano()),
+		EquipmentID: approval.EquipmentID,
+		Type:        "onu_provision",
+		Payload:     payload,
+	}
+
+	if _, err := e.execute(ctx, spec, oltConfig, cmd); err != nil {
+		e.releaseONUID(approval.EquipmentID, approval.PONPort, onuID, false)
+		return fail(err)
+	}
+	e.releaseONUID(approval.EquipmentID, approval.PONPort, onuID, true)
+
+	result.Action = "provisioned"
+	log.Printf("[command] Auto-provisioned ONU %s on %s %s as ONU %d (rule %s)",
+		approval.Serial, approval.EquipmentID, approval.PONPort, onuID, approval.Rule)
+	e.emit(agent.EventTypeONUAutoProvisioned, agent.SeverityInfo,
+		fmt.Sprintf("ONU %s auto-provisioned on %s as ONU %d (rule %s)", approval.Serial, approval.PONPort, onuID, approval.Rule),
+		approval.EquipmentID, map[string]interface{}{
+			"serial":  approval.Serial,
+			"ponPort": approval.PONPort,
+			"onuId":   onuID,
+			"rule":    approval.Rule,
+		})
+	e.triggerPoll(approval.EquipmentID)
+	return result
+}
+
+// reserveONUID picks the lowest ONU ID on a PON port that is neither in use
+// according to the latest poller snapshot nor handed out to another
+// auto-provisioned ONU the snapshot does not show yet. The ID stays reserved
+// until it is released.
+func (e *Executor) reserveONUID(equipmentID, ponPort string) (int, error) {
+	if e.onuSnapshot == nil {
+		return 0, fmt.Errorf("no ONU snapshot available to allocate an ONU ID")
+	}
+	onus, polledAt, ok := e.onuSnapshot(equipmentID)
+	if !ok {
+		return 0, fmt.Errorf("OLT %s has not been polled yet, cannot allocate an ONU ID", equipmentID)
+	}
+
+	used := make(map[int]bool)
+	for _, onu := range onus {
+		if samePONPort(onu.PONPort, ponPort) {
+			used[onu.ONUID] = true
+		}
+	}
+
+	e.autoProvision.mu.Lock()
+	defer e.autoProvision.mu.Unlock()
+
+	if e.autoProvision.reserved == nil {
+		e.autoProvision.reserved = make(map[string]map[int]time.Time)
+	}
+	key := reservationKey(equipmentID, ponPort)
+	reserved := e.autoProvision.reserved[key]
+	if reserved == nil {
+		reserved = make(map[int]time.Time)
+		e.autoProvision.reserved[key] = reserved
+	}
+	for id, provisionedAt := range reserved {
+		// A poll that ran after provisioning already reports the ONU
+		if !provisionedAt.IsZero() && polledAt.After(provisionedAt) {
+			delete(reserved, id)
+			continue
+		}
+		used[id] = true
+	}
+
+	for id := 1; id <= maxONUsPerPort; id++ {
+		if !used[id] {
+			reserved[id] = time.Time{}
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no free ONU ID on PON port %s", ponPort)
+}
+
+// releaseONUID ends the in-flight reservation of an ONU ID. A provisioned ID
+// stays reserved until a poll reports it.
+func (e *Executor) releaseONUID(equipmentID, ponPort string, onuID int, provisioned bool) {
+	e.autoProvision.mu.Lock()
+	defer e.autoProvision.mu.Unlock()
+
+	reserved := e.autoProvision.reserved[reservationKey(equipmentID, ponPort)]
+	if reserved == nil {
+		return
+	}
+	if provisioned {
+		reserved[onuID] = time.Now()
+	} else {
+		delete(reserved, onuID)
+	}
+}
+
+// reservationKey returns the key of a PON port's ONU ID reservations, with
+// the port written as slot/port so both spellings share reservations.
+func reservationKey(equipmentID, ponPort string) string {
+	if slot, port, err := parsePonPort(ponPort); err == nil {
+		ponPort = fmt.Sprintf("%d/%d", slot, port)
+	}
+	return equipmentID + " " + ponPort
+}
+
+// emit reports an event to the control plane when an emitter is configured.
+func (e *Executor) emit(eventType string, severity agent.EventSeverity, content, entityID string, metadata map[string]interface{}) {
+	if e.emitEvent == nil {
+		return
+	}
+	event := &agent.EmitEventRequest{
+		EventType: eventType,
+		Severity:  severity,
+		Content:   content,
+		EntityID:  entityID,
+		Metadata:  metadata,
+	}
+	if err := e.emitEvent(event); err != nil {
+		log.Printf("[command] Failed to emit %s event: %v", eventType, err)
+	}
+}
+
+// handleAutoProvisionApprove provisions an ONU held for approval.
+func (e *Executor) handleAutoProvisionApprove(ctx context.Context, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	serial, _ := cmd.Payload["serial"].(string)
+	if serial == "" {
+		return nil, fmt.Errorf("serial is required")
+	}
+	if dryRunRequested(cmd) {
+		return nil, fmt.Errorf("dryRun is not supported for auto_provision_approve")
+	}
+	approval, ok := e.takeApproval(serial)
+	if !ok {
+		return nil, fmt.Errorf("no ONU %s is waiting for approval", serial)
+	}
+
+	result := e.provisionDiscovered(ctx, approval)
+	response := map[string]interface{}{
+		"success": result.Action == "provisioned",
+		"result":  result,
+	}
+	if result.Error != "" {
+		// Keep the ONU waiting so the approval can be retried
+		e.restoreApproval(approval)
+		return response, fmt.Errorf("failed to provision ONU %s: %s", serial, result.Error)
+	}
+	return response, nil
+}
+
+// handleAutoProvisionReject drops an ONU held for approval.
+func (e *Executor) handleAutoProvisionReject(ctx context.Context, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	serial, _ := cmd.Payload["serial"].(string)
+	if serial == "" {
+		return nil, fmt.Errorf("serial is required")
+	}
+	approval, ok := e.takeApproval(serial)
+	if !ok {
+		return nil, fmt.Errorf("no ONU %s is waiting for approval", serial)
+	}
+	log.Printf("[command] Rejected auto-provisioning of ONU %s on %s", serial, approval.EquipmentID)
+
+	return map[string]interface{}{
+		"success":  true,
+		"rejected": approval,
+	}, nil
+}
+
+// handleAutoProvisionPending lists the ONUs held for approval.
+func (e *Executor) handleAutoProvisionPending(ctx context.Context, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	pending := e.PendingApprovals()
+	return map[string]interface{}{
+		"pending": pending,
+		"count":   len(pending),
+	}, nil
+}
@@ -0,0 +1,330 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoProvisionPolicyMatch(t *testing.T) {
+	policy, err := NewAutoProvisionPolicy(AutoProvisionConfig{
+		Enabled: true,
+		Rules: []AutoProvisionRule{
+			{Name: "huawei-port1", Match: AutoProvisionMatch{Vendor: "HWTC", PONPorts: []string{"0/1"}}, Mode: AutoProvisionAuto},
+			{Name: "vsol-model", Match: AutoProvisionMatch{SerialPrefix: "gpon", Model: "V2801S", EquipmentIDs: []string{"olt-1"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		equipmentID string
+		discovery   types.ONUDiscovery
+		wantRule    string
+		wantMode    string
+	}{
+		{
+			name:        "vendor from serial prefix",
+			equipmentID: "olt-1",
+			discovery:   types.ONUDiscovery{Serial: "HWTC12345678", PONPort: "0/1"},
+			wantRule:    "huawei-port1",
+			wantMode:    AutoProvisionAuto,
+		},
+		{
+			name:        "other PON port",
+			equipmentID: "olt-1",
+			discovery:   types.ONUDiscovery{Serial: "HWTC12345678", PONPort: "0/2"},
+		},
+		{
+			name:        "serial prefix and model, default mode",
+			equipmentID: "olt-1",
+			discovery:   types.ONUDiscovery{Serial: "GPON00000001", PONPort: "0/2", Model: "v2801s"},
+			wantRule:    "vsol-model",
+			wantMode:    AutoProvisionApproval,
+		},
+		{
+			name:        "other equipment",
+			equipmentID: "olt-2",
+			discovery:   types.ONUDiscovery{Serial: "GPON00000001", PONPort: "0/2", Model: "V2801S"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, mode, ok := policy.Match(tt.equipmentID, tt.discovery)
+			if tt.wantRule == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.wantRule, rule.Name)
+			assert.Equal(t, tt.wantMode, mode)
+		})
+	}
+}
+
+func TestNewAutoProvisionPolicyValidation(t *testing.T) {
+	_, err := NewAutoProvisionPolicy(AutoProvisionConfig{Mode: "yolo"})
+	assert.ErrorContains(t, err, "invalid mode")
+
+	_, err = NewAutoProvisionPolicy(AutoProvisionConfig{Rules: []AutoProvisionRule{{Name: "a"}, {Name: "a"}}})
+	assert.ErrorContains(t, err, "duplicate rule")
+
+	_, err = NewAutoProvisionPolicy(AutoProvisionConfig{Rules: []AutoProvisionRule{{Name: "a", Template: AutoProvisionTemplate{VLAN: 5000}}}})
+	assert.ErrorContains(t, err, "invalid VLAN")
+}
+
+func TestLoadAutoProvisionPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	policy, err := LoadAutoProvisionPolicy(filepath.Join(dir, DefaultAutoProvisionFile))
+	require.NoError(t, err)
+	assert.False(t, policy.Enabled(), "missing file should disable auto-provisioning")
+
+	path := filepath.Join(dir, DefaultAutoProvisionFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"enabled": true,
+		"mode": "auto",
+		"rules": [{"name": "all", "template": {"lineProfile": "line_vlan_100", "vlan": 100}}]
+	}`), 0600))
+	policy, err = LoadAutoProvisionPolicy(path)
+	require.NoError(t, err)
+	assert.True(t, policy.Enabled())
+}
+
+func newAutoProvisionTestExecutor(t *testing.T, olt *fakeOLT, mode string, onus []ONUSnapshot) (*Executor, *[]*agent.EmitEventRequest) {
+	t.Helper()
+
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": olt})
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(CommandSpec{
+		Type:    "onu_provision",
+		CLI:     (*Executor).handleONUProvision,
+		Mutates: true,
+	}))
+	require.NoError(t, registry.Register(CommandSpec{
+		Type:    "auto_provision_approve",
+		Local:   (*Executor).handleAutoProvisionApprove,
+		Mutates: true,
+	}))
+	e.SetRegistry(registry)
+	polledAt := time.Now()
+	e.SetONUSnapshot(func(oltID string) ([]ONUSnapshot, time.Time, bool) {
+		return onus, polledAt, true
+	})
+
+	policy, err := NewAutoProvisionPolicy(AutoProvisionConfig{
+		Enabled: true,
+		Mode:    mode,
+		Rules: []AutoProvisionRule{{
+			Name:     "residential",
+			Match:    AutoProvisionMatch{SerialPrefix: "GPON"},
+			Template: AutoProvisionTemplate{ONUProfile: "HG8010H", LineProfile: "line_vlan_100", VLAN: 100, Description: "auto"},
+		}},
+	})
+	require.NoError(t, err)
+	e.SetAutoProvisionPolicy(policy)
+
+	var events []*agent.EmitEventRequest
+	e.SetEventEmitter(func(event *agent.EmitEventRequest) error {
+		events = append(events, event)
+		return nil
+	})
+	return e, &events
+}
+
+func TestAutoProvision_AutoMode(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, events := newAutoProvisionTestExecutor(t, olt, AutoProvisionAuto, []ONUSnapshot{
+		{PONPort: "0/1", ONUID: 1, Serial: "GPON0000000A"},
+		{PONPort: "0/1", ONUID: 2, Serial: "GPON0000000B"},
+		{PONPort: "0/2", ONUID: 3, Serial: "GPON0000000C"},
+	})
+
+	results := e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{
+		{Serial: "GPON00000001", PONPort: "0/1"},
+		{Serial: "HWTC00000001", PONPort: "0/1"},
+	})
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "provisioned", results[0].Action)
+	assert.Equal(t, 3, results[0].ONUID, "lowest free ONU ID on the port")
+	assert.Equal(t, "unmatched", results[1].Action)
+
+	onu := olt.onu("0/1", 3)
+	require.NotNil(t, onu)
+	assert.Equal(t, "GPON00000001", onu.SerialNumber)
+	assert.Equal(t, "line_vlan_100", onu.LineProfile)
+	assert.Equal(t, 100, onu.NativeVLAN)
+	assert.Equal(t, "auto", onu.Description)
+
+	require.Len(t, *events, 1)
+	assert.Equal(t, agent.EventTypeONUAutoProvisioned, (*events)[0].EventType)
+	assert.Equal(t, "olt-1", (*events)[0].EntityID)
+}
+
+func TestAutoProvision_FailureIsReported(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.addErr = assert.AnError
+	e, events := newAutoProvisionTestExecutor(t, olt, AutoProvisionAuto, nil)
+
+	results := e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1"}})
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "failed", results[0].Action)
+	assert.Contains(t, results[0].Error, "failed to provision ONU")
+	require.Len(t, *events, 1)
+	assert.Equal(t, agent.EventTypeONUAutoProvisionFailed, (*events)[0].EventType)
+	assert.Equal(t, agent.SeverityWarning, (*events)[0].Severity)
+}
+
+func TestAutoProvision_SamePortBatchGetsDistinctIDs(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, _ := newAutoProvisionTestExecutor(t, olt, AutoProvisionAuto, []ONUSnapshot{
+		{PONPort: "0/1", ONUID: 1, Serial: "GPON0000000A"},
+	})
+
+	results := e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{
+		{Serial: "GPON00000001", PONPort: "0/1"},
+		{Serial: "GPON00000002", PONPort: "0/1"},
+	})
+	require.Len(t, results, 2)
+	assert.Equal(t, 2, results[0].ONUID)
+	assert.Equal(t, 3, results[1].ONUID, "the ID given to the first ONU is not in the snapshot yet")
+
+	// A failed provisioning gives its ID back
+	olt.addErr = assert.AnError
+	results = e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000003", PONPort: "0/1"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, "failed", results[0].Action)
+	olt.addErr = nil
+	results = e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000003", PONPort: "0/1"}})
+	assert.Equal(t, 4, results[0].ONUID)
+}
+
+func TestAutoProvision_PortWrittenDifferently(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, _ := newAutoProvisionTestExecutor(t, olt, AutoProvisionAuto, []ONUSnapshot{
+		{PONPort: "0/0/1", ONUID: 1, Serial: "GPON0000000A"},
+		{PONPort: "0/0/1", ONUID: 2, Serial: "GPON0000000B"},
+	})
+
+	results := e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, "provisioned", results[0].Action)
+	assert.Equal(t, 3, results[0].ONUID, "IDs in use on frame/slot/port 0/0/1 are taken on 0/1")
+
+	// Reservations are shared by both spellings of the port
+	first, err := e.reserveONUID("olt-1", "0/1")
+	require.NoError(t, err)
+	second, err := e.reserveONUID("olt-1", "0/0/1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, first)
+	assert.Equal(t, 5, second)
+}
+
+func TestAutoProvision_FailedApprovalStaysPending(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, _ := newAutoProvisionTestExecutor(t, olt, AutoProvisionApproval, nil)
+	e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1"}})
+
+	olt.addErr = assert.AnError
+	approve := agent.PendingCommand{
+		ID: "cmd-1", Type: "auto_provision_approve", Payload: map[string]interface{}{"serial": "GPON00000001"},
+	}
+	_, err := e.handleAutoProvisionApprove(context.Background(), approve)
+	require.Error(t, err)
+	require.Len(t, e.PendingApprovals(), 1, "a failed approval can be retried")
+
+	olt.addErr = nil
+	_, err = e.handleAutoProvisionApprove(context.Background(), approve)
+	require.NoError(t, err)
+	assert.Empty(t, e.PendingApprovals())
+}
+
+func TestAutoProvision_PendingApprovalsExpire(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, _ := newAutoProvisionTestExecutor(t, olt, AutoProvisionApproval, nil)
+	e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1"}})
+
+	e.autoProvision.mu.Lock()
+	approval := e.autoProvision.pending["GPON00000001"]
+	approval.heldAt = time.Now().Add(-pendingApprovalTTL - time.Minute)
+	e.autoProvision.pending["GPON00000001"] = approval
+	e.autoProvision.mu.Unlock()
+
+	assert.Empty(t, e.PendingApprovals())
+}
+
+func TestAutoProvision_ApprovalMode(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, events := newAutoProvisionTestExecutor(t, olt, AutoProvisionApproval, nil)
+
+	results := e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1", Model: "V2801S"}})
+	require.Len(t, results, 1)
+	assert.Equal(t, "pending_approval", results[0].Action)
+	assert.Empty(t, olt.onus, "nothing is provisioned before approval")
+	require.Len(t, *events, 1)
+	assert.Equal(t, agent.EventTypeONUPendingApproval, (*events)[0].EventType)
+
+	pending := e.PendingApprovals()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "residential", pending[0].Rule)
+	assert.Equal(t, "V2801S", pending[0].Model)
+
+	result, err := e.handleAutoProvisionApprove(context.Background(), agent.PendingCommand{
+		ID: "cmd-1", Type: "auto_provision_approve", Payload: map[string]interface{}{"serial": "GPON00000001"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, result["success"])
+	assert.NotNil(t, olt.onu("0/1", 1))
+	assert.Empty(t, e.PendingApprovals())
+
+	_, err = e.handleAutoProvisionApprove(context.Background(), agent.PendingCommand{
+		ID: "cmd-2", Type: "auto_provision_approve", Payload: map[string]interface{}{"serial": "GPON00000001"},
+	})
+	assert.ErrorContains(t, err, "is waiting for approval")
+}
+
+func TestAutoProvision_BlackoutHoldsForApproval(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, _ := newAutoProvisionTestExecutor(t, olt, AutoProvisionAuto, nil)
+	config := e.oltConfigs["olt-1"]
+	now := time.Now().UTC()
+	config.BlackoutWindows = []agent.MaintenanceWindow{{
+		Start:    now.Add(-time.Hour).Format("15:04"),
+		End:      now.Add(time.Hour).Format("15:04"),
+		Timezone: "UTC",
+	}}
+	e.oltConfigs["olt-1"] = config
+
+	results := e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1"}})
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "pending_approval", results[0].Action)
+	pending := e.PendingApprovals()
+	require.Len(t, pending, 1)
+	assert.Contains(t, pending[0].Reason, "blocked by")
+	assert.Empty(t, olt.onus)
+}
+
+func TestHandleAutoProvisionReject(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	e, _ := newAutoProvisionTestExecutor(t, olt, AutoProvisionApproval, nil)
+	e.AutoProvision(context.Background(), "olt-1", []types.ONUDiscovery{{Serial: "GPON00000001", PONPort: "0/1"}})
+
+	result, err := e.handleAutoProvisionReject(context.Background(), agent.PendingCommand{
+		Payload: map[string]interface{}{"serial": "GPON00000001"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, result["success"])
+	assert.Empty(t, e.PendingApprovals())
+	assert.Empty(t, olt.onus)
+}
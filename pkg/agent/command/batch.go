@@ -0,0 +1,262 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+)
+
+const (
+	// defaultBatchConcurrency is the number of OLTs a batch works on at once.
+	defaultBatchConcurrency = 4
+	// maxBatchConcurrency caps the concurrency requested in the payload.
+	maxBatchConcurrency = 16
+)
+
+// batchOperation is one parsed item of a batch command.
+type batchOperation struct {
+	index int
+	cmd   agent.PendingCommand
+}
+
+// parseBatchOperations validates the batch payload. Operations default to the
+// batch command's EquipmentID and get IDs derived from the batch command ID.
+func parseBatchOperations(cmd agent.PendingCommand) ([]batchOperation, error) {
+	operationsRaw, ok := cmd.Payload["operations"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid operations payload: expected array")
+	}
+	if len(operationsRaw) == 0 {
+		return nil, fmt.Errorf("no operations provided")
+	}
+
+	ops := make([]batchOperation, 0, len(operationsRaw))
+	for i, opRaw := range operationsRaw {
+		opMap, ok := opRaw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation %d: invalid operation format", i)
+		}
+
+		cmdType, _ := opMap["type"].(string)
+		if cmdType == "" {
+			return nil, fmt.Errorf("operation %d: type is required", i)
+		}
+
+		equipmentID, _ := opMap["equipmentId"].(string)
+		if equipmentID == "" {
+			equipmentID = cmd.EquipmentID
+		}
+		if equipmentID == "" {
+			return nil, fmt.Errorf("operation %d: equipmentId is required", i)
+		}
+
+		payload, _ := opMap["payload"].(map[string]interface{})
+		if payload == nil {
+			payload = map[string]interface{}{}
+		}
+
+		ops = append(ops, batchOperation{
+			index: i,
+			cmd: agent.PendingCommand{
+				ID:          fmt.Sprintf("%s/%d", cmd.ID, i),
+				EquipmentID: equipmentID,
+				Type:        cmdType,
+				Payload:     payload,
+			},
+		})
+	}
+
+	return ops, nil
+}
+
+// batchProgress tracks item outcomes and streams them to the control plane.
+type batchProgress struct {
+	mu       sync.Mutex
+	e        *Executor
+	cmdID    string
+	progress agent.CommandProgressRequest
+}
+
+// record counts a finished item and pushes a progress update.
+func (p *batchProgress) record(item agent.BatchItemResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.progress.Completed++
+	switch item.Status {
+	case "succeeded":
+		p.progress.Succeeded++
+	case "failed":
+		p.progress.Failed++
+	default:
+		p.progress.Skipped++
+	}
+
+	if p.e.client == nil {
+		return
+	}
+	update := p.progress
+	update.Item = &item
+	if err := p.e.client.PushCommandProgress(p.cmdID, &update); err != nil {
+		log.Printf("[command] Failed to push progress for command %s: %v", p.cmdID, err)
+	}
+}
+
+// handleBatch fans a list of operations out across the OLTs managed by this
+// agent. Operations on the same OLT run in order, one at a time; up to
+// "concurrency" OLTs are worked on in parallel. With stopOnError: true the
+// first failure skips every operation that has not started yet.
+func (e *Executor) handleBatch(ctx context.Context, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	ops, err := parseBatchOperations(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// The batch is guarded as a whole; confirming it confirms its items
+	if err := e.checkBatchImpact(cmd, ops); err != nil {
+		return nil, err
+	}
+	if impactConfirmed(cmd) {
+		for _, op := range ops {
+			op.cmd.Payload["confirmImpact"] = true
+		}
+	}
+
+	stopOnError, _ := cmd.Payload["stopOnError"].(bool)
+	concurrency := defaultBatchConcurrency
+	if c, ok := cmd.Payload["concurrency"].(float64); ok && c >= 1 {
+		concurrency = min(int(c), maxBatchConcurrency)
+	}
+
+	// Group operations per OLT, keeping payload order
+	var groupOrder []string
+	groups := make(map[string][]batchOperation)
+	for _, op := range ops {
+		if _, ok := groups[op.cmd.EquipmentID]; !ok {
+			groupOrder = append(groupOrder, op.cmd.EquipmentID)
+		}
+		groups[op.cmd.EquipmentID] = append(groups[op.cmd.EquipmentID], op)
+	}
+
+	log.Printf("[command] Starting batch %s: %d operations across %d OLTs (concurrency: %d, stopOnError: %v)",
+		cmd.ID, len(ops), len(groupOrder), concurrency, stopOnError)
+
+	progress := &batchProgress{e: e, cmdID: cmd.ID, progress: agent.CommandProgressRequest{Total: len(ops)}}
+	items := make([]agent.BatchItemResult, len(ops))
+	var stopped atomic.Bool
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, equipmentID := range groupOrder {
+		wg.Add(1)
+		go func(group []batchOperation) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, op := range group {
+				var item agent.BatchItemResult
+				if stopped.Load() || ctx.Err() != nil {
+					item = agent.BatchItemResult{
+						Index:       op.index,
+						EquipmentID: op.cmd.EquipmentID,
+						Type:        op.cmd.Type,
+						Status:      "skipped",
+						Error:       "skipped after an earlier failure",
+					}
+					if ctx.Err() != nil {
+						item.Error = ctx.Err().Error()
+					}
+				} else {
+					item = e.runBatchItem(ctx, op)
+					if item.Status == "failed" && stopOnError {
+						stopped.Store(true)
+					}
+				}
+				items[op.index] = item
+				progress.record(item)
+			}
+		}(groups[equipmentID])
+	}
+	wg.Wait()
+
+	// Refresh every OLT a successful operation asked to update
+	refreshed := make(map[string]bool)
+	for _, item := range items {
+		if immediateUpdate, _ := item.Result["immediateUpdate"].(bool); immediateUpdate && !refreshed[item.EquipmentID] {
+			refreshed[item.EquipmentID] = true
+			e.triggerPoll(item.EquipmentID)
+		}
+	}
+
+	summary := progress.progress
+	log.Printf("[command] Batch %s finished: %d succeeded, %d failed, %d skipped",
+		cmd.ID, summary.Succeeded, summary.Failed, summary.Skipped)
+
+	result := map[string]interface{}{
+		"total":     summary.Total,
+		"succeeded": summary.Succeeded,
+		"failed":    summary.Failed,
+		"skipped":   summary.Skipped,
+		"items":     items,
+	}
+	if summary.Failed > 0 {
+		return result, fmt.Errorf("batch completed with %d of %d operations failed", summary.Failed, summary.Total)
+	}
+	return result, nil
+}
+
+// runBatchItem executes one batch operation on its OLT.
+func (e *Executor) runBatchItem(ctx context.Context, op batchOperation) agent.BatchItemResult {
+	startTime := time.Now()
+	item := agent.BatchItemResult{
+		Index:       op.index,
+		EquipmentID: op.cmd.EquipmentID,
+		Type:        op.cmd.Type,
+	}
+	fail := func(err error) agent.BatchItemResult {
+		item.Status = "failed"
+		item.Error = err.Error()
+		item.DurationMs = time.Since(startTime).Milliseconds()
+		return item
+	}
+
+	spec, ok := e.commands().Lookup(op.cmd.Type)
+	if !ok {
+		return fail(fmt.Errorf("unsupported command type: %s", op.cmd.Type))
+	}
+	if spec.Local != nil {
+		return fail(fmt.Errorf("%s operations cannot be nested in a batch", op.cmd.Type))
+	}
+
+	oltConfig, ok := e.oltConfig(op.cmd.EquipmentID)
+	if !ok {
+		return fail(fmt.Errorf("OLT configuration not found for equipment %s", op.cmd.EquipmentID))
+	}
+
+	// Batches run now, so items in an OLT blackout fail instead of waiting
+	if spec.Mutates && !dryRunRequested(op.cmd) {
+		runAt, reason, err := scheduledRunTime(op.cmd, true, oltConfig.BlackoutWindows, startTime)
+		if err != nil {
+			return fail(err)
+		}
+		if runAt.After(startTime) {
+			return fail(fmt.Errorf("blocked by %s until %s", reason, runAt.Format(time.RFC3339)))
+		}
+	}
+
+	result, err := e.execute(ctx, spec, oltConfig, op.cmd)
+	item.Result = result
+	if err != nil {
+		return fail(err)
+	}
+
+	item.Status = "succeeded"
+	item.DurationMs = time.Since(startTime).Milliseconds()
+	return item
+}
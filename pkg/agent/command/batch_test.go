@@ -0,0 +1,143 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchTestExecutor returns an executor with two OLTs and a registry with
+// the batch command and a "custom_op" that fails when its payload says so.
+func newBatchTestExecutor(t *testing.T) (*Executor, *[]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var ran []string
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(CommandSpec{Type: "batch", Local: (*Executor).handleBatch}))
+	require.NoError(t, registry.Register(CommandSpec{
+		Type: "custom_op",
+		CLI: func(e *Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			mu.Lock()
+			ran = append(ran, cmd.ID)
+			mu.Unlock()
+			if fail, _ := cmd.Payload["fail"].(bool); fail {
+				return nil, errors.New("operation failed")
+			}
+			return map[string]interface{}{"success": true, "olt": cmd.EquipmentID}, nil
+		},
+	}))
+
+	e := newTestExecutor()
+	e.SetRegistry(registry)
+	e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		return &mockCLIDriver{vendor: config.Vendor}, nil
+	}
+	e.UpdateOLTConfigs([]agent.OLTConfig{
+		{ID: "olt-1", Vendor: "huawei"},
+		{ID: "olt-2", Vendor: "vsol"},
+	})
+	return e, &ran
+}
+
+func batchCommand(stopOnError bool, operations ...map[string]interface{}) agent.PendingCommand {
+	ops := make([]interface{}, len(operations))
+	for i, op := range operations {
+		ops[i] = op
+	}
+	return agent.PendingCommand{
+		ID:   "batch-1",
+		Type: "batch",
+		Payload: map[string]interface{}{
+			"operations":  ops,
+			"stopOnError": stopOnError,
+			"concurrency": float64(1),
+		},
+	}
+}
+
+func TestParseBatchOperations(t *testing.T) {
+	_, err := parseBatchOperations(agent.PendingCommand{Payload: map[string]interface{}{}})
+	assert.Error(t, err)
+
+	_, err = parseBatchOperations(batchCommand(false, map[string]interface{}{"equipmentId": "olt-1"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "operation 0: type is required")
+
+	_, err = parseBatchOperations(batchCommand(false, map[string]interface{}{"type": "custom_op"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "equipmentId is required")
+
+	cmd := batchCommand(false, map[string]interface{}{"type": "custom_op"})
+	cmd.EquipmentID = "olt-1"
+	ops, err := parseBatchOperations(cmd)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, "batch-1/0", ops[0].cmd.ID)
+	assert.Equal(t, "olt-1", ops[0].cmd.EquipmentID)
+}
+
+func TestHandleBatch(t *testing.T) {
+	t.Run("fans out across OLTs", func(t *testing.T) {
+		e, ran := newBatchTestExecutor(t)
+		cmd := batchCommand(false,
+			map[string]interface{}{"equipmentId": "olt-1", "type": "custom_op"},
+			map[string]interface{}{"equipmentId": "olt-2", "type": "custom_op"},
+			map[string]interface{}{"equipmentId": "olt-1", "type": "custom_op"},
+		)
+
+		result, err := e.handleBatch(context.Background(), cmd)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result["succeeded"])
+		assert.Len(t, *ran, 3)
+
+		items := result["items"].([]agent.BatchItemResult)
+		require.Len(t, items, 3)
+		for i, item := range items {
+			assert.Equal(t, i, item.Index)
+			assert.Equal(t, "succeeded", item.Status)
+			assert.Equal(t, item.EquipmentID, item.Result["olt"])
+		}
+	})
+
+	t.Run("reports item failures", func(t *testing.T) {
+		e, _ := newBatchTestExecutor(t)
+		cmd := batchCommand(false,
+			map[string]interface{}{"equipmentId": "olt-1", "type": "custom_op", "payload": map[string]interface{}{"fail": true}},
+			map[string]interface{}{"equipmentId": "olt-9", "type": "custom_op"},
+			map[string]interface{}{"equipmentId": "olt-2", "type": "batch"},
+			map[string]interface{}{"equipmentId": "olt-2", "type": "custom_op"},
+		)
+
+		result, err := e.handleBatch(context.Background(), cmd)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "3 of 4 operations failed")
+
+		items := result["items"].([]agent.BatchItemResult)
+		assert.Equal(t, "operation failed", items[0].Error)
+		assert.Contains(t, items[1].Error, "OLT configuration not found")
+		assert.Contains(t, items[2].Error, "cannot be nested")
+		assert.Equal(t, "succeeded", items[3].Status)
+	})
+
+	t.Run("stop on error skips remaining operations", func(t *testing.T) {
+		e, ran := newBatchTestExecutor(t)
+		cmd := batchCommand(true,
+			map[string]interface{}{"equipmentId": "olt-1", "type": "custom_op", "payload": map[string]interface{}{"fail": true}},
+			map[string]interface{}{"equipmentId": "olt-1", "type": "custom_op"},
+		)
+
+		result, err := e.handleBatch(context.Background(), cmd)
+		require.Error(t, err)
+		assert.Equal(t, 1, result["failed"])
+		assert.Equal(t, 1, result["skipped"])
+		assert.Equal(t, []string{"batch-1/0"}, *ran)
+	})
+}
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	southbound "github.com/nanoncore/nano-southbound"
+)
+
+// SupportedCommandTypes returns every command type registered in the default registry, sorted.
+func SupportedCommandTypes() []string {
+	return DefaultRegistry.Types()
+}
+
+// Capabilities builds a capability report for every configured OLT.
+// No connection is made to the devices: the matrix is derived from the CLI
+// driver's VendorCapabilities and the southbound vendor capability matrix.
+func (e *Executor) Capabilities() []agent.OLTCapabilities {
+	configs := e.oltConfigList()
+	reports := make([]agent.OLTCapabilities, 0, len(configs))
+	for _, oltConfig := range configs {
+		reports = append(reports, e.oltCapabilities(oltConfig))
+	}
+	return reports
+}
+
+// oltCapabilities builds the capability report for a single OLT.
+func (e *Executor) oltCapabilities(oltConfig agent.OLTConfig) agent.OLTCapabilities {
+	report := agent.OLTCapabilities{
+		OLTID:  oltConfig.ID,
+		Vendor: oltConfig.Vendor,
+		Model:  oltConfig.Model,
+	}
+
+	// The CLI driver is created but never connected; GetCapabilities is static.
+	var caps *cli.VendorCapabilities
+	driver, err := e.createDriver(oltConfig)
+	if err != nil {
+		report.Error = err.Error()
+	} else {
+		caps = driver.GetCapabilities()
+		if caps == nil {
+			caps = cli.GetCapabilities(oltConfig.Vendor, oltConfig.Model)
+		}
+		report.CLI = caps
+	}
+
+	snmpRead := false
+	if sbCaps, ok := southbound.GetVendorCapabilities(southbound.Vendor(strings.ToLower(oltConfig.Vendor))); ok {
+		protocols := make([]string, 0, len(sbCaps.SupportedProtocols))
+		for _, p := range sbCaps.SupportedProtocols {
+			protocols = append(protocols, string(p))
+			if p == southbound.ProtocolSNMP && oltConfig.Protocols.SNMP.Enabled {
+				snmpRead = true
+			}
+		}
+		report.Southbound = &agent.SouthboundCapabilities{
+			PrimaryProtocol:    string(sbCaps.PrimaryProtocol),
+			SupportedProtocols: protocols,
+			ConfigMethod:       string(sbCaps.ConfigMethod),
+			TelemetryMethod:    string(sbCaps.TelemetryMethod),
+			SupportsStreaming:  sbCaps.SupportsStreaming,
+		}
+	}
+
+	registry := e.commands()
+	report.SupportedCommands = []string{}
+	for _, cmdType := range registry.Types() {
+		spec, _ := registry.Lookup(cmdType)
+		if commandSupported(spec, caps, oltConfig.Vendor, snmpRead) {
+			report.SupportedCommands = append(report.SupportedCommands, cmdType)
+		} else {
+			report.UnsupportedCommands = append(report.UnsupportedCommands, cmdType)
+		}
+	}
+
+	return report
+}
+
+// commandSupported evaluates a command spec against the OLT's capabilities.
+// caps is nil when no CLI driver is available for the vendor.
+func commandSupported(spec CommandSpec, caps *cli.VendorCapabilities, vendor string, snmpRead bool) bool {
+	if spec.Preferred == TransportDriverV2 && snmpRead {
+		return true
+	}
+	if caps == nil {
+		return false
+	}
+	if len(spec.Vendors) > 0 {
+		matched := false
+		for _, v := range spec.Vendors {
+			if strings.EqualFold(v, vendor) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if spec.Requires != nil && !spec.Requires(caps) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package command
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportedCommandTypes(t *testing.T) {
+	cmdTypes := SupportedCommandTypes()
+
+	assert.Contains(t, cmdTypes, "onu_provision")
+	assert.Contains(t, cmdTypes, "onu_bulk_provision")
+	assert.Contains(t, cmdTypes, "service_port_add")
+	assert.IsIncreasing(t, cmdTypes)
+}
+
+func TestExecutorCapabilities(t *testing.T) {
+	e := newTestExecutor()
+	e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		switch config.Vendor {
+		case "huawei":
+			return &mockCLIDriver{vendor: "huawei", capabilities: cli.FullCapabilities("huawei", "")}, nil
+		case "zte":
+			return &mockCLIDriver{vendor: "zte", capabilities: &cli.VendorCapabilities{
+				Vendor:          "zte",
+				HasCLI:          true,
+				SupportsONUInfo: true,
+				SupportsVLAN:    true,
+			}}, nil
+		default:
+			return nil, errors.New("unsupported vendor: " + config.Vendor)
+		}
+	}
+	e.UpdateOLTConfigs([]agent.OLTConfig{
+		{ID: "olt-b", Vendor: "zte"},
+		{ID: "olt-a", Vendor: "huawei", Protocols: agent.OLTProtocols{SNMP: agent.SNMPConfig{Enabled: true}}},
+		{ID: "olt-c", Vendor: "acme"},
+	})
+
+	reports := e.Capabilities()
+	require.Len(t, reports, 3)
+
+	t.Run("full capabilities", func(t *testing.T) {
+		huawei := reports[0]
+		assert.Equal(t, "olt-a", huawei.OLTID)
+		assert.Equal(t, SupportedCommandTypes(), huawei.SupportedCommands)
+		assert.Empty(t, huawei.UnsupportedCommands)
+		require.NotNil(t, huawei.Southbound)
+		assert.Contains(t, huawei.Southbound.SupportedProtocols, "snmp")
+	})
+
+	t.Run("partial capabilities", func(t *testing.T) {
+		zte := reports[1]
+		assert.Equal(t, "olt-b", zte.OLTID)
+		assert.Contains(t, zte.SupportedCommands, "onu_list")
+		assert.Contains(t, zte.SupportedCommands, "vlan_list")
+		assert.Contains(t, zte.UnsupportedCommands, "onu_provision")
+		assert.Contains(t, zte.UnsupportedCommands, "port_enable")
+		// VLAN creation uses hand-written Huawei/V-SOL sequences only
+		assert.Contains(t, zte.UnsupportedCommands, "vlan_create")
+	})
+
+	t.Run("no driver", func(t *testing.T) {
+		acme := reports[2]
+		assert.Equal(t, "olt-c", acme.OLTID)
+		assert.NotEmpty(t, acme.Error)
+		assert.Nil(t, acme.CLI)
+		assert.Nil(t, acme.Southbound)
+		assert.Empty(t, acme.SupportedCommands)
+		assert.Len(t, acme.UnsupportedCommands, len(SupportedCommandTypes()))
+	})
+}
+
+func TestCommandSupportedSNMPRead(t *testing.T) {
+	req, ok := DefaultRegistry.Lookup("onu_list")
+	require.True(t, ok)
+	provision, ok := DefaultRegistry.Lookup("onu_provision")
+	require.True(t, ok)
+
+	assert.True(t, commandSupported(req, nil, "zte", true))
+	assert.False(t, commandSupported(req, nil, "zte", false))
+	assert.False(t, commandSupported(provision, nil, "zte", true))
+}
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+// DefaultCLIPolicyFile is the cli_exec policy file name inside the config directory.
+const DefaultCLIPolicyFile = "cli_policy.json"
+
+// maxCLIExecOutput caps the output returned per command.
+const maxCLIExecOutput = 64 * 1024
+
+// defaultCLIAllow permits read-only display/show commands only.
+var defaultCLIAllow = []string{`^display\s`, `^show\s`}
+
+// CLIPolicyRules is an allowlist and denylist of command regexes.
+type CLIPolicyRules struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// CLIPolicyConfig is the on-disk cli_exec policy. Vendor allowlists replace
+// the default allowlist; denylists from both are always applied.
+type CLIPolicyConfig struct {
+	Default CLIPolicyRules            `json:"default"`
+	Vendors map[string]CLIPolicyRules `json:"vendors,omitempty"`
+}
+
+// CLIPolicy decides which raw commands cli_exec may run.
+type CLIPolicy struct {
+	allow       []*regexp.Regexp
+	deny        []*regexp.Regexp
+	vendorAllow map[string][]*regexp.Regexp
+	vendorDeny  map[string][]*regexp.Regexp
+}
+
+// DefaultCLIPolicy allows display/show commands on every vendor.
+func DefaultCLIPolicy() *CLIPolicy {
+	policy, err := NewCLIPolicy(CLIPolicyConfig{})
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+// NewCLIPolicy compiles a policy config. An empty default allowlist falls
+// back to display/show commands only.
+func NewCLIPolicy(config CLIPolicyConfig) (*CLIPolicy, error) {
+	allow := config.Default.Allow
+	if len(allow) == 0 {
+		allow = defaultCLIAllow
+	}
+
+	policy := &CLIPolicy{
+		vendorAllow: make(map[string][]*regexp.Regexp),
+		vendorDeny:  make(map[string][]*regexp.Regexp),
+	}
+	var err error
+	if policy.allow, err = compilePatterns(allow); err != nil {
+		return nil, err
+	}
+	if policy.deny, err = compilePatterns(config.Default.Deny); err != nil {
+		return nil, err
+	}
+	for vendor, rules := range config.Vendors {
+		vendor = strings.ToLower(vendor)
+		if policy.vendorAllow[vendor], err = compilePatterns(rules.Allow); err != nil {
+			return nil, fmt.Errorf("vendor %s: %w", vendor, err)
+		}
+		if policy.vendorDeny[vendor], err = compilePatterns(rules.Deny); err != nil {
+			return nil, fmt.Errorf("vendor %s: %w", vendor, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// LoadCLIPolicy reads a policy from a JSON file. A missing file yields the
+// default policy.
+func LoadCLIPolicy(path string) (*CLIPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultCLIPolicy(), nil
+		}
+		return nil, fmt.Errorf("failed to read CLI policy: %w", err)
+	}
+
+	var config CLIPolicyConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse CLI policy: %w", err)
+	}
+
+	return NewCLIPolicy(config)
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Check returns an error if the command may not run on the vendor's OLTs.
+func (p *CLIPolicy) Check(vendor, command string) error {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return fmt.Errorf("command is empty")
+	}
+	if strings.IndexFunc(command, unicode.IsControl) >= 0 {
+		return fmt.Errorf("command must be a single line without control characters")
+	}
+
+	vendor = strings.ToLower(vendor)
+	for _, deny := range [][]*regexp.Regexp{p.deny, p.vendorDeny[vendor]} {
+		for _, re := range deny {
+			if re.MatchString(command) {
+				return fmt.Errorf("command %q is denied by policy (%s)", command, re)
+			}
+		}
+	}
+
+	allow := p.allow
+	if vendorAllow := p.vendorAllow[vendor]; len(vendorAllow) > 0 {
+		allow = vendorAllow
+	}
+	for _, re := range allow {
+		if re.MatchString(command) {
+			return nil
+		}
+	}
+	return fmt.Errorf("command %q is not allowed by policy for vendor %s", command, vendor)
+}
+
+// SetCLIPolicy sets the policy applied to cli_exec commands.
+func (e *Executor) SetCLIPolicy(policy *CLIPolicy) {
+	e.cliPolicy = policy
+}
+
+// cliExecCommands extracts the commands from a cli_exec payload, which may
+// carry a single "command" or a "commands" list.
+func cliExecCommands(cmd agent.PendingCommand) ([]string, error) {
+	var commands []string
+	if command, ok := cmd.Payload["command"].(string); ok && command != "" {
+		commands = append(commands, command)
+	}
+	if list, ok := cmd.Payload["commands"].([]interface{}); ok {
+		for i, c := range list {
+			command, ok := c.(string)
+			if !ok {
+				return nil, fmt.Errorf("commands[%d] must be a string", i)
+			}
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	return commands, nil
+}
+
+// handleCLIExec runs operator-supplied commands through the CLI driver.
+// Every command is checked against the policy before any of them runs.
+func (e *Executor) handleCLIExec(ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	commands, err := cliExecCommands(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := e.cliPolicy
+	if policy == nil {
+		policy = DefaultCLIPolicy()
+	}
+	for _, command := range commands {
+		if err := policy.Check(driver.Vendor(), command); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs := make([]map[string]interface{}, 0, len(commands))
+	for _, command := range commands {
+		command = strings.TrimSpace(command)
+		reportStep(ctx, "executing", command, nil)
+
+		output, err := driver.Execute(ctx, command)
+		if err != nil {
+			return map[string]interface{}{
+				"outputs": outputs,
+			}, fmt.Errorf("command %q failed: %w", command, err)
+		}
+
+		truncated := len(output) > maxCLIExecOutput
+		if truncated {
+			output = output[:maxCLIExecOutput]
+		}
+		outputs = append(outputs, map[string]interface{}{
+			"command":   command,
+			"output":    output,
+			"truncated": truncated,
+		})
+	}
+
+	return map[string]interface{}{
+		"outputs": outputs,
+	}, nil
+}
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLIPolicyCheck(t *testing.T) {
+	defaultPolicy := DefaultCLIPolicy()
+	custom, err := NewCLIPolicy(CLIPolicyConfig{
+		Default: CLIPolicyRules{Deny: []string{`current-configuration`}},
+		Vendors: map[string]CLIPolicyRules{
+			"VSOL": {Allow: []string{`^show\s`, `^ping\s`}, Deny: []string{`^show running`}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		policy  *CLIPolicy
+		vendor  string
+		command string
+		allowed bool
+	}{
+		{"default allows display", defaultPolicy, "huawei", "display ont info 0 1 1 1", true},
+		{"default allows show", defaultPolicy, "vsol", "  show onu info  ", true},
+		{"default rejects config", defaultPolicy, "huawei", "undo ont 0 1 1", false},
+		{"rejects multi-line", defaultPolicy, "huawei", "display version\nreboot", false},
+		{"rejects empty", defaultPolicy, "huawei", " ", false},
+		{"default deny applies to every vendor", custom, "huawei", "display current-configuration", false},
+		{"vendor allowlist replaces default", custom, "vsol", "ping 10.0.0.1", true},
+		{"vendor allowlist excludes display", custom, "vsol", "display version", false},
+		{"vendor deny", custom, "vsol", "show running-config", false},
+		{"other vendors keep default allowlist", custom, "huawei", "ping 10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.vendor, tt.command)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	_, err = NewCLIPolicy(CLIPolicyConfig{Default: CLIPolicyRules{Allow: []string{`(`}}})
+	assert.Error(t, err)
+}
+
+func TestLoadCLIPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	policy, err := LoadCLIPolicy(filepath.Join(dir, DefaultCLIPolicyFile))
+	require.NoError(t, err)
+	assert.NoError(t, policy.Check("huawei", "display version"))
+
+	path := filepath.Join(dir, "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"vendors":{"huawei":{"allow":["^display ont"]}}}`), 0600))
+	policy, err = LoadCLIPolicy(path)
+	require.NoError(t, err)
+	assert.NoError(t, policy.Check("huawei", "display ont info 0 1 1 1"))
+	assert.Error(t, policy.Check("huawei", "display version"))
+}
+
+func TestHandleCLIExec(t *testing.T) {
+	var executed []string
+	mock := &mockCLIDriver{
+		vendor: "huawei",
+		executeFunc: func(ctx context.Context, cmd string) (string, error) {
+			executed = append(executed, cmd)
+			if cmd == "display board 0" {
+				return "", errors.New("timeout")
+			}
+			return "output of " + cmd, nil
+		},
+	}
+	e := newTestExecutor()
+
+	t.Run("runs allowed commands", func(t *testing.T) {
+		executed = nil
+		cmd := agent.PendingCommand{Type: "cli_exec", Payload: map[string]interface{}{
+			"commands": []interface{}{"display version", "display ont info 0 1 1 1"},
+		}}
+		result, err := e.handleCLIExec(context.Background(), mock, cmd)
+		require.NoError(t, err)
+		outputs := result["outputs"].([]map[string]interface{})
+		require.Len(t, outputs, 2)
+		assert.Equal(t, "output of display version", outputs[0]["output"])
+		assert.Equal(t, false, outputs[0]["truncated"])
+	})
+
+	t.Run("rejects the whole request before running anything", func(t *testing.T) {
+		executed = nil
+		cmd := agent.PendingCommand{Type: "cli_exec", Payload: map[string]interface{}{
+			"commands": []interface{}{"display version", "reboot system"},
+		}}
+		_, err := e.handleCLIExec(context.Background(), mock, cmd)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not allowed by policy")
+		assert.Empty(t, executed)
+	})
+
+	t.Run("returns outputs gathered before a failure", func(t *testing.T) {
+		cmd := agent.PendingCommand{Type: "cli_exec", Payload: map[string]interface{}{
+			"commands": []interface{}{"display version", "display board 0"},
+		}}
+		result, err := e.handleCLIExec(context.Background(), mock, cmd)
+		require.Error(t, err)
+		assert.Len(t, result["outputs"], 1)
+	})
+
+	t.Run("requires a command", func(t *testing.T) {
+		_, err := e.handleCLIExec(context.Background(), mock, agent.PendingCommand{Type: "cli_exec", Payload: map[string]interface{}{}})
+		assert.Error(t, err)
+	})
+}
+
+func TestCLIExecIsAudited(t *testing.T) {
+	spec, ok := DefaultRegistry.Lookup("cli_exec")
+	require.True(t, ok)
+	assert.True(t, spec.Audit)
+	assert.False(t, spec.Mutates)
+}
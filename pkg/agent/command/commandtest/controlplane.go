@@ -0,0 +1,193 @@
+// Package commandtest runs PendingCommands end to end through a real
+// command.Executor for handler regression tests. The executor talks to a
+// fake control plane served by httptest and drives the registered vendor
+// CLI driver against a recorded transcript (see package clitest).
+package commandtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+)
+
+// ControlPlane is a fake control plane that records what the agent reports
+// about its commands, ONUs and events.
+type ControlPlane struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	requests  []string // "METHOD /path", in arrival order
+	acks      []string
+	results   map[string]agent.CommandResultRequest
+	progress  map[string][]agent.CommandProgressRequest
+	partials  map[string][]agent.CommandPartialResultRequest
+	scheduled map[string]agent.CommandScheduledRequest
+	onus      map[string][]agent.ONUData // OLT ID -> pushed ONUs
+	events    []agent.EmitEventRequest
+}
+
+// NewControlPlane starts a fake control plane that is shut down when the test ends.
+func NewControlPlane(t testing.TB) *ControlPlane {
+	t.Helper()
+
+	c := &ControlPlane{
+		results:   make(map[string]agent.CommandResultRequest),
+		progress:  make(map[string][]agent.CommandProgressRequest),
+		partials:  make(map[string][]agent.CommandPartialResultRequest),
+		scheduled: make(map[string]agent.CommandScheduledRequest),
+		onus:      make(map[string][]agent.ONUData),
+	}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := c.handle(r); err != nil {
+			t.Errorf("control plane: %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	t.Cleanup(c.server.Close)
+
+	return c
+}
+
+// URL returns the control plane's base URL.
+func (c *ControlPlane) URL() string {
+	return c.server.URL
+}
+
+// Client returns an agent client connected to the control plane.
+func (c *ControlPlane) Client() *agent.Client {
+	return agent.NewClient(c.server.URL, "test-token")
+}
+
+// handle records one request.
+func (c *ControlPlane) handle(r *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, r.Method+" "+r.URL.Path)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 5 && parts[0] == "api" && parts[2] == "commands":
+		id := parts[3]
+		switch parts[4] {
+		case "ack":
+			c.acks = append(c.acks, id)
+		case "result":
+			var req agent.CommandResultRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return err
+			}
+			if _, ok := c.results[id]; ok {
+				return fmt.Errorf("result for command %s pushed twice", id)
+			}
+			c.results[id] = req
+		case "progress":
+			var req agent.CommandProgressRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return err
+			}
+			c.progress[id] = append(c.progress[id], req)
+		case "partial":
+			var req agent.CommandPartialResultRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return err
+			}
+			c.partials[id] = append(c.partials[id], req)
+		case "schedule":
+			var req agent.CommandScheduledRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				return err
+			}
+			c.scheduled[id] = req
+		default:
+			return fmt.Errorf("unknown command endpoint")
+		}
+	case len(parts) == 5 && parts[0] == "api" && parts[2] == "equipment" && parts[4] == "onus":
+		var req agent.PushONUsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return err
+		}
+		c.onus[parts[3]] = append(c.onus[parts[3]], req.ONUs...)
+	case r.URL.Path == "/api/network-events":
+		var req agent.EmitEventRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return err
+		}
+		c.events = append(c.events, req)
+	default:
+		return fmt.Errorf("unexpected request")
+	}
+	return nil
+}
+
+// Requests returns every request received, as "METHOD /path", in order.
+func (c *ControlPlane) Requests() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.requests...)
+}
+
+// Acked reports whether a command was acknowledged.
+func (c *ControlPlane) Acked(commandID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.acks {
+		if id == commandID {
+			return true
+		}
+	}
+	return false
+}
+
+// Result returns the final result pushed for a command.
+func (c *ControlPlane) Result(commandID string) (agent.CommandResultRequest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[commandID]
+	return result, ok
+}
+
+// Progress returns the progress updates pushed for a command.
+func (c *ControlPlane) Progress(commandID string) []agent.CommandProgressRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agent.CommandProgressRequest(nil), c.progress[commandID]...)
+}
+
+// Steps returns the intermediate steps pushed for a command.
+func (c *ControlPlane) Steps(commandID string) []agent.CommandPartialResultRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agent.CommandPartialResultRequest(nil), c.partials[commandID]...)
+}
+
+// Scheduled returns the schedule report for a command held for later.
+func (c *ControlPlane) Scheduled(commandID string) (agent.CommandScheduledRequest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	scheduled, ok := c.scheduled[commandID]
+	return scheduled, ok
+}
+
+// ONUs returns the ONU updates pushed for an OLT.
+func (c *ControlPlane) ONUs(oltID string) []agent.ONUData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agent.ONUData(nil), c.onus[oltID]...)
+}
+
+// Events returns the network events emitted.
+func (c *ControlPlane) Events() []agent.EmitEventRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]agent.EmitEventRequest(nil), c.events...)
+}
@@ -0,0 +1,179 @@
+package commandtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/command"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli/clitest"
+
+	// Register the vendor CLI drivers the transcripts are replayed against
+	_ "github.com/nanoncore/nano-agent/pkg/southbound/vendors/huawei"
+	_ "github.com/nanoncore/nano-agent/pkg/southbound/vendors/vsol"
+)
+
+// DefaultOLTID is the equipment ID of the harness OLT.
+const DefaultOLTID = "olt-1"
+
+// Options configures a Harness.
+type Options struct {
+	// Vendor selects the registered CLI driver (e.g. "huawei", "vsol").
+	Vendor string
+	// Model is passed to the driver for model-specific capabilities.
+	Model string
+	// Transcript is the path of the recorded CLI session to replay.
+	Transcript string
+	// OLT overrides the OLT configuration. ID, Vendor and Model default to
+	// DefaultOLTID and the options above.
+	OLT agent.OLTConfig
+	// Registry overrides the command registry. Nil means CLIOnly(command.DefaultRegistry).
+	Registry *command.Registry
+}
+
+// Harness runs commands through a command.Executor wired to a fake control
+// plane and a replayed CLI session.
+type Harness struct {
+	ControlPlane *ControlPlane
+	Executor     *command.Executor
+	Replay       *clitest.Replay
+	OLT          agent.OLTConfig
+
+	t    testing.TB
+	next int
+}
+
+// New creates a harness. The test fails at cleanup if the commands sent to
+// the OLT diverged from the transcript or left recorded commands unsent.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+
+	transcript, err := clitest.LoadTranscript(opts.Transcript)
+	if err != nil {
+		t.Fatalf("commandtest: %v", err)
+	}
+	replay := clitest.NewReplay(transcript)
+
+	olt := opts.OLT
+	if olt.ID == "" {
+		olt.ID = DefaultOLTID
+	}
+	if olt.Vendor == "" {
+		olt.Vendor = opts.Vendor
+	}
+	if olt.Model == "" {
+		olt.Model = opts.Model
+	}
+	if olt.Address == "" {
+		olt.Address = "192.0.2.1"
+	}
+	if olt.Protocols.SSH.Username == "" {
+		olt.Protocols.SSH.Username = "admin"
+		olt.Protocols.SSH.Password = "admin"
+	}
+
+	controlPlane := NewControlPlane(t)
+	executor := command.NewExecutor(controlPlane.Client(), func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		config.Dial = replay.Dial
+		return cli.CreateDriver(config, olt.Model)
+	})
+	executor.UpdateOLTConfigs([]agent.OLTConfig{olt})
+
+	registry := opts.Registry
+	if registry == nil {
+		registry = CLIOnly(command.DefaultRegistry)
+	}
+	executor.SetRegistry(registry)
+
+	// Keep the production limits except the pause between sessions, which
+	// would only slow tests down
+	guard := command.DefaultGuardConfig()
+	guard.MinSessionInterval = 0
+	executor.SetGuardConfig(guard)
+
+	h := &Harness{
+		ControlPlane: controlPlane,
+		Executor:     executor,
+		Replay:       replay,
+		OLT:          olt,
+		t:            t,
+	}
+	t.Cleanup(func() {
+		if err := replay.Err(); err != nil {
+			t.Errorf("commandtest: %v", err)
+		}
+	})
+	return h
+}
+
+// Run executes a command and returns the result the agent pushed for it.
+// EquipmentID defaults to the harness OLT and ID to a generated one.
+func (h *Harness) Run(cmd agent.PendingCommand) agent.CommandResultRequest {
+	h.t.Helper()
+
+	if cmd.ID == "" {
+		h.next++
+		cmd.ID = fmt.Sprintf("cmd-%d", h.next)
+	}
+	if cmd.EquipmentID == "" {
+		cmd.EquipmentID = h.OLT.ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := h.Executor.ProcessCommands(ctx, []agent.PendingCommand{cmd}); err != nil {
+		h.t.Fatalf("commandtest: process command %s: %v", cmd.ID, err)
+	}
+
+	if !h.ControlPlane.Acked(cmd.ID) {
+		h.t.Fatalf("commandtest: command %s was not acknowledged", cmd.ID)
+	}
+	result, ok := h.ControlPlane.Result(cmd.ID)
+	if !ok {
+		h.t.Fatalf("commandtest: no result pushed for command %s", cmd.ID)
+	}
+	return result
+}
+
+// Command builds a PendingCommand with a JSON payload, decoded the way
+// commands arrive from the control plane (numbers become float64).
+func Command(t testing.TB, cmdType, payload string) agent.PendingCommand {
+	t.Helper()
+
+	cmd := agent.PendingCommand{Type: cmdType}
+	if payload != "" {
+		if err := json.Unmarshal([]byte(payload), &cmd.Payload); err != nil {
+			t.Fatalf("commandtest: invalid %s payload: %v", cmdType, err)
+		}
+	}
+	return cmd
+}
+
+// CLIOnly returns a copy of a registry whose commands run on the CLI driver
+// alone. Commands with a CLI handler no longer prefer DriverV2, and verified
+// handlers run as if SNMP were unavailable, so no southbound driver is
+// dialled. Commands with only a DriverV2 handler are kept as they are.
+func CLIOnly(base *command.Registry) *command.Registry {
+	registry := command.NewRegistry()
+	for _, cmdType := range base.Types() {
+		spec, _ := base.Lookup(cmdType)
+
+		if verified := spec.Verified; verified != nil {
+			spec.CLI = func(e *command.Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+				return verified(e, ctx, driver, nil, cmd)
+			}
+			spec.Verified = nil
+		}
+		if spec.CLI != nil {
+			spec.Preferred = command.TransportCLI
+		}
+
+		// Specs from a valid registry stay valid
+		_ = registry.Register(spec)
+	}
+	return registry
+}
@@ -0,0 +1,67 @@
+package commandtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHuaweiONUProvision(t *testing.T) {
+	h := New(t, Options{Vendor: "huawei", Model: "MA5800-X7", Transcript: "testdata/huawei/onu_provision.txt"})
+
+	result := h.Run(Command(t, "onu_provision", `{
+		"serial": "HWTC12345678",
+		"ponPort": "0/1/1",
+		"onuId": 5,
+		"lineProfile": "10",
+		"serviceProfile": "20",
+		"description": "Customer-A"
+	}`))
+
+	require.True(t, result.Success, result.Error)
+	assert.True(t, result.Verified)
+	onu := result.Result["onu"].(map[string]interface{})
+	assert.Equal(t, "HWTC12345678", onu["serial"])
+	assert.Equal(t, "online", onu["status"])
+	assert.Equal(t, "HWTC12345678", result.PostState["serial"])
+	assert.Equal(t, "10", result.PostState["lineProfile"])
+
+	onus := h.ControlPlane.ONUs(DefaultOLTID)
+	require.Len(t, onus, 1)
+	assert.Equal(t, "HWTC12345678", onus[0].Serial)
+	assert.Equal(t, 5, onus[0].ONUID)
+	assert.Equal(t, 1834, onus[0].Distance)
+}
+
+func TestHuaweiONUProvision_SerialConflict(t *testing.T) {
+	h := New(t, Options{Vendor: "huawei", Model: "MA5800-X7", Transcript: "testdata/huawei/onu_provision_sn_conflict.txt"})
+
+	result := h.Run(Command(t, "onu_provision", `{
+		"serial": "HWTC12345678",
+		"ponPort": "0/1/1",
+		"onuId": 5,
+		"lineProfile": "10",
+		"serviceProfile": "20"
+	}`))
+
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "ONT add failed: Failure: SN already exists")
+	assert.Empty(t, h.ControlPlane.ONUs(DefaultOLTID))
+}
+
+func TestVSOLVLANList(t *testing.T) {
+	h := New(t, Options{Vendor: "vsol", Transcript: "testdata/vsol/vlan_list.txt"})
+
+	result := h.Run(Command(t, "vlan_list", ""))
+
+	require.True(t, result.Success, result.Error)
+	assert.EqualValues(t, 4, result.Result["count"])
+	vlans := result.Result["vlans"].([]interface{})
+	assert.EqualValues(t, 701, vlans[2].(map[string]interface{})["id"])
+	assert.Equal(t, []string{
+		"POST /api/v1/commands/cmd-1/ack",
+		"POST /api/v1/commands/cmd-1/partial",
+		"POST /api/v1/commands/cmd-1/result",
+	}, h.ControlPlane.Requests())
+}
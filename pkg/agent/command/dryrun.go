@@ -0,0 +1,108 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+// dryRunKey marks a context as belonging to a dry-run execution.
+type dryRunKey struct{}
+
+// withDryRun returns a context that marks handlers as running in dry-run mode.
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// isDryRun reports whether handlers are running in dry-run mode.
+// Verification loops, sleeps and ONU pushes are skipped in dry-run mode.
+func isDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// dryRunRequested returns true if the command payload asks for a dry run.
+func dryRunRequested(cmd agent.PendingCommand) bool {
+	if dryRun, ok := cmd.Payload["dryRun"].(bool); ok {
+		return dryRun
+	}
+	dryRun, _ := cmd.Payload["dry_run"].(bool)
+	return dryRun
+}
+
+// plan runs a mutating command against a CLI driver in dry-run mode.
+// Read-only commands still reach the OLT so the handler can capture pre-state;
+// every other command is recorded and held back. The result lists the exact
+// command sequence, the pre-state and any validation or capability problems.
+func (e *Executor) plan(ctx context.Context, spec CommandSpec, oltConfig agent.OLTConfig, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	driver, err := e.createDriver(oltConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create driver: %w", err)
+	}
+
+	dryRunner, ok := driver.(cli.DryRunner)
+	if !ok {
+		return nil, fmt.Errorf("dry run not supported by %s driver", oltConfig.Vendor)
+	}
+
+	problems := []string{}
+	caps := driver.GetCapabilities()
+	if caps == nil {
+		caps = cli.GetCapabilities(oltConfig.Vendor, oltConfig.Model)
+	}
+	if !commandSupported(spec, caps, oltConfig.Vendor, false) {
+		problems = append(problems, fmt.Sprintf("%s is not supported for vendor %s", cmd.Type, oltConfig.Vendor))
+	}
+
+	release, err := e.acquireSession(ctx, oltConfig.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := driver.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to OLT: %w", err)
+	}
+	defer driver.Close()
+
+	dryRunner.SetDryRun(true)
+	defer dryRunner.SetDryRun(false)
+
+	// Run the CLI handler without SNMP verification; verified-only commands
+	// get a nil DriverV2 and fall back to their CLI path.
+	planCtx := withDryRun(ctx)
+	var handlerResult map[string]interface{}
+	if spec.CLI != nil {
+		handlerResult, err = spec.CLI(e, planCtx, driver, cmd)
+	} else {
+		handlerResult, err = spec.Verified(e, planCtx, driver, nil, cmd)
+	}
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	planned := dryRunner.PlannedCommands()
+	held := 0
+	for _, p := range planned {
+		if !p.Executed {
+			held++
+		}
+	}
+	log.Printf("[command] Dry run of %s planned %d commands (%d held back, %d problems)", cmd.Type, len(planned), held, len(problems))
+
+	result := map[string]interface{}{
+		"dryRun":      true,
+		"commandType": cmd.Type,
+		"commands":    planned,
+		"problems":    problems,
+		"valid":       len(problems) == 0,
+	}
+	if preState, ok := handlerResult["preState"]; ok {
+		result["preState"] = preState
+	}
+
+	return result, nil
+}
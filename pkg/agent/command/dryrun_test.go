@@ -0,0 +1,119 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockDryRunDriver records commands like BaseCLIDriver does in dry-run mode.
+type mockDryRunDriver struct {
+	*mockCLIDriver
+	dryRun  bool
+	planned []cli.PlannedCommand
+	sent    []string
+}
+
+func (m *mockDryRunDriver) SetDryRun(enabled bool) {
+	m.dryRun = enabled
+	if enabled {
+		m.planned = nil
+	}
+}
+
+func (m *mockDryRunDriver) PlannedCommands() []cli.PlannedCommand {
+	return m.planned
+}
+
+func (m *mockDryRunDriver) Execute(ctx context.Context, cmd string) (string, error) {
+	if m.dryRun {
+		readOnly := cli.IsReadOnlyCommand(cmd)
+		m.planned = append(m.planned, cli.PlannedCommand{Command: cmd, Executed: readOnly})
+		if !readOnly {
+			return "", nil
+		}
+	}
+	m.sent = append(m.sent, cmd)
+	return m.mockCLIDriver.Execute(ctx, cmd)
+}
+
+func TestDryRunRequested(t *testing.T) {
+	assert.True(t, dryRunRequested(agent.PendingCommand{Payload: map[string]interface{}{"dryRun": true}}))
+	assert.True(t, dryRunRequested(agent.PendingCommand{Payload: map[string]interface{}{"dry_run": true}}))
+	assert.False(t, dryRunRequested(agent.PendingCommand{Payload: map[string]interface{}{"dryRun": false}}))
+	assert.False(t, dryRunRequested(agent.PendingCommand{}))
+}
+
+func TestExecutorPlan(t *testing.T) {
+	spec, ok := DefaultRegistry.Lookup("vlan_create")
+	require.True(t, ok)
+
+	newDriver := func(vendor string) *mockDryRunDriver {
+		return &mockDryRunDriver{mockCLIDriver: &mockCLIDriver{
+			vendor:       vendor,
+			capabilities: cli.FullCapabilities(vendor, ""),
+		}}
+	}
+
+	t.Run("holds back mutating commands", func(t *testing.T) {
+		driver := newDriver("huawei")
+		e := newTestExecutor()
+		e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) { return driver, nil }
+
+		cmd := agent.PendingCommand{
+			Type:    "vlan_create",
+			Payload: map[string]interface{}{"vlanId": float64(100), "name": "internet", "dryRun": true},
+		}
+		result, err := e.plan(context.Background(), spec, agent.OLTConfig{ID: "olt-1", Vendor: "huawei"}, cmd)
+		require.NoError(t, err)
+
+		assert.Equal(t, true, result["dryRun"])
+		assert.Equal(t, true, result["valid"])
+		assert.Empty(t, result["problems"])
+		assert.Equal(t, []cli.PlannedCommand{
+			{Command: "vlan 100 smart\n vlan name 100 internet\n quit", Executed: false},
+		}, result["commands"])
+		assert.Empty(t, driver.sent)
+	})
+
+	t.Run("reports validation problems", func(t *testing.T) {
+		driver := newDriver("huawei")
+		e := newTestExecutor()
+		e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) { return driver, nil }
+
+		cmd := agent.PendingCommand{Type: "vlan_create", Payload: map[string]interface{}{"dryRun": true}}
+		result, err := e.plan(context.Background(), spec, agent.OLTConfig{ID: "olt-1", Vendor: "huawei"}, cmd)
+		require.NoError(t, err)
+
+		assert.Equal(t, false, result["valid"])
+		assert.Contains(t, result["problems"], "vlanId is required")
+	})
+
+	t.Run("reports capability problems", func(t *testing.T) {
+		driver := newDriver("zte")
+		e := newTestExecutor()
+		e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) { return driver, nil }
+
+		cmd := agent.PendingCommand{Type: "vlan_create", Payload: map[string]interface{}{"vlanId": float64(100), "dryRun": true}}
+		result, err := e.plan(context.Background(), spec, agent.OLTConfig{ID: "olt-1", Vendor: "zte"}, cmd)
+		require.NoError(t, err)
+
+		assert.Equal(t, false, result["valid"])
+		assert.Contains(t, result["problems"], "vlan_create is not supported for vendor zte")
+	})
+
+	t.Run("driver without dry-run support", func(t *testing.T) {
+		e := newTestExecutor()
+		e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+			return &mockCLIDriver{vendor: "huawei"}, nil
+		}
+
+		_, err := e.plan(context.Background(), spec, agent.OLTConfig{ID: "olt-1", Vendor: "huawei"}, agent.PendingCommand{Type: "vlan_create"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dry run not supported")
+	})
+}
@@ -7,10 +7,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/snmp"
 	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
 	southbound "github.com/nanoncore/nano-southbound"
 	"github.com/nanoncore/nano-southbound/types"
@@ -23,8 +26,25 @@ type PollTriggerFunc func(ctx context.Context, oltID string) error
 type Executor struct {
 	client        *agent.Client
 	driverFactory func(config cli.CLIConfig) (cli.CLIDriver, error)
-	oltConfigs    map[string]agent.OLTConfig // equipmentID -> OLTConfig
-	pollTrigger   PollTriggerFunc            // Optional callback to trigger immediate poll
+	pollTrigger   PollTriggerFunc   // Optional callback to trigger immediate poll
+	registry      *Registry         // Command handlers; nil means DefaultRegistry
+	auditLog      *AuditLog         // Optional audit log for mutating commands
+	schedule      *Schedule         // Deferred commands; nil runs everything immediately
+	cliPolicy     *CLIPolicy        // Policy for cli_exec; nil means DefaultCLIPolicy
+	onuSnapshot   ONUSnapshotFunc   // Optional poller snapshot for impact estimates
+	emitEvent     EventFunc         // Optional network event emitter
+	templates     *ServiceTemplates // Service templates for onu_provision
+
+	autoProvision autoProvisioner
+
+	// oltConfigs is replaced on config refresh while scheduled commands and
+	// auto-provisioning read it from other goroutines
+	configMu   sync.RWMutex
+	oltConfigs map[string]agent.OLTConfig // equipmentID -> OLTConfig
+
+	guardMu     sync.Mutex
+	guardConfig GuardConfig
+	guards      map[string]*oltGuard // OLT ID -> rate limiter and session slots
 }
 
 // NewExecutor creates a new command executor.
@@ -33,9 +53,36 @@ func NewExecutor(client *agent.Client, driverFactory func(config cli.CLIConfig)
 		client:        client,
 		driverFactory: driverFactory,
 		oltConfigs:    make(map[string]agent.OLTConfig),
+		registry:      DefaultRegistry,
+		schedule:      NewSchedule(),
+		templates:     NewServiceTemplates(),
+		guardConfig:   DefaultGuardConfig(),
 	}
 }
 
+// SetRegistry replaces the command registry used to dispatch commands.
+func (e *Executor) SetRegistry(registry *Registry) {
+	e.registry = registry
+}
+
+// SetAuditLog sets the audit log that records every executed mutating command.
+func (e *Executor) SetAuditLog(auditLog *AuditLog) {
+	e.auditLog = auditLog
+}
+
+// CommandTypes returns the command types this executor can dispatch, sorted.
+func (e *Executor) CommandTypes() []string {
+	return e.commands().Types()
+}
+
+// commands returns the registry in use, defaulting to DefaultRegistry.
+func (e *Executor) commands() *Registry {
+	if e.registry == nil {
+		return DefaultRegistry
+	}
+	return e.registry
+}
+
 // SetPollTrigger sets the callback function for triggering immediate polls.
 // When a command result includes immediateUpdate: true, this function will be called.
 func (e *Executor) SetPollTrigger(trigger PollTriggerFunc) {
@@ -44,14 +91,41 @@ func (e *Executor) SetPollTrigger(trigger PollTriggerFunc) {
 
 // UpdateOLTConfigs updates the cached OLT configurations.
 func (e *Executor) UpdateOLTConfigs(olts []agent.OLTConfig) {
-	e.oltConfigs = make(map[string]agent.OLTConfig)
+	configs := make(map[string]agent.OLTConfig, len(olts))
 	for _, olt := range olts {
-		e.oltConfigs[olt.ID] = olt
+		configs[olt.ID] = olt
 	}
+
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+	e.oltConfigs = configs
+}
+
+// oltConfig returns the cached configuration of an OLT.
+func (e *Executor) oltConfig(equipmentID string) (agent.OLTConfig, bool) {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+
+	config, ok := e.oltConfigs[equipmentID]
+	return config, ok
+}
+
+// oltConfigList returns the cached OLT configurations ordered by ID.
+func (e *Executor) oltConfigList() []agent.OLTConfig {
+	e.configMu.RLock()
+	defer e.configMu.RUnlock()
+
+	configs := make([]agent.OLTConfig, 0, len(e.oltConfigs))
+	for _, config := range e.oltConfigs {
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ID < configs[j].ID })
+	return configs
 }
 
 // ProcessCommands executes all pending commands sequentially.
 // Each command is acknowledged before execution and results are pushed after completion.
+// Commands that may not run yet are held in the schedule and reported as scheduled.
 func (e *Executor) ProcessCommands(ctx context.Context, commands []agent.PendingCommand) error {
 	for _, cmd := range commands {
 		if err := e.executeCommand(ctx, cmd); err != nil {
@@ -67,8 +141,25 @@ func (e *Executor) ProcessCommands(ctx context.Context, commands []agent.Pending
 // 2. Execute the command via the appropriate driver
 // 3. Push the result back to the control plane
 func (e *Executor) executeCommand(ctx context.Context, cmd agent.PendingCommand) error {
+	if e.schedule != nil && e.schedule.Has(cmd.ID) {
+		// The control plane re-sent a command we already hold
+		return nil
+	}
+	return e.runCommand(ctx, cmd)
+}
+
+// runCommand runs a command that is not held by the schedule, or that the
+// schedule released because it is due.
+func (e *Executor) runCommand(ctx context.Context, cmd agent.PendingCommand) error {
 	startTime := time.Now()
 
+	// Hold commands that must wait for notBefore, a maintenance window or
+	// the end of an OLT blackout window
+	held, scheduleErr := e.deferCommand(cmd, startTime)
+	if held {
+		return nil
+	}
+
 	// 1. Acknowledge the command
 	_, err := e.client.AckCommand(cmd.ID)
 	if err != nil {
@@ -76,166 +167,216 @@ func (e *Executor) executeCommand(ctx context.Context, cmd agent.PendingCommand)
 	}
 	log.Printf("[command] Acknowledged command %s (type: %s)", cmd.ID, cmd.Type)
 
-	// 2. Get OLT configuration
-	oltConfig, ok := e.oltConfigs[cmd.EquipmentID]
+	if scheduleErr != nil {
+		return e.pushError(cmd.ID, startTime, scheduleErr)
+	}
+
+	// 2. Look up the handler for this command type
+	spec, ok := e.commands().Lookup(cmd.Type)
 	if !ok {
-		return e.pushError(cmd.ID, startTime, fmt.Errorf("OLT configuration not found for equipment %s", cmd.EquipmentID))
+		return e.pushError(cmd.ID, startTime, fmt.Errorf("unsupported command type: %s", cmd.Type))
 	}
 
-	// 3. For read operations (onu_list, onu_get, port_list, olt_status), use southbound driver (DriverV2) for efficient SNMP-based operations
+	// 3. Execute, streaming intermediate steps; queued steps are flushed
+	// before the final result is pushed
 	var result map[string]interface{}
-	if cmd.Type == "onu_list" || cmd.Type == "onu_get" || cmd.Type == "port_list" || cmd.Type == "olt_status" {
-		sbDriver, driverV2, err := e.createSouthboundDriver(ctx, oltConfig)
-		if err != nil {
-			// Fall back to CLI driver
-			log.Printf("[command] Southbound driver unavailable, using CLI fallback: %v", err)
-		} else {
-			switch cmd.Type {
-			case "onu_list":
-				result, err = e.handleONUListV2(ctx, driverV2, cmd)
-			case "onu_get":
-				result, err = e.handleONUGetV2(ctx, driverV2, cmd)
-			case "port_list":
-				result, err = e.handlePortListV2(ctx, driverV2, cmd)
-			case "olt_status":
-				result, err = e.handleOLTStatusV2(ctx, driverV2, cmd)
-			}
-			_ = sbDriver.Disconnect(ctx)
-			if err != nil {
-				// DriverV2 operation failed, fall back to CLI
-				log.Printf("[command] DriverV2 operation failed, using CLI fallback: %v", err)
-			} else {
-				goto pushResult
-			}
+	if spec.Local != nil {
+		reporter := e.newStepReporter(cmd.ID)
+		result, err = spec.Local(e, withStepReporter(ctx, reporter), cmd)
+		reporter.close()
+	} else {
+		oltConfig, ok := e.oltConfig(cmd.EquipmentID)
+		if !ok {
+			return e.pushError(cmd.ID, startTime, fmt.Errorf("OLT configuration not found for equipment %s", cmd.EquipmentID))
 		}
+		reporter := e.newStepReporter(cmd.ID)
+		result, err = e.execute(withStepReporter(ctx, reporter), spec, oltConfig, cmd)
+		reporter.close()
 	}
-
-	// 3b. For onu_discover, use CLI-based DriverV2 (requires CLI for "show onu auto-find")
-	if cmd.Type == "onu_discover" {
-		sbDriver, driverV2, err := e.createSouthboundDriverCLI(ctx, oltConfig)
-		if err != nil {
-			log.Printf("[command] CLI southbound driver unavailable, using CLI fallback: %v", err)
-		} else {
-			result, err = e.handleONUDiscoverV2(ctx, driverV2, cmd)
-			_ = sbDriver.Disconnect(ctx)
-			if err != nil {
-				log.Printf("[command] DriverV2 onu_discover failed, using CLI fallback: %v", err)
-			} else {
-				goto pushResult
-			}
+	if err != nil {
+		// For bulk operations, we may have partial results even on error
+		// Push the result with the error so the UI can show details
+		if result != nil {
+			return e.pushErrorWithResult(cmd.ID, startTime, err, result)
 		}
+		return e.pushError(cmd.ID, startTime, err)
 	}
 
-	// 4. For provisioning commands, use CLI for execution + SNMP for verification
-	if isProvisioningCommand(cmd.Type) {
-		// Create CLI driver for command execution
-		driver, err := e.createDriver(oltConfig)
-		if err != nil {
-			return e.pushError(cmd.ID, startTime, fmt.Errorf("failed to create driver: %w", err))
-		}
+	duration := time.Since(startTime)
 
-		if err := driver.Connect(ctx); err != nil {
-			return e.pushError(cmd.ID, startTime, fmt.Errorf("failed to connect to OLT: %w", err))
-		}
-		defer driver.Close()
+	// 4. Push result
+	resultReq := &agent.CommandResultRequest{
+		Success:    true,
+		Result:     result,
+		DurationMs: duration.Milliseconds(),
+	}
 
-		// Create DriverV2 for SNMP-based verification (best effort)
-		var driverV2 types.DriverV2
-		sbDriver, dv2, err := e.createSouthboundDriver(ctx, oltConfig)
-		if err != nil {
-			log.Printf("[command] SNMP driver unavailable for verification, will use CLI only: %v", err)
+	// Extract verified from result if present (for suspend/resume commands)
+	if verified, ok := result["verified"].(bool); ok {
+		resultReq.Verified = verified
+	}
+
+	// Extract pre/post state from result if present
+	setResultState(resultReq, result)
+
+	_, pushErr := e.client.PushCommandResult(cmd.ID, resultReq)
+	if pushErr != nil {
+		log.Printf("[command] Failed to push result for command %s: %v", cmd.ID, pushErr)
+		return pushErr
+	}
+
+	log.Printf("[command] Command %s completed successfully (duration: %v)", cmd.ID, duration)
+
+	// Trigger immediate poll if requested
+	if immediateUpdate, ok := result["immediateUpdate"].(bool); ok && immediateUpdate {
+		e.triggerPoll(cmd.EquipmentID)
+	}
+
+	return nil
+}
+
+// triggerPoll refreshes an OLT's data in the background after a change.
+func (e *Executor) triggerPoll(equipmentID string) {
+	if e.pollTrigger == nil {
+		return
+	}
+
+	log.Printf("[command] Triggering immediate poll for equipment %s", equipmentID)
+	go func() {
+		pollCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		if pollErr := e.pollTrigger(pollCtx, equipmentID); pollErr != nil {
+			log.Printf("[command] Immediate poll failed for %s: %v", equipmentID, pollErr)
 		} else {
-			driverV2 = dv2
-			defer sbDriver.Disconnect(ctx)
+			log.Printf("[command] Immediate poll completed for %s", equipmentID)
 		}
+	}()
+}
 
-		// Execute provisioning command with SNMP verification capability
-		result, err = e.dispatchProvisioning(ctx, driver, driverV2, cmd)
-		if err != nil {
-			// For bulk operations, we may have partial results even on error
-			// Push the result with the error so the UI can show details
-			if result != nil {
-				return e.pushErrorWithResult(cmd.ID, startTime, err, result)
-			}
-			return e.pushError(cmd.ID, startTime, err)
-		}
-		goto pushResult
+// execute runs a command on an OLT, falling back to CLI where needed.
+// Mutating commands with dryRun: true are planned instead of executed, and
+// executed mutating or audited commands are recorded in the audit log.
+func (e *Executor) execute(ctx context.Context, spec CommandSpec, oltConfig agent.OLTConfig, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	if err := e.waitForCommandSlot(ctx, oltConfig.ID); err != nil {
+		return nil, err
 	}
 
-	// Create CLI driver and connect for other commands (or onu_list fallback)
-	{
-		driver, err := e.createDriver(oltConfig)
-		if err != nil {
-			return e.pushError(cmd.ID, startTime, fmt.Errorf("failed to create driver: %w", err))
-		}
+	if spec.Mutates && dryRunRequested(cmd) {
+		return e.plan(ctx, spec, oltConfig, cmd)
+	}
 
-		if err := driver.Connect(ctx); err != nil {
-			return e.pushError(cmd.ID, startTime, fmt.Errorf("failed to connect to OLT: %w", err))
-		}
-		defer driver.Close()
+	if err := e.checkImpact(spec, oltConfig, cmd); err != nil {
+		return nil, err
+	}
 
-		// 5. Execute the command based on type
-		result, err = e.dispatch(ctx, driver, cmd)
-		if err != nil {
-			return e.pushError(cmd.ID, startTime, err)
+	startTime := time.Now()
+	result, err := e.run(ctx, spec, oltConfig, cmd)
+	if spec.Mutates || spec.Audit {
+		e.audit(cmd, oltConfig, startTime, result, err)
+	}
+	return result, err
+}
+
+// run executes a command according to its spec. Commands that prefer a
+// DriverV2 transport try it first and fall back to the CLI driver on failure.
+// Commands with a verified handler run on CLI with best-effort SNMP verification.
+func (e *Executor) run(ctx context.Context, spec CommandSpec, oltConfig agent.OLTConfig, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	if spec.Preferred != TransportCLI {
+		result, err := e.runDriverV2(ctx, spec, oltConfig, cmd)
+		if err == nil {
+			return result, nil
+		}
+		if spec.CLI == nil && spec.Verified == nil {
+			return nil, err
 		}
+		log.Printf("[command] %s via %s failed, using CLI fallback: %v", cmd.Type, spec.Preferred, err)
 	}
 
-pushResult:
-	duration := time.Since(startTime)
+	driver, disconnect, err := e.connectCLI(ctx, oltConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer disconnect()
 
-	// 5. Push result
-	resultReq := &agent.CommandResultRequest{
-		Success:    err == nil,
-		Result:     result,
-		DurationMs: duration.Milliseconds(),
+	preState := e.captureState(ctx, spec, driver, cmd)
+	result, err := e.runCLI(ctx, spec, oltConfig, driver, cmd)
+	if preState != nil {
+		attachState(result, preState, e.captureState(ctx, spec, driver, cmd))
 	}
+	return result, err
+}
+
+// connectCLI creates a CLI driver for an OLT and connects it once a session
+// slot is free. The returned function closes the session and frees the slot.
+func (e *Executor) connectCLI(ctx context.Context, oltConfig agent.OLTConfig) (cli.CLIDriver, func(), error) {
+	driver, err := e.createDriver(oltConfig)
 	if err != nil {
-		resultReq.Error = err.Error()
+		return nil, nil, fmt.Errorf("failed to create driver: %w", err)
 	}
 
-	// Extract verified from result if present (for suspend/resume commands)
-	if verified, ok := result["verified"].(bool); ok {
-		resultReq.Verified = verified
+	release, err := e.acquireSession(ctx, oltConfig.ID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Extract pre/post state from result if present
-	if preState, ok := result["preState"].(map[string]interface{}); ok {
-		resultReq.PreState = preState
+	if err := driver.Connect(ctx); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to connect to OLT: %w", err)
 	}
-	if postState, ok := result["postState"].(map[string]interface{}); ok {
-		resultReq.PostState = postState
+	reportStep(ctx, "connected", fmt.Sprintf("connected to %s via %s", oltConfig.ID, TransportCLI), nil)
+
+	return driver, func() {
+		driver.Close()
+		release()
+	}, nil
+}
+
+// runCLI executes a command's CLI or verified handler on a connected driver.
+func (e *Executor) runCLI(ctx context.Context, spec CommandSpec, oltConfig agent.OLTConfig, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	if spec.Verified != nil {
+		// Create DriverV2 for SNMP-based verification (best effort). Without
+		// SNMP it would be a second CLI login next to the held session, so
+		// verification uses CLI only.
+		var driverV2 types.DriverV2
+		if southboundProtocol(oltConfig) != southbound.ProtocolSNMP {
+			log.Printf("[command] SNMP disabled for %s, verification will use CLI only", oltConfig.ID)
+		} else if sbDriver, dv2, err := e.createSouthboundDriver(ctx, oltConfig); err != nil {
+			log.Printf("[command] SNMP driver unavailable for verification, will use CLI only: %v", err)
+		} else {
+			driverV2 = dv2
+			defer sbDriver.Disconnect(ctx)
+		}
+
+		return spec.Verified(e, ctx, driver, driverV2, cmd)
 	}
 
-	_, pushErr := e.client.PushCommandResult(cmd.ID, resultReq)
-	if pushErr != nil {
-		log.Printf("[command] Failed to push result for command %s: %v", cmd.ID, pushErr)
-		return pushErr
+	return spec.CLI(e, ctx, driver, cmd)
+}
+
+// runDriverV2 executes a command on the southbound DriverV2 for its preferred transport.
+func (e *Executor) runDriverV2(ctx context.Context, spec CommandSpec, oltConfig agent.OLTConfig, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	createDriver := e.createSouthboundDriver
+	if spec.Preferred == TransportDriverV2CLI {
+		createDriver = e.createSouthboundDriverCLI
 	}
 
-	if err != nil {
-		log.Printf("[command] Command %s failed: %v (duration: %v)", cmd.ID, err, duration)
-	} else {
-		log.Printf("[command] Command %s completed successfully (duration: %v)", cmd.ID, duration)
-	}
-
-	// Trigger immediate poll if requested and successful
-	if err == nil && e.pollTrigger != nil {
-		if immediateUpdate, ok := result["immediateUpdate"].(bool); ok && immediateUpdate {
-			log.Printf("[command] Triggering immediate poll for equipment %s", cmd.EquipmentID)
-			go func(equipmentID string) {
-				pollCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-				defer cancel()
-				if pollErr := e.pollTrigger(pollCtx, equipmentID); pollErr != nil {
-					log.Printf("[command] Immediate poll failed for %s: %v", equipmentID, pollErr)
-				} else {
-					log.Printf("[command] Immediate poll completed for %s", equipmentID)
-				}
-			}(cmd.EquipmentID)
+	// Drivers that log in over CLI count against the OLT's session limit
+	if spec.Preferred == TransportDriverV2CLI || southboundProtocol(oltConfig) == southbound.ProtocolCLI {
+		release, err := e.acquireSession(ctx, oltConfig.ID)
+		if err != nil {
+			return nil, err
 		}
+		defer release()
 	}
 
-	return err
+	sbDriver, driverV2, err := createDriver(ctx, oltConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer sbDriver.Disconnect(ctx)
+	reportStep(ctx, "connected", fmt.Sprintf("connected to %s via %s", oltConfig.ID, spec.Preferred), nil)
+
+	return spec.DriverV2(e, ctx, driverV2, cmd)
 }
 
 // pushError is a helper to push an error result for a command.
@@ -263,6 +404,7 @@ func (e *Executor) pushErrorWithResult(commandID string, startTime time.Time, er
 		Result:     result,
 		DurationMs: duration.Milliseconds(),
 	}
+	setResultState(resultReq, result)
 	_, pushErr := e.client.PushCommandResult(commandID, resultReq)
 	if pushErr != nil {
 		log.Printf("[command] Failed to push error result with data for command %s: %v", commandID, pushErr)
@@ -285,16 +427,21 @@ func (e *Executor) createDriver(oltConfig agent.OLTConfig) (cli.CLIDriver, error
 	return e.driverFactory(cliConfig)
 }
 
+// southboundProtocol returns the protocol createSouthboundDriver connects
+// with: SNMP if enabled for the OLT, CLI otherwise.
+func southboundProtocol(oltConfig agent.OLTConfig) southbound.Protocol {
+	if oltConfig.Protocols.SNMP.Enabled {
+		return southbound.ProtocolSNMP
+	}
+	return southbound.ProtocolCLI
+}
+
 // createSouthboundDriver creates a southbound driver for read operations.
 // This driver supports DriverV2 interface with efficient SNMP-based operations.
 func (e *Executor) createSouthboundDriver(ctx context.Context, oltConfig agent.OLTConfig) (southbound.Driver, types.DriverV2, error) {
 	vendor := southbound.Vendor(strings.ToLower(oltConfig.Vendor))
 
-	// Determine protocol - prefer SNMP for read operations if enabled
-	protocol := southbound.ProtocolCLI
-	if oltConfig.Protocols.SNMP.Enabled {
-		protocol = southbound.ProtocolSNMP
-	}
+	protocol := southboundProtocol(oltConfig)
 
 	config := &southbound.EquipmentConfig{
 		Address:  oltConfig.Address,
@@ -302,14 +449,31 @@ func (e *Executor) createSouthboundDriver(ctx context.Context, oltConfig agent.O
 		Protocol: protocol,
 	}
 
+	var snmpMetadata map[string]string
+	var username, password string
 	if protocol == southbound.ProtocolSNMP {
+		snmpCfg, err := snmp.NewDeviceConfig(oltConfig.Name, oltConfig.Address, snmp.Settings(oltConfig.Protocols.SNMP), 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		snmpMetadata = snmpCfg.DriverMetadata()
 		config.Port = oltConfig.Protocols.SNMP.Port
-		config.SNMPCommunity = oltConfig.Protocols.SNMP.Community
-		config.SNMPVersion = oltConfig.Protocols.SNMP.Version
+		config.SNMPCommunity = snmpCfg.Community
+		config.SNMPVersion = snmpMetadata["snmp_version"]
+		if snmpCfg.Version == snmp.SNMPv3 {
+			// The SNMP driver authenticates SNMPv3 with the credentials it is
+			// connected with; the driver itself is created without them so
+			// vendor adapters don't try them as a CLI login
+			username, password, err = snmpCfg.DriverCredentials()
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid SNMP credentials for %s: %w", oltConfig.Name, err)
+			}
+		}
 	} else {
 		config.Port = oltConfig.Protocols.SSH.Port
 		config.Username = oltConfig.Protocols.SSH.Username
 		config.Password = oltConfig.Protocols.SSH.Password
+		username, password = config.Username, config.Password
 	}
 
 	driver, err := southbound.NewDriver(vendor, protocol, config)
@@ -326,19 +490,18 @@ func (e *Executor) createSouthboundDriver(ctx context.Context, oltConfig agent.O
 		Address:       oltConfig.Address,
 		Port:          config.Port,
 		Protocol:      types.Protocol(protocol),
-		Username:      config.Username,
-		Password:      config.Password,
+		Username:      username,
+		Password:      password,
 		SNMPCommunity: config.SNMPCommunity,
 		SNMPVersion:   config.SNMPVersion,
 		Metadata:      make(map[string]string),
 		Timeout:       30 * time.Second,
 	}
-	if protocol == southbound.ProtocolSNMP {
-		typesConfig.Metadata["snmp_community"] = oltConfig.Protocols.SNMP.Community
-		typesConfig.Metadata["snmp_version"] = oltConfig.Protocols.SNMP.Version
+	for k, v := range snmpMetadata {
+		typesConfig.Metadata[k] = v
 	}
 	if err := driver.Connect(ctx, typesConfig); err != nil {
-		return nil, nil, fmt.Errorf("failed to connect southbound driver: %w", err)
+		return nil, nil, fmt.Errorf("failed to connect southbound driver: %w", snmp.ExplainError(err))
 	}
 
 	// Check if driver supports DriverV2
@@ -366,8 +529,10 @@ func (e *Executor) createSouthboundDriverCLI(ctx context.Context, oltConfig agen
 		Password: oltConfig.Protocols.SSH.Password,
 	}
 
-	// Also set SNMP config so the adapter can create a secondary SNMP driver for monitoring
-	if oltConfig.Protocols.SNMP.Enabled {
+	// Also set SNMP config so the adapter can create a secondary SNMP driver for monitoring.
+	// That driver would authenticate SNMPv3 with the SSH credentials, so it is only
+	// set up for community-based versions.
+	if oltConfig.Protocols.SNMP.Enabled && !isSNMPv3(oltConfig) {
 		config.SecondaryPort = oltConfig.Protocols.SNMP.Port
 		config.SNMPCommunity = oltConfig.Protocols.SNMP.Community
 		config.SNMPVersion = oltConfig.Protocols.SNMP.Version
@@ -407,6 +572,12 @@ func (e *Executor) createSouthboundDriverCLI(ctx context.Context, oltConfig agen
 	return driver, driverV2, nil
 }
 
+// isSNMPv3 reports whether an OLT is configured for SNMPv3.
+func isSNMPv3(oltConfig agent.OLTConfig) bool {
+	version, err := snmp.ParseVersion(oltConfig.Protocols.SNMP.Version)
+	return err == nil && version == snmp.SNMPv3
+}
+
 // handleONUListV2 retrieves all ONUs using the efficient DriverV2 interface.
 func (e *Executor) handleONUListV2(ctx context.Context, driver types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error) {
 	// Get optional filters from payload
@@ -477,104 +648,3 @@ func (e *Executor) handleONUListV2(ctx context.Context, driver types.DriverV2, c
 		"count": len(onus),
 	}, nil
 }
-
-// dispatch routes the command to the appropriate handler based on type.
-func (e *Executor) dispatch(ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
-	switch cmd.Type {
-	// VLAN commands
-	case "vlan_list":
-		return e.handleVLANList(ctx, driver, cmd)
-	case "vlan_get":
-		return e.handleVLANGet(ctx, driver, cmd)
-	case "vlan_create":
-		return e.handleVLANCreate(ctx, driver, cmd)
-	case "vlan_delete":
-		return e.handleVLANDelete(ctx, driver, cmd)
-
-	// ONU commands
-	case "onu_list":
-		return e.handleONUList(ctx, driver, cmd)
-	case "onu_get":
-		return e.handleONUGet(ctx, driver, cmd)
-	case "onu_discover":
-		return e.handleONUDiscover(ctx, driver, cmd)
-	case "onu_provision":
-		return e.handleONUProvision(ctx, driver, cmd)
-	case "onu_update":
-		return e.handleONUUpdate(ctx, driver, cmd)
-	case "onu_delete":
-		return e.handleONUDelete(ctx, driver, cmd)
-	case "onu_suspend":
-		return e.handleONUSuspend(ctx, driver, cmd)
-	case "onu_resume":
-		return e.handleONUResume(ctx, driver, cmd)
-	case "onu_reboot":
-		return e.handleONUReboot(ctx, driver, cmd)
-	case "onu_diagnostics":
-		return e.handleONUDiagnostics(ctx, driver, cmd)
-
-	// Port commands
-	case "port_list":
-		return e.handlePortList(ctx, driver, cmd)
-	case "port_enable":
-		return e.handlePortEnable(ctx, driver, cmd)
-	case "port_disable":
-		return e.handlePortDisable(ctx, driver, cmd)
-	case "port_power":
-		return e.handlePortPower(ctx, driver, cmd)
-
-	// Service port commands
-	case "service_port_list":
-		return e.handleServicePortList(ctx, driver, cmd)
-	case "service_port_add":
-		return e.handleServicePortAdd(ctx, driver, cmd)
-	case "service_port_delete":
-		return e.handleServicePortDelete(ctx, driver, cmd)
-
-	// OLT status commands
-	case "olt_status":
-		return e.handleOLTStatus(ctx, driver, cmd)
-	case "olt_alarms":
-		return e.handleOLTAlarms(ctx, driver, cmd)
-	case "olt_health_check":
-		return e.handleOLTHealthCheck(ctx, driver, cmd)
-
-	default:
-		return nil, fmt.Errorf("unsupported command type: %s", cmd.Type)
-	}
-}
-
-// isProvisioningCommand returns true if the command type is a provisioning operation
-// that benefits from SNMP-based verification after CLI execution.
-func isProvisioningCommand(cmdType string) bool {
-	switch cmdType {
-	case "onu_suspend", "onu_resume", "onu_provision", "onu_delete", "onu_update", "onu_reboot", "onu_bulk_provision":
-		return true
-	default:
-		return false
-	}
-}
-
-// dispatchProvisioning routes provisioning commands to handlers that support SNMP verification.
-// These handlers use CLI for command execution and SNMP (via driverV2) for verification.
-func (e *Executor) dispatchProvisioning(ctx context.Context, driver cli.CLIDriver, driverV2 types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error) {
-	switch cmd.Type {
-	case "onu_suspend":
-		return e.handleONUSuspendWithVerification(ctx, driver, driverV2, cmd)
-	case "onu_resume":
-		return e.handleONUResumeWithVerification(ctx, driver, driverV2, cmd)
-	case "onu_provision":
-		return e.handleONUProvisionWithVerification(ctx, driver, driverV2, cmd)
-	case "onu_delete":
-		return e.handleONUDeleteWithVerification(ctx, driver, driverV2, cmd)
-	case "onu_update":
-		return e.handleONUUpdateWithVerification(ctx, driver, driverV2, cmd)
-	case "onu_reboot":
-		return e.handleONURebootWithVerification(ctx, driver, driverV2, cmd)
-	case "onu_bulk_provision":
-		return e.handleONUBulkProvisionWithVerification(ctx, driver, driverV2, cmd)
-	default:
-		// Fallback to regular dispatch without SNMP verification
-		return e.dispatch(ctx, driver, cmd)
-	}
-}
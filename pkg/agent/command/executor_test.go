@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snmpV3OLT() agent.OLTConfig {
+	return agent.OLTConfig{
+		Name:    "olt-1",
+		Vendor:  "huawei",
+		Address: "192.0.2.1",
+		Protocols: agent.OLTProtocols{
+			SNMP: agent.SNMPConfig{
+				Enabled:      true,
+				Port:         161,
+				Version:      "3",
+				Username:     "nms",
+				AuthProtocol: "SHA",
+				AuthPassword: "authpass1",
+				PrivProtocol: "AES",
+				PrivPassword: "authpass1",
+			},
+		},
+	}
+}
+
+func TestIsSNMPv3(t *testing.T) {
+	assert.True(t, isSNMPv3(snmpV3OLT()))
+
+	cfg := snmpV3OLT()
+	cfg.Protocols.SNMP.Version = "2c"
+	assert.False(t, isSNMPv3(cfg))
+}
+
+func TestCreateSouthboundDriverRejectsBadSNMPv3Credentials(t *testing.T) {
+	e := &Executor{}
+
+	cfg := snmpV3OLT()
+	cfg.Protocols.SNMP.AuthPassword = "short"
+	_, _, err := e.createSouthboundDriver(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid SNMP credentials for olt-1")
+	assert.Contains(t, err.Error(), "auth password")
+
+	cfg = snmpV3OLT()
+	cfg.Protocols.SNMP.AuthProtocol = "MD5"
+	_, _, err = e.createSouthboundDriver(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SHA authentication with AES privacy")
+
+	cfg = snmpV3OLT()
+	cfg.Protocols.SNMP.Version = "4"
+	_, _, err = e.createSouthboundDriver(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported SNMP version")
+}
+
+func TestUpdateOLTConfigsWhileReading(t *testing.T) {
+	e := newTestExecutor()
+	e.UpdateOLTConfigs([]agent.OLTConfig{{ID: "olt-1"}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			e.UpdateOLTConfigs([]agent.OLTConfig{{ID: "olt-1"}, {ID: "olt-2"}})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_, ok := e.oltConfig("olt-1")
+		assert.True(t, ok)
+	}
+	<-done
+	assert.Len(t, e.oltConfigList(), 2)
+}
@@ -0,0 +1,367 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+)
+
+// GuardConfig protects OLTs from command floods and limits the blast radius
+// of destructive commands. Zero values disable the corresponding guard.
+type GuardConfig struct {
+	// CommandsPerMinute is the sustained command rate per OLT.
+	CommandsPerMinute int
+	// CommandBurst is the number of commands an idle OLT accepts at once.
+	CommandBurst int
+	// MaxCLISessions is the number of concurrent CLI sessions per OLT.
+	MaxCLISessions int
+	// MinSessionInterval is the minimum time between CLI session opens per OLT.
+	MinSessionInterval time.Duration
+	// MaxImpactONUs is the number of online ONUs a destructive command may
+	// affect without confirmImpact: true in its payload.
+	MaxImpactONUs int
+	// SnapshotMaxAge is the oldest poller snapshot trusted for impact estimates.
+	SnapshotMaxAge time.Duration
+}
+
+// DefaultGuardConfig returns conservative limits suitable for small OLT CPUs.
+func DefaultGuardConfig() GuardConfig {
+	return GuardConfig{
+		CommandsPerMinute:  60,
+		CommandBurst:       10,
+		MaxCLISessions:     1,
+		MinSessionInterval: time.Second,
+		MaxImpactONUs:      32,
+		SnapshotMaxAge:     15 * time.Minute,
+	}
+}
+
+// ONUSnapshot is the poller's latest view of one ONU.
+type ONUSnapshot struct {
+	PONPort string
+	ONUID   int
+	Serial  string
+	Status  string
+	VLAN    int
+}
+
+// ONUSnapshotFunc returns the ONUs seen by the latest poll of an OLT and when
+// that poll ran. ok is false when the OLT has not been polled yet.
+type ONUSnapshotFunc func(oltID string) (onus []ONUSnapshot, at time.Time, ok bool)
+
+// ImpactFunc returns the online ONUs a command would take out of service.
+type ImpactFunc func(cmd agent.PendingCommand, onus []ONUSnapshot) ([]ONUSnapshot, error)
+
+// SetGuardConfig replaces the rate limits and blast-radius guard.
+func (e *Executor) SetGuardConfig(config GuardConfig) {
+	e.guardMu.Lock()
+	defer e.guardMu.Unlock()
+	e.guardConfig = config
+	e.guards = make(map[string]*oltGuard)
+}
+
+// SetONUSnapshot sets the source of ONU snapshots for impact estimates.
+func (e *Executor) SetONUSnapshot(snapshot ONUSnapshotFunc) {
+	e.onuSnapshot = snapshot
+}
+
+// oltGuard holds the rate limiter and session slots of one OLT.
+type oltGuard struct {
+	commands *tokenBucket
+	sessions chan struct{}
+
+	mu          sync.Mutex
+	lastSession time.Time
+}
+
+// guard returns the guard state for an OLT, creating it on first use.
+func (e *Executor) guard(oltID string) (*oltGuard, GuardConfig) {
+	e.guardMu.Lock()
+	defer e.guardMu.Unlock()
+
+	if e.guards == nil {
+		e.guards = make(map[string]*oltGuard)
+	}
+	g, ok := e.guards[oltID]
+	if !ok {
+		g = &oltGuard{}
+		if e.guardConfig.CommandsPerMinute > 0 {
+			g.commands = newTokenBucket(float64(e.guardConfig.CommandsPerMinute)/60, max(e.guardConfig.CommandBurst, 1))
+		}
+		if e.guardConfig.MaxCLISessions > 0 {
+			g.sessions = make(chan struct{}, e.guardConfig.MaxCLISessions)
+		}
+		e.guards[oltID] = g
+	}
+	return g, e.guardConfig
+}
+
+// waitForCommandSlot blocks until the OLT's command rate limit admits another command.
+func (e *Executor) waitForCommandSlot(ctx context.Context, oltID string) error {
+	g, _ := e.guard(oltID)
+	if g.commands == nil {
+		return nil
+	}
+	if err := g.commands.wait(ctx); err != nil {
+		return fmt.Errorf("rate limit for OLT %s: %w", oltID, err)
+	}
+	return nil
+}
+
+// acquireSession blocks until the OLT has a free CLI session slot and the
+// minimum interval since the last session open has passed. The returned
+// release function must be called when the session closes.
+func (e *Executor) acquireSession(ctx context.Context, oltID string) (func(), error) {
+	g, config := e.guard(oltID)
+
+	if g.sessions != nil {
+		select {
+		case g.sessions <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for a CLI session on OLT %s: %w", oltID, ctx.Err())
+		}
+	}
+	release := func() {
+		if g.sessions != nil {
+			<-g.sessions
+		}
+	}
+
+	if config.MinSessionInterval > 0 {
+		g.mu.Lock()
+		wait := time.Until(g.lastSession.Add(config.MinSessionInterval))
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				g.mu.Unlock()
+				release()
+				return nil, fmt.Errorf("waiting for a CLI session on OLT %s: %w", oltID, ctx.Err())
+			}
+		}
+		g.lastSession = time.Now()
+		g.mu.Unlock()
+	}
+
+	return release, nil
+}
+
+// impactConfirmed returns true if the payload acknowledges a large impact.
+func impactConfirmed(cmd agent.PendingCommand) bool {
+	confirmed, _ := cmd.Payload["confirmImpact"].(bool)
+	return confirmed
+}
+
+// checkImpact refuses destructive commands that would take more than
+// MaxImpactONUs online ONUs out of service unless the payload sets
+// confirmImpact: true. Without a fresh poller snapshot the impact cannot be
+// estimated and the command is allowed.
+func (e *Executor) checkImpact(spec CommandSpec, oltConfig agent.OLTConfig, cmd agent.PendingCommand) error {
+	if spec.Impact == nil || impactConfirmed(cmd) {
+		return nil
+	}
+	onus, at, limit, ok := e.impactSnapshot(oltConfig.ID, cmd.Type)
+	if !ok {
+		return nil
+	}
+
+	affected, err := spec.Impact(cmd, onus)
+	if err != nil {
+		return err
+	}
+	if len(affected) > limit {
+		return fmt.Errorf("%s would affect %d online ONUs (limit %d, snapshot from %s); resend with confirmImpact: true to proceed",
+			cmd.Type, len(affected), limit, at.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// impactSnapshot returns the ONU snapshot of an OLT and its impact limit.
+// ok is false when the guard is disabled or no recent snapshot exists.
+func (e *Executor) impactSnapshot(oltID, cmdType string) (onus []ONUSnapshot, at time.Time, limit int, ok bool) {
+	_, config := e.guard(oltID)
+	if config.MaxImpactONUs <= 0 || e.onuSnapshot == nil {
+		return nil, time.Time{}, 0, false
+	}
+
+	onus, at, ok = e.onuSnapshot(oltID)
+	if !ok || (config.SnapshotMaxAge > 0 && time.Since(at) > config.SnapshotMaxAge) {
+		log.Printf("[command] No recent ONU snapshot for %s, cannot estimate impact of %s", oltID, cmdType)
+		return nil, time.Time{}, 0, false
+	}
+	return onus, at, config.MaxImpactONUs, true
+}
+
+// checkBatchImpact applies the blast-radius guard to a batch as a whole: the
+// online ONUs its guarded operations would take out of service are added up
+// per OLT, so a large outage cannot be split into items that each pass.
+// Operations whose impact cannot be estimated are left to their own check.
+func (e *Executor) checkBatchImpact(cmd agent.PendingCommand, ops []batchOperation) error {
+	if impactConfirmed(cmd) {
+		return nil
+	}
+
+	var oltOrder []string
+	byOLT := make(map[string][]agent.PendingCommand)
+	for _, op := range ops {
+		spec, ok := e.commands().Lookup(op.cmd.Type)
+		if !ok || spec.Impact == nil || impactConfirmed(op.cmd) || dryRunRequested(op.cmd) {
+			continue
+		}
+		if _, ok := byOLT[op.cmd.EquipmentID]; !ok {
+			oltOrder = append(oltOrder, op.cmd.EquipmentID)
+		}
+		byOLT[op.cmd.EquipmentID] = append(byOLT[op.cmd.EquipmentID], op.cmd)
+	}
+
+	for _, oltID := range oltOrder {
+		onus, at, limit, ok := e.impactSnapshot(oltID, cmd.Type)
+		if !ok {
+			continue
+		}
+		affected := make(map[string]bool)
+		for _, opCmd := range byOLT[oltID] {
+			spec, _ := e.commands().Lookup(opCmd.Type)
+			onusAffected, err := spec.Impact(opCmd, onus)
+			if err != nil {
+				continue
+			}
+			for _, onu := range onusAffected {
+				affected[fmt.Sprintf("%s:%d", onu.PONPort, onu.ONUID)] = true
+			}
+		}
+		if len(affected) > limit {
+			return fmt.Errorf("batch would affect %d online ONUs on %s (limit %d, snapshot from %s); resend with confirmImpact: true to proceed",
+				len(affected), oltID, limit, at.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// portImpact returns the online ONUs on the payload's PON port.
+func portImpact(cmd agent.PendingCommand, onus []ONUSnapshot) ([]ONUSnapshot, error) {
+	port, _ := cmd.Payload["port"].(string)
+	if port == "" {
+		return nil, fmt.Errorf("port is required")
+	}
+	slot, portNum, err := parsePonPort(port)
+	if err != nil {
+		return nil, err
+	}
+
+	var affected []ONUSnapshot
+	for _, onu := range onus {
+		onuSlot, onuPort, err := parsePonPort(onu.PONPort)
+		if err != nil || onuSlot != slot || onuPort != portNum {
+			continue
+		}
+		if strings.EqualFold(onu.Status, "online") {
+			affected = append(affected, onu)
+		}
+	}
+	return affected, nil
+}
+
+// onuImpact returns the payload's ONU, selected by serial or by ponPort and
+// onuId, if it is online.
+func onuImpact(cmd agent.PendingCommand, onus []ONUSnapshot) ([]ONUSnapshot, error) {
+	serial, _ := cmd.Payload["serial"].(string)
+	ponPort, _ := cmd.Payload["ponPort"].(string)
+	onuIDFloat, _ := cmd.Payload["onuId"].(float64)
+	onuID := int(onuIDFloat)
+	if serial == "" && (ponPort == "" || onuID == 0) {
+		return nil, fmt.Errorf("either serial or ponPort+onuId is required")
+	}
+
+	for _, onu := range onus {
+		matches := serial != "" && strings.EqualFold(onu.Serial, serial)
+		if !matches && ponPort != "" && onu.ONUID == onuID {
+			matches = samePONPort(onu.PONPort, ponPort)
+		}
+		if !matches {
+			continue
+		}
+		if strings.EqualFold(onu.Status, "online") {
+			return []ONUSnapshot{onu}, nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// vlanImpact returns the online ONUs on the payload's VLAN.
+func vlanImpact(cmd agent.PendingCommand, onus []ONUSnapshot) ([]ONUSnapshot, error) {
+	vlanID, ok := cmd.Payload["vlanId"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("vlanId is required")
+	}
+
+	var affected []ONUSnapshot
+	for _, onu := range onus {
+		if onu.VLAN == int(vlanID) && strings.EqualFold(onu.Status, "online") {
+			affected = append(affected, onu)
+		}
+	}
+	return affected, nil
+}
+
+// samePONPort reports whether two PON port names refer to the same port,
+// whether written as slot/port or frame/slot/port.
+func samePONPort(a, b string) bool {
+	slotA, portA, errA := parsePonPort(a)
+	slotB, portB, errB := parsePonPort(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return slotA == slotB && portA == portB
+}
+
+// tokenBucket is a simple token bucket rate limiter.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:     rate,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
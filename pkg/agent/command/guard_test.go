@@ -0,0 +1,266 @@
+package command
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(20, 2) // 20/s, burst 2
+
+	ctx := context.Background()
+	start := time.Now()
+	require.NoError(t, bucket.wait(ctx))
+	require.NoError(t, bucket.wait(ctx))
+	assert.Less(t, time.Since(start), 20*time.Millisecond, "burst should not wait")
+
+	require.NoError(t, bucket.wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "third token needs a refill")
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, bucket.wait(cancelled))
+}
+
+func TestAcquireSession(t *testing.T) {
+	e := newTestExecutor()
+	e.SetGuardConfig(GuardConfig{MaxCLISessions: 1})
+
+	release, err := e.acquireSession(context.Background(), "olt-1")
+	require.NoError(t, err)
+
+	// Other OLTs have their own slots
+	releaseOther, err := e.acquireSession(context.Background(), "olt-2")
+	require.NoError(t, err)
+	releaseOther()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = e.acquireSession(ctx, "olt-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waiting for a CLI session on OLT olt-1")
+
+	release()
+	release, err = e.acquireSession(context.Background(), "olt-1")
+	require.NoError(t, err)
+	release()
+}
+
+// sessionCountingDriver records the most CLI sessions open at once.
+type sessionCountingDriver struct {
+	*mockCLIDriver
+	open, peak *int32
+}
+
+func (d sessionCountingDriver) Connect(ctx context.Context) error {
+	n := atomic.AddInt32(d.open, 1)
+	if n > atomic.LoadInt32(d.peak) {
+		atomic.StoreInt32(d.peak, n)
+	}
+	return nil
+}
+
+func (d sessionCountingDriver) Close() error {
+	atomic.AddInt32(d.open, -1)
+	return nil
+}
+
+func TestRunLimitsCLISessionsWithoutSNMP(t *testing.T) {
+	// Southbound CLI logins land on this listener
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	var open, peak, logins int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&logins, 1)
+			conn.Close()
+		}
+	}()
+
+	e := newTestExecutor()
+	e.SetGuardConfig(GuardConfig{MaxCLISessions: 1})
+	e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		return sessionCountingDriver{mockCLIDriver: &mockCLIDriver{vendor: config.Vendor}, open: &open, peak: &peak}, nil
+	}
+	oltConfig := agent.OLTConfig{ID: "olt-1", Vendor: "vsol", Address: "127.0.0.1", Protocols: agent.OLTProtocols{
+		SSH: agent.SSHConfig{Enabled: true, Port: listener.Addr().(*net.TCPAddr).Port, Username: "admin", Password: "admin"},
+	}}
+
+	// Verified commands do not open a second CLI session for verification
+	var verifiedWith types.DriverV2
+	verified := CommandSpec{
+		Type: "custom_write",
+		Verified: func(e *Executor, ctx context.Context, driver cli.CLIDriver, driverV2 types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			verifiedWith = driverV2
+			return map[string]interface{}{}, nil
+		},
+	}
+	_, err = e.run(context.Background(), verified, oltConfig, agent.PendingCommand{Type: "custom_write"})
+	require.NoError(t, err)
+	assert.Nil(t, verifiedWith)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+	assert.Zero(t, atomic.LoadInt32(&logins), "no southbound CLI login next to the held session")
+
+	// DriverV2 commands log in over CLI without SNMP, so they wait for a slot
+	release, err := e.acquireSession(context.Background(), "olt-1")
+	require.NoError(t, err)
+	defer release()
+	read := CommandSpec{
+		Type:      "custom_read",
+		Preferred: TransportDriverV2,
+		DriverV2: func(e *Executor, ctx context.Context, driverV2 types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			return map[string]interface{}{}, nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = e.run(ctx, read, oltConfig, agent.PendingCommand{Type: "custom_read"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "waiting for a CLI session on OLT olt-1")
+	assert.Zero(t, atomic.LoadInt32(&logins))
+}
+
+func TestCheckImpact(t *testing.T) {
+	onus := []ONUSnapshot{
+		{PONPort: "0/1/1", ONUID: 1, Status: "online"},
+		{PONPort: "0/1/1", ONUID: 2, Status: "online"},
+		{PONPort: "0/1/1", ONUID: 3, Status: "offline"},
+		{PONPort: "0/1/2", ONUID: 1, Status: "online"},
+	}
+	snapshotAt := time.Now()
+
+	e := newTestExecutor()
+	e.SetGuardConfig(GuardConfig{MaxImpactONUs: 1, SnapshotMaxAge: time.Minute})
+	e.SetONUSnapshot(func(oltID string) ([]ONUSnapshot, time.Time, bool) {
+		if oltID != "olt-1" {
+			return nil, time.Time{}, false
+		}
+		return onus, snapshotAt, true
+	})
+
+	spec, ok := DefaultRegistry.Lookup("port_disable")
+	require.True(t, ok)
+	olt := agent.OLTConfig{ID: "olt-1", Vendor: "huawei"}
+
+	t.Run("refuses large impact", func(t *testing.T) {
+		cmd := agent.PendingCommand{Type: "port_disable", Payload: map[string]interface{}{"port": "0/1/1"}}
+		err := e.checkImpact(spec, olt, cmd)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "would affect 2 online ONUs (limit 1")
+	})
+
+	t.Run("confirmImpact overrides", func(t *testing.T) {
+		cmd := agent.PendingCommand{Type: "port_disable", Payload: map[string]interface{}{"port": "0/1/1", "confirmImpact": true}}
+		assert.NoError(t, e.checkImpact(spec, olt, cmd))
+	})
+
+	t.Run("small impact is allowed", func(t *testing.T) {
+		cmd := agent.PendingCommand{Type: "port_disable", Payload: map[string]interface{}{"port": "1/2"}}
+		assert.NoError(t, e.checkImpact(spec, olt, cmd))
+	})
+
+	t.Run("no snapshot", func(t *testing.T) {
+		cmd := agent.PendingCommand{Type: "port_disable", Payload: map[string]interface{}{"port": "0/1/1"}}
+		assert.NoError(t, e.checkImpact(spec, agent.OLTConfig{ID: "olt-2"}, cmd))
+	})
+
+	t.Run("stale snapshot", func(t *testing.T) {
+		snapshotAt = time.Now().Add(-time.Hour)
+		defer func() { snapshotAt = time.Now() }()
+		cmd := agent.PendingCommand{Type: "port_disable", Payload: map[string]interface{}{"port": "0/1/1"}}
+		assert.NoError(t, e.checkImpact(spec, olt, cmd))
+	})
+
+	t.Run("unguarded commands", func(t *testing.T) {
+		enable, ok := DefaultRegistry.Lookup("port_enable")
+		require.True(t, ok)
+		cmd := agent.PendingCommand{Type: "port_enable", Payload: map[string]interface{}{"port": "0/1/1"}}
+		assert.NoError(t, e.checkImpact(enable, olt, cmd))
+	})
+}
+
+func TestONUAndVLANImpact(t *testing.T) {
+	onus := []ONUSnapshot{
+		{PONPort: "0/1/1", ONUID: 1, Serial: "HWTC00000001", Status: "online", VLAN: 100},
+		{PONPort: "0/1/1", ONUID: 2, Serial: "HWTC00000002", Status: "offline", VLAN: 100},
+		{PONPort: "0/1/2", ONUID: 1, Serial: "HWTC00000003", Status: "online", VLAN: 200},
+	}
+
+	affected, err := onuImpact(agent.PendingCommand{Payload: map[string]interface{}{"ponPort": "1/1", "onuId": float64(1)}}, onus)
+	require.NoError(t, err)
+	assert.Equal(t, []ONUSnapshot{onus[0]}, affected)
+
+	affected, err = onuImpact(agent.PendingCommand{Payload: map[string]interface{}{"serial": "hwtc00000002"}}, onus)
+	require.NoError(t, err)
+	assert.Empty(t, affected, "offline ONUs are not affected")
+
+	_, err = onuImpact(agent.PendingCommand{Payload: map[string]interface{}{}}, onus)
+	assert.Error(t, err)
+
+	affected, err = vlanImpact(agent.PendingCommand{Payload: map[string]interface{}{"vlanId": float64(100)}}, onus)
+	require.NoError(t, err)
+	assert.Equal(t, []ONUSnapshot{onus[0]}, affected)
+
+	for _, cmdType := range []string{"onu_reboot", "onu_delete", "onu_suspend", "vlan_delete", "port_disable"} {
+		spec, ok := DefaultRegistry.Lookup(cmdType)
+		require.True(t, ok)
+		assert.NotNil(t, spec.Impact, cmdType)
+	}
+}
+
+func TestCheckBatchImpact(t *testing.T) {
+	onus := []ONUSnapshot{
+		{PONPort: "0/1/1", ONUID: 1, Status: "online"},
+		{PONPort: "0/1/1", ONUID: 2, Status: "online"},
+		{PONPort: "0/1/1", ONUID: 3, Status: "online"},
+	}
+	e := newTestExecutor()
+	e.SetGuardConfig(GuardConfig{MaxImpactONUs: 2, SnapshotMaxAge: time.Minute})
+	e.SetONUSnapshot(func(oltID string) ([]ONUSnapshot, time.Time, bool) {
+		return onus, time.Now(), oltID == "olt-1"
+	})
+
+	reboot := func(onuID int) map[string]interface{} {
+		return map[string]interface{}{"type": "onu_reboot", "payload": map[string]interface{}{"ponPort": "0/1/1", "onuId": float64(onuID)}}
+	}
+	batch := agent.PendingCommand{ID: "batch-1", EquipmentID: "olt-1", Type: "batch", Payload: map[string]interface{}{
+		"operations": []interface{}{reboot(1), reboot(2), reboot(3), reboot(1)},
+	}}
+	ops, err := parseBatchOperations(batch)
+	require.NoError(t, err)
+
+	// Every reboot passes on its own, but together they exceed the limit
+	spec, _ := DefaultRegistry.Lookup("onu_reboot")
+	assert.NoError(t, e.checkImpact(spec, agent.OLTConfig{ID: "olt-1"}, ops[0].cmd))
+	err = e.checkBatchImpact(batch, ops)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "batch would affect 3 online ONUs on olt-1 (limit 2")
+
+	_, err = e.handleBatch(context.Background(), batch)
+	assert.ErrorContains(t, err, "batch would affect 3 online ONUs")
+
+	batch.Payload["confirmImpact"] = true
+	assert.NoError(t, e.checkBatchImpact(batch, ops))
+
+	// Operations on OLTs without a snapshot are not estimated
+	batch = agent.PendingCommand{ID: "batch-2", EquipmentID: "olt-2", Type: "batch", Payload: map[string]interface{}{
+		"operations": []interface{}{reboot(1), reboot(2), reboot(3)},
+	}}
+	ops, err = parseBatchOperations(batch)
+	require.NoError(t, err)
+	assert.NoError(t, e.checkBatchImpact(batch, ops))
+}
@@ -190,15 +190,21 @@ func (e *Executor) handleOLTHealthCheck(ctx context.Context, driver cli.CLIDrive
 		pingCmd = "display version"
 	}
 
+	reportStep(ctx, "running ping", fmt.Sprintf("checking CLI responsiveness (%s)", pingCmd), nil)
+	pingStart := time.Now()
 	_, err := driver.Execute(ctx, pingCmd)
 	if err != nil {
+		reportStep(ctx, "ping failed", err.Error(), nil)
 		return map[string]interface{}{
 			"healthy": false,
 			"message": fmt.Sprintf("OLT communication failed: %v", err),
 		}, nil
 	}
 
+	reportStep(ctx, "ping ok", fmt.Sprintf("CLI responded in %dms", time.Since(pingStart).Milliseconds()), nil)
+
 	// Check PON ports
+	reportStep(ctx, "reading PON ports", "", nil)
 	ports, portsErr := driver.ListPONPorts(ctx)
 	var healthIssues []string
 
@@ -217,6 +223,7 @@ func (e *Executor) handleOLTHealthCheck(ctx context.Context, driver cli.CLIDrive
 		if downPorts > 0 {
 			healthIssues = append(healthIssues, fmt.Sprintf("%d PON ports are down despite being enabled", downPorts))
 		}
+		reportStep(ctx, "read PON ports", fmt.Sprintf("%d ports, %d down", len(ports), downPorts), nil)
 	}
 
 	healthy := len(healthIssues) == 0
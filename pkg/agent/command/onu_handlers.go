@@ -25,6 +25,10 @@ func verifyONUStateChange(
 	maxRetries int,
 	retryDelay time.Duration,
 ) (*cli.ONUCLIInfo, bool) {
+	if isDryRun(ctx) {
+		return nil, false
+	}
+
 	var lastInfo *cli.ONUCLIInfo
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
@@ -61,6 +65,10 @@ func verifyONUDeleted(
 	maxRetries int,
 	retryDelay time.Duration,
 ) bool {
+	if isDryRun(ctx) {
+		return false
+	}
+
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			time.Sleep(retryDelay)
@@ -84,6 +92,10 @@ func verifyONUExists(
 	maxRetries int,
 	retryDelay time.Duration,
 ) (*cli.ONUCLIInfo, bool) {
+	if isDryRun(ctx) {
+		return nil, false
+	}
+
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
 			time.Sleep(retryDelay)
@@ -191,8 +203,8 @@ func verifyONUStateChangeSNMP(
 }
 
 // pushONUUpdate pushes ONU data to database immediately (best effort).
-func (e *Executor) pushONUUpdate(oltID, serial, ponPort string, onuID int, status string, info *cli.ONUCLIInfo) {
-	if e.client == nil || serial == "" {
+func (e *Executor) pushONUUpdate(ctx context.Context, oltID, serial, ponPort string, onuID int, status string, info *cli.ONUCLIInfo) {
+	if e.client == nil || serial == "" || isDryRun(ctx) {
 		return
 	}
 
@@ -216,6 +228,22 @@ func (e *Executor) pushONUUpdate(oltID, serial, ponPort string, onuID int, statu
 	}
 }
 
+// rollbackApplied runs the compensating actions for steps a failed driver
+// call applied before it failed. It returns nil when nothing needs undoing.
+func rollbackApplied(ctx context.Context, driver cli.CLIDriver, err error) *cli.RollbackResult {
+	steps := cli.AppliedSteps(err)
+	if len(steps) == 0 || isDryRun(ctx) {
+		return nil
+	}
+
+	slog.Warn("rolling back partially applied operation", "steps", len(steps), "error", err)
+	result := cli.Rollback(ctx, driver.Execute, steps)
+	if !result.Success {
+		slog.Error("rollback incomplete, OLT may be left half-configured", "steps", result.Steps)
+	}
+	return result
+}
+
 // handleONUList retrieves all ONUs from the OLT using CLI commands.
 // Note: The DriverV2/SNMP path is handled separately in executor.go via handleONUListV2.
 // This function is only called as a CLI fallback when SNMP is unavailable.
@@ -465,9 +493,32 @@ func (e *Executor) handleONUProvision(ctx context.Context, driver cli.CLIDriver,
 		return nil, fmt.Errorf("ponPort is required")
 	}
 
+	// Expand the service template for this vendor; explicit payload fields
+	// override what the template provides
+	vendor := driver.Vendor()
+	var template *expandedTemplate
+	if templateName, _ := cmd.Payload["template"].(string); templateName != "" {
+		var err error
+		template, err = e.expandTemplate(templateName, vendor)
+		if err != nil {
+			return nil, err
+		}
+		if lineProfile == "" {
+			lineProfile = template.Provision.LineProfile
+		}
+		if serviceProfile == "" {
+			serviceProfile = template.Provision.ServiceProfile
+		}
+		if onuProfile == "" {
+			onuProfile = template.Provision.ONUProfile
+		}
+		if vlan == 0 {
+			vlan = template.Provision.NativeVLAN
+		}
+	}
+
 	// NAN-241: Profiles are optional for V-SOL (uses onu confirm auto-provision)
 	// For other vendors (Huawei, ZTE), profiles are required
-	vendor := driver.Vendor()
 	if vendor != "vsol" {
 		if lineProfile == "" {
 			return nil, fmt.Errorf("lineProfile is required for vendor %s", vendor)
@@ -488,12 +539,25 @@ func (e *Executor) handleONUProvision(ctx context.Context, driver cli.CLIDriver,
 		ServiceProfile: serviceProfile,
 		NativeVLAN:     vlan,
 	}
+	if template != nil {
+		req.AllowedVLANs = template.Provision.AllowedVLANs
+		req.ServicePorts = template.Provision.ServicePorts
+	}
 
-	// Add ONU
+	// Add ONU, undoing any steps applied before a failure
 	err := driver.AddONU(ctx, req)
 	if err != nil {
+		if rollback := rollbackApplied(ctx, driver, err); rollback != nil {
+			return map[string]interface{}{
+				"success":      false,
+				"appliedSteps": cli.AppliedSteps(err),
+				"rollback":     rollback,
+			}, fmt.Errorf("failed to provision ONU: %w", err)
+		}
 		return nil, fmt.Errorf("failed to provision ONU: %w", err)
 	}
+	// V-SOL assigns the ONU ID when none was given and reports it in req
+	onuID = req.OnuID
 
 	// Verify ONU was created with retry
 	postInfo, verified := verifyONUExists(ctx, driver, ponPort, onuID, serial, 3, 500*time.Millisecond)
@@ -502,12 +566,34 @@ func (e *Executor) handleONUProvision(ctx context.Context, driver cli.CLIDriver,
 		return nil, fmt.Errorf("verification failed: ONU %s not found after provision", serial)
 	}
 
+	// Apply the template's VLAN translations and traffic profile; an explicit
+	// trafficProfile overrides the template's. A failure here would leave a
+	// half-configured ONU, so it is deleted again
+	trafficProfile := 0
+	if template != nil {
+		for _, translation := range template.Translations {
+			if err := driver.AddVLANTranslation(ctx, ponPort, onuID, translation); err != nil {
+				return removeProvisionedONU(ctx, driver, ponPort, onuID, fmt.Errorf("failed to add VLAN translation %d->%d: %w",
+					translation.CustomerVLAN, translation.ServiceVLAN, err))
+			}
+		}
+		trafficProfile = template.TrafficProfile
+	}
+	if trafficProfileFloat, ok := cmd.Payload["trafficProfile"].(float64); ok && trafficProfileFloat > 0 {
+		trafficProfile = int(trafficProfileFloat)
+	}
+	if trafficProfile > 0 {
+		if err := driver.AssignTrafficProfile(ctx, ponPort, onuID, trafficProfile); err != nil {
+			return removeProvisionedONU(ctx, driver, ponPort, onuID, fmt.Errorf("failed to assign traffic profile: %w", err))
+		}
+	}
+
 	// Push to database immediately
 	status := "online"
 	if postInfo != nil {
 		status = postInfo.Status
 	}
-	e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, status, postInfo)
+	e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, status, postInfo)
 
 	result := map[string]interface{}{
 		"success":         true,
@@ -526,10 +612,34 @@ func (e *Executor) handleONUProvision(ctx context.Context, driver cli.CLIDriver,
 	if postInfo != nil {
 		result["onu"].(map[string]interface{})["status"] = postInfo.Status
 	}
+	if template != nil {
+		result["onu"].(map[string]interface{})["template"] = template.Name
+	}
 
 	return result, nil
 }
 
+// removeProvisionedONU deletes an ONU whose provisioning failed after AddONU
+// and returns the provisioning error with the rollback outcome.
+func removeProvisionedONU(ctx context.Context, driver cli.CLIDriver, ponPort string, onuID int, provisionErr error) (map[string]interface{}, error) {
+	result := map[string]interface{}{
+		"success": false,
+		"ponPort": ponPort,
+		"onuId":   onuID,
+	}
+	if isDryRun(ctx) {
+		return result, provisionErr
+	}
+
+	if err := driver.DeleteONU(ctx, ponPort, onuID); err != nil {
+		slog.Error("failed to remove partially provisioned ONU", "ponPort", ponPort, "onuId", onuID, "error", err)
+		result["rollback"] = map[string]interface{}{"success": false, "error": err.Error()}
+		return result, fmt.Errorf("%w; removing the ONU also failed: %v", provisionErr, err)
+	}
+	result["rollback"] = map[string]interface{}{"success": true, "deleted": true}
+	return result, provisionErr
+}
+
 // handleONUDelete removes an ONU from the OLT.
 func (e *Executor) handleONUDelete(ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
 	serial, _ := cmd.Payload["serial"].(string)
@@ -607,7 +717,9 @@ func (e *Executor) handleONUReboot(ctx context.Context, driver cli.CLIDriver, cm
 	}
 
 	// Wait a bit longer for reboot to complete
-	time.Sleep(2 * time.Second)
+	if !isDryRun(ctx) {
+		time.Sleep(2 * time.Second)
+	}
 
 	// Verify ONU came back online (more retries and longer delay for reboot)
 	postInfo, verified := verifyONUStateChange(
@@ -623,7 +735,7 @@ func (e *Executor) handleONUReboot(ctx context.Context, driver cli.CLIDriver, cm
 		status = postInfo.Status
 	}
 	if serial != "" {
-		e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, status, postInfo)
+		e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, status, postInfo)
 	}
 
 	return map[string]interface{}{
@@ -644,50 +756,153 @@ func (e *Executor) handleONUDiagnostics(ctx context.Context, driver cli.CLIDrive
 		return nil, fmt.Errorf("ponPort and onuId are required")
 	}
 
-	diag, err := driver.GetONUDiagnostics(ctx, ponPort, onuID)
+	reportStep(ctx, "reading diagnostics", fmt.Sprintf("reading diagnostics for ONU %s/%d", ponPort, onuID), nil)
+	info, err := driver.GetONUInfo(ctx, ponPort, onuID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ONU diagnostics: %w", err)
 	}
+	if info == nil {
+		return nil, fmt.Errorf("ONU %d not found on %s", onuID, ponPort)
+	}
+	reportStep(ctx, "read status", info.Status, map[string]interface{}{
+		"serial": info.SerialNumber,
+		"status": info.Status,
+	})
 
-	return map[string]interface{}{
-		"diagnostics": map[string]interface{}{
-			"serial":  diag.SerialNumber,
-			"ponPort": diag.PonPort,
-			"onuId":   diag.OnuID,
-			"status":  diag.Status,
-			"optical": map[string]interface{}{
-				"rxPower":       diag.Optical.RxPower,
-				"txPower":       diag.Optical.TxPower,
-				"oltRxPower":    diag.Optical.OltRxPower,
-				"temperature":   diag.Optical.Temperature,
-				"voltage":       diag.Optical.Voltage,
-				"biasCurrent":   diag.Optical.BiasCurrent,
-				"rxPowerStatus": diag.Optical.RxPowerStatus,
-			},
-			"counters": map[string]interface{}{
-				"rxBytes":   diag.Counters.RxBytes,
-				"txBytes":   diag.Counters.TxBytes,
-				"rxPackets": diag.Counters.RxPackets,
-				"txPackets": diag.Counters.TxPackets,
-				"rxErrors":  diag.Counters.RxErrors,
-				"txErrors":  diag.Counters.TxErrors,
-			},
-			"health": map[string]interface{}{
-				"cpuUsage":    diag.Health.CPUUsage,
-				"memoryUsage": diag.Health.MemoryUsage,
-				"temperature": diag.Health.Temperature,
-				"uptime":      diag.Health.Uptime,
-				"firmwareVer": diag.Health.FirmwareVer,
-				"lastReboot":  diag.Health.LastReboot,
-			},
-			"connectivity": map[string]interface{}{
-				"distance":      diag.Connectivity.Distance,
-				"rtt":           diag.Connectivity.RTT,
-				"offlineReason": diag.Connectivity.OfflineReason,
-				"offlineCount":  diag.Connectivity.OfflineCount,
-			},
-		},
-	}, nil
+	// Each section is read and streamed as a partial result on its own, so
+	// the UI can render it while the slower sections are still being read.
+	// A section that fails is reported and left out of the result
+	sectionErrors := make(map[string]interface{})
+	readSection := func(name string, read func() (map[string]interface{}, string, error)) map[string]interface{} {
+		section, message, err := read()
+		if err != nil {
+			sectionErrors[name] = err.Error()
+			reportStep(ctx, name+" failed", err.Error(), nil)
+			return nil
+		}
+		reportStep(ctx, "read "+name, message, map[string]interface{}{name: section})
+		return section
+	}
+
+	optical := readSection("optical", func() (map[string]interface{}, string, error) {
+		diag, err := driver.GetOpticalDiagnostics(ctx, ponPort, onuID)
+		if err != nil {
+			return nil, "", err
+		}
+		if diag == nil {
+			diag = &cli.OpticalDiagnostics{}
+		}
+		return map[string]interface{}{
+			"rxPower":       diag.RxPower,
+			"txPower":       diag.TxPower,
+			"oltRxPower":    diag.OltRxPower,
+			"temperature":   diag.Temperature,
+			"voltage":       diag.Voltage,
+			"biasCurrent":   diag.BiasCurrent,
+			"rxPowerStatus": diag.RxPowerStatus,
+		}, fmt.Sprintf("rx power %.2f dBm", diag.RxPower), nil
+	})
+
+	counters := readSection("counters", func() (map[string]interface{}, string, error) {
+		c, err := driver.GetONUCounters(ctx, ponPort, onuID)
+		if err != nil {
+			return nil, "", err
+		}
+		if c == nil {
+			c = &cli.PerformanceCounters{}
+		}
+		return map[string]interface{}{
+			"rxBytes":   c.RxBytes,
+			"txBytes":   c.TxBytes,
+			"rxPackets": c.RxPackets,
+			"txPackets": c.TxPackets,
+			"rxErrors":  c.RxErrors,
+			"txErrors":  c.TxErrors,
+		}, "", nil
+	})
+
+	// Drivers that cannot read health and connectivity on their own read
+	// them with the full diagnostics, once
+	var fullDiag *cli.ONUDiagnostics
+	readFull := func() (*cli.ONUDiagnostics, error) {
+		if fullDiag == nil {
+			diag, err := driver.GetONUDiagnostics(ctx, ponPort, onuID)
+			if err != nil {
+				return nil, err
+			}
+			if diag == nil {
+				diag = &cli.ONUDiagnostics{}
+			}
+			fullDiag = diag
+		}
+		return fullDiag, nil
+	}
+	healthReader, separate := driver.(cli.ONUHealthReader)
+
+	health := readSection("health", func() (map[string]interface{}, string, error) {
+		var h *cli.DeviceHealth
+		if separate {
+			if h, err = healthReader.GetONUHealth(ctx, ponPort, onuID); err != nil {
+				return nil, "", err
+			}
+		} else {
+			diag, err := readFull()
+			if err != nil {
+				return nil, "", err
+			}
+			h = &diag.Health
+		}
+		if h == nil {
+			h = &cli.DeviceHealth{}
+		}
+		return map[string]interface{}{
+			"cpuUsage":    h.CPUUsage,
+			"memoryUsage": h.MemoryUsage,
+			"temperature": h.Temperature,
+			"uptime":      h.Uptime,
+			"firmwareVer": h.FirmwareVer,
+			"lastReboot":  h.LastReboot,
+		}, "", nil
+	})
+
+	connectivity := readSection("connectivity", func() (map[string]interface{}, string, error) {
+		var c *cli.ConnectivityInfo
+		if separate {
+			if c, err = healthReader.GetONUConnectivity(ctx, ponPort, onuID); err != nil {
+				return nil, "", err
+			}
+		} else {
+			diag, err := readFull()
+			if err != nil {
+				return nil, "", err
+			}
+			c = &diag.Connectivity
+		}
+		if c == nil {
+			c = &cli.ConnectivityInfo{}
+		}
+		return map[string]interface{}{
+			"distance":      c.Distance,
+			"rtt":           c.RTT,
+			"offlineReason": c.OfflineReason,
+			"offlineCount":  c.OfflineCount,
+		}, "", nil
+	})
+
+	diagnostics := map[string]interface{}{
+		"serial":       info.SerialNumber,
+		"ponPort":      ponPort,
+		"onuId":        onuID,
+		"status":       info.Status,
+		"optical":      optical,
+		"counters":     counters,
+		"health":       health,
+		"connectivity": connectivity,
+	}
+	if len(sectionErrors) > 0 {
+		diagnostics["errors"] = sectionErrors
+	}
+	return map[string]interface{}{"diagnostics": diagnostics}, nil
 }
 
 // handleONUDiscover discovers unprovisioned ONUs on the OLT.
@@ -882,7 +1097,7 @@ func (e *Executor) handleONUUpdate(ctx context.Context, driver cli.CLIDriver, cm
 	if postInfo != nil {
 		status = postInfo.Status
 	}
-	e.pushONUUpdate(cmd.EquipmentID, preInfo.SerialNumber, ponPort, onuID, status, postInfo)
+	e.pushONUUpdate(ctx, cmd.EquipmentID, preInfo.SerialNumber, ponPort, onuID, status, postInfo)
 
 	var postState map[string]interface{}
 	if postInfo != nil {
@@ -968,7 +1183,7 @@ func (e *Executor) handleONUSuspend(ctx context.Context, driver cli.CLIDriver, c
 	} else if postInfo != nil {
 		serial = postInfo.SerialNumber
 	}
-	e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, "suspended", postInfo)
+	e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, "suspended", postInfo)
 
 	var postState map[string]interface{}
 	if postInfo != nil {
@@ -1048,7 +1263,7 @@ func (e *Executor) handleONUResume(ctx context.Context, driver cli.CLIDriver, cm
 	} else if postInfo != nil {
 		serial = postInfo.SerialNumber
 	}
-	e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, "online", postInfo)
+	e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, "online", postInfo)
 
 	var postState map[string]interface{}
 	if postInfo != nil {
@@ -1206,7 +1421,7 @@ func (e *Executor) handleONUSuspendWithVerification(ctx context.Context, driver
 	if verified && postStatus != "" {
 		statusToReport = postStatus
 	}
-	e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, statusToReport, nil)
+	e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, statusToReport, nil)
 
 	postState := map[string]interface{}{
 		"serial":   serial,
@@ -1319,7 +1534,7 @@ func (e *Executor) handleONUResumeWithVerification(ctx context.Context, driver c
 	if verified && postStatus != "" {
 		statusToReport = postStatus
 	}
-	e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, statusToReport, nil)
+	e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, statusToReport, nil)
 
 	postState := map[string]interface{}{
 		"serial":   serial,
@@ -1429,6 +1644,9 @@ func (e *Executor) handleONUBulkProvision(ctx context.Context, driverV2 types.Dr
 	}
 
 	// Call driver BulkProvision
+	if driverV2 == nil {
+		return nil, fmt.Errorf("southbound driver not available for bulk provision")
+	}
 	result, err := driverV2.BulkProvision(ctx, operations)
 	if err != nil {
 		return nil, fmt.Errorf("bulk provision failed: %w", err)
@@ -1464,7 +1682,7 @@ func (e *Executor) handleONUBulkProvision(ctx context.Context, driverV2 types.Dr
 	// Push individual ONU updates for successful provisions
 	for _, r := range result.Results {
 		if r.Success {
-			e.pushONUUpdate(cmd.EquipmentID, r.Serial, r.PONPort, r.ONUID, "online", nil)
+			e.pushONUUpdate(ctx, cmd.EquipmentID, r.Serial, r.PONPort, r.ONUID, "online", nil)
 		}
 	}
 
@@ -1783,13 +2001,16 @@ func (e *Executor) handleONUBulkProvisionSequential(ctx context.Context, driver
 		if err != nil {
 			resultMap["success"] = false
 			resultMap["error"] = err.Error()
+			if rollback := rollbackApplied(ctx, driver, err); rollback != nil {
+				resultMap["rollback"] = rollback
+			}
 			failed++
 			slog.Warn("failed to provision ONU", "serial", serial, "error", err)
 		} else {
 			resultMap["success"] = true
 			succeeded++
 			// Push immediate update
-			e.pushONUUpdate(cmd.EquipmentID, serial, ponPort, onuID, "online", nil)
+			e.pushONUUpdate(ctx, cmd.EquipmentID, serial, ponPort, onuID, "online", nil)
 			slog.Info("provisioned ONU", "serial", serial, "ponPort", ponPort, "onuId", onuID)
 		}
 
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nanoncore/nano-agent/pkg/agent"
 	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -708,3 +709,85 @@ func TestHandleONUBulkProvision_PayloadParsing(t *testing.T) {
 		})
 	}
 }
+
+// =============================================================================
+// Provisioning rollback Tests
+// =============================================================================
+
+// mockCLIDriverWithAddONU is a mock whose AddONU can fail part-way.
+type mockCLIDriverWithAddONU struct {
+	mockCLIDriver
+	addONUFunc func(ctx context.Context, req *cli.ONUProvisionRequest) error
+}
+
+func (m *mockCLIDriverWithAddONU) AddONU(ctx context.Context, req *cli.ONUProvisionRequest) error {
+	if m.addONUFunc != nil {
+		return m.addONUFunc(ctx, req)
+	}
+	return nil
+}
+
+func TestHandleONUProvision_RollbackOnPartialFailure(t *testing.T) {
+	var executed []string
+	mock := &mockCLIDriverWithAddONU{
+		mockCLIDriver: mockCLIDriver{
+			vendor: "vsol",
+			executeFunc: func(ctx context.Context, cmd string) (string, error) {
+				executed = append(executed, cmd)
+				return "", nil
+			},
+		},
+		addONUFunc: func(ctx context.Context, req *cli.ONUProvisionRequest) error {
+			return &cli.PartialApplyError{
+				Operation: "ONU add",
+				Applied: []cli.AppliedStep{
+					{Name: "onu_add", Command: "onu add 5 profile default sn VSOL12345678", Undo: []string{"interface gpon 0/1", "no onu 5", "exit"}},
+					{Name: "line_profile", Command: "onu 5 profile line name internet"},
+				},
+				Err: errors.New("failed to create TCONT: command failed"),
+			}
+		},
+	}
+
+	e := newTestExecutor()
+	cmd := agent.PendingCommand{
+		Type: "onu_provision",
+		Payload: map[string]interface{}{
+			"serial":     "VSOL12345678",
+			"ponPort":    "0/1",
+			"onuId":      float64(5),
+			"onuProfile": "default",
+			"vlan":       float64(100),
+		},
+	}
+
+	result, err := e.handleONUProvision(context.Background(), mock, cmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create TCONT")
+	require.NotNil(t, result)
+	assert.Equal(t, false, result["success"])
+
+	rollback, ok := result["rollback"].(*cli.RollbackResult)
+	require.True(t, ok)
+	assert.True(t, rollback.Success)
+	assert.Equal(t, []string{"interface gpon 0/1", "no onu 5", "exit"}, executed)
+}
+
+func TestHandleONUProvision_NoRollbackWithoutAppliedSteps(t *testing.T) {
+	mock := &mockCLIDriverWithAddONU{
+		mockCLIDriver: mockCLIDriver{vendor: "vsol"},
+		addONUFunc: func(ctx context.Context, req *cli.ONUProvisionRequest) error {
+			return errors.New("ONU add failed: serial not in auto-find")
+		},
+	}
+
+	e := newTestExecutor()
+	cmd := agent.PendingCommand{
+		Type:    "onu_provision",
+		Payload: map[string]interface{}{"serial": "VSOL12345678", "ponPort": "0/1"},
+	}
+
+	result, err := e.handleONUProvision(context.Background(), mock, cmd)
+	require.Error(t, err)
+	assert.Nil(t, result)
+}
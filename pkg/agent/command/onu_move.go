@@ -0,0 +1,416 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+var (
+	// onuMoveOnlineTimeout is how long a moved ONU has to come online on its
+	// target port before the move is rolled back.
+	onuMoveOnlineTimeout = 2 * time.Minute
+	// onuMovePollInterval is the delay between online checks on the target.
+	onuMovePollInterval = 5 * time.Second
+)
+
+// onuLocation identifies an ONU entry on an OLT.
+type onuLocation struct {
+	EquipmentID string
+	PONPort     string
+	ONUID       int
+}
+
+func (l onuLocation) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"equipmentId": l.EquipmentID,
+		"ponPort":     l.PONPort,
+		"onuId":       l.ONUID,
+	}
+}
+
+// onuMoveRequest is a parsed onu_move payload.
+type onuMoveRequest struct {
+	source onuLocation
+	target onuLocation
+	// overrides replaces the profiles and description read from the source
+	overrides     cli.ONUProvisionRequest
+	onlineTimeout time.Duration
+}
+
+// parseONUMove validates an onu_move payload. The target defaults to the
+// command's OLT and the source ONU ID.
+func parseONUMove(cmd agent.PendingCommand) (onuMoveRequest, error) {
+	req := onuMoveRequest{onlineTimeout: onuMoveOnlineTimeout}
+
+	req.source.EquipmentID = cmd.EquipmentID
+	req.source.PONPort, _ = cmd.Payload["ponPort"].(string)
+	onuIDFloat, _ := cmd.Payload["onuId"].(float64)
+	req.source.ONUID = int(onuIDFloat)
+	if req.source.PONPort == "" || req.source.ONUID == 0 {
+		return req, fmt.Errorf("ponPort and onuId are required")
+	}
+
+	req.target.EquipmentID, _ = cmd.Payload["targetEquipmentId"].(string)
+	if req.target.EquipmentID == "" {
+		req.target.EquipmentID = cmd.EquipmentID
+	}
+	req.target.PONPort, _ = cmd.Payload["targetPonPort"].(string)
+	if req.target.PONPort == "" {
+		return req, fmt.Errorf("targetPonPort is required")
+	}
+	targetIDFloat, _ := cmd.Payload["targetOnuId"].(float64)
+	req.target.ONUID = int(targetIDFloat)
+	if req.target.ONUID == 0 {
+		req.target.ONUID = req.source.ONUID
+	}
+	if req.target == req.source {
+		return req, fmt.Errorf("target is the ONU's current location")
+	}
+
+	req.overrides.LineProfile, _ = cmd.Payload["lineProfile"].(string)
+	req.overrides.ServiceProfile, _ = cmd.Payload["serviceProfile"].(string)
+	req.overrides.ONUProfile, _ = cmd.Payload["onuProfile"].(string)
+	req.overrides.Description, _ = cmd.Payload["description"].(string)
+
+	if timeout, ok := cmd.Payload["onlineTimeoutSeconds"].(float64); ok && timeout > 0 {
+		req.onlineTimeout = time.Duration(timeout * float64(time.Second))
+	}
+
+	return req, nil
+}
+
+// onuConfig is the configuration of a provisioned ONU as read from the OLT.
+type onuConfig struct {
+	Info         *cli.ONUCLIInfo
+	VLAN         *cli.VLANConfig
+	ServicePorts []map[string]interface{}
+}
+
+// readONUConfig reads an ONU's registration, VLAN config and service ports.
+// Only the registration is required; the rest is best effort because not
+// every vendor can report it.
+func (e *Executor) readONUConfig(ctx context.Context, driver cli.CLIDriver, ponPort string, onuID int) (*onuConfig, error) {
+	info, err := driver.GetONUInfo(ctx, ponPort, onuID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ONU %d on %s: %w", onuID, ponPort, err)
+	}
+	if info == nil || info.SerialNumber == "" {
+		return nil, fmt.Errorf("ONU %d not found on PON port %s", onuID, ponPort)
+	}
+	config := &onuConfig{Info: info}
+
+	if vlan, err := driver.GetVLANConfig(ctx, ponPort, onuID); err != nil {
+		slog.Warn("failed to read ONU VLAN config", "ponPort", ponPort, "onuId", onuID, "error", err)
+	} else {
+		config.VLAN = vlan
+	}
+
+	if caps := driver.GetCapabilities(); caps != nil && caps.SupportsServicePorts {
+		state, err := e.getServicePortState(ctx, driver, ponPort, onuID)
+		if err != nil {
+			slog.Warn("failed to read ONU service ports", "ponPort", ponPort, "onuId", onuID, "error", err)
+		} else {
+			servicePorts, _ := state["servicePorts"].([]map[string]interface{})
+			for _, sp := range servicePorts {
+				// Generic parsing cannot tell ONUs apart, so keep matches only
+				if id, ok := sp["onuId"].(int); ok && id == onuID {
+					config.ServicePorts = append(config.ServicePorts, sp)
+				}
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// toState returns the config in the preState/postState format.
+func (c *onuConfig) toState() map[string]interface{} {
+	state := map[string]interface{}{
+		"serial":         c.Info.SerialNumber,
+		"ponPort":        c.Info.PonPort,
+		"onuId":          c.Info.OnuID,
+		"status":         c.Info.Status,
+		"type":           c.Info.Type,
+		"description":    c.Info.Description,
+		"lineProfile":    c.Info.LineProfile,
+		"serviceProfile": c.Info.ServiceProfile,
+		"onuProfile":     c.Info.ONUProfile,
+	}
+	if c.VLAN != nil {
+		state["nativeVlan"] = c.VLAN.NativeVLAN
+		state["taggedVlans"] = c.VLAN.TaggedVLANs
+		state["translations"] = c.VLAN.Translations
+	}
+	if c.ServicePorts != nil {
+		state["servicePorts"] = c.ServicePorts
+	}
+	return state
+}
+
+// provisionRequest rebuilds the ONU's configuration at a new location.
+// Non-empty override fields replace the values read from the OLT.
+func (c *onuConfig) provisionRequest(at onuLocation, overrides cli.ONUProvisionRequest) *cli.ONUProvisionRequest {
+	req := &cli.ONUProvisionRequest{
+		PonPort:        at.PONPort,
+		OnuID:          at.ONUID,
+		SerialNumber:   c.Info.SerialNumber,
+		Type:           c.Info.Type,
+		Description:    c.Info.Description,
+		LineProfile:    c.Info.LineProfile,
+		ServiceProfile: c.Info.ServiceProfile,
+		ONUProfile:     c.Info.ONUProfile,
+	}
+	if overrides.ONUProfile != "" {
+		req.ONUProfile = overrides.ONUProfile
+	}
+	if overrides.Description != "" {
+		req.Description = overrides.Description
+	}
+	if overrides.LineProfile != "" {
+		req.LineProfile = overrides.LineProfile
+	}
+	if overrides.ServiceProfile != "" {
+		req.ServiceProfile = overrides.ServiceProfile
+	}
+
+	if c.VLAN != nil {
+		req.NativeVLAN = c.VLAN.NativeVLAN
+		if req.NativeVLAN == 0 {
+			req.NativeVLAN = c.VLAN.ServiceVLAN
+		}
+		req.AllowedVLANs = c.VLAN.TaggedVLANs
+	}
+
+	// The native VLAN is provisioned by AddONU itself and occupies the first
+	// service port; the remaining service ports follow it
+	index := 1
+	if req.NativeVLAN > 0 {
+		index = 2
+	}
+	for _, sp := range c.ServicePorts {
+		vlan, _ := sp["vlanId"].(int)
+		if vlan == 0 || vlan == req.NativeVLAN {
+			continue
+		}
+		gemPort, _ := sp["gemPort"].(int)
+		req.ServicePorts = append(req.ServicePorts, cli.ServicePortSpec{
+			Index:   index,
+			VLAN:    vlan,
+			GemPort: gemPort,
+		})
+		index++
+	}
+
+	return req
+}
+
+// handleONUMove moves a provisioned ONU to another PON port on the same or a
+// different OLT, carrying over its profiles, VLANs and service ports.
+//
+// Across OLTs the ONU is provisioned on the target first and the source entry
+// is deleted only once the ONU is online on the target; if it does not come
+// online the target entry is removed again. On a single OLT the serial number
+// cannot be registered twice, so the source entry is deleted first and
+// re-provisioned if the target fails.
+func (e *Executor) handleONUMove(ctx context.Context, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	req, err := parseONUMove(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if dryRunRequested(cmd) {
+		return nil, fmt.Errorf("dryRun is not supported for onu_move")
+	}
+
+	sourceConfig, ok := e.oltConfig(req.source.EquipmentID)
+	if !ok {
+		return nil, fmt.Errorf("OLT configuration not found for equipment %s", req.source.EquipmentID)
+	}
+	targetConfig, ok := e.oltConfig(req.target.EquipmentID)
+	if !ok {
+		return nil, fmt.Errorf("OLT configuration not found for equipment %s", req.target.EquipmentID)
+	}
+	sameOLT := req.source.EquipmentID == req.target.EquipmentID
+
+	// The source OLT's blackout windows were checked when the command was
+	// scheduled; a different target OLT must not be in blackout either
+	startTime := time.Now()
+	if !sameOLT {
+		runAt, reason, err := scheduledRunTime(cmd, true, targetConfig.BlackoutWindows, startTime)
+		if err != nil {
+			return nil, err
+		}
+		if runAt.After(startTime) {
+			return nil, fmt.Errorf("target OLT %s blocked by %s until %s", targetConfig.ID, reason, runAt.Format(time.RFC3339))
+		}
+	}
+
+	if err := e.waitForCommandSlot(ctx, sourceConfig.ID); err != nil {
+		return nil, err
+	}
+	source, disconnectSource, err := e.connectCLI(ctx, sourceConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer disconnectSource()
+
+	target := source
+	if !sameOLT {
+		if err := e.waitForCommandSlot(ctx, targetConfig.ID); err != nil {
+			return nil, err
+		}
+		var disconnectTarget func()
+		target, disconnectTarget, err = e.connectCLI(ctx, targetConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer disconnectTarget()
+	}
+
+	result, err := e.moveONU(ctx, source, target, req)
+	e.audit(cmd, sourceConfig, startTime, result, err)
+	if err != nil {
+		return result, err
+	}
+
+	if !sameOLT {
+		e.triggerPoll(req.target.EquipmentID)
+	}
+	return result, nil
+}
+
+// moveONU performs the move on connected drivers. source and target are the
+// same driver when the move stays on one OLT.
+func (e *Executor) moveONU(ctx context.Context, source, target cli.CLIDriver, req onuMoveRequest) (map[string]interface{}, error) {
+	sameOLT := req.source.EquipmentID == req.target.EquipmentID
+
+	reportStep(ctx, "reading", fmt.Sprintf("reading ONU %d on %s", req.source.ONUID, req.source.PONPort), nil)
+	config, err := e.readONUConfig(ctx, source, req.source.PONPort, req.source.ONUID)
+	if err != nil {
+		return nil, err
+	}
+	serial := config.Info.SerialNumber
+	targetReq := config.provisionRequest(req.target, req.overrides)
+	sourceReq := config.provisionRequest(req.source, cli.ONUProvisionRequest{})
+	if sourceReq.ONUProfile == "" {
+		// The profile could not be read back; the one given for the target
+		// is the best guess for restoring the source
+		sourceReq.ONUProfile = req.overrides.ONUProfile
+	}
+
+	// Nothing may be deleted unless both the target and, on a single OLT,
+	// the restore of the source can actually be provisioned
+	if err := checkProvisionRequest(target.Vendor(), targetReq); err != nil {
+		return nil, fmt.Errorf("cannot provision ONU %s on the target: %w", serial, err)
+	}
+	if sameOLT {
+		if err := checkProvisionRequest(source.Vendor(), sourceReq); err != nil {
+			return nil, fmt.Errorf("cannot restore ONU %s if the move fails: %w", serial, err)
+		}
+	}
+
+	log.Printf("[command] Moving ONU %s from %s %s:%d to %s %s:%d",
+		serial, req.source.EquipmentID, req.source.PONPort, req.source.ONUID,
+		req.target.EquipmentID, req.target.PONPort, req.target.ONUID)
+
+	result := map[string]interface{}{
+		"success":  false,
+		"serial":   serial,
+		"source":   req.source.toMap(),
+		"target":   req.target.toMap(),
+		"config":   targetReq,
+		"preState": config.toState(),
+	}
+
+	if sameOLT {
+		reportStep(ctx, "deleting", fmt.Sprintf("removing ONU %s from %s", serial, req.source.PONPort), nil)
+		if err := source.DeleteONU(ctx, req.source.PONPort, req.source.ONUID); err != nil {
+			return result, fmt.Errorf("failed to delete ONU from source port: %w", err)
+		}
+	}
+
+	reportStep(ctx, "provisioning", fmt.Sprintf("provisioning ONU %s on %s", serial, req.target.PONPort), nil)
+	info, err := provisionMovedONU(ctx, target, targetReq, config.VLAN, req.onlineTimeout)
+	if err != nil {
+		result["rollback"] = rollbackMove(ctx, source, target, sameOLT, targetReq, sourceReq, config.VLAN)
+		return result, fmt.Errorf("failed to move ONU %s: %w", serial, err)
+	}
+
+	if !sameOLT {
+		reportStep(ctx, "deleting", fmt.Sprintf("removing ONU %s from %s", serial, req.source.EquipmentID), nil)
+		if err := source.DeleteONU(ctx, req.source.PONPort, req.source.ONUID); err != nil {
+			// The ONU is in service on the target; only the stale entry remains
+			result["sourceDeleted"] = false
+			return result, fmt.Errorf("ONU %s is online on the target but deleting the source entry failed: %w", serial, err)
+		}
+	}
+
+	e.pushONUUpdate(ctx, req.target.EquipmentID, serial, req.target.PONPort, req.target.ONUID, info.Status, info)
+
+	result["success"] = true
+	result["verified"] = true
+	result["sourceDeleted"] = true
+	result["immediateUpdate"] = true
+	result["postState"] = map[string]interface{}{
+		"serial":  info.SerialNumber,
+		"ponPort": req.target.PONPort,
+		"onuId":   req.target.ONUID,
+		"status":  info.Status,
+	}
+	return result, nil
+}
+
+// provisionMovedONU provisions the ONU on its target, restores its VLAN
+// translations and waits for it to come online.
+func provisionMovedONU(ctx context.Context, driver cli.CLIDriver, req *cli.ONUProvisionRequest, vlan *cli.VLANConfig, timeout time.Duration) (*cli.ONUCLIInfo, error) {
+	if err := provisionONUConfig(ctx, driver, req, vlan); err != nil {
+		return nil, err
+	}
+
+	retries := max(int(timeout/onuMovePollInterval), 1)
+	info, online := verifyONUStateChange(ctx, driver, req.PonPort, req.OnuID, []string{"online"}, retries, onuMovePollInterval)
+	if !online {
+		status := "not found"
+		if info != nil {
+			status = info.Status
+		}
+		return nil, fmt.Errorf("ONU did not come online on %s:%d within %s (status: %s)", req.PonPort, req.OnuID, timeout, status)
+	}
+	return info, nil
+}
+
+// rollbackMove removes the target entry and, for moves within one OLT,
+// re-provisions the ONU where it was.
+func rollbackMove(ctx context.Context, source, target cli.CLIDriver, sameOLT bool, targetReq, sourceReq *cli.ONUProvisionRequest, vlan *cli.VLANConfig) map[string]interface{} {
+	rollback := map[string]interface{}{}
+	var errs []string
+
+	if info, _ := target.GetONUInfo(ctx, targetReq.PonPort, targetReq.OnuID); info != nil {
+		if err := target.DeleteONU(ctx, targetReq.PonPort, targetReq.OnuID); err != nil {
+			errs = append(errs, fmt.Sprintf("remove target entry: %v", err))
+		}
+	}
+	rollback["targetRemoved"] = len(errs) == 0
+
+	if sameOLT {
+		restored := true
+		if err := provisionONUConfig(ctx, source, sourceReq, vlan); err != nil {
+			errs = append(errs, fmt.Sprintf("restore source entry: %v", err))
+			restored = false
+		}
+		rollback["sourceRestored"] = restored
+	}
+
+	rollback["success"] = len(errs) == 0
+	if len(errs) > 0 {
+		rollback["errors"] = errs
+		slog.Error("ONU move rollback incomplete", "serial", targetReq.SerialNumber, "errors", strings.Join(errs, "; "))
+	}
+	return rollback
+}
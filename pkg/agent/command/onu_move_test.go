@@ -0,0 +1,359 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOLT is an in-memory OLT that stores provisioned ONUs by location.
+type fakeOLT struct {
+	mockCLIDriver
+	mu   sync.Mutex
+	onus map[string]*cli.ONUProvisionRequest
+	vlan map[string]*cli.VLANConfig
+	// status reported for ONUs provisioned on the OLT
+	status    string
+	addErr    error
+	deleteErr error
+	// rejectSerial makes AddONU fail for one serial number
+	rejectSerial string
+	deleted      []string
+	// translations and traffic profiles applied after provisioning
+	translations map[string][]cli.VLANTranslation
+	traffic      map[string]int
+	// translationErr makes AddVLANTranslation fail
+	translationErr error
+}
+
+func newFakeOLT(vendor string) *fakeOLT {
+	return &fakeOLT{
+		mockCLIDriver: mockCLIDriver{vendor: vendor},
+		onus:          make(map[string]*cli.ONUProvisionRequest),
+		vlan:          make(map[string]*cli.VLANConfig),
+		translations:  make(map[string][]cli.VLANTranslation),
+		traffic:       make(map[string]int),
+		status:        "online",
+	}
+}
+
+func fakeKey(ponPort string, onuID int) string {
+	return fmt.Sprintf("%s:%d", ponPort, onuID)
+}
+
+func (f *fakeOLT) AddONU(ctx context.Context, req *cli.ONUProvisionRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.addErr != nil {
+		return f.addErr
+	}
+	if f.rejectSerial != "" && req.SerialNumber == f.rejectSerial {
+		return fmt.Errorf("serial %s rejected", req.SerialNumber)
+	}
+	// Like the V-SOL driver, an explicit ONU ID needs the hardware profile
+	if f.vendor == "vsol" && req.OnuID > 0 && req.ONUProfile == "" {
+		return fmt.Errorf("ONU profile is required when specifying an ONU ID")
+	}
+	for _, onu := range f.onus {
+		if onu.SerialNumber == req.SerialNumber {
+			return fmt.Errorf("serial %s already registered", req.SerialNumber)
+		}
+	}
+	// Like "onu confirm", V-SOL picks the next free ID and reports it back
+	if f.vendor == "vsol" && req.OnuID <= 0 {
+		req.OnuID = 1
+		for f.onus[fakeKey(req.PonPort, req.OnuID)] != nil {
+			req.OnuID++
+		}
+	}
+	stored := *req
+	f.onus[fakeKey(req.PonPort, req.OnuID)] = &stored
+	return nil
+}
+
+func (f *fakeOLT) DeleteONU(ctx context.Context, ponPort string, onuID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	f.deleted = append(f.deleted, fakeKey(ponPort, onuID))
+	delete(f.onus, fakeKey(ponPort, onuID))
+	return nil
+}
+
+func (f *fakeOLT) GetONUInfo(ctx context.Context, ponPort string, onuID int) (*cli.ONUCLIInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	onu, ok := f.onus[fakeKey(ponPort, onuID)]
+	if !ok {
+		return nil, nil
+	}
+	return &cli.ONUCLIInfo{
+		PonPort:        ponPort,
+		OnuID:          onuID,
+		SerialNumber:   onu.SerialNumber,
+		Status:         f.status,
+		Description:    onu.Description,
+		LineProfile:    onu.LineProfile,
+		ServiceProfile: onu.ServiceProfile,
+		ONUProfile:     onu.ONUProfile,
+	}, nil
+}
+
+func (f *fakeOLT) GetVLANConfig(ctx context.Context, ponPort string, onuID int) (*cli.VLANConfig, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.vlan[fakeKey(ponPort, onuID)], nil
+}
+
+func (f *fakeOLT) AddVLANTranslation(ctx context.Context, ponPort string, onuID int, translation cli.VLANTranslation) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.translationErr != nil {
+		return f.translationErr
+	}
+	key := fakeKey(ponPort, onuID)
+	f.translations[key] = append(f.translations[key], translation)
+	return nil
+}
+
+func (f *fakeOLT) AssignTrafficProfile(ctx context.Context, ponPort string, onuID int, profileID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.traffic[fakeKey(ponPort, onuID)] = profileID
+	return nil
+}
+
+func (f *fakeOLT) onu(ponPort string, onuID int) *cli.ONUProvisionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.onus[fakeKey(ponPort, onuID)]
+}
+
+func newMoveTestExecutor(t *testing.T, olts map[string]*fakeOLT) *Executor {
+	t.Helper()
+
+	interval := onuMovePollInterval
+	onuMovePollInterval = time.Millisecond
+	t.Cleanup(func() { onuMovePollInterval = interval })
+
+	e := newTestExecutor()
+	byAddress := make(map[string]*fakeOLT)
+	for id, olt := range olts {
+		e.oltConfigs[id] = agent.OLTConfig{ID: id, Address: id, Vendor: olt.vendor}
+		byAddress[id] = olt
+	}
+	e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		return byAddress[config.Host], nil
+	}
+	return e
+}
+
+func moveCommand(payload map[string]interface{}) agent.PendingCommand {
+	return agent.PendingCommand{ID: "cmd-1", EquipmentID: "olt-1", Type: "onu_move", Payload: payload}
+}
+
+func TestParseONUMove(t *testing.T) {
+	req, err := parseONUMove(moveCommand(map[string]interface{}{
+		"ponPort":              "0/1",
+		"onuId":                float64(5),
+		"targetPonPort":        "0/2",
+		"lineProfile":          "line_vlan_200",
+		"onlineTimeoutSeconds": float64(30),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, onuLocation{EquipmentID: "olt-1", PONPort: "0/1", ONUID: 5}, req.source)
+	assert.Equal(t, onuLocation{EquipmentID: "olt-1", PONPort: "0/2", ONUID: 5}, req.target)
+	assert.Equal(t, "line_vlan_200", req.overrides.LineProfile)
+	assert.Equal(t, 30*time.Second, req.onlineTimeout)
+
+	_, err = parseONUMove(moveCommand(map[string]interface{}{"ponPort": "0/1", "onuId": float64(5)}))
+	assert.ErrorContains(t, err, "targetPonPort is required")
+
+	_, err = parseONUMove(moveCommand(map[string]interface{}{
+		"ponPort": "0/1", "onuId": float64(5), "targetPonPort": "0/1",
+	}))
+	assert.ErrorContains(t, err, "current location")
+}
+
+func TestONUConfigProvisionRequest(t *testing.T) {
+	config := &onuConfig{
+		Info: &cli.ONUCLIInfo{
+			SerialNumber:   "HWTC12345678",
+			Description:    "customer-42",
+			LineProfile:    "10",
+			ServiceProfile: "20",
+		},
+		VLAN: &cli.VLANConfig{NativeVLAN: 100, TaggedVLANs: []int{100, 300}},
+		ServicePorts: []map[string]interface{}{
+			{"vlanId": 100, "gemPort": 1, "onuId": 5},
+			{"vlanId": 300, "gemPort": 2, "onuId": 5},
+		},
+	}
+
+	req := config.provisionRequest(onuLocation{PONPort: "0/0/2", ONUID: 7}, cli.ONUProvisionRequest{ServiceProfile: "21"})
+
+	assert.Equal(t, "0/0/2", req.PonPort)
+	assert.Equal(t, 7, req.OnuID)
+	assert.Equal(t, "HWTC12345678", req.SerialNumber)
+	assert.Equal(t, "customer-42", req.Description)
+	assert.Equal(t, "10", req.LineProfile)
+	assert.Equal(t, "21", req.ServiceProfile)
+	assert.Equal(t, 100, req.NativeVLAN)
+	assert.Equal(t, []int{100, 300}, req.AllowedVLANs)
+	assert.Equal(t, []cli.ServicePortSpec{{Index: 2, VLAN: 300, GemPort: 2}}, req.ServicePorts)
+}
+
+func TestHandleONUMove_AcrossOLTs(t *testing.T) {
+	src := newFakeOLT("vsol")
+	dst := newFakeOLT("vsol")
+	src.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", Description: "cust", LineProfile: "line_vlan_100", ONUProfile: "HG8010H"}
+	src.vlan[fakeKey("0/1", 5)] = &cli.VLANConfig{NativeVLAN: 100}
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": src, "olt-2": dst})
+
+	result, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":           "0/1",
+		"onuId":             float64(5),
+		"targetEquipmentId": "olt-2",
+		"targetPonPort":     "0/3",
+		"targetOnuId":       float64(9),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, true, result["success"])
+
+	moved := dst.onu("0/3", 9)
+	require.NotNil(t, moved)
+	assert.Equal(t, "GPON00000001", moved.SerialNumber)
+	assert.Equal(t, "cust", moved.Description)
+	assert.Equal(t, "line_vlan_100", moved.LineProfile)
+	assert.Equal(t, "HG8010H", moved.ONUProfile)
+	assert.Equal(t, 100, moved.NativeVLAN)
+	assert.Nil(t, src.onu("0/1", 5), "source entry should be deleted")
+}
+
+func TestHandleONUMove_AcrossOLTsRollsBackWhenTargetOffline(t *testing.T) {
+	src := newFakeOLT("vsol")
+	dst := newFakeOLT("vsol")
+	dst.status = "offline"
+	src.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", ONUProfile: "HG8010H"}
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": src, "olt-2": dst})
+
+	result, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":              "0/1",
+		"onuId":                float64(5),
+		"targetEquipmentId":    "olt-2",
+		"targetPonPort":        "0/3",
+		"onlineTimeoutSeconds": float64(0.005),
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not come online")
+
+	rollback, ok := result["rollback"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, true, rollback["success"])
+	assert.Nil(t, dst.onu("0/3", 5), "target entry should be removed")
+	assert.NotNil(t, src.onu("0/1", 5), "source entry must be untouched")
+	assert.Empty(t, src.deleted)
+}
+
+func TestHandleONUMove_SameOLT(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", Description: "cust", ONUProfile: "HG8010H"}
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": olt})
+
+	_, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":       "0/1",
+		"onuId":         float64(5),
+		"targetPonPort": "0/2",
+	}))
+	require.NoError(t, err)
+	assert.Nil(t, olt.onu("0/1", 5))
+	require.NotNil(t, olt.onu("0/2", 5))
+	assert.Equal(t, "cust", olt.onu("0/2", 5).Description)
+}
+
+func TestHandleONUMove_SameOLTRestoresSourceOnFailure(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.status = "offline"
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", Description: "cust", ONUProfile: "HG8010H"}
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": olt})
+
+	result, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":              "0/1",
+		"onuId":                float64(5),
+		"targetPonPort":        "0/2",
+		"onlineTimeoutSeconds": float64(0.005),
+	}))
+	require.Error(t, err)
+
+	rollback := result["rollback"].(map[string]interface{})
+	assert.Equal(t, true, rollback["sourceRestored"])
+	assert.Nil(t, olt.onu("0/2", 5))
+	restored := olt.onu("0/1", 5)
+	require.NotNil(t, restored)
+	assert.Equal(t, "cust", restored.Description)
+	assert.Equal(t, "HG8010H", restored.ONUProfile)
+}
+
+func TestHandleONUMove_SameOLTRestoresWithOverrideProfile(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.status = "offline"
+	// The profile cannot be read back, so the operator supplies it
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001"}
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": olt})
+
+	result, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":              "0/1",
+		"onuId":                float64(5),
+		"targetPonPort":        "0/2",
+		"onuProfile":           "HG8010H",
+		"onlineTimeoutSeconds": float64(0.005),
+	}))
+	require.Error(t, err)
+
+	rollback := result["rollback"].(map[string]interface{})
+	assert.Equal(t, true, rollback["success"])
+	restored := olt.onu("0/1", 5)
+	require.NotNil(t, restored, "source entry must be restored")
+	assert.Equal(t, "HG8010H", restored.ONUProfile)
+}
+
+func TestHandleONUMove_MissingProfileDeletesNothing(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001"}
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": olt})
+
+	_, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":       "0/1",
+		"onuId":         float64(5),
+		"targetPonPort": "0/2",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "onuProfile is required")
+	assert.Empty(t, olt.deleted)
+	assert.NotNil(t, olt.onu("0/1", 5))
+}
+
+func TestHandleONUMove_SourceNotFound(t *testing.T) {
+	src := newFakeOLT("vsol")
+	dst := newFakeOLT("vsol")
+	e := newMoveTestExecutor(t, map[string]*fakeOLT{"olt-1": src, "olt-2": dst})
+
+	_, err := e.handleONUMove(context.Background(), moveCommand(map[string]interface{}{
+		"ponPort":           "0/1",
+		"onuId":             float64(5),
+		"targetEquipmentId": "olt-2",
+		"targetPonPort":     "0/3",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+	assert.Empty(t, dst.onus)
+}
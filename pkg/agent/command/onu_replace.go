@@ -0,0 +1,181 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+// Serial replacement methods reported in onu_replace results.
+const (
+	replaceInPlace     = "in_place"
+	replaceReprovision = "reprovision"
+)
+
+// handleONUReplace re-binds a PON port/ONU ID and its services to a new ONU
+// serial, e.g. after a customer's ONT was swapped. Drivers that implement
+// cli.SerialReplacer change the serial in place; otherwise the entry is
+// deleted and re-provisioned with the exact same configuration, restoring the
+// old serial if that fails.
+func (e *Executor) handleONUReplace(ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	ponPort, _ := cmd.Payload["ponPort"].(string)
+	onuIDFloat, _ := cmd.Payload["onuId"].(float64)
+	onuID := int(onuIDFloat)
+	newSerial, _ := cmd.Payload["newSerial"].(string)
+	oldSerial, _ := cmd.Payload["oldSerial"].(string)
+	onuProfile, _ := cmd.Payload["onuProfile"].(string)
+
+	if ponPort == "" || onuID == 0 {
+		return nil, fmt.Errorf("ponPort and onuId are required")
+	}
+	newSerial = strings.ToUpper(strings.TrimSpace(newSerial))
+	if newSerial == "" {
+		return nil, fmt.Errorf("newSerial is required")
+	}
+	if strings.ContainsAny(newSerial, " \t") {
+		return nil, fmt.Errorf("invalid newSerial %q", newSerial)
+	}
+
+	config, err := e.readONUConfig(ctx, driver, ponPort, onuID)
+	if err != nil {
+		return nil, err
+	}
+	currentSerial := config.Info.SerialNumber
+	if oldSerial != "" && !strings.EqualFold(oldSerial, currentSerial) {
+		return nil, fmt.Errorf("ONU %d on %s has serial %s, not %s", onuID, ponPort, currentSerial, oldSerial)
+	}
+	if strings.EqualFold(currentSerial, newSerial) {
+		return nil, fmt.Errorf("ONU %d on %s already has serial %s", onuID, ponPort, newSerial)
+	}
+
+	at := onuLocation{EquipmentID: cmd.EquipmentID, PONPort: ponPort, ONUID: onuID}
+	oldReq := config.provisionRequest(at, cli.ONUProvisionRequest{})
+	if oldReq.ONUProfile == "" {
+		// The profile could not be read back; restore with the given one
+		oldReq.ONUProfile = onuProfile
+	}
+	newReq := config.provisionRequest(at, cli.ONUProvisionRequest{ONUProfile: onuProfile})
+	newReq.SerialNumber = newSerial
+
+	result := map[string]interface{}{
+		"success":   false,
+		"ponPort":   ponPort,
+		"onuId":     onuID,
+		"oldSerial": currentSerial,
+		"newSerial": newSerial,
+	}
+
+	if replacer, ok := driver.(cli.SerialReplacer); ok {
+		result["method"] = replaceInPlace
+		reportStep(ctx, "replacing", fmt.Sprintf("changing serial %s to %s in place", currentSerial, newSerial), nil)
+		if err := replacer.ReplaceONUSerial(ctx, ponPort, onuID, newSerial); err != nil {
+			return result, fmt.Errorf("failed to replace ONU serial: %w", err)
+		}
+	} else {
+		result["method"] = replaceReprovision
+		result["config"] = newReq
+		// The entry is deleted first, so both the new serial and the
+		// restore of the old one must be provisionable
+		if err := checkProvisionRequest(driver.Vendor(), newReq); err != nil {
+			return result, fmt.Errorf("cannot replace ONU serial: %w", err)
+		}
+		if err := checkProvisionRequest(driver.Vendor(), oldReq); err != nil {
+			return result, fmt.Errorf("cannot replace ONU serial: %w", err)
+		}
+		if err := reprovisionSerial(ctx, driver, oldReq, newReq, config.VLAN); err != nil {
+			result["rollback"] = restoreSerial(ctx, driver, oldReq, config.VLAN)
+			return result, fmt.Errorf("failed to replace ONU serial: %w", err)
+		}
+	}
+
+	if isDryRun(ctx) {
+		return result, nil
+	}
+
+	// The new ONT may not be powered yet, so require the binding but only
+	// report whether it is online
+	info, verified := verifyONUExists(ctx, driver, ponPort, onuID, newSerial, 3, 500*time.Millisecond)
+	if !verified {
+		return result, fmt.Errorf("verification failed: ONU %d on %s is not bound to serial %s", onuID, ponPort, newSerial)
+	}
+	log.Printf("[command] Replaced ONU serial %s with %s on %s:%d (%s)", currentSerial, newSerial, ponPort, onuID, result["method"])
+
+	e.pushONUUpdate(ctx, cmd.EquipmentID, newSerial, ponPort, onuID, info.Status, info)
+
+	result["success"] = true
+	result["verified"] = true
+	result["online"] = strings.EqualFold(info.Status, "online")
+	result["status"] = info.Status
+	result["immediateUpdate"] = true
+	return result, nil
+}
+
+// reprovisionSerial deletes an ONU entry and provisions the same
+// configuration for a new serial.
+func reprovisionSerial(ctx context.Context, driver cli.CLIDriver, oldReq, newReq *cli.ONUProvisionRequest, vlan *cli.VLANConfig) error {
+	reportStep(ctx, "deleting", fmt.Sprintf("removing serial %s", oldReq.SerialNumber), nil)
+	if err := driver.DeleteONU(ctx, oldReq.PonPort, oldReq.OnuID); err != nil {
+		return fmt.Errorf("failed to delete ONU: %w", err)
+	}
+
+	reportStep(ctx, "provisioning", fmt.Sprintf("provisioning serial %s", newReq.SerialNumber), nil)
+	return provisionONUConfig(ctx, driver, newReq, vlan)
+}
+
+// restoreSerial puts the old serial's entry back after a failed replacement.
+func restoreSerial(ctx context.Context, driver cli.CLIDriver, oldReq *cli.ONUProvisionRequest, vlan *cli.VLANConfig) map[string]interface{} {
+	if isDryRun(ctx) {
+		return nil
+	}
+
+	// Whatever reached the OLT for the new serial has to go first
+	if info, _ := driver.GetONUInfo(ctx, oldReq.PonPort, oldReq.OnuID); info != nil {
+		if info.SerialNumber == oldReq.SerialNumber {
+			return map[string]interface{}{"success": true, "restored": true}
+		}
+		if err := driver.DeleteONU(ctx, oldReq.PonPort, oldReq.OnuID); err != nil {
+			return map[string]interface{}{"success": false, "error": fmt.Sprintf("remove new entry: %v", err)}
+		}
+	}
+
+	if err := provisionONUConfig(ctx, driver, oldReq, vlan); err != nil {
+		return map[string]interface{}{"success": false, "error": fmt.Sprintf("restore old serial: %v", err)}
+	}
+	return map[string]interface{}{"success": true, "restored": true}
+}
+
+// checkProvisionRequest reports a provisioning request the vendor's driver is
+// known to reject, so callers can refuse before deleting the entry it is
+// meant to replace.
+func checkProvisionRequest(vendor string, req *cli.ONUProvisionRequest) error {
+	// V-SOL only auto-assigns the ONU ID without a profile; an explicit ID
+	// needs the hardware profile
+	if vendor == "vsol" && req.OnuID > 0 && req.ONUProfile == "" {
+		return fmt.Errorf("onuProfile is required to provision ONU %d on %s with an explicit ONU ID", req.OnuID, req.PonPort)
+	}
+	return nil
+}
+
+// provisionONUConfig provisions an ONU and restores its VLAN translations,
+// undoing any partially applied provisioning steps on failure.
+func provisionONUConfig(ctx context.Context, driver cli.CLIDriver, req *cli.ONUProvisionRequest, vlan *cli.VLANConfig) error {
+	if err := driver.AddONU(ctx, req); err != nil {
+		rollbackApplied(ctx, driver, err)
+		return fmt.Errorf("failed to provision ONU on %s: %w", req.PonPort, err)
+	}
+
+	if vlan != nil {
+		for _, translation := range vlan.Translations {
+			if err := driver.AddVLANTranslation(ctx, req.PonPort, req.OnuID, translation); err != nil {
+				return fmt.Errorf("failed to restore VLAN translation %d->%d: %w",
+					translation.CustomerVLAN, translation.ServiceVLAN, err)
+			}
+		}
+	}
+	return nil
+}
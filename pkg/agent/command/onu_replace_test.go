@@ -0,0 +1,150 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReplacerOLT is a fakeOLT that swaps serials in place.
+type fakeReplacerOLT struct {
+	*fakeOLT
+	replaced int
+}
+
+func (f *fakeReplacerOLT) ReplaceONUSerial(ctx context.Context, ponPort string, onuID int, serial string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replaced++
+	f.onus[fakeKey(ponPort, onuID)].SerialNumber = serial
+	return nil
+}
+
+func replaceCommand(payload map[string]interface{}) agent.PendingCommand {
+	return agent.PendingCommand{ID: "cmd-1", EquipmentID: "olt-1", Type: "onu_replace", Payload: payload}
+}
+
+func TestHandleONUReplace_InPlace(t *testing.T) {
+	olt := &fakeReplacerOLT{fakeOLT: newFakeOLT("huawei")}
+	olt.onus[fakeKey("0/0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "HWTC00000001", LineProfile: "10"}
+	e := newTestExecutor()
+
+	result, err := e.handleONUReplace(context.Background(), olt, replaceCommand(map[string]interface{}{
+		"ponPort":   "0/0/1",
+		"onuId":     float64(5),
+		"newSerial": "hwtc00000002",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, replaceInPlace, result["method"])
+	assert.Equal(t, "HWTC00000001", result["oldSerial"])
+	assert.Equal(t, "HWTC00000002", result["newSerial"])
+	assert.Equal(t, true, result["verified"])
+	assert.Equal(t, true, result["online"])
+	assert.Equal(t, 1, olt.replaced)
+	assert.Empty(t, olt.deleted, "in-place replacement must not delete the entry")
+	assert.Equal(t, "10", olt.onu("0/0/1", 5).LineProfile)
+}
+
+func TestHandleONUReplace_Reprovision(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", Description: "cust", LineProfile: "line_vlan_100", ONUProfile: "HG8010H"}
+	olt.vlan[fakeKey("0/1", 5)] = &cli.VLANConfig{NativeVLAN: 100}
+	e := newTestExecutor()
+
+	result, err := e.handleONUReplace(context.Background(), olt, replaceCommand(map[string]interface{}{
+		"ponPort":   "0/1",
+		"onuId":     float64(5),
+		"newSerial": "GPON00000002",
+		"oldSerial": "GPON00000001",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, replaceReprovision, result["method"])
+
+	onu := olt.onu("0/1", 5)
+	require.NotNil(t, onu)
+	assert.Equal(t, "GPON00000002", onu.SerialNumber)
+	assert.Equal(t, "cust", onu.Description)
+	assert.Equal(t, "line_vlan_100", onu.LineProfile)
+	assert.Equal(t, 100, onu.NativeVLAN)
+}
+
+func TestHandleONUReplace_RestoresOldSerialOnFailure(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.rejectSerial = "GPON00000002"
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", Description: "cust", ONUProfile: "HG8010H"}
+	e := newTestExecutor()
+
+	result, err := e.handleONUReplace(context.Background(), olt, replaceCommand(map[string]interface{}{
+		"ponPort":   "0/1",
+		"onuId":     float64(5),
+		"newSerial": "GPON00000002",
+	}))
+	require.Error(t, err)
+
+	rollback, ok := result["rollback"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, true, rollback["restored"])
+	onu := olt.onu("0/1", 5)
+	require.NotNil(t, onu)
+	assert.Equal(t, "GPON00000001", onu.SerialNumber)
+	assert.Equal(t, "cust", onu.Description)
+}
+
+func TestHandleONUReplace_ONUProfile(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.rejectSerial = "GPON00000002"
+	// The hardware profile cannot be read back from this OLT
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001", Description: "cust"}
+	e := newTestExecutor()
+
+	payload := map[string]interface{}{
+		"ponPort":   "0/1",
+		"onuId":     float64(5),
+		"newSerial": "GPON00000002",
+	}
+	_, err := e.handleONUReplace(context.Background(), olt, replaceCommand(payload))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "onuProfile is required")
+	assert.Empty(t, olt.deleted, "nothing may be deleted without a profile")
+
+	// With the profile given the old serial is restored after the failure
+	payload["onuProfile"] = "HG8010H"
+	result, err := e.handleONUReplace(context.Background(), olt, replaceCommand(payload))
+	require.Error(t, err)
+	rollback := result["rollback"].(map[string]interface{})
+	assert.Equal(t, true, rollback["restored"])
+	onu := olt.onu("0/1", 5)
+	require.NotNil(t, onu)
+	assert.Equal(t, "GPON00000001", onu.SerialNumber)
+	assert.Equal(t, "HG8010H", onu.ONUProfile)
+}
+
+func TestHandleONUReplace_Validation(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.onus[fakeKey("0/1", 5)] = &cli.ONUProvisionRequest{SerialNumber: "GPON00000001"}
+	e := newTestExecutor()
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		wantErr string
+	}{
+		{"missing location", map[string]interface{}{"newSerial": "GPON00000002"}, "ponPort and onuId are required"},
+		{"missing serial", map[string]interface{}{"ponPort": "0/1", "onuId": float64(5)}, "newSerial is required"},
+		{"unknown ONU", map[string]interface{}{"ponPort": "0/1", "onuId": float64(6), "newSerial": "GPON00000002"}, "not found"},
+		{"old serial mismatch", map[string]interface{}{"ponPort": "0/1", "onuId": float64(5), "newSerial": "GPON00000002", "oldSerial": "GPON00000009"}, "not GPON00000009"},
+		{"same serial", map[string]interface{}{"ponPort": "0/1", "onuId": float64(5), "newSerial": "GPON00000001"}, "already has serial"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := e.handleONUReplace(context.Background(), olt, replaceCommand(tt.payload))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+	assert.Empty(t, olt.deleted)
+}
@@ -0,0 +1,417 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/nanoncore/nano-southbound/types"
+)
+
+// Transport identifies the driver a command prefers to run against.
+type Transport int
+
+const (
+	// TransportCLI runs the command on the CLI driver only.
+	TransportCLI Transport = iota
+	// TransportDriverV2 runs the command on the southbound DriverV2, using SNMP
+	// when the OLT has it enabled. The CLI handler is used as fallback.
+	TransportDriverV2
+	// TransportDriverV2CLI runs the command on a CLI-backed southbound DriverV2
+	// (e.g. "show onu auto-find"). The CLI handler is used as fallback.
+	TransportDriverV2CLI
+)
+
+// String returns the transport name.
+func (t Transport) String() string {
+	switch t {
+	case TransportCLI:
+		return "cli"
+	case TransportDriverV2:
+		return "driverv2"
+	case TransportDriverV2CLI:
+		return "driverv2-cli"
+	default:
+		return "unknown"
+	}
+}
+
+// CLIHandlerFunc executes a command on a connected CLI driver.
+type CLIHandlerFunc func(e *Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error)
+
+// DriverV2HandlerFunc executes a command on a connected southbound DriverV2.
+type DriverV2HandlerFunc func(e *Executor, ctx context.Context, driverV2 types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error)
+
+// VerifiedHandlerFunc executes a command on the CLI driver and verifies the
+// outcome through DriverV2. driverV2 is nil when SNMP is unavailable.
+type VerifiedHandlerFunc func(e *Executor, ctx context.Context, driver cli.CLIDriver, driverV2 types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error)
+
+// LocalHandlerFunc executes a command that is not bound to a single OLT, such
+// as a batch fanning out to several OLTs. No driver is created for it.
+type LocalHandlerFunc func(e *Executor, ctx context.Context, cmd agent.PendingCommand) (map[string]interface{}, error)
+
+// CommandSpec declares how a command type is executed.
+type CommandSpec struct {
+	// Type is the command type as sent by the control plane (e.g. "onu_provision").
+	Type string
+	// Preferred is the transport tried first.
+	Preferred Transport
+	// DriverV2 handles the command when Preferred is a DriverV2 transport.
+	DriverV2 DriverV2HandlerFunc
+	// CLI handles the command on the CLI driver, and is the fallback when the
+	// DriverV2 path fails.
+	CLI CLIHandlerFunc
+	// Verified handles the command on the CLI driver with DriverV2 verification.
+	// When set it takes precedence over CLI.
+	Verified VerifiedHandlerFunc
+	// Local handles commands that are not bound to the command's EquipmentID.
+	// It cannot be combined with the driver handlers.
+	Local LocalHandlerFunc
+	// Mutates reports whether the command changes device state.
+	Mutates bool
+	// Impact returns the online ONUs a destructive command would take out of
+	// service, for the executor's blast-radius guard. Nil means not guarded.
+	Impact ImpactFunc
+	// Audit records the command in the audit log even if it does not mutate.
+	// Mutating commands are always audited.
+	Audit bool
+	// State captures the device state a mutating command affects. The executor
+	// runs it before and after the handler and reports the snapshots as
+	// preState/postState unless the handler captured its own.
+	State CLIHandlerFunc
+
+	// Requires reports whether the vendor capability matrix allows the command.
+	// Nil means the command only needs a working CLI session.
+	Requires func(caps *cli.VendorCapabilities) bool
+	// Vendors restricts commands built from hand-written vendor CLI sequences.
+	// Empty means any vendor with a registered CLI driver.
+	Vendors []string
+}
+
+// NeedsVerification reports whether the command verifies its outcome via DriverV2.
+func (s CommandSpec) NeedsVerification() bool {
+	return s.Verified != nil
+}
+
+// validate checks that the spec has a usable handler for its preferred transport.
+func (s CommandSpec) validate() error {
+	if s.Type == "" {
+		return fmt.Errorf("command spec has no type")
+	}
+	if s.Local != nil {
+		if s.DriverV2 != nil || s.CLI != nil || s.Verified != nil {
+			return fmt.Errorf("command %s mixes a local handler with driver handlers", s.Type)
+		}
+		return nil
+	}
+	if s.Preferred != TransportCLI && s.DriverV2 == nil {
+		return fmt.Errorf("command %s prefers %s but has no DriverV2 handler", s.Type, s.Preferred)
+	}
+	if s.DriverV2 == nil && s.CLI == nil && s.Verified == nil {
+		return fmt.Errorf("command %s has no handler", s.Type)
+	}
+	return nil
+}
+
+// Registry maps command types to their specs.
+type Registry struct {
+	mu    sync.RWMutex
+	specs map[string]CommandSpec
+}
+
+// NewRegistry creates an empty command registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		specs: make(map[string]CommandSpec),
+	}
+}
+
+// Register adds or replaces the spec for a command type.
+func (r *Registry) Register(spec CommandSpec) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[spec.Type] = spec
+	return nil
+}
+
+// Lookup returns the spec registered for a command type.
+func (r *Registry) Lookup(cmdType string) (CommandSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[cmdType]
+	return spec, ok
+}
+
+// Types returns the registered command types, sorted.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cmdTypes := make([]string, 0, len(r.specs))
+	for cmdType := range r.specs {
+		cmdTypes = append(cmdTypes, cmdType)
+	}
+	sort.Strings(cmdTypes)
+	return cmdTypes
+}
+
+// DefaultRegistry holds the built-in command handlers.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	// Registered in init because handlers such as batch dispatch through
+	// the registry themselves
+	registerBuiltins(DefaultRegistry)
+}
+
+// Register adds a command spec to the default registry.
+func Register(spec CommandSpec) error {
+	return DefaultRegistry.Register(spec)
+}
+
+// mustRegister registers a built-in spec, panicking on programming errors.
+func (r *Registry) mustRegister(spec CommandSpec) {
+	if err := r.Register(spec); err != nil {
+		panic(err)
+	}
+}
+
+// hardwiredVendors lists vendors with hand-written CLI sequences in the handlers.
+var hardwiredVendors = []string{"huawei", "vsol"}
+
+// registerBuiltins registers the built-in command handlers.
+func registerBuiltins(r *Registry) {
+	// VLAN commands
+	r.mustRegister(CommandSpec{
+		Type:     "vlan_list",
+		CLI:      (*Executor).handleVLANList,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsVLAN },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "vlan_get",
+		CLI:      (*Executor).handleVLANGet,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsVLAN },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "vlan_create",
+		CLI:      (*Executor).handleVLANCreate,
+		Mutates:  true,
+		State:    (*Executor).vlanState,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsVLAN },
+		Vendors:  hardwiredVendors,
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "vlan_delete",
+		CLI:      (*Executor).handleVLANDelete,
+		Mutates:  true,
+		Impact:   vlanImpact,
+		State:    (*Executor).vlanState,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsVLAN },
+		Vendors:  hardwiredVendors,
+	})
+
+	// ONU read commands
+	r.mustRegister(CommandSpec{
+		Type:      "onu_list",
+		Preferred: TransportDriverV2,
+		DriverV2:  (*Executor).handleONUListV2,
+		CLI:       (*Executor).handleONUList,
+		Requires:  func(c *cli.VendorCapabilities) bool { return c.SupportsONUInfo },
+	})
+	r.mustRegister(CommandSpec{
+		Type:      "onu_get",
+		Preferred: TransportDriverV2,
+		DriverV2:  (*Executor).handleONUGetV2,
+		CLI:       (*Executor).handleONUGet,
+		Requires:  func(c *cli.VendorCapabilities) bool { return c.SupportsONUInfo },
+	})
+	r.mustRegister(CommandSpec{
+		Type:      "onu_discover",
+		Preferred: TransportDriverV2CLI,
+		DriverV2:  (*Executor).handleONUDiscoverV2,
+		CLI:       (*Executor).handleONUDiscover,
+		Requires:  func(c *cli.VendorCapabilities) bool { return c.SupportsONUInfo },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_diagnostics",
+		CLI:      (*Executor).handleONUDiagnostics,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.CanRunDiagnostics() },
+	})
+
+	// ONU provisioning commands (CLI execution + SNMP verification)
+	r.mustRegister(CommandSpec{
+		Type:     "onu_provision",
+		CLI:      (*Executor).handleONUProvision,
+		Verified: (*Executor).handleONUProvisionWithVerification,
+		Mutates:  true,
+		State:    (*Executor).onuState,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsProvision },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_update",
+		CLI:      (*Executor).handleONUUpdate,
+		Verified: (*Executor).handleONUUpdateWithVerification,
+		Mutates:  true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsProvision },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_delete",
+		CLI:      (*Executor).handleONUDelete,
+		Verified: (*Executor).handleONUDeleteWithVerification,
+		Mutates:  true,
+		Impact:   onuImpact,
+		State:    (*Executor).onuState,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsDelete },
+	})
+	r.mustRegister(CommandSpec{
+		Type:    "onu_move",
+		Local:   (*Executor).handleONUMove,
+		Mutates: true,
+		Requires: func(c *cli.VendorCapabilities) bool {
+			return c.SupportsProvision && c.SupportsDelete
+		},
+	})
+	r.mustRegister(CommandSpec{
+		Type:    "onu_replace",
+		CLI:     (*Executor).handleONUReplace,
+		Mutates: true,
+		State:   (*Executor).onuState,
+		Requires: func(c *cli.VendorCapabilities) bool {
+			return c.SupportsProvision && c.SupportsDelete
+		},
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_suspend",
+		CLI:      (*Executor).handleONUSuspend,
+		Verified: (*Executor).handleONUSuspendWithVerification,
+		Mutates:  true,
+		Impact:   onuImpact,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsProvision },
+		Vendors:  hardwiredVendors,
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_resume",
+		CLI:      (*Executor).handleONUResume,
+		Verified: (*Executor).handleONUResumeWithVerification,
+		Mutates:  true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsProvision },
+		Vendors:  hardwiredVendors,
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_reboot",
+		CLI:      (*Executor).handleONUReboot,
+		Verified: (*Executor).handleONURebootWithVerification,
+		Mutates:  true,
+		Impact:   onuImpact,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsReboot },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "onu_bulk_provision",
+		CLI:      (*Executor).handleONUBulkProvisionSequential,
+		Verified: (*Executor).handleONUBulkProvisionWithVerification,
+		Mutates:  true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsProvision },
+	})
+
+	// Port commands
+	r.mustRegister(CommandSpec{
+		Type:      "port_list",
+		Preferred: TransportDriverV2,
+		DriverV2:  (*Executor).handlePortListV2,
+		CLI:       (*Executor).handlePortList,
+		Requires:  func(c *cli.VendorCapabilities) bool { return c.SupportsPortList },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "port_enable",
+		CLI:      (*Executor).handlePortEnable,
+		Mutates:  true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsPortControl },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "port_disable",
+		CLI:      (*Executor).handlePortDisable,
+		Mutates:  true,
+		Impact:   portImpact,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsPortControl },
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "port_power",
+		CLI:      (*Executor).handlePortPower,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsPortList },
+	})
+
+	// Service port commands
+	r.mustRegister(CommandSpec{
+		Type:     "service_port_list",
+		CLI:      (*Executor).handleServicePortList,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsServicePorts },
+		Vendors:  hardwiredVendors,
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "service_port_add",
+		CLI:      (*Executor).handleServicePortAdd,
+		Mutates:  true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsServicePorts },
+		Vendors:  hardwiredVendors,
+	})
+	r.mustRegister(CommandSpec{
+		Type:     "service_port_delete",
+		CLI:      (*Executor).handleServicePortDelete,
+		Mutates:  true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.SupportsServicePorts },
+		Vendors:  hardwiredVendors,
+	})
+
+	// OLT status commands
+	r.mustRegister(CommandSpec{
+		Type:      "olt_status",
+		Preferred: TransportDriverV2,
+		DriverV2:  (*Executor).handleOLTStatusV2,
+		CLI:       (*Executor).handleOLTStatus,
+	})
+	r.mustRegister(CommandSpec{
+		Type: "olt_alarms",
+		CLI:  (*Executor).handleOLTAlarms,
+	})
+	r.mustRegister(CommandSpec{
+		Type: "olt_health_check",
+		CLI:  (*Executor).handleOLTHealthCheck,
+	})
+
+	// Raw CLI passthrough, restricted by the executor's CLI policy
+	r.mustRegister(CommandSpec{
+		Type:     "cli_exec",
+		CLI:      (*Executor).handleCLIExec,
+		Audit:    true,
+		Requires: func(c *cli.VendorCapabilities) bool { return c.HasCLI },
+	})
+
+	// Auto-provisioning approvals
+	r.mustRegister(CommandSpec{
+		Type:    "auto_provision_approve",
+		Local:   (*Executor).handleAutoProvisionApprove,
+		Mutates: true,
+	})
+	r.mustRegister(CommandSpec{
+		Type:  "auto_provision_reject",
+		Local: (*Executor).handleAutoProvisionReject,
+	})
+	r.mustRegister(CommandSpec{
+		Type:  "auto_provision_pending",
+		Local: (*Executor).handleAutoProvisionPending,
+	})
+
+	// Multi-OLT commands
+	r.mustRegister(CommandSpec{
+		Type:  "batch",
+		Local: (*Executor).handleBatch,
+	})
+
+}
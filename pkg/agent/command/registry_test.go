@@ -0,0 +1,109 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	cliHandler := func(e *Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+		return nil, nil
+	}
+
+	tests := []struct {
+		name    string
+		spec    CommandSpec
+		wantErr string
+	}{
+		{
+			name:    "missing type",
+			spec:    CommandSpec{CLI: cliHandler},
+			wantErr: "no type",
+		},
+		{
+			name:    "missing handler",
+			spec:    CommandSpec{Type: "custom"},
+			wantErr: "no handler",
+		},
+		{
+			name:    "preferred DriverV2 without handler",
+			spec:    CommandSpec{Type: "custom", Preferred: TransportDriverV2, CLI: cliHandler},
+			wantErr: "no DriverV2 handler",
+		},
+		{
+			name: "valid CLI command",
+			spec: CommandSpec{Type: "custom", CLI: cliHandler},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			err := r.Register(tt.spec)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Empty(t, r.Types())
+				return
+			}
+			require.NoError(t, err)
+			_, ok := r.Lookup(tt.spec.Type)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	for _, cmdType := range []string{"onu_list", "onu_get", "port_list", "olt_status"} {
+		spec, ok := DefaultRegistry.Lookup(cmdType)
+		require.True(t, ok, cmdType)
+		assert.Equal(t, TransportDriverV2, spec.Preferred, cmdType)
+		assert.NotNil(t, spec.CLI, "%s needs a CLI fallback", cmdType)
+		assert.False(t, spec.Mutates, cmdType)
+	}
+
+	discover, ok := DefaultRegistry.Lookup("onu_discover")
+	require.True(t, ok)
+	assert.Equal(t, TransportDriverV2CLI, discover.Preferred)
+
+	for _, cmdType := range []string{"onu_suspend", "onu_resume", "onu_provision", "onu_delete", "onu_update", "onu_reboot", "onu_bulk_provision"} {
+		spec, ok := DefaultRegistry.Lookup(cmdType)
+		require.True(t, ok, cmdType)
+		assert.True(t, spec.NeedsVerification(), cmdType)
+		assert.True(t, spec.Mutates, cmdType)
+	}
+}
+
+func TestExecutorRunFallsBackToCLI(t *testing.T) {
+	e := newTestExecutor()
+	e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		return &mockCLIDriver{vendor: config.Vendor}, nil
+	}
+
+	var v2Called, cliCalled bool
+	spec := CommandSpec{
+		Type:      "custom_read",
+		Preferred: TransportDriverV2,
+		DriverV2: func(e *Executor, ctx context.Context, driverV2 types.DriverV2, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			v2Called = true
+			return nil, nil
+		},
+		CLI: func(e *Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			cliCalled = true
+			return map[string]interface{}{"vendor": driver.Vendor()}, nil
+		},
+	}
+
+	// The southbound factory rejects unknown vendors, forcing the CLI fallback
+	result, err := e.run(context.Background(), spec, agent.OLTConfig{ID: "olt-1", Vendor: "acme"}, agent.PendingCommand{Type: "custom_read"})
+	require.NoError(t, err)
+	assert.False(t, v2Called)
+	assert.True(t, cliCalled)
+	assert.Equal(t, "acme", result["vendor"])
+}
@@ -0,0 +1,407 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+)
+
+// DefaultScheduleFile is the schedule file name inside the config directory.
+const DefaultScheduleFile = "schedule.json"
+
+// maxScheduleIterations bounds the search for a time that satisfies both a
+// maintenance window and the OLT's blackout windows.
+const maxScheduleIterations = 16
+
+// ScheduledCommand is a command held until RunAt.
+type ScheduledCommand struct {
+	Command     agent.PendingCommand `json:"command"`
+	RunAt       time.Time            `json:"runAt"`
+	Reason      string               `json:"reason,omitempty"`
+	ScheduledAt time.Time            `json:"scheduledAt"`
+}
+
+// Schedule holds deferred commands. When backed by a file, every change is
+// persisted so scheduled commands survive agent restarts.
+type Schedule struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]ScheduledCommand // commandID -> entry
+	running map[string]bool             // commandIDs returned by Due and not yet Done
+}
+
+// NewSchedule creates an in-memory schedule.
+func NewSchedule() *Schedule {
+	return &Schedule{
+		entries: make(map[string]ScheduledCommand),
+		running: make(map[string]bool),
+	}
+}
+
+// OpenSchedule loads (or creates) a schedule persisted at path.
+func OpenSchedule(path string) (*Schedule, error) {
+	s := NewSchedule()
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read schedule: %w", err)
+	}
+
+	var entries []ScheduledCommand
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse schedule: %w", err)
+	}
+	for _, entry := range entries {
+		s.entries[entry.Command.ID] = entry
+	}
+
+	return s, nil
+}
+
+// Add stores or replaces a scheduled command.
+func (s *Schedule) Add(entry ScheduledCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[entry.Command.ID] = entry
+	return s.save()
+}
+
+// Has reports whether a command is already scheduled.
+func (s *Schedule) Has(commandID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.entries[commandID]
+	return ok
+}
+
+// List returns all scheduled commands ordered by run time.
+func (s *Schedule) List() []ScheduledCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted(func(ScheduledCommand) bool { return true })
+}
+
+// Due returns the commands whose run time has passed, ordered by run time.
+// They stay scheduled, so a crash while they run does not lose them, but are
+// not returned again until Done is called.
+func (s *Schedule) Due(now time.Time) []ScheduledCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := s.sorted(func(entry ScheduledCommand) bool {
+		return !entry.RunAt.After(now) && !s.running[entry.Command.ID]
+	})
+	for _, entry := range due {
+		s.running[entry.Command.ID] = true
+	}
+	return due
+}
+
+// Done removes a command returned by Due after it ran. A command that was
+// rescheduled while running keeps its new entry.
+func (s *Schedule) Done(entry ScheduledCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.running, entry.Command.ID)
+	current, ok := s.entries[entry.Command.ID]
+	if !ok || !current.RunAt.Equal(entry.RunAt) {
+		return nil
+	}
+	delete(s.entries, entry.Command.ID)
+	return s.save()
+}
+
+func (s *Schedule) sorted(include func(ScheduledCommand) bool) []ScheduledCommand {
+	entries := make([]ScheduledCommand, 0, len(s.entries))
+	for _, entry := range s.entries {
+		if include(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].RunAt.Equal(entries[j].RunAt) {
+			return entries[i].Command.ID < entries[j].Command.ID
+		}
+		return entries[i].RunAt.Before(entries[j].RunAt)
+	})
+	return entries
+}
+
+// save writes the schedule to disk. Callers must hold s.mu.
+func (s *Schedule) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("failed to create schedule directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s.sorted(func(ScheduledCommand) bool { return true }), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	// Write atomically so a crash never leaves a truncated schedule
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write schedule: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write schedule: %w", err)
+	}
+
+	return nil
+}
+
+// SetSchedule sets the schedule that holds commands until their notBefore
+// time, maintenance window or the end of an OLT blackout window.
+func (e *Executor) SetSchedule(schedule *Schedule) {
+	e.schedule = schedule
+}
+
+// ScheduledCommands returns the commands currently held by the schedule.
+func (e *Executor) ScheduledCommands() []ScheduledCommand {
+	if e.schedule == nil {
+		return nil
+	}
+	return e.schedule.List()
+}
+
+// RunScheduled executes scheduled commands whose run time has passed.
+// Commands whose window closed again (e.g. a blackout was added) are
+// rescheduled rather than run.
+func (e *Executor) RunScheduled(ctx context.Context) error {
+	if e.schedule == nil {
+		return nil
+	}
+
+	for _, entry := range e.schedule.Due(time.Now()) {
+		log.Printf("[command] Running scheduled command %s (type: %s, scheduled for %s)",
+			entry.Command.ID, entry.Command.Type, entry.RunAt.Format(time.RFC3339))
+		if err := e.runCommand(ctx, entry.Command); err != nil {
+			log.Printf("[command] Error executing command %s: %v", entry.Command.ID, err)
+		}
+		if err := e.schedule.Done(entry); err != nil {
+			log.Printf("[command] Failed to persist schedule: %v", err)
+		}
+	}
+	return nil
+}
+
+// deferCommand holds a command that may not run yet and reports it to the
+// control plane as scheduled. It returns true when the command was held.
+func (e *Executor) deferCommand(cmd agent.PendingCommand, now time.Time) (bool, error) {
+	if e.schedule == nil {
+		return false, nil
+	}
+
+	mutates := false
+	if spec, ok := e.commands().Lookup(cmd.Type); ok {
+		mutates = spec.Mutates && !dryRunRequested(cmd)
+	}
+	oltConfig, _ := e.oltConfig(cmd.EquipmentID)
+	blackouts := oltConfig.BlackoutWindows
+
+	runAt, reason, err := scheduledRunTime(cmd, mutates, blackouts, now)
+	if err != nil {
+		return false, err
+	}
+	if !runAt.After(now) {
+		return false, nil
+	}
+
+	entry := ScheduledCommand{
+		Command:     cmd,
+		RunAt:       runAt,
+		Reason:      reason,
+		ScheduledAt: now,
+	}
+	if err := e.schedule.Add(entry); err != nil {
+		log.Printf("[command] Failed to persist schedule: %v", err)
+	}
+	log.Printf("[command] Scheduled command %s (type: %s) for %s: %s", cmd.ID, cmd.Type, runAt.Format(time.RFC3339), reason)
+
+	if e.client != nil {
+		scheduledReq := &agent.CommandScheduledRequest{
+			Status:       "scheduled",
+			ScheduledFor: runAt,
+			Reason:       reason,
+		}
+		if err := e.client.ReportCommandScheduled(cmd.ID, scheduledReq); err != nil {
+			log.Printf("[command] Failed to report command %s as scheduled: %v", cmd.ID, err)
+		}
+	}
+
+	return true, nil
+}
+
+// scheduledRunTime returns the earliest time at or after now that the command
+// may run: after notBefore, inside its maintenance window and, for mutating
+// commands, outside the OLT's blackout windows. The reason is empty when the
+// command may run immediately.
+func scheduledRunTime(cmd agent.PendingCommand, mutates bool, blackouts []agent.MaintenanceWindow, now time.Time) (time.Time, string, error) {
+	runAt := now
+	reason := ""
+	if cmd.NotBefore != nil && cmd.NotBefore.After(runAt) {
+		runAt = *cmd.NotBefore
+		reason = fmt.Sprintf("not before %s", cmd.NotBefore.Format(time.RFC3339))
+	}
+
+	for i := 0; i < maxScheduleIterations; i++ {
+		moved := false
+
+		if cmd.MaintenanceWindow != nil {
+			w := *cmd.MaintenanceWindow
+			open, _, err := windowContains(w, runAt)
+			if err != nil {
+				return time.Time{}, "", fmt.Errorf("invalid maintenance window: %w", err)
+			}
+			if !open {
+				next, err := windowNextStart(w, runAt)
+				if err != nil {
+					return time.Time{}, "", fmt.Errorf("invalid maintenance window: %w", err)
+				}
+				runAt = next
+				reason = fmt.Sprintf("maintenance window %s-%s", w.Start, w.End)
+				moved = true
+			}
+		}
+
+		if mutates {
+			for _, w := range blackouts {
+				inside, end, err := windowContains(w, runAt)
+				if err != nil {
+					return time.Time{}, "", fmt.Errorf("invalid blackout window: %w", err)
+				}
+				if inside {
+					runAt = end
+					reason = fmt.Sprintf("OLT blackout window %s-%s", w.Start, w.End)
+					moved = true
+				}
+			}
+		}
+
+		if !moved {
+			return runAt, reason, nil
+		}
+	}
+
+	return time.Time{}, "", fmt.Errorf("no time found inside the maintenance window and outside blackout windows")
+}
+
+// windowContains reports whether t falls inside an occurrence of w and, if
+// so, when that occurrence ends.
+func windowContains(w agent.MaintenanceWindow, t time.Time) (bool, time.Time, error) {
+	loc, err := windowLocation(w)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	local := t.In(loc)
+
+	// An occurrence that started yesterday may wrap past midnight into today
+	for _, offset := range []int{-1, 0} {
+		start, end, ok, err := windowOccurrence(w, local.AddDate(0, 0, offset))
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if ok && !t.Before(start) && t.Before(end) {
+			return true, end, nil
+		}
+	}
+	return false, time.Time{}, nil
+}
+
+// windowNextStart returns the start of the next occurrence of w after t.
+func windowNextStart(w agent.MaintenanceWindow, t time.Time) (time.Time, error) {
+	loc, err := windowLocation(w)
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := t.In(loc)
+
+	for offset := 0; offset <= 7; offset++ {
+		start, _, ok, err := windowOccurrence(w, local.AddDate(0, 0, offset))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ok && start.After(t) {
+			return start, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("window %s-%s has no valid days", w.Start, w.End)
+}
+
+// windowOccurrence returns the occurrence of w that starts on day's date.
+// ok is false when the window does not run on that weekday.
+func windowOccurrence(w agent.MaintenanceWindow, day time.Time) (start, end time.Time, ok bool, err error) {
+	startH, startM, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	endH, endM, err := parseClock(w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+
+	if len(w.Days) > 0 && !windowRunsOn(w.Days, day.Weekday()) {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	y, m, d := day.Date()
+	start = time.Date(y, m, d, startH, startM, 0, 0, day.Location())
+	end = time.Date(y, m, d, endH, endM, 0, 0, day.Location())
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, true, nil
+}
+
+// windowRunsOn reports whether a day list ("mon", "Tuesday", ...) includes weekday.
+func windowRunsOn(days []string, weekday time.Weekday) bool {
+	want := strings.ToLower(weekday.String()[:3])
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if len(day) >= 3 && day[:3] == want {
+			return true
+		}
+	}
+	return false
+}
+
+func windowLocation(w agent.MaintenanceWindow) (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q: %w", w.Timezone, err)
+	}
+	return loc, nil
+}
+
+// parseClock parses an HH:MM time of day.
+func parseClock(s string) (int, int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q (expected HH:MM)", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
@@ -0,0 +1,211 @@
+package command
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestWindowContains(t *testing.T) {
+	night := agent.MaintenanceWindow{Start: "23:00", End: "02:00", Timezone: "UTC"}
+
+	tests := []struct {
+		name    string
+		window  agent.MaintenanceWindow
+		at      string
+		inside  bool
+		wantEnd string
+	}{
+		{"before wrap window", night, "2026-03-02T22:59:00Z", false, ""},
+		{"start of wrap window", night, "2026-03-02T23:00:00Z", true, "2026-03-03T02:00:00Z"},
+		{"after midnight", night, "2026-03-03T01:30:00Z", true, "2026-03-03T02:00:00Z"},
+		{"end is exclusive", night, "2026-03-03T02:00:00Z", false, ""},
+		{"weekday only window on sunday", agent.MaintenanceWindow{Start: "08:00", End: "18:00", Timezone: "UTC", Days: []string{"mon", "tue", "wed", "thu", "fri"}}, "2026-03-01T10:00:00Z", false, ""},
+		{"weekday only window on monday", agent.MaintenanceWindow{Start: "08:00", End: "18:00", Timezone: "UTC", Days: []string{"Monday"}}, "2026-03-02T10:00:00Z", true, "2026-03-02T18:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inside, end, err := windowContains(tt.window, utc(tt.at))
+			require.NoError(t, err)
+			assert.Equal(t, tt.inside, inside)
+			if tt.inside {
+				assert.True(t, utc(tt.wantEnd).Equal(end), "end = %s", end)
+			}
+		})
+	}
+
+	_, _, err := windowContains(agent.MaintenanceWindow{Start: "25:00", End: "02:00"}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestScheduledRunTime(t *testing.T) {
+	now := utc("2026-03-02T14:00:00Z") // Monday
+	notBefore := utc("2026-03-02T20:00:00Z")
+	night := &agent.MaintenanceWindow{Start: "01:00", End: "05:00", Timezone: "UTC"}
+	blackouts := []agent.MaintenanceWindow{{Start: "00:00", End: "02:00", Timezone: "UTC"}}
+
+	tests := []struct {
+		name       string
+		cmd        agent.PendingCommand
+		mutates    bool
+		blackouts  []agent.MaintenanceWindow
+		wantRunAt  string
+		wantReason string
+	}{
+		{
+			name:      "immediate",
+			cmd:       agent.PendingCommand{Type: "onu_reboot"},
+			mutates:   true,
+			wantRunAt: "2026-03-02T14:00:00Z",
+		},
+		{
+			name:       "not before",
+			cmd:        agent.PendingCommand{Type: "onu_reboot", NotBefore: &notBefore},
+			mutates:    true,
+			wantRunAt:  "2026-03-02T20:00:00Z",
+			wantReason: "not before",
+		},
+		{
+			name:       "maintenance window",
+			cmd:        agent.PendingCommand{Type: "onu_reboot", MaintenanceWindow: night},
+			mutates:    true,
+			wantRunAt:  "2026-03-03T01:00:00Z",
+			wantReason: "maintenance window 01:00-05:00",
+		},
+		{
+			name:       "maintenance window pushed past blackout",
+			cmd:        agent.PendingCommand{Type: "onu_reboot", MaintenanceWindow: night},
+			mutates:    true,
+			blackouts:  blackouts,
+			wantRunAt:  "2026-03-03T02:00:00Z",
+			wantReason: "OLT blackout window 00:00-02:00",
+		},
+		{
+			name:      "read-only commands ignore blackouts",
+			cmd:       agent.PendingCommand{Type: "onu_list"},
+			blackouts: []agent.MaintenanceWindow{{Start: "13:00", End: "15:00", Timezone: "UTC"}},
+			wantRunAt: "2026-03-02T14:00:00Z",
+		},
+		{
+			name:       "mutating command inside blackout",
+			cmd:        agent.PendingCommand{Type: "port_disable"},
+			mutates:    true,
+			blackouts:  []agent.MaintenanceWindow{{Start: "13:00", End: "15:00", Timezone: "UTC"}},
+			wantRunAt:  "2026-03-02T15:00:00Z",
+			wantReason: "OLT blackout window 13:00-15:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runAt, reason, err := scheduledRunTime(tt.cmd, tt.mutates, tt.blackouts, now)
+			require.NoError(t, err)
+			assert.True(t, utc(tt.wantRunAt).Equal(runAt), "runAt = %s", runAt)
+			if tt.wantReason == "" {
+				assert.Empty(t, reason)
+			} else {
+				assert.Contains(t, reason, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("window fully inside blackout", func(t *testing.T) {
+		cmd := agent.PendingCommand{Type: "onu_reboot", MaintenanceWindow: night}
+		_, _, err := scheduledRunTime(cmd, true, []agent.MaintenanceWindow{{Start: "00:00", End: "06:00", Timezone: "UTC"}}, now)
+		assert.Error(t, err)
+	})
+}
+
+func TestSchedulePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultScheduleFile)
+	schedule, err := OpenSchedule(path)
+	require.NoError(t, err)
+
+	now := utc("2026-03-02T14:00:00Z")
+	require.NoError(t, schedule.Add(ScheduledCommand{Command: agent.PendingCommand{ID: "cmd-late"}, RunAt: now.Add(2 * time.Hour)}))
+	require.NoError(t, schedule.Add(ScheduledCommand{Command: agent.PendingCommand{ID: "cmd-soon"}, RunAt: now.Add(time.Hour)}))
+
+	reopened, err := OpenSchedule(path)
+	require.NoError(t, err)
+	entries := reopened.List()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "cmd-soon", entries[0].Command.ID)
+
+	due := reopened.Due(now.Add(90 * time.Minute))
+	require.Len(t, due, 1)
+	assert.Equal(t, "cmd-soon", due[0].Command.ID)
+	assert.Empty(t, reopened.Due(now.Add(90*time.Minute)), "a running command is not returned twice")
+
+	// Until it has run the command survives a restart
+	restarted, err := OpenSchedule(path)
+	require.NoError(t, err)
+	assert.True(t, restarted.Has("cmd-soon"))
+
+	require.NoError(t, reopened.Done(due[0]))
+	reopened, err = OpenSchedule(path)
+	require.NoError(t, err)
+	assert.False(t, reopened.Has("cmd-soon"))
+	assert.True(t, reopened.Has("cmd-late"))
+}
+
+func TestScheduleDoneKeepsRescheduledCommand(t *testing.T) {
+	schedule := NewSchedule()
+	now := utc("2026-03-02T14:00:00Z")
+	require.NoError(t, schedule.Add(ScheduledCommand{Command: agent.PendingCommand{ID: "cmd-1"}, RunAt: now}))
+
+	due := schedule.Due(now)
+	require.Len(t, due, 1)
+	// A blackout was added meanwhile, so running it scheduled it again
+	require.NoError(t, schedule.Add(ScheduledCommand{Command: agent.PendingCommand{ID: "cmd-1"}, RunAt: now.Add(time.Hour)}))
+	require.NoError(t, schedule.Done(due[0]))
+
+	entries := schedule.List()
+	require.Len(t, entries, 1)
+	assert.True(t, now.Add(time.Hour).Equal(entries[0].RunAt))
+}
+
+func TestExecutorDeferCommand(t *testing.T) {
+	e := newTestExecutor()
+	e.SetSchedule(NewSchedule())
+	e.UpdateOLTConfigs([]agent.OLTConfig{{
+		ID:              "olt-1",
+		Vendor:          "huawei",
+		BlackoutWindows: []agent.MaintenanceWindow{{Start: "13:00", End: "15:00", Timezone: "UTC"}},
+	}})
+	now := utc("2026-03-02T14:00:00Z")
+
+	reboot := agent.PendingCommand{ID: "cmd-1", EquipmentID: "olt-1", Type: "onu_reboot"}
+	held, err := e.deferCommand(reboot, now)
+	require.NoError(t, err)
+	assert.True(t, held)
+
+	scheduled := e.ScheduledCommands()
+	require.Len(t, scheduled, 1)
+	assert.True(t, utc("2026-03-02T15:00:00Z").Equal(scheduled[0].RunAt))
+
+	// A re-sent command stays held without being scheduled twice
+	require.NoError(t, e.executeCommand(context.Background(), reboot))
+	assert.Len(t, e.ScheduledCommands(), 1)
+
+	// Read-only and dry-run commands are not affected by blackouts
+	held, err = e.deferCommand(agent.PendingCommand{ID: "cmd-2", EquipmentID: "olt-1", Type: "onu_list"}, now)
+	require.NoError(t, err)
+	assert.False(t, held)
+	held, err = e.deferCommand(agent.PendingCommand{ID: "cmd-3", EquipmentID: "olt-1", Type: "onu_reboot", Payload: map[string]interface{}{"dryRun": true}}, now)
+	require.NoError(t, err)
+	assert.False(t, held)
+}
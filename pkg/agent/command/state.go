@@ -0,0 +1,182 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+// captureState runs a spec's state capture, returning nil on failure so a
+// missing snapshot never blocks the command itself.
+func (e *Executor) captureState(ctx context.Context, spec CommandSpec, driver cli.CLIDriver, cmd agent.PendingCommand) map[string]interface{} {
+	if spec.State == nil || isDryRun(ctx) {
+		return nil
+	}
+	state, err := spec.State(e, ctx, driver, cmd)
+	if err != nil {
+		log.Printf("[command] Failed to capture state for %s: %v", cmd.Type, err)
+		return nil
+	}
+	return state
+}
+
+// attachState fills in preState/postState captured by the executor unless the
+// handler already reported its own.
+func attachState(result, preState, postState map[string]interface{}) {
+	if result == nil {
+		return
+	}
+	if _, ok := result["preState"].(map[string]interface{}); !ok && preState != nil {
+		result["preState"] = preState
+	}
+	if _, ok := result["postState"].(map[string]interface{}); !ok && postState != nil {
+		result["postState"] = postState
+	}
+}
+
+// resultState extracts the pre/post state maps from a handler result.
+func resultState(result map[string]interface{}) (preState, postState map[string]interface{}) {
+	preState, _ = result["preState"].(map[string]interface{})
+	postState, _ = result["postState"].(map[string]interface{})
+	return preState, postState
+}
+
+// setResultState copies pre/post state and their diff onto a result request.
+func setResultState(resultReq *agent.CommandResultRequest, result map[string]interface{}) {
+	preState, postState := resultState(result)
+	resultReq.PreState = preState
+	resultReq.PostState = postState
+	if preState != nil || postState != nil {
+		resultReq.Diff = diffState(preState, postState)
+	}
+}
+
+// diffState compares pre and post state and returns the changed fields sorted
+// by path. Nested maps are compared field by field; other values (including
+// slices) are compared as a whole.
+func diffState(preState, postState map[string]interface{}) []agent.StateChange {
+	changes := []agent.StateChange{}
+	diffMaps("", preState, postState, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffMaps(prefix string, before, after map[string]interface{}, changes *[]agent.StateChange) {
+	for key, b := range before {
+		path := joinPath(prefix, key)
+		a, ok := after[key]
+		if !ok {
+			*changes = append(*changes, agent.StateChange{Path: path, Op: "removed", Before: b})
+			continue
+		}
+		bm, bIsMap := b.(map[string]interface{})
+		am, aIsMap := a.(map[string]interface{})
+		if bIsMap && aIsMap {
+			diffMaps(path, bm, am, changes)
+			continue
+		}
+		if !valuesEqual(b, a) {
+			*changes = append(*changes, agent.StateChange{Path: path, Op: "changed", Before: b, After: a})
+		}
+	}
+	for key, a := range after {
+		if _, ok := before[key]; !ok {
+			*changes = append(*changes, agent.StateChange{Path: joinPath(prefix, key), Op: "added", After: a})
+		}
+	}
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// valuesEqual compares state values, treating numbers of different Go types
+// (e.g. int from a handler and float64 from a JSON payload) as equal.
+func valuesEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	af, aok := toFloat(a)
+	bf, bok := toFloat(b)
+	return aok && bok && af == bf
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// vlanState captures whether the payload's VLAN exists and its attributes.
+func (e *Executor) vlanState(ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	vlanID, ok := cmd.Payload["vlanId"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("vlanId is required")
+	}
+
+	vlans, err := driver.ListVLANs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VLANs: %w", err)
+	}
+
+	state := map[string]interface{}{
+		"vlanId": int(vlanID),
+		"exists": false,
+	}
+	for _, vlan := range vlans {
+		if vlan.ID == int(vlanID) {
+			state["exists"] = true
+			state["name"] = vlan.Name
+			state["description"] = vlan.Description
+			break
+		}
+	}
+	return state, nil
+}
+
+// onuState captures whether the payload's ONU (ponPort + onuId) exists and its
+// configuration. It returns nil when the payload does not identify the ONU.
+func (e *Executor) onuState(ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+	ponPort, _ := cmd.Payload["ponPort"].(string)
+	onuIDFloat, _ := cmd.Payload["onuId"].(float64)
+	onuID := int(onuIDFloat)
+	if ponPort == "" || onuID == 0 {
+		// ONUs addressed by serial only (or auto-assigned IDs) cannot be
+		// located before the command runs
+		return nil, nil
+	}
+
+	state := map[string]interface{}{
+		"ponPort": ponPort,
+		"onuId":   onuID,
+		"exists":  false,
+	}
+	info, err := driver.GetONUInfo(ctx, ponPort, onuID)
+	if err != nil || info == nil {
+		// Most drivers report a missing ONU as an error
+		return state, nil
+	}
+	state["exists"] = true
+	state["serial"] = info.SerialNumber
+	state["status"] = info.Status
+	state["description"] = info.Description
+	state["lineProfile"] = info.LineProfile
+	state["serviceProfile"] = info.ServiceProfile
+	return state, nil
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffState(t *testing.T) {
+	preState := map[string]interface{}{
+		"exists": false,
+		"vlanId": 100,
+		"status": "online",
+		"vlan":   map[string]interface{}{"name": "old", "tagged": []string{"0/1"}},
+	}
+	postState := map[string]interface{}{
+		"exists": true,
+		"vlanId": float64(100),
+		"name":   "VLAN100",
+		"vlan":   map[string]interface{}{"name": "new", "tagged": []string{"0/1"}},
+	}
+
+	changes := diffState(preState, postState)
+	assert.Equal(t, []agent.StateChange{
+		{Path: "exists", Op: "changed", Before: false, After: true},
+		{Path: "name", Op: "added", After: "VLAN100"},
+		{Path: "status", Op: "removed", Before: "online"},
+		{Path: "vlan.name", Op: "changed", Before: "old", After: "new"},
+	}, changes)
+
+	assert.Empty(t, diffState(nil, nil))
+	assert.Len(t, diffState(nil, map[string]interface{}{"exists": true}), 1)
+}
+
+func TestExecutorRunCapturesState(t *testing.T) {
+	e := newTestExecutor()
+	e.driverFactory = func(config cli.CLIConfig) (cli.CLIDriver, error) {
+		return &mockCLIDriver{vendor: config.Vendor}, nil
+	}
+
+	exists := false
+	spec := CommandSpec{
+		Type:    "custom_create",
+		Mutates: true,
+		CLI: func(e *Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			exists = true
+			return map[string]interface{}{"success": true}, nil
+		},
+		State: func(e *Executor, ctx context.Context, driver cli.CLIDriver, cmd agent.PendingCommand) (map[string]interface{}, error) {
+			return map[string]interface{}{"exists": exists}, nil
+		},
+	}
+
+	result, err := e.run(context.Background(), spec, agent.OLTConfig{ID: "olt-1", Vendor: "vsol"}, agent.PendingCommand{Type: "custom_create"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"exists": false}, result["preState"])
+	assert.Equal(t, map[string]interface{}{"exists": true}, result["postState"])
+
+	resultReq := &agent.CommandResultRequest{}
+	setResultState(resultReq, result)
+	assert.Equal(t, []agent.StateChange{{Path: "exists", Op: "changed", Before: false, After: true}}, resultReq.Diff)
+}
+
+func TestAttachStateKeepsHandlerState(t *testing.T) {
+	handlerState := map[string]interface{}{"status": "online"}
+	result := map[string]interface{}{"preState": handlerState}
+
+	attachState(result, map[string]interface{}{"exists": true}, map[string]interface{}{"exists": false})
+	assert.Equal(t, handlerState, result["preState"])
+	assert.Equal(t, map[string]interface{}{"exists": false}, result["postState"])
+}
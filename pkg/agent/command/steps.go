@@ -0,0 +1,106 @@
+package command
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+)
+
+// stepBufferSize is the number of steps queued before a handler blocks on a
+// slow control plane.
+const stepBufferSize = 32
+
+// stepReporterKey carries a command's step reporter in the handler context.
+type stepReporterKey struct{}
+
+// stepReporter streams intermediate steps of one command to the control plane.
+// Steps are pushed in order by a background goroutine so handlers are not
+// slowed down by the API round trip.
+type stepReporter struct {
+	commandID string
+	push      func(commandID string, req *agent.CommandPartialResultRequest) error
+
+	mu      sync.Mutex
+	seq     int
+	closed  bool
+	updates chan *agent.CommandPartialResultRequest
+	done    chan struct{}
+}
+
+// newStepReporter creates a reporter for a command. Without a client, steps
+// are only logged.
+func (e *Executor) newStepReporter(commandID string) *stepReporter {
+	r := &stepReporter{commandID: commandID}
+	if e.client != nil {
+		r.push = e.client.PushCommandPartialResult
+	}
+	r.start()
+	return r
+}
+
+func (r *stepReporter) start() {
+	r.updates = make(chan *agent.CommandPartialResultRequest, stepBufferSize)
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		for update := range r.updates {
+			if r.push == nil {
+				continue
+			}
+			if err := r.push(r.commandID, update); err != nil {
+				log.Printf("[command] Failed to push step %q for command %s: %v", update.Step, r.commandID, err)
+			}
+		}
+	}()
+}
+
+// report queues a step. Reports after close are dropped.
+func (r *stepReporter) report(step, message string, data map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+
+	r.seq++
+	log.Printf("[command] Command %s step %d: %s %s", r.commandID, r.seq, step, message)
+	r.updates <- &agent.CommandPartialResultRequest{
+		Sequence:  r.seq,
+		Step:      step,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// close flushes queued steps so they reach the control plane before the
+// final result.
+func (r *stepReporter) close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.updates)
+	r.mu.Unlock()
+
+	<-r.done
+}
+
+// withStepReporter returns a context that lets handlers report steps.
+func withStepReporter(ctx context.Context, r *stepReporter) context.Context {
+	return context.WithValue(ctx, stepReporterKey{}, r)
+}
+
+// reportStep streams an intermediate step of the running command, such as
+// "connected" or "read optical". data carries partial results and may be nil.
+// It is a no-op outside of command execution.
+func reportStep(ctx context.Context, step, message string, data map[string]interface{}) {
+	if r, ok := ctx.Value(stepReporterKey{}).(*stepReporter); ok {
+		r.report(step, message, data)
+	}
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingStepReporter returns a reporter whose pushes are recorded.
+func newRecordingStepReporter(commandID string) (*stepReporter, func() []agent.CommandPartialResultRequest) {
+	var mu sync.Mutex
+	var pushed []agent.CommandPartialResultRequest
+
+	r := &stepReporter{
+		commandID: commandID,
+		push: func(id string, req *agent.CommandPartialResultRequest) error {
+			mu.Lock()
+			defer mu.Unlock()
+			pushed = append(pushed, *req)
+			return nil
+		},
+	}
+	r.start()
+	return r, func() []agent.CommandPartialResultRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return pushed
+	}
+}
+
+func TestStepReporter(t *testing.T) {
+	r, pushed := newRecordingStepReporter("cmd-1")
+	ctx := withStepReporter(context.Background(), r)
+
+	reportStep(ctx, "connected", "connected to olt-1 via cli", nil)
+	reportStep(ctx, "read optical", "", map[string]interface{}{"rxPower": -21.5})
+	r.close()
+
+	// Steps after close are dropped and closing twice is safe
+	reportStep(ctx, "late", "", nil)
+	r.close()
+
+	steps := pushed()
+	require.Len(t, steps, 2)
+	assert.Equal(t, 1, steps[0].Sequence)
+	assert.Equal(t, "connected", steps[0].Step)
+	assert.Equal(t, 2, steps[1].Sequence)
+	assert.Equal(t, -21.5, steps[1].Data["rxPower"])
+
+	// Reporting without a reporter is a no-op
+	reportStep(context.Background(), "ignored", "", nil)
+}
+
+func TestHandleOLTHealthCheckReportsSteps(t *testing.T) {
+	r, pushed := newRecordingStepReporter("cmd-1")
+	ctx := withStepReporter(context.Background(), r)
+
+	mock := &mockCLIDriver{
+		vendor: "huawei",
+		listPONPortsFunc: func(ctx context.Context) ([]cli.PONPortInfo, error) {
+			return []cli.PONPortInfo{
+				{Slot: 0, Port: 1, AdminStatus: "enable", Status: "up"},
+				{Slot: 0, Port: 2, AdminStatus: "enable", Status: "down"},
+			}, nil
+		},
+	}
+
+	e := newTestExecutor()
+	result, err := e.handleOLTHealthCheck(ctx, mock, agent.PendingCommand{Type: "olt_health_check"})
+	require.NoError(t, err)
+	assert.Equal(t, false, result["healthy"])
+	r.close()
+
+	var names []string
+	for _, step := range pushed() {
+		names = append(names, step.Step)
+	}
+	assert.Equal(t, []string{"running ping", "ping ok", "reading PON ports", "read PON ports"}, names)
+}
+
+// diagnosticsOLT reads ONU diagnostics section by section.
+type diagnosticsOLT struct {
+	*fakeOLT
+	counterErr error
+	fullReads  int
+}
+
+func (d *diagnosticsOLT) GetOpticalDiagnostics(ctx context.Context, ponPort string, onuID int) (*cli.OpticalDiagnostics, error) {
+	return &cli.OpticalDiagnostics{RxPower: -21.5}, nil
+}
+
+func (d *diagnosticsOLT) GetONUCounters(ctx context.Context, ponPort string, onuID int) (*cli.PerformanceCounters, error) {
+	if d.counterErr != nil {
+		return nil, d.counterErr
+	}
+	return &cli.PerformanceCounters{RxBytes: 1000}, nil
+}
+
+func (d *diagnosticsOLT) GetONUHealth(ctx context.Context, ponPort string, onuID int) (*cli.DeviceHealth, error) {
+	return &cli.DeviceHealth{FirmwareVer: "V5R019"}, nil
+}
+
+func (d *diagnosticsOLT) GetONUConnectivity(ctx context.Context, ponPort string, onuID int) (*cli.ConnectivityInfo, error) {
+	return &cli.ConnectivityInfo{Distance: 1200}, nil
+}
+
+func (d *diagnosticsOLT) GetONUDiagnostics(ctx context.Context, ponPort string, onuID int) (*cli.ONUDiagnostics, error) {
+	d.fullReads++
+	return &cli.ONUDiagnostics{}, nil
+}
+
+func TestHandleONUDiagnosticsReportsEachSection(t *testing.T) {
+	r, pushed := newRecordingStepReporter("cmd-1")
+	ctx := withStepReporter(context.Background(), r)
+
+	olt := &diagnosticsOLT{fakeOLT: newFakeOLT("huawei"), counterErr: errors.New("counters unavailable")}
+	olt.onus[fakeKey("0/1", 3)] = &cli.ONUProvisionRequest{PonPort: "0/1", OnuID: 3, SerialNumber: "HWTC12345678"}
+
+	e := newTestExecutor()
+	result, err := e.handleONUDiagnostics(ctx, olt, agent.PendingCommand{
+		Payload: map[string]interface{}{"ponPort": "0/1", "onuId": float64(3)},
+	})
+	require.NoError(t, err, "a failed section does not fail the command")
+	r.close()
+
+	diag := result["diagnostics"].(map[string]interface{})
+	assert.Equal(t, "HWTC12345678", diag["serial"])
+	assert.Equal(t, -21.5, diag["optical"].(map[string]interface{})["rxPower"])
+	assert.Equal(t, "V5R019", diag["health"].(map[string]interface{})["firmwareVer"])
+	assert.Equal(t, 1200, diag["connectivity"].(map[string]interface{})["distance"])
+	assert.Equal(t, map[string]interface{}{"counters": "counters unavailable"}, diag["errors"])
+	assert.Zero(t, olt.fullReads, "sections are read on their own")
+
+	var names []string
+	for _, step := range pushed() {
+		names = append(names, step.Step)
+	}
+	assert.Equal(t, []string{
+		"reading diagnostics", "read status", "read optical", "counters failed", "read health", "read connectivity",
+	}, names)
+
+	_, err = e.handleONUDiagnostics(context.Background(), olt, agent.PendingCommand{
+		Payload: map[string]interface{}{"ponPort": "0/1", "onuId": float64(9)},
+	})
+	assert.ErrorContains(t, err, "ONU 9 not found on 0/1")
+}
@@ -0,0 +1,263 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+// DefaultServiceTemplateFile is the service template cache file name inside
+// the config directory.
+const DefaultServiceTemplateFile = "service_templates.json"
+
+// ServiceTemplates caches the service templates delivered by the control
+// plane. When backed by a file, the cache is persisted so templates keep
+// working while the control plane is unreachable.
+type ServiceTemplates struct {
+	mu        sync.RWMutex
+	path      string
+	templates map[string]agent.ServiceTemplate // name -> template
+}
+
+// NewServiceTemplates creates an empty in-memory template cache.
+func NewServiceTemplates() *ServiceTemplates {
+	return &ServiceTemplates{templates: make(map[string]agent.ServiceTemplate)}
+}
+
+// OpenServiceTemplates loads (or creates) a template cache persisted at path.
+func OpenServiceTemplates(path string) (*ServiceTemplates, error) {
+	s := NewServiceTemplates()
+	s.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read service templates: %w", err)
+	}
+
+	var templates []agent.ServiceTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse service templates: %w", err)
+	}
+	for _, template := range templates {
+		s.templates[template.Name] = template
+	}
+
+	return s, nil
+}
+
+// Replace swaps the cached templates for the control plane's current set.
+// The cache is only rewritten when the set changed.
+func (s *ServiceTemplates) Replace(templates []agent.ServiceTemplate) error {
+	replacement := make(map[string]agent.ServiceTemplate, len(templates))
+	for _, template := range templates {
+		if template.Name == "" {
+			return fmt.Errorf("service template has no name")
+		}
+		replacement[template.Name] = template
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if templatesEqual(s.templates, replacement) {
+		return nil
+	}
+	s.templates = replacement
+	return s.save()
+}
+
+// Get returns the template with the given name.
+func (s *ServiceTemplates) Get(name string) (agent.ServiceTemplate, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	template, ok := s.templates[name]
+	return template, ok
+}
+
+// Names returns the names of all cached templates, sorted.
+func (s *ServiceTemplates) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.templates))
+	for name := range s.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// save writes the cache to disk. Callers hold s.mu.
+func (s *ServiceTemplates) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("failed to create service template directory: %w", err)
+	}
+
+	templates := make([]agent.ServiceTemplate, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal service templates: %w", err)
+	}
+
+	// Write atomically so a crash never leaves a truncated cache
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write service templates: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write service templates: %w", err)
+	}
+
+	return nil
+}
+
+func templatesEqual(a, b map[string]agent.ServiceTemplate) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	aData, errA := json.Marshal(a)
+	bData, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aData) == string(bData)
+}
+
+// SetServiceTemplates sets the template cache used by onu_provision.
+func (e *Executor) SetServiceTemplates(templates *ServiceTemplates) {
+	e.templates = templates
+}
+
+// UpdateServiceTemplates replaces the cached service templates.
+func (e *Executor) UpdateServiceTemplates(templates []agent.ServiceTemplate) error {
+	if e.templates == nil {
+		e.templates = NewServiceTemplates()
+	}
+	return e.templates.Replace(templates)
+}
+
+// expandedTemplate is a service template resolved for one vendor.
+type expandedTemplate struct {
+	Name           string
+	Provision      cli.ONUProvisionRequest // profiles, VLANs and service ports
+	Translations   []cli.VLANTranslation
+	TrafficProfile int
+}
+
+// expandTemplate resolves a cached service template for a vendor.
+func (e *Executor) expandTemplate(name, vendor string) (*expandedTemplate, error) {
+	if e.templates == nil {
+		return nil, fmt.Errorf("unknown service template %q", name)
+	}
+	template, ok := e.templates.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown service template %q", name)
+	}
+	return expandServiceTemplate(template, vendor)
+}
+
+// expandServiceTemplate merges a template's vendor settings over its defaults
+// and converts them into driver requests. Service ports are numbered after
+// the native VLAN's service port.
+func expandServiceTemplate(template agent.ServiceTemplate, vendor string) (*expandedTemplate, error) {
+	settings := template.ServiceTemplateSettings
+	for name, override := range template.Vendors {
+		if strings.EqualFold(name, vendor) {
+			settings = mergeTemplateSettings(settings, override)
+			break
+		}
+	}
+
+	if settings.VLAN < 0 || settings.VLAN > 4094 {
+		return nil, fmt.Errorf("service template %s: invalid VLAN %d", template.Name, settings.VLAN)
+	}
+
+	expanded := &expandedTemplate{
+		Name:           template.Name,
+		TrafficProfile: settings.TrafficProfile,
+		Provision: cli.ONUProvisionRequest{
+			LineProfile:    settings.LineProfile,
+			ServiceProfile: settings.ServiceProfile,
+			ONUProfile:     settings.ONUProfile,
+			NativeVLAN:     settings.VLAN,
+			AllowedVLANs:   settings.AllowedVLANs,
+		},
+	}
+
+	index := 1
+	if settings.VLAN > 0 {
+		index = 2
+	}
+	for _, port := range settings.ServicePorts {
+		if port.VLAN < 1 || port.VLAN > 4094 {
+			return nil, fmt.Errorf("service template %s: invalid service port VLAN %d", template.Name, port.VLAN)
+		}
+		expanded.Provision.ServicePorts = append(expanded.Provision.ServicePorts, cli.ServicePortSpec{
+			Index:    index,
+			VLAN:     port.VLAN,
+			GemPort:  port.GemPort,
+			UserVLAN: port.UserVLAN,
+			Mode:     port.Mode,
+		})
+		index++
+	}
+
+	for _, translation := range settings.VLANTranslations {
+		mode := translation.Mode
+		if mode == "" {
+			mode = "translate"
+		}
+		expanded.Translations = append(expanded.Translations, cli.VLANTranslation{
+			CustomerVLAN: translation.CustomerVLAN,
+			ServiceVLAN:  translation.ServiceVLAN,
+			Mode:         mode,
+		})
+	}
+
+	return expanded, nil
+}
+
+// mergeTemplateSettings returns base with every field set in override replaced.
+func mergeTemplateSettings(base, override agent.ServiceTemplateSettings) agent.ServiceTemplateSettings {
+	if override.LineProfile != "" {
+		base.LineProfile = override.LineProfile
+	}
+	if override.ServiceProfile != "" {
+		base.ServiceProfile = override.ServiceProfile
+	}
+	if override.ONUProfile != "" {
+		base.ONUProfile = override.ONUProfile
+	}
+	if override.TrafficProfile != 0 {
+		base.TrafficProfile = override.TrafficProfile
+	}
+	if override.VLAN != 0 {
+		base.VLAN = override.VLAN
+	}
+	if override.AllowedVLANs != nil {
+		base.AllowedVLANs = override.AllowedVLANs
+	}
+	if override.ServicePorts != nil {
+		base.ServicePorts = override.ServicePorts
+	}
+	if override.VLANTranslations != nil {
+		base.VLANTranslations = override.VLANTranslations
+	}
+	return base
+}
@@ -0,0 +1,154 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func residentialTemplate() agent.ServiceTemplate {
+	return agent.ServiceTemplate{
+		Name: "residential-100M",
+		ServiceTemplateSettings: agent.ServiceTemplateSettings{
+			VLAN:           100,
+			TrafficProfile: 10,
+			ServicePorts:   []agent.ServiceTemplatePort{{VLAN: 300, GemPort: 2}},
+		},
+		Vendors: map[string]agent.ServiceTemplateSettings{
+			"huawei": {
+				LineProfile:      "10",
+				ServiceProfile:   "20",
+				VLANTranslations: []agent.ServiceTemplateTranslation{{CustomerVLAN: 10, ServiceVLAN: 100}},
+			},
+			"vsol": {
+				LineProfile:    "line_vlan_100",
+				TrafficProfile: 3,
+			},
+		},
+	}
+}
+
+func TestExpandServiceTemplate(t *testing.T) {
+	huawei, err := expandServiceTemplate(residentialTemplate(), "Huawei")
+	require.NoError(t, err)
+	assert.Equal(t, "10", huawei.Provision.LineProfile)
+	assert.Equal(t, "20", huawei.Provision.ServiceProfile)
+	assert.Equal(t, 100, huawei.Provision.NativeVLAN)
+	assert.Equal(t, 10, huawei.TrafficProfile)
+	assert.Equal(t, []cli.ServicePortSpec{{Index: 2, VLAN: 300, GemPort: 2}}, huawei.Provision.ServicePorts)
+	assert.Equal(t, []cli.VLANTranslation{{CustomerVLAN: 10, ServiceVLAN: 100, Mode: "translate"}}, huawei.Translations)
+
+	vsol, err := expandServiceTemplate(residentialTemplate(), "vsol")
+	require.NoError(t, err)
+	assert.Equal(t, "line_vlan_100", vsol.Provision.LineProfile)
+	assert.Empty(t, vsol.Provision.ServiceProfile)
+	assert.Equal(t, 3, vsol.TrafficProfile)
+	assert.Empty(t, vsol.Translations)
+
+	bad := residentialTemplate()
+	bad.ServicePorts = []agent.ServiceTemplatePort{{VLAN: 0}}
+	_, err = expandServiceTemplate(bad, "vsol")
+	assert.ErrorContains(t, err, "invalid service port VLAN")
+}
+
+func TestServiceTemplatesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultServiceTemplateFile)
+
+	templates, err := OpenServiceTemplates(path)
+	require.NoError(t, err)
+	assert.Empty(t, templates.Names())
+
+	require.NoError(t, templates.Replace([]agent.ServiceTemplate{residentialTemplate(), {Name: "business-1G"}}))
+	assert.Equal(t, []string{"business-1G", "residential-100M"}, templates.Names())
+
+	reopened, err := OpenServiceTemplates(path)
+	require.NoError(t, err)
+	template, ok := reopened.Get("residential-100M")
+	require.True(t, ok)
+	assert.Equal(t, residentialTemplate(), template)
+
+	assert.Error(t, reopened.Replace([]agent.ServiceTemplate{{}}), "templates need a name")
+}
+
+func TestHandleONUProvision_Template(t *testing.T) {
+	olt := newFakeOLT("huawei")
+	e := newTestExecutor()
+	require.NoError(t, e.UpdateServiceTemplates([]agent.ServiceTemplate{residentialTemplate()}))
+
+	result, err := e.handleONUProvision(context.Background(), olt, agent.PendingCommand{
+		ID:   "cmd-1",
+		Type: "onu_provision",
+		Payload: map[string]interface{}{
+			"serial":         "HWTC12345678",
+			"ponPort":        "0/1/0",
+			"onuId":          float64(4),
+			"template":       "residential-100M",
+			"serviceProfile": "21",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "residential-100M", result["onu"].(map[string]interface{})["template"])
+
+	onu := olt.onu("0/1/0", 4)
+	require.NotNil(t, onu)
+	assert.Equal(t, "10", onu.LineProfile)
+	assert.Equal(t, "21", onu.ServiceProfile, "payload overrides the template")
+	assert.Equal(t, 100, onu.NativeVLAN)
+	assert.Len(t, onu.ServicePorts, 1)
+	assert.Len(t, olt.translations[fakeKey("0/1/0", 4)], 1)
+	assert.Equal(t, 10, olt.traffic[fakeKey("0/1/0", 4)])
+}
+
+func TestHandleONUProvision_TemplateFailureRemovesONU(t *testing.T) {
+	olt := newFakeOLT("huawei")
+	olt.translationErr = fmt.Errorf("translation rejected")
+	e := newTestExecutor()
+	require.NoError(t, e.UpdateServiceTemplates([]agent.ServiceTemplate{residentialTemplate()}))
+
+	result, err := e.handleONUProvision(context.Background(), olt, agent.PendingCommand{
+		Payload: map[string]interface{}{
+			"serial":   "HWTC12345678",
+			"ponPort":  "0/1/0",
+			"onuId":    float64(4),
+			"template": "residential-100M",
+		},
+	})
+	assert.ErrorContains(t, err, "translation rejected")
+	assert.Equal(t, map[string]interface{}{"success": true, "deleted": true}, result["rollback"])
+	assert.Nil(t, olt.onu("0/1/0", 4))
+	assert.Equal(t, []string{"0/1/0:4"}, olt.deleted)
+}
+
+func TestHandleONUProvision_TemplateUsesAssignedONUID(t *testing.T) {
+	olt := newFakeOLT("vsol")
+	olt.onus[fakeKey("0/1", 1)] = &cli.ONUProvisionRequest{PonPort: "0/1", OnuID: 1, SerialNumber: "GPON00000001"}
+	e := newTestExecutor()
+	require.NoError(t, e.UpdateServiceTemplates([]agent.ServiceTemplate{residentialTemplate()}))
+
+	result, err := e.handleONUProvision(context.Background(), olt, agent.PendingCommand{
+		Payload: map[string]interface{}{
+			"serial":   "GPON00000002",
+			"ponPort":  "0/1",
+			"template": "residential-100M",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result["onu"].(map[string]interface{})["onuId"])
+	assert.Equal(t, 3, olt.traffic[fakeKey("0/1", 2)])
+	assert.NotContains(t, olt.traffic, fakeKey("0/1", 0))
+}
+
+func TestHandleONUProvision_UnknownTemplate(t *testing.T) {
+	e := newTestExecutor()
+
+	_, err := e.handleONUProvision(context.Background(), newFakeOLT("vsol"), agent.PendingCommand{
+		Payload: map[string]interface{}{"serial": "GPON00000001", "ponPort": "0/1", "template": "missing"},
+	})
+	assert.ErrorContains(t, err, `unknown service template "missing"`)
+}
@@ -33,6 +33,34 @@ type Config struct {
 	AgentID           string `json:"agent_id,omitempty"`
 	AgentAPIKey       string `json:"agent_api_key,omitempty"`
 	AgentAPIKeyPrefix string `json:"agent_api_key_prefix,omitempty"`
+
+	// Sinks receive OLT poll results in addition to the control plane
+	Sinks []SinkConfig `json:"sinks,omitempty"`
+}
+
+// SinkConfig configures an output that receives OLT poll results.
+type SinkConfig struct {
+	Name     string `json:"name,omitempty"` // defaults to the type
+	Type     string `json:"type"`           // jsonl, influxdb, mqtt or kafka
+	Disabled bool   `json:"disabled,omitempty"`
+
+	// Destination
+	Path     string `json:"path,omitempty"`     // jsonl: file to append to
+	URL      string `json:"url,omitempty"`      // influxdb: server, mqtt: broker, kafka: REST proxy
+	Topic    string `json:"topic,omitempty"`    // mqtt and kafka; {olt_id} is replaced for mqtt
+	Database string `json:"database,omitempty"` // influxdb 1.x
+	Org      string `json:"org,omitempty"`      // influxdb 2.x
+	Bucket   string `json:"bucket,omitempty"`   // influxdb 2.x
+	Token    string `json:"token,omitempty"`    // influxdb 2.x
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	ClientID string `json:"client_id,omitempty"` // mqtt
+
+	// Buffering
+	BufferSize    int `json:"buffer_size,omitempty"`    // records kept while the sink is down (default 1000)
+	MaxAge        int `json:"max_age,omitempty"`        // seconds a buffered record is kept (default 900)
+	BatchSize     int `json:"batch_size,omitempty"`     // records per write (default 100)
+	FlushInterval int `json:"flush_interval,omitempty"` // seconds between writes (default 10)
 }
 
 // Credentials holds the user's authentication credentials (stored separately).
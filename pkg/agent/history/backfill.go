@@ -0,0 +1,267 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+)
+
+const (
+	// gapFile records when metric pushes started failing, so the gap is
+	// backfilled even after a restart.
+	gapFile = "gap.json"
+
+	// backfillBatchSize is how many metrics a backfill push carries.
+	backfillBatchSize = 1000
+)
+
+// errBackfillStopped ends a backfill scan early.
+var errBackfillStopped = errors.New("backfill stopped")
+
+// gapState is the content of the gap file.
+type gapState struct {
+	Since time.Time `json:"since"`
+}
+
+// Gap returns when metric pushes started failing, or the zero time if the
+// control plane has all history.
+func (s *Store) Gap() time.Time {
+	data, err := os.ReadFile(filepath.Join(s.dir, gapFile))
+	if err != nil {
+		return time.Time{}
+	}
+	var gap gapState
+	if err := json.Unmarshal(data, &gap); err != nil {
+		return time.Time{}
+	}
+	return gap.Since
+}
+
+// setGap records the start of a gap, or clears it for the zero time.
+func (s *Store) setGap(since time.Time) error {
+	path := filepath.Join(s.dir, gapFile)
+	if since.IsZero() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear history gap: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(gapState{Since: since})
+	if err != nil {
+		return fmt.Errorf("failed to encode history gap: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to record history gap: %w", err)
+	}
+	return nil
+}
+
+// BackfillPusher wraps the control plane metrics pusher. While pushes fail
+// it records when the gap started; once a push succeeds again it replays the
+// ONU history of the gap from the store in the background. Replayed samples
+// carry their original timestamps, so points the control plane already
+// received are overwritten rather than duplicated.
+type BackfillPusher struct {
+	live   poller.MetricsPusher // pusher for live metrics
+	replay poller.MetricsPusher // pusher for backfilled metrics, without buffering
+	store  *Store
+
+	mu        sync.Mutex
+	gapSince  time.Time // start of the current gap, zero when none
+	running   bool      // a backfill is running
+	failedAt  time.Time // last failure of a live push
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	logPrefix string
+}
+
+// NewBackfillPusher creates a BackfillPusher pushing live metrics through
+// live and backfilled metrics through replay. A gap left by a previous run
+// is backfilled after the first successful push.
+func NewBackfillPusher(live, replay poller.MetricsPusher, store *Store) *BackfillPusher {
+	return &BackfillPusher{
+		live:      live,
+		replay:    replay,
+		store:     store,
+		gapSince:  store.Gap(),
+		stop:      make(chan struct{}),
+		logPrefix: "[history]",
+	}
+}
+
+// PushMetrics pushes live metrics and tracks gaps in what the control plane
+// received.
+func (b *BackfillPusher) PushMetrics(batch *poller.MetricsBatch) (*poller.PushMetricsResponse, error) {
+	resp, err := b.live.PushMetrics(batch)
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil || resp == nil || !resp.Success {
+		b.failedAt = now
+		if b.gapSince.IsZero() {
+			b.gapSince = batchStart(batch, now)
+			if err := b.store.setGap(b.gapSince); err != nil {
+				b.log("%v", err)
+			}
+			b.log("Metric pushes failing, keeping history from %s for backfill", b.gapSince.Format(time.RFC3339))
+		}
+		return resp, err
+	}
+
+	if !b.gapSince.IsZero() && !b.running {
+		b.running = true
+		b.wg.Add(1)
+		go b.backfill(b.gapSince, now)
+	}
+	return resp, err
+}
+
+// Stop stops a running backfill and waits for it.
+func (b *BackfillPusher) Stop() {
+	close(b.stop)
+	b.wg.Wait()
+}
+
+// backfill replays the history in [from, until) and closes the gap if no
+// live push failed meanwhile. On failure the gap stays open from the first
+// sample not replayed.
+func (b *BackfillPusher) backfill(from, until time.Time) {
+	defer b.wg.Done()
+
+	b.log("Backfilling ONU history from %s to %s", from.Format(time.RFC3339), until.Format(time.RFC3339))
+	var (
+		pending   []poller.MetricSample
+		pendingAt time.Time // time of the first sample in pending
+		pushed    int
+	)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		resp, err := b.replay.PushMetrics(&poller.MetricsBatch{Metrics: pending})
+		if err == nil && (resp == nil || !resp.Success) {
+			err = errors.New("control plane did not accept backfilled metrics")
+		}
+		if err != nil {
+			return err
+		}
+		pushed += len(pending)
+		pending = pending[:0]
+		pendingAt = time.Time{}
+		return nil
+	}
+
+	err := b.store.Scan(Query{From: from, To: until}, func(sample Sample) error {
+		select {
+		case <-b.stop:
+			return errBackfillStopped
+		default:
+		}
+		if pendingAt.IsZero() {
+			pendingAt = sample.Time
+		}
+		pending = append(pending, SampleMetrics(sample)...)
+		if len(pending) >= backfillBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.running = false
+
+	if err != nil {
+		// Resume from the oldest sample not pushed yet
+		if !pendingAt.IsZero() && pendingAt.After(b.gapSince) {
+			b.gapSince = pendingAt
+			if err := b.store.setGap(b.gapSince); err != nil {
+				b.log("%v", err)
+			}
+		}
+		b.log("Backfill stopped after %d metrics: %v", pushed, err)
+		return
+	}
+
+	if b.failedAt.After(until) {
+		// Pushes failed again while backfilling; the new gap starts there
+		b.gapSince = b.failedAt
+	} else {
+		b.gapSince = time.Time{}
+	}
+	if err := b.store.setGap(b.gapSince); err != nil {
+		b.log("%v", err)
+	}
+	b.log("Backfilled %d metrics", pushed)
+}
+
+// batchStart returns the time of the oldest metric in a batch.
+func batchStart(batch *poller.MetricsBatch, fallback time.Time) time.Time {
+	start := fallback
+	if batch == nil {
+		return start
+	}
+	for _, m := range batch.Metrics {
+		if m.Timestamp == 0 {
+			continue
+		}
+		if t := time.UnixMilli(m.Timestamp); t.Before(start) {
+			start = t
+		}
+	}
+	return start
+}
+
+// SampleMetrics converts a sample to the ONU metrics the poller pushes.
+func SampleMetrics(sample Sample) []poller.MetricSample {
+	labels := map[string]string{
+		"olt_id":     sample.OLTID,
+		"olt_name":   sample.OLTName,
+		"onu_serial": sample.Serial,
+		"pon_port":   sample.PONPort,
+	}
+	values := []struct {
+		name  string
+		value float64
+	}{
+		{"onu_rx_power_dbm", sample.RxPower},
+		{"onu_tx_power_dbm", sample.TxPower},
+		{"onu_temperature_celsius", sample.Temperature},
+		{"onu_bytes_up_total", float64(sample.BytesUp)},
+		{"onu_bytes_down_total", float64(sample.BytesDown)},
+		{"onu_bytes_up_per_second", float64(sample.OutputRateBps)},
+		{"onu_bytes_down_per_second", float64(sample.InputRateBps)},
+	}
+	ts := sample.Time.UnixMilli()
+	metrics := make([]poller.MetricSample, 0, len(values))
+	for _, v := range values {
+		// As in live pushes, zero means no reading
+		if v.value == 0 {
+			continue
+		}
+		metrics = append(metrics, poller.MetricSample{
+			Name:      v.name,
+			Value:     v.value,
+			Timestamp: ts,
+			Labels:    labels,
+		})
+	}
+	return metrics
+}
+
+// log outputs a log message with the history prefix.
+func (b *BackfillPusher) log(format string, args ...interface{}) {
+	log.Printf("%s %s "+format, append([]interface{}{time.Now().Format("15:04:05"), b.logPrefix}, args...)...)
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchablePusher fails while down is set and records accepted metrics.
+type switchablePusher struct {
+	mu       sync.Mutex
+	down     bool
+	accepted []poller.MetricSample
+}
+
+func (p *switchablePusher) setDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+}
+
+func (p *switchablePusher) PushMetrics(batch *poller.MetricsBatch) (*poller.PushMetricsResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down {
+		return nil, errors.New("connection refused")
+	}
+	p.accepted = append(p.accepted, batch.Metrics...)
+	return &poller.PushMetricsResponse{Success: true, Count: len(batch.Metrics)}, nil
+}
+
+func TestBackfillAfterOutage(t *testing.T) {
+	store, err := Open(t.TempDir(), Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	controlPlane := &switchablePusher{down: true}
+	b := NewBackfillPusher(controlPlane, controlPlane, store)
+
+	// Polls during the outage are kept in history
+	outage := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 3; i++ {
+		at := outage.Add(time.Duration(i) * 30 * time.Minute)
+		store.Append([]Sample{{Time: at, OLTID: "olt-1", Serial: "A", RxPower: -21}})
+		_, err := b.PushMetrics(&poller.MetricsBatch{Metrics: []poller.MetricSample{{Name: "onu_rx_power_dbm", Value: -21, Timestamp: at.UnixMilli()}}})
+		require.Error(t, err)
+	}
+	assert.Equal(t, outage.UnixMilli(), store.Gap().UnixMilli(), "gap is persisted")
+
+	// The control plane comes back
+	controlPlane.setDown(false)
+	_, err = b.PushMetrics(&poller.MetricsBatch{Metrics: []poller.MetricSample{{Name: "olt_cpu_percent", Value: 10, Timestamp: time.Now().UnixMilli()}}})
+	require.NoError(t, err)
+	b.wg.Wait()
+
+	var backfilled []poller.MetricSample
+	for _, m := range controlPlane.accepted {
+		if m.Name == "onu_rx_power_dbm" {
+			backfilled = append(backfilled, m)
+		}
+	}
+	require.Len(t, backfilled, 3)
+	assert.Equal(t, outage.UnixMilli(), backfilled[0].Timestamp, "samples keep their original time")
+	assert.Equal(t, "A", backfilled[0].Labels["onu_serial"])
+	assert.True(t, store.Gap().IsZero(), "gap is closed")
+}
+
+func TestBackfillResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, Options{})
+	require.NoError(t, err)
+	at := time.Now().Add(-time.Hour)
+	require.NoError(t, store.Append([]Sample{{Time: at, OLTID: "olt-1", Serial: "A", TxPower: 2.1}}))
+	require.NoError(t, store.setGap(at))
+	require.NoError(t, store.Close())
+
+	store, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer store.Close()
+	controlPlane := &switchablePusher{}
+	b := NewBackfillPusher(controlPlane, controlPlane, store)
+	_, err = b.PushMetrics(&poller.MetricsBatch{})
+	require.NoError(t, err)
+	b.wg.Wait()
+
+	require.Len(t, controlPlane.accepted, 1)
+	assert.Equal(t, "onu_tx_power_dbm", controlPlane.accepted[0].Name)
+	assert.True(t, store.Gap().IsZero())
+}
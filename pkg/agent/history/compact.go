@@ -0,0 +1,375 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Segment kinds, used as file name prefixes.
+const (
+	kindRaw         = "raw"
+	kindDownsampled = "ds"
+)
+
+const (
+	// segmentSpan is the time covered by a raw segment.
+	segmentSpan = time.Hour
+
+	// daySpan is the time covered by a merged downsampled segment.
+	daySpan = 24 * time.Hour
+
+	segmentExt = ".jsonl"
+)
+
+// segment is a history file covering [start, start+span).
+type segment struct {
+	path  string
+	kind  string
+	start time.Time
+	span  time.Duration
+}
+
+func (s segment) end() time.Time {
+	return s.start.Add(s.span)
+}
+
+// segmentName returns the file name of a segment, such as
+// raw-20260301T10.jsonl for an hour or ds-20260301.jsonl for a day.
+func segmentName(kind string, start time.Time, span time.Duration) string {
+	layout := "20060102T15"
+	if span == daySpan {
+		layout = "20060102"
+	}
+	return kind + "-" + start.UTC().Format(layout) + segmentExt
+}
+
+// parseSegment parses a segment file name. ok is false for other files.
+func parseSegment(name string) (seg segment, ok bool) {
+	base, found := strings.CutSuffix(name, segmentExt)
+	if !found {
+		return segment{}, false
+	}
+	kind, stamp, found := strings.Cut(base, "-")
+	if !found || (kind != kindRaw && kind != kindDownsampled) {
+		return segment{}, false
+	}
+	if start, err := time.Parse("20060102T15", stamp); err == nil {
+		return segment{kind: kind, start: start, span: segmentSpan}, true
+	}
+	if start, err := time.Parse("20060102", stamp); err == nil && kind == kindDownsampled {
+		return segment{kind: kind, start: start, span: daySpan}, true
+	}
+	return segment{}, false
+}
+
+// listSegments returns the segments in dir ordered by start time.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history segments: %w", err)
+	}
+	var segments []segment
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		seg, ok := parseSegment(entry.Name())
+		if !ok {
+			continue
+		}
+		seg.path = filepath.Join(dir, entry.Name())
+		segments = append(segments, seg)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		if !segments[i].start.Equal(segments[j].start) {
+			return segments[i].start.Before(segments[j].start)
+		}
+		return segments[i].span > segments[j].span
+	})
+	return segments, nil
+}
+
+// Compact deletes segments past the retention, downsamples raw segments
+// past the raw retention and merges the downsampled hours of past days into
+// daily segments.
+func (s *Store) Compact(now time.Time) error {
+	segments, err := listSegments(s.dir)
+	if err != nil {
+		return err
+	}
+	rawCutoff := now.Add(-s.opts.RawRetention)
+	retentionCutoff := now.Add(-s.opts.Retention)
+
+	for _, seg := range segments {
+		switch {
+		case !seg.end().After(retentionCutoff):
+			if err := s.removeSegment(seg); err != nil {
+				return err
+			}
+		case seg.kind == kindRaw && !seg.end().After(rawCutoff):
+			if err := s.downsampleSegment(seg); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Merge the downsampled hours of days entirely past the raw retention
+	segments, err = listSegments(s.dir)
+	if err != nil {
+		return err
+	}
+	days := make(map[time.Time][]segment)
+	for _, seg := range segments {
+		if seg.kind != kindDownsampled {
+			continue
+		}
+		day := seg.start.Truncate(daySpan)
+		if day.Add(daySpan).After(rawCutoff) {
+			continue
+		}
+		days[day] = append(days[day], seg)
+	}
+	for day, daySegments := range days {
+		if len(daySegments) == 1 && daySegments[0].span == daySpan {
+			continue
+		}
+		if err := s.mergeSegments(daySegments, filepath.Join(s.dir, segmentName(kindDownsampled, day, daySpan))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downsampleSegment replaces a raw segment with its downsampled samples.
+func (s *Store) downsampleSegment(seg segment) error {
+	s.releaseSegment(seg)
+
+	var samples []Sample
+	if err := scanFile(seg.path, func(sample Sample) error {
+		samples = append(samples, sample)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// A downsampled segment left by an interrupted compaction is merged
+	target := filepath.Join(s.dir, segmentName(kindDownsampled, seg.start, seg.span))
+	if err := scanFile(target, func(sample Sample) error {
+		samples = append(samples, sample)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := writeSegment(target, dedupe(downsample(samples, s.opts.Resolution))); err != nil {
+		return err
+	}
+	return s.removeSegment(seg)
+}
+
+// mergeSegments merges downsampled segments into one file and removes the
+// others.
+func (s *Store) mergeSegments(segments []segment, target string) error {
+	var samples []Sample
+	for _, seg := range segments {
+		if err := scanFile(seg.path, func(sample Sample) error {
+			samples = append(samples, sample)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if err := writeSegment(target, dedupe(samples)); err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if seg.path == target {
+			continue
+		}
+		if err := s.removeSegment(seg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseSegment closes a raw segment if it is the open one.
+func (s *Store) releaseSegment(seg segment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil && seg.kind == kindRaw && s.fileStart.Equal(seg.start) {
+		s.closeSegment()
+	}
+}
+
+// removeSegment deletes a segment file.
+func (s *Store) removeSegment(seg segment) error {
+	s.releaseSegment(seg)
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove history segment: %w", err)
+	}
+	return nil
+}
+
+// writeSegment atomically replaces path with samples.
+func writeSegment(path string, samples []Sample) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304 - path is built from the history directory
+	if err != nil {
+		return fmt.Errorf("failed to create history segment: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, sample := range samples {
+		if err := enc.Encode(sample); err != nil {
+			file.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to encode sample: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write history segment: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to sync history segment: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to close history segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace history segment: %w", err)
+	}
+	return nil
+}
+
+// sampleKey identifies the sample of an ONU at a point in time.
+type sampleKey struct {
+	oltID  string
+	serial string
+	time   int64
+}
+
+// dedupe drops all but the last of samples with the same ONU and time, and
+// orders the rest by time.
+func dedupe(samples []Sample) []Sample {
+	index := make(map[sampleKey]int, len(samples))
+	out := make([]Sample, 0, len(samples))
+	for _, sample := range samples {
+		key := sampleKey{sample.OLTID, sample.Serial, sample.Time.UnixNano()}
+		if i, ok := index[key]; ok {
+			out[i] = sample
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, sample)
+	}
+	sortSamples(out)
+	return out
+}
+
+// sortSamples orders samples by time, then OLT and serial.
+func sortSamples(samples []Sample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		a, b := samples[i], samples[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.OLTID != b.OLTID {
+			return a.OLTID < b.OLTID
+		}
+		return a.Serial < b.Serial
+	})
+}
+
+// bucket accumulates the samples of an ONU within one resolution bucket.
+type bucket struct {
+	last    Sample // latest sample: status, counters and labels
+	n       int
+	rx, tx  weightedMean
+	temp    weightedMean
+	in, out weightedMean
+}
+
+// weightedMean averages values weighted by the samples they summarize.
+type weightedMean struct {
+	sum    float64
+	weight int
+}
+
+func (m *weightedMean) add(v float64, weight int) {
+	m.sum += v * float64(weight)
+	m.weight += weight
+}
+
+func (m weightedMean) value() float64 {
+	if m.weight == 0 {
+		return 0
+	}
+	return m.sum / float64(m.weight)
+}
+
+// downsample summarizes samples per ONU and resolution bucket: optical
+// readings and rates are averaged, while status and counters are the
+// latest in the bucket. Optical readings and rates of zero, which mean no
+// reading (e.g. a fast poll without traffic counters), are not averaged in.
+func downsample(samples []Sample, resolution time.Duration) []Sample {
+	buckets := make(map[sampleKey]*bucket)
+	for _, sample := range samples {
+		weight := sample.Samples
+		if weight <= 0 {
+			weight = 1
+		}
+		start := sample.Time.UTC().Truncate(resolution)
+		key := sampleKey{sample.OLTID, sample.Serial, start.UnixNano()}
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		if b.n == 0 || !sample.Time.Before(b.last.Time) {
+			b.last = sample
+		}
+		b.n += weight
+		if sample.RxPower != 0 {
+			b.rx.add(sample.RxPower, weight)
+		}
+		if sample.TxPower != 0 {
+			b.tx.add(sample.TxPower, weight)
+		}
+		if sample.Temperature != 0 {
+			b.temp.add(sample.Temperature, weight)
+		}
+		if sample.InputRateBps != 0 {
+			b.in.add(float64(sample.InputRateBps), weight)
+		}
+		if sample.OutputRateBps != 0 {
+			b.out.add(float64(sample.OutputRateBps), weight)
+		}
+	}
+
+	out := make([]Sample, 0, len(buckets))
+	for key, b := range buckets {
+		sample := b.last
+		sample.Time = time.Unix(0, key.time).UTC()
+		sample.RxPower = b.rx.value()
+		sample.TxPower = b.tx.value()
+		sample.Temperature = b.temp.value()
+		sample.InputRateBps = uint64(b.in.value())
+		sample.OutputRateBps = uint64(b.out.value())
+		sample.Samples = b.n
+		out = append(out, sample)
+	}
+	sortSamples(out)
+	return out
+}
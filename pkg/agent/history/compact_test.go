@@ -0,0 +1,103 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSegment(t *testing.T) {
+	seg, ok := parseSegment("raw-20260301T10.jsonl")
+	require.True(t, ok)
+	assert.Equal(t, kindRaw, seg.kind)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), seg.start)
+	assert.Equal(t, time.Hour, seg.span)
+
+	seg, ok = parseSegment("ds-20260301.jsonl")
+	require.True(t, ok)
+	assert.Equal(t, 24*time.Hour, seg.span)
+
+	for _, name := range []string{"gap.json", "raw-20260301.jsonl", "ds-20260301T10.jsonl.tmp", "raw-bad.jsonl"} {
+		_, ok := parseSegment(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{Time: start.Add(1 * time.Minute), OLTID: "olt-1", Serial: "A", Status: "online", RxPower: -20, BytesUp: 100, OutputRateBps: 10},
+		{Time: start.Add(4 * time.Minute), OLTID: "olt-1", Serial: "A", Status: "online"},
+		{Time: start.Add(6 * time.Minute), OLTID: "olt-1", Serial: "A", Status: "online", OutputRateBps: 20},
+		{Time: start.Add(11 * time.Minute), OLTID: "olt-1", Serial: "A", Status: "los", RxPower: -24, BytesUp: 300, OutputRateBps: 30},
+		{Time: start.Add(16 * time.Minute), OLTID: "olt-1", Serial: "A", Status: "online", RxPower: -22},
+	}
+
+	out := downsample(samples, 15*time.Minute)
+	require.Len(t, out, 2)
+	first := out[0]
+	assert.Equal(t, start, first.Time)
+	assert.Equal(t, 4, first.Samples)
+	assert.Equal(t, -22.0, first.RxPower, "missing readings are not averaged in")
+	assert.Equal(t, uint64(20), first.OutputRateBps, "missing rates are not averaged in")
+	assert.Equal(t, "los", first.Status, "status is the latest in the bucket")
+	assert.Equal(t, uint64(300), first.BytesUp, "counters are the latest in the bucket")
+	assert.Equal(t, start.Add(15*time.Minute), out[1].Time)
+
+	// Downsampling again weighs buckets by the samples they summarize
+	coarse := downsample(out, time.Hour)
+	require.Len(t, coarse, 1)
+	assert.Equal(t, 5, coarse[0].Samples)
+	assert.Equal(t, -22.0, coarse[0].RxPower)
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	store := &Store{dir: dir, opts: Options{Retention: 72 * time.Hour, RawRetention: 24 * time.Hour, Resolution: 15 * time.Minute}}
+	now := time.Date(2026, 3, 5, 12, 30, 0, 0, time.UTC)
+
+	var samples []Sample
+	for _, at := range []time.Time{
+		now.Add(-100 * time.Hour), // past retention
+		time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 3, 9, 5, 0, 0, time.UTC),
+		time.Date(2026, 3, 3, 22, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC), // downsampled, day not past raw retention
+		now.Add(-20 * time.Hour),                     // raw
+	} {
+		samples = append(samples, Sample{Time: at, OLTID: "olt-1", Serial: "A", Status: "online", RxPower: -20})
+	}
+	require.NoError(t, store.Append(samples))
+	require.NoError(t, store.Close())
+
+	require.NoError(t, store.Compact(now))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{
+		"ds-20260303.jsonl",
+		"ds-20260304T10.jsonl",
+		"raw-20260304T16.jsonl",
+	}, names)
+
+	got, err := store.Query(Query{})
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+	assert.Equal(t, 2, got[0].Samples, "09:00 and 09:05 share a bucket")
+	assert.Zero(t, got[3].Samples, "recent samples keep full resolution")
+
+	// Compaction is idempotent
+	require.NoError(t, store.Compact(now))
+	again, err := store.Query(Query{})
+	require.NoError(t, err)
+	assert.Equal(t, got, again)
+	assert.NoFileExists(t, filepath.Join(dir, "ds-20260303.jsonl.tmp"))
+}
@@ -0,0 +1,384 @@
+// Package history keeps ONU optical, traffic and status history on the
+// agent's disk, so it survives control plane and uplink outages. Samples are
+// appended to hourly segments; older segments are downsampled and merged
+// into daily segments, and segments past the retention are deleted.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+)
+
+const (
+	// DefaultDir is the history directory inside the agent config directory.
+	DefaultDir = "history"
+
+	// DefaultRetention is how long history is kept.
+	DefaultRetention = 30 * 24 * time.Hour
+
+	// DefaultRawRetention is how long samples are kept at full resolution
+	// before they are downsampled.
+	DefaultRawRetention = 24 * time.Hour
+
+	// DefaultResolution is the bucket size of downsampled samples.
+	DefaultResolution = 15 * time.Minute
+
+	// compactionInterval is how often segments are compacted.
+	compactionInterval = time.Hour
+
+	// submitBufferSize is how many poll records may wait to be written.
+	submitBufferSize = 256
+)
+
+// Options configures a Store.
+type Options struct {
+	Retention    time.Duration // how long history is kept (default 30 days)
+	RawRetention time.Duration // how long samples keep full resolution (default 24h)
+	Resolution   time.Duration // bucket size of downsampled samples (default 15m)
+}
+
+// Sample is the state of an ONU at one point in time.
+type Sample struct {
+	Time    time.Time `json:"t"`
+	OLTID   string    `json:"olt"`
+	OLTName string    `json:"oltName,omitempty"`
+	Serial  string    `json:"sn"`
+	PONPort string    `json:"port,omitempty"`
+	Status  string    `json:"status,omitempty"`
+
+	RxPower     float64 `json:"rx,omitempty"`   // dBm
+	TxPower     float64 `json:"tx,omitempty"`   // dBm
+	Temperature float64 `json:"temp,omitempty"` // °C
+
+	BytesUp       uint64 `json:"bytesUp,omitempty"`
+	BytesDown     uint64 `json:"bytesDown,omitempty"`
+	InputRateBps  uint64 `json:"inBps,omitempty"`
+	OutputRateBps uint64 `json:"outBps,omitempty"`
+
+	// Samples is how many raw samples a downsampled sample summarizes; it
+	// is zero for raw samples
+	Samples int `json:"n,omitempty"`
+}
+
+// Query selects samples. Empty fields match everything.
+type Query struct {
+	Serial string
+	OLTID  string
+	From   time.Time
+	To     time.Time
+}
+
+// matches reports whether a sample is selected by the query.
+func (q Query) matches(s Sample) bool {
+	if q.Serial != "" && !strings.EqualFold(s.Serial, q.Serial) {
+		return false
+	}
+	if q.OLTID != "" && s.OLTID != q.OLTID {
+		return false
+	}
+	if !q.From.IsZero() && s.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !s.Time.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// Store is an append-only on-disk store of ONU samples. It implements
+// poller.ResultSink.
+type Store struct {
+	dir  string
+	opts Options
+
+	mu        sync.Mutex
+	file      *os.File  // open raw segment
+	fileStart time.Time // start of the open raw segment
+
+	records chan *poller.SinkRecord // submitted records waiting to be written
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Open opens the store in dir, creating it if needed, and starts writing
+// submitted records and compacting in the background.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.Retention <= 0 {
+		opts.Retention = DefaultRetention
+	}
+	if opts.RawRetention <= 0 {
+		opts.RawRetention = DefaultRawRetention
+	}
+	if opts.RawRetention < segmentSpan {
+		opts.RawRetention = segmentSpan
+	}
+	if opts.Resolution <= 0 {
+		opts.Resolution = DefaultResolution
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	s := &Store{
+		dir:     dir,
+		opts:    opts,
+		records: make(chan *poller.SinkRecord, submitBufferSize),
+		stop:    make(chan struct{}),
+	}
+	s.wg.Add(2)
+	go s.writeLoop()
+	go s.compactLoop()
+	return s, nil
+}
+
+// OpenReadOnly opens the store in dir for queries, without compaction. It
+// can be used while a daemon writes to the same directory.
+func OpenReadOnly(dir string) (*Store, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("no history at %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Name returns the sink name.
+func (s *Store) Name() string {
+	return "history"
+}
+
+// Submit queues the ONUs of a poll result for writing, so a slow disk does
+// not hold back the poller. When the queue is full the record is dropped.
+func (s *Store) Submit(record *poller.SinkRecord) {
+	select {
+	case s.records <- record:
+	default:
+		log.Printf("%s [history] Write queue full, dropping %d ONUs for %s",
+			time.Now().Format("15:04:05"), len(record.ONUs), record.OLTID)
+	}
+}
+
+// writeLoop writes submitted records until the store is closed, then
+// writes the records still queued.
+func (s *Store) writeLoop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case record := <-s.records:
+			s.write(record)
+		case <-s.stop:
+			for {
+				select {
+				case record := <-s.records:
+					s.write(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// write records the ONUs of a poll result.
+func (s *Store) write(record *poller.SinkRecord) {
+	samples := SamplesFromRecord(record)
+	if len(samples) == 0 {
+		return
+	}
+	if err := s.Append(samples); err != nil {
+		log.Printf("%s [history] Failed to record %d samples for %s: %v",
+			time.Now().Format("15:04:05"), len(samples), record.OLTID, err)
+	}
+}
+
+// SamplesFromRecord converts the ONUs of a poll result to samples.
+func SamplesFromRecord(record *poller.SinkRecord) []Sample {
+	samples := make([]Sample, 0, len(record.ONUs))
+	for _, onu := range record.ONUs {
+		if onu.Serial == "" {
+			continue
+		}
+		samples = append(samples, Sample{
+			Time:          record.Timestamp,
+			OLTID:         record.OLTID,
+			OLTName:       record.OLTName,
+			Serial:        onu.Serial,
+			PONPort:       onu.PONPort,
+			Status:        onu.Status,
+			RxPower:       onu.RxPower,
+			TxPower:       onu.TxPower,
+			Temperature:   onu.Temperature,
+			BytesUp:       onu.BytesUp,
+			BytesDown:     onu.BytesDown,
+			InputRateBps:  onu.InputRateBps,
+			OutputRateBps: onu.OutputRateBps,
+		})
+	}
+	return samples
+}
+
+// Append writes samples to the raw segment of the hour they were taken in.
+func (s *Store) Append(samples []Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Group by segment so each segment is written once
+	bySegment := make(map[time.Time][]byte)
+	var starts []time.Time
+	for _, sample := range samples {
+		start := sample.Time.UTC().Truncate(segmentSpan)
+		line, err := json.Marshal(sample)
+		if err != nil {
+			return fmt.Errorf("failed to encode sample: %w", err)
+		}
+		if _, ok := bySegment[start]; !ok {
+			starts = append(starts, start)
+		}
+		bySegment[start] = append(append(bySegment[start], line...), '\n')
+	}
+
+	for _, start := range starts {
+		if err := s.openSegment(start); err != nil {
+			return err
+		}
+		if _, err := s.file.Write(bySegment[start]); err != nil {
+			return fmt.Errorf("failed to write history segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// openSegment makes the raw segment starting at start the open one.
+// Callers hold s.mu.
+func (s *Store) openSegment(start time.Time) error {
+	if s.file != nil && s.fileStart.Equal(start) {
+		return nil
+	}
+	s.closeSegment()
+	path := filepath.Join(s.dir, segmentName(kindRaw, start, segmentSpan))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history segment: %w", err)
+	}
+	s.file = file
+	s.fileStart = start
+	return nil
+}
+
+// closeSegment syncs and closes the open raw segment. Callers hold s.mu.
+func (s *Store) closeSegment() {
+	if s.file == nil {
+		return
+	}
+	_ = s.file.Sync()
+	_ = s.file.Close()
+	s.file = nil
+}
+
+// Scan calls fn for each stored sample selected by q, segment by segment in
+// time order. Samples within a segment are in the order they were written.
+func (s *Store) Scan(q Query, fn func(Sample) error) error {
+	segments, err := listSegments(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if !q.From.IsZero() && !seg.end().After(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !seg.start.Before(q.To) {
+			continue
+		}
+		if err := scanFile(seg.path, func(sample Sample) error {
+			if !q.matches(sample) {
+				return nil
+			}
+			return fn(sample)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the samples selected by q in time order.
+func (s *Store) Query(q Query) ([]Sample, error) {
+	var samples []Sample
+	err := s.Scan(q, func(sample Sample) error {
+		samples = append(samples, sample)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	return samples, nil
+}
+
+// Close writes the queued records, stops compaction and closes the open
+// segment.
+func (s *Store) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		s.wg.Wait()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeSegment()
+	return nil
+}
+
+// compactLoop compacts segments at startup and then every compaction interval.
+func (s *Store) compactLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(compactionInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Compact(time.Now()); err != nil {
+			log.Printf("%s [history] Compaction failed: %v", time.Now().Format("15:04:05"), err)
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scanFile calls fn for each sample in a segment file. Lines that do not
+// parse, such as a line cut short by a crash, are skipped.
+func scanFile(path string, fn func(Sample) error) error {
+	file, err := os.Open(path) // #nosec G304 - path comes from listing the history directory
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Removed by a concurrent compaction
+			return nil
+		}
+		return fmt.Errorf("failed to open history segment: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var sample Sample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			continue
+		}
+		if err := fn(sample); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
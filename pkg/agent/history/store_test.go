@@ -0,0 +1,95 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreAppendAndQuery(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, Options{})
+	require.NoError(t, err)
+
+	// Recent enough not to be compacted when the store opens
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	at := hour.Add(55 * time.Minute)
+	store.Submit(&poller.SinkRecord{
+		OLTID:     "olt-1",
+		OLTName:   "OLT 1",
+		Timestamp: at,
+		ONUs: []poller.ONUData{
+			{Serial: "HWTC12345678", PONPort: "0/1", Status: "online", RxPower: -21.5, BytesUp: 1000},
+			{Serial: "HWTC87654321", PONPort: "0/2", Status: "los"},
+		},
+	})
+	store.Submit(&poller.SinkRecord{
+		OLTID:     "olt-1",
+		Timestamp: at.Add(10 * time.Minute),
+		ONUs:      []poller.ONUData{{Serial: "HWTC12345678", Status: "offline"}},
+	})
+	require.NoError(t, store.Close())
+
+	// Samples land in the segment of the hour they were taken in
+	assert.FileExists(t, filepath.Join(dir, segmentName(kindRaw, hour, segmentSpan)))
+	assert.FileExists(t, filepath.Join(dir, segmentName(kindRaw, hour.Add(time.Hour), segmentSpan)))
+
+	reader, err := OpenReadOnly(dir)
+	require.NoError(t, err)
+	samples, err := reader.Query(Query{Serial: "hwtc12345678"})
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "online", samples[0].Status)
+	assert.Equal(t, -21.5, samples[0].RxPower)
+	assert.Equal(t, "OLT 1", samples[0].OLTName)
+	assert.Equal(t, "offline", samples[1].Status)
+
+	samples, err = reader.Query(Query{From: at.Add(time.Minute), To: at.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, at.Add(10*time.Minute), samples[0].Time.UTC())
+}
+
+func TestStoreCloseWritesQueuedRecords(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, Options{})
+	require.NoError(t, err)
+
+	at := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	for i := 0; i < submitBufferSize; i++ {
+		store.Submit(&poller.SinkRecord{
+			OLTID:     "olt-1",
+			Timestamp: at.Add(time.Duration(i) * time.Second),
+			ONUs:      []poller.ONUData{{Serial: "HWTC12345678", Status: "online"}},
+		})
+	}
+	require.NoError(t, store.Close())
+
+	reader, err := OpenReadOnly(dir)
+	require.NoError(t, err)
+	samples, err := reader.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, samples, submitBufferSize)
+}
+
+func TestScanSkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	line := `{"t":"2026-03-01T10:00:00Z","olt":"olt-1","sn":"A","status":"online"}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "raw-20260301T10.jsonl"), []byte(line+`{"t":"2026-03-01T10:05`), 0600))
+
+	reader, err := OpenReadOnly(dir)
+	require.NoError(t, err)
+	samples, err := reader.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+}
+
+func TestOpenReadOnlyMissingDir(t *testing.T) {
+	_, err := OpenReadOnly(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
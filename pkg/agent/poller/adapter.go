@@ -79,6 +79,12 @@ func (a *ClientAdapter) PushTelemetry(oltID string, telemetry *TelemetryData) (*
 		IsHealthy:     telemetry.IsHealthy,
 		Firmware:      telemetry.Firmware,
 		SerialNumber:  telemetry.SerialNumber,
+
+		Stale:               telemetry.Stale,
+		StaleGroups:         telemetry.StaleGroups,
+		LastError:           telemetry.LastError,
+		LastSuccessAt:       telemetry.LastSuccessAt,
+		ConsecutiveFailures: telemetry.ConsecutiveFailures,
 	}
 
 	// Call the agent client
@@ -127,6 +133,92 @@ func (a *ClientAdapter) PushMetrics(batch *MetricsBatch) (*PushMetricsResponse,
 	}, nil
 }
 
+// PushAutofind implements the AutofindPusher interface.
+func (a *ClientAdapter) PushAutofind(oltID string, report *AutofindReport) (*PushAutofindResponse, error) {
+	// Convert poller.AutofindReport to agent.PushAutofindRequest
+	req := &agent.PushAutofindRequest{
+		New:          convertAutofindEntries(report.New),
+		Persisting:   convertAutofindEntries(report.Persisting),
+		Vanished:     convertAutofindEntries(report.Vanished),
+		DiscoveredAt: report.DiscoveredAt,
+	}
+
+	// Call the agent client
+	resp, err := a.client.PushAutofind(oltID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert response
+	return &PushAutofindResponse{
+		Success: resp.Success,
+		Message: resp.Message,
+	}, nil
+}
+
+// convertAutofindEntries converts poller autofind entries to agent entries.
+// Empty lists are sent as [] rather than null.
+func convertAutofindEntries(entries []AutofindEntry) []agent.AutofindEntry {
+	agentEntries := make([]agent.AutofindEntry, len(entries))
+	for i, e := range entries {
+		agentEntries[i] = agent.AutofindEntry{
+			Serial:    e.Serial,
+			PONPort:   e.PONPort,
+			Model:     e.Model,
+			Vendor:    e.Vendor,
+			MAC:       e.MAC,
+			Distance:  e.Distance,
+			RxPower:   e.RxPower,
+			FirstSeen: e.FirstSeen,
+			LastSeen:  e.LastSeen,
+		}
+	}
+	return agentEntries
+}
+
+// PushPorts implements the PortPusher interface.
+func (a *ClientAdapter) PushPorts(oltID string, ports []PortData) (*PushPortsResponse, error) {
+	// Convert poller.PortData to agent.PortData
+	agentPorts := make([]agent.PortData, len(ports))
+	for i, port := range ports {
+		agentPorts[i] = agent.PortData{
+			Port:        port.Port,
+			Type:        port.Type,
+			AdminState:  port.AdminState,
+			OperState:   port.OperState,
+			Description: port.Description,
+			ONUCount:    port.ONUCount,
+			MaxONUs:     port.MaxONUs,
+			TxPower:     port.TxPower,
+			RxPower:     port.RxPower,
+			SpeedMbps:   port.SpeedMbps,
+			BytesIn:     port.BytesIn,
+			BytesOut:    port.BytesOut,
+			PacketsIn:   port.PacketsIn,
+			PacketsOut:  port.PacketsOut,
+			ErrorsIn:    port.ErrorsIn,
+			ErrorsOut:   port.ErrorsOut,
+			DiscardsIn:  port.DiscardsIn,
+			DiscardsOut: port.DiscardsOut,
+
+			InputRateBps:  port.InputRateBps,
+			OutputRateBps: port.OutputRateBps,
+		}
+	}
+
+	// Call the agent client
+	resp, err := a.client.PushPorts(oltID, agentPorts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert response
+	return &PushPortsResponse{
+		Success: resp.Success,
+		Message: resp.Message,
+	}, nil
+}
+
 // ConvertOLTConfigs converts agent.OLTConfig to poller.OLTConfig.
 func ConvertOLTConfigs(agentConfigs []agent.OLTConfig) []OLTConfig {
 	configs := make([]OLTConfig, len(agentConfigs))
@@ -143,6 +235,12 @@ func ConvertOLTConfigs(agentConfigs []agent.OLTConfig) []OLTConfig {
 					Port:      cfg.Protocols.SNMP.Port,
 					Community: cfg.Protocols.SNMP.Community,
 					Version:   cfg.Protocols.SNMP.Version,
+
+					Username:     cfg.Protocols.SNMP.Username,
+					AuthProtocol: cfg.Protocols.SNMP.AuthProtocol,
+					AuthPassword: cfg.Protocols.SNMP.AuthPassword,
+					PrivProtocol: cfg.Protocols.SNMP.PrivProtocol,
+					PrivPassword: cfg.Protocols.SNMP.PrivPassword,
 				},
 				SSH: SSHConfig{
 					Enabled:  cfg.Protocols.SSH.Enabled,
@@ -152,9 +250,15 @@ func ConvertOLTConfigs(agentConfigs []agent.OLTConfig) []OLTConfig {
 				},
 			},
 			Polling: OLTPollingConfig{
-				Enabled:  cfg.Polling.Enabled,
-				Interval: cfg.Polling.Interval,
-				Metrics:  cfg.Polling.Metrics,
+				Enabled:          cfg.Polling.Enabled,
+				Interval:         cfg.Polling.Interval,
+				DetailedInterval: cfg.Polling.DetailedInterval,
+				Metrics:          cfg.Polling.Metrics,
+				MetricIntervals:  cfg.Polling.MetricIntervals,
+				UplinkPorts:      cfg.Polling.UplinkPorts,
+
+				DetailConcurrency: cfg.Polling.DetailConcurrency,
+				DetailTimeout:     cfg.Polling.DetailTimeout,
 			},
 			Discovery: OLTDiscoveryConfig{
 				Enabled:  cfg.Discovery.Enabled,
@@ -0,0 +1,304 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-southbound/types"
+)
+
+const (
+	// defaultDetailConcurrency is how many PON ports of an OLT are read in
+	// parallel during a detailed poll when the OLT does not set it.
+	defaultDetailConcurrency = 2
+
+	// maxDetailConcurrency caps the connections a detailed poll opens to
+	// one OLT.
+	maxDetailConcurrency = 8
+
+	// detailBudgetFraction is the share of the poll interval detail reads
+	// may take, so a large OLT does not hold a worker past its next poll.
+	detailBudgetFraction = 0.8
+
+	// maxDetailAttempts is how many polls of a cycle may fail to read a PON
+	// port before the cycle gives up on it.
+	maxDetailAttempts = 3
+)
+
+// onuDetailer is implemented by drivers that read per-ONU optical and
+// traffic details.
+type onuDetailer interface {
+	GetAllONUDetails(ctx context.Context, onus []types.ONUInfo) ([]types.ONUInfo, error)
+}
+
+// detailChunk is the ONUs of one PON port, read together.
+type detailChunk struct {
+	port string
+	onus []types.ONUInfo
+}
+
+// detailCheckpoint tracks a detailed poll cycle that spans several polls.
+// A cycle ends once every PON port is read or given up on, or once it is
+// older than the detailed interval. Callers hold p.mu.
+type detailCheckpoint struct {
+	started  time.Time
+	done     map[string]bool // PON ports read or given up on in this cycle
+	failed   map[string]bool // PON ports given up on in this cycle
+	attempts map[string]int  // failed reads of each PON port in this cycle
+}
+
+// newDetailCheckpoint starts a detailed poll cycle.
+func newDetailCheckpoint(started time.Time) *detailCheckpoint {
+	return &detailCheckpoint{
+		started:  started,
+		done:     make(map[string]bool),
+		failed:   make(map[string]bool),
+		attempts: make(map[string]int),
+	}
+}
+
+// giveUp records a PON port as failed for the rest of the cycle.
+func (cp *detailCheckpoint) giveUp(port string) {
+	cp.done[port] = true
+	cp.failed[port] = true
+}
+
+// failedPorts returns the PON ports given up on in the cycle, sorted.
+func (cp *detailCheckpoint) failedPorts() []string {
+	ports := make([]string, 0, len(cp.failed))
+	for port := range cp.failed {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	return ports
+}
+
+// detailConcurrency returns how many PON ports of an OLT are read in parallel.
+func detailConcurrency(cfg OLTPollingConfig) int {
+	n := cfg.DetailConcurrency
+	if n <= 0 {
+		n = defaultDetailConcurrency
+	}
+	if n > maxDetailConcurrency {
+		n = maxDetailConcurrency
+	}
+	return n
+}
+
+// detailCycleLimit returns how long a detailed poll cycle may run: the
+// shorter of the optical and traffic intervals.
+func detailCycleLimit(cfg OLTPollingConfig) time.Duration {
+	limit := groupInterval(cfg, GroupONUOptical)
+	if traffic := groupInterval(cfg, GroupONUTraffic); traffic < limit {
+		limit = traffic
+	}
+	return limit
+}
+
+// detailBudget returns how long the detail reads of one poll may take.
+func detailBudget(cfg OLTPollingConfig) time.Duration {
+	if cfg.DetailTimeout > 0 {
+		return time.Duration(cfg.DetailTimeout) * time.Second
+	}
+	return time.Duration(float64(groupInterval(cfg, GroupONUStatus)) * detailBudgetFraction)
+}
+
+// detailChunks groups ONUs by PON port, skipping ports already read in the
+// current cycle. Chunks are ordered by port.
+func detailChunks(onus []types.ONUInfo, done map[string]bool) []detailChunk {
+	byPort := make(map[string][]types.ONUInfo)
+	for _, onu := range onus {
+		if done[onu.PONPort] {
+			continue
+		}
+		byPort[onu.PONPort] = append(byPort[onu.PONPort], onu)
+	}
+	chunks := make([]detailChunk, 0, len(byPort))
+	for port, portONUs := range byPort {
+		chunks = append(chunks, detailChunk{port: port, onus: portONUs})
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].port < chunks[j].port })
+	return chunks
+}
+
+// fetchDetailChunks reads the details of each chunk with up to concurrency
+// chunks in flight. Worker i reads through the detailer returned by open(i);
+// a worker that cannot open one leaves its chunks to the others. Chunks not
+// started before ctx is done are left unread. It returns the details read
+// and the PON ports whose read failed, both keyed by PON port, and the first
+// error.
+func fetchDetailChunks(ctx context.Context, chunks []detailChunk, concurrency int,
+	open func(i int) (onuDetailer, func(), error)) (map[string][]types.ONUInfo, map[string]error, error) {
+	if concurrency > len(chunks) {
+		concurrency = len(chunks)
+	}
+
+	work := make(chan detailChunk, len(chunks))
+	for _, chunk := range chunks {
+		work <- chunk
+	}
+	close(work)
+
+	var (
+		mu       sync.Mutex
+		fetched  = make(map[string][]types.ONUInfo)
+		failed   = make(map[string]error)
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			detailer, release, err := open(i)
+			if err != nil {
+				fail(err)
+				return
+			}
+			defer release()
+
+			for chunk := range work {
+				if ctx.Err() != nil {
+					return
+				}
+				onus, err := detailer.GetAllONUDetails(ctx, chunk.onus)
+				if err != nil {
+					mu.Lock()
+					failed[chunk.port] = err
+					mu.Unlock()
+					fail(fmt.Errorf("PON port %s: %w", chunk.port, err))
+					continue
+				}
+				mu.Lock()
+				fetched[chunk.port] = onus
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	return fetched, failed, firstErr
+}
+
+// pollDetails reads per-ONU details for a detailed poll, PON port by PON
+// port. Ports are read in parallel within the poll's detail budget; ports
+// not read in time are checkpointed and read first by the next polls. A port
+// that fails maxDetailAttempts times, or is still unread when the cycle
+// outlives the detailed interval, is recorded as failed for the cycle. The
+// returned list has the details read by this poll and basic data for the
+// rest, whose serials are left out of result.detailed.
+func (p *Poller) pollDetails(ctx context.Context, state *OLTState, detailer onuDetailer, onus []types.ONUInfo, result *PollResult) []types.ONUInfo {
+	p.mu.Lock()
+	cp := state.detail
+	if cp == nil {
+		cp = newDetailCheckpoint(result.Timestamp)
+		state.detail = cp
+	}
+	chunks := detailChunks(onus, cp.done)
+	cfg := state.Config
+	p.mu.Unlock()
+
+	p.log("Running detailed poll for %s (%d ONUs, %d PON ports left in cycle)", cfg.Name, len(onus), len(chunks))
+
+	detailCtx, cancel := context.WithTimeout(ctx, detailBudget(cfg.Polling))
+	defer cancel()
+
+	// Worker 0 reads over the poll's connection, the others over their own
+	open := func(i int) (onuDetailer, func(), error) {
+		if i == 0 {
+			return detailer, func() {}, nil
+		}
+		driver, disconnect, err := p.connect(ctx, cfg, p.determineProtocol(cfg))
+		if err != nil {
+			return nil, nil, fmt.Errorf("detail connection %d: %w", i, err)
+		}
+		extra, ok := driver.(onuDetailer)
+		if !ok {
+			disconnect()
+			return nil, nil, fmt.Errorf("detail connection %d: driver does not read ONU details", i)
+		}
+		return extra, disconnect, nil
+	}
+	fetched, failures, err := fetchDetailChunks(detailCtx, chunks, detailConcurrency(cfg.Polling), open)
+
+	p.mu.Lock()
+	for port := range fetched {
+		cp.done[port] = true
+	}
+	for port := range failures {
+		cp.attempts[port]++
+		if cp.attempts[port] >= maxDetailAttempts {
+			cp.giveUp(port)
+		}
+	}
+	left := detailChunks(onus, cp.done)
+	if len(left) > 0 && result.Timestamp.Sub(cp.started) >= detailCycleLimit(cfg.Polling) {
+		// The next cycle is due; stop resuming this one
+		for _, chunk := range left {
+			cp.giveUp(chunk.port)
+		}
+		left = nil
+	}
+	remaining := len(left)
+	failedPorts := cp.failedPorts()
+	if remaining == 0 {
+		state.detail = nil
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		p.log("Warning: detailed poll of %s read %d of %d PON ports: %v", cfg.Name, len(fetched), len(chunks), err)
+	}
+	if remaining == 0 && len(failedPorts) > 0 {
+		failed := fmt.Errorf("detailed poll cycle failed for PON ports %s", strings.Join(failedPorts, ", "))
+		if err != nil {
+			failed = fmt.Errorf("%w: %v", failed, err)
+		}
+		result.groupFailed(GroupONUOptical, failed)
+		result.groupFailed(GroupONUTraffic, failed)
+		p.log("Detailed poll cycle of %s ended with %d failed PON ports (started %s)", cfg.Name, len(failedPorts), cp.started.Format(time.RFC3339))
+	} else if remaining > 0 {
+		incomplete := fmt.Errorf("detailed poll incomplete: %d PON ports left", remaining)
+		if err != nil {
+			incomplete = fmt.Errorf("%w: %v", incomplete, err)
+		}
+		result.groupFailed(GroupONUOptical, incomplete)
+		result.groupFailed(GroupONUTraffic, incomplete)
+		p.log("Detailed poll of %s will resume with %d PON ports left", cfg.Name, remaining)
+	} else {
+		p.log("Detailed poll cycle of %s complete (started %s)", cfg.Name, cp.started.Format(time.RFC3339))
+	}
+
+	detailed := make(map[string]types.ONUInfo)
+	for _, portONUs := range fetched {
+		for _, onu := range portONUs {
+			detailed[onu.Serial] = onu
+		}
+	}
+	result.detailed = make(map[string]bool, len(detailed))
+	merged := make([]types.ONUInfo, len(onus))
+	for i, onu := range onus {
+		if d, ok := detailed[onu.Serial]; ok {
+			merged[i] = d
+			result.detailed[onu.Serial] = true
+		} else {
+			merged[i] = onu
+		}
+	}
+	return merged
+}
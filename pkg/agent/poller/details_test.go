@@ -0,0 +1,203 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDetailer fills in Rx power and tracks how many reads run at once.
+type fakeDetailer struct {
+	delay    time.Duration
+	failPort string
+	active   *int32
+	peak     *int32
+}
+
+func (f fakeDetailer) GetAllONUDetails(ctx context.Context, onus []types.ONUInfo) ([]types.ONUInfo, error) {
+	n := atomic.AddInt32(f.active, 1)
+	defer atomic.AddInt32(f.active, -1)
+	for {
+		peak := atomic.LoadInt32(f.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(f.peak, peak, n) {
+			break
+		}
+	}
+
+	if len(onus) > 0 && onus[0].PONPort == f.failPort {
+		return nil, errors.New("timeout reading optical info")
+	}
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	detailed := make([]types.ONUInfo, len(onus))
+	for i, onu := range onus {
+		onu.RxPowerDBm = -20
+		detailed[i] = onu
+	}
+	return detailed, nil
+}
+
+func testONUs() []types.ONUInfo {
+	return []types.ONUInfo{
+		{PONPort: "0/2", Serial: "C"},
+		{PONPort: "0/1", Serial: "A"},
+		{PONPort: "0/1", Serial: "B"},
+		{PONPort: "0/3", Serial: "D"},
+	}
+}
+
+func TestDetailChunks(t *testing.T) {
+	chunks := detailChunks(testONUs(), map[string]bool{"0/2": true})
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "0/1", chunks[0].port)
+	assert.Len(t, chunks[0].onus, 2)
+	assert.Equal(t, "0/3", chunks[1].port)
+}
+
+func TestDetailSettings(t *testing.T) {
+	assert.Equal(t, 2, detailConcurrency(OLTPollingConfig{}))
+	assert.Equal(t, maxDetailConcurrency, detailConcurrency(OLTPollingConfig{DetailConcurrency: 50}))
+	assert.Equal(t, 48*time.Second, detailBudget(OLTPollingConfig{Interval: 60}))
+	assert.Equal(t, 20*time.Second, detailBudget(OLTPollingConfig{Interval: 60, DetailTimeout: 20}))
+}
+
+func TestFetchDetailChunksBoundsConcurrency(t *testing.T) {
+	var active, peak int32
+	chunks := []detailChunk{
+		{port: "0/1", onus: []types.ONUInfo{{PONPort: "0/1", Serial: "A"}}},
+		{port: "0/2", onus: []types.ONUInfo{{PONPort: "0/2", Serial: "B"}}},
+		{port: "0/3", onus: []types.ONUInfo{{PONPort: "0/3", Serial: "C"}}},
+		{port: "0/4", onus: []types.ONUInfo{{PONPort: "0/4", Serial: "D"}}},
+		{port: "0/5", onus: []types.ONUInfo{{PONPort: "0/5", Serial: "E"}}},
+	}
+	open := func(i int) (onuDetailer, func(), error) {
+		return fakeDetailer{delay: 20 * time.Millisecond, failPort: "0/4", active: &active, peak: &peak}, func() {}, nil
+	}
+
+	fetched, failed, err := fetchDetailChunks(context.Background(), chunks, 2, open)
+	assert.EqualError(t, err, "PON port 0/4: timeout reading optical info")
+	assert.Len(t, failed, 1)
+	assert.Contains(t, failed, "0/4")
+	assert.Len(t, fetched, 4)
+	assert.NotContains(t, fetched, "0/4")
+	assert.Equal(t, -20.0, fetched["0/5"][0].RxPowerDBm)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+func TestFetchDetailChunksKeepsPartialDataOnTimeout(t *testing.T) {
+	var active, peak int32
+	chunks := detailChunks(testONUs(), nil)
+	open := func(i int) (onuDetailer, func(), error) {
+		if i > 0 {
+			return nil, nil, errors.New("connection refused")
+		}
+		return fakeDetailer{delay: 50 * time.Millisecond, active: &active, peak: &peak}, func() {}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 75*time.Millisecond)
+	defer cancel()
+	fetched, _, err := fetchDetailChunks(ctx, chunks, 3, open)
+	require.Error(t, err)
+	assert.Contains(t, fetched, "0/1", "ports read before the timeout are kept")
+	assert.Less(t, len(fetched), len(chunks))
+}
+
+func TestPollDetailsResumesFromCheckpoint(t *testing.T) {
+	var active, peak int32
+	p := New(nil, nil, nil, nil)
+	state := &OLTState{Config: OLTConfig{ID: "olt-1", Name: "OLT 1", Polling: OLTPollingConfig{
+		Enabled:           true,
+		Interval:          60,
+		DetailConcurrency: 1,
+	}}}
+
+	// The first poll cannot read port 0/2
+	detailer := fakeDetailer{failPort: "0/2", active: &active, peak: &peak}
+	result := &PollResult{Timestamp: time.Now(), Groups: []MetricGroup{GroupONUStatus, GroupONUOptical, GroupONUTraffic}}
+	onus := p.pollDetails(context.Background(), state, detailer, testONUs(), result)
+	require.Len(t, onus, 4)
+	assert.Equal(t, -20.0, onus[1].RxPowerDBm)
+	assert.Zero(t, onus[0].RxPowerDBm, "unread ports keep basic data")
+	assert.True(t, result.hasDetails("A"))
+	assert.False(t, result.hasDetails("C"))
+	assert.Contains(t, result.GroupErrors, GroupONUOptical)
+	require.NotNil(t, state.detail)
+	assert.Equal(t, map[string]bool{"0/1": true, "0/3": true}, state.detail.done)
+
+	// The unfinished cycle is due again on the fast interval
+	state.LastCollected = map[MetricGroup]time.Time{GroupONUOptical: result.Timestamp}
+	assert.Equal(t, result.Timestamp.Add(time.Minute), groupDueAt(state, GroupONUOptical))
+
+	// The next poll reads only the port left over
+	detailer.failPort = ""
+	result = &PollResult{Timestamp: time.Now(), Groups: []MetricGroup{GroupONUOptical, GroupONUTraffic}}
+	onus = p.pollDetails(context.Background(), state, detailer, testONUs(), result)
+	assert.Equal(t, -20.0, onus[0].RxPowerDBm)
+	assert.True(t, result.hasDetails("C"))
+	assert.False(t, result.hasDetails("A"), "ports read by the previous poll are not read again")
+	assert.Empty(t, result.GroupErrors)
+	assert.Nil(t, state.detail, "cycle is complete")
+}
+
+func TestPollDetailsGivesUpOnFailingPort(t *testing.T) {
+	var active, peak int32
+	p := New(nil, nil, nil, nil)
+	state := &OLTState{Config: OLTConfig{ID: "olt-1", Name: "OLT 1", Polling: OLTPollingConfig{
+		Enabled:          true,
+		Interval:         60,
+		DetailedInterval: 3600,
+	}}}
+	detailer := fakeDetailer{failPort: "0/2", active: &active, peak: &peak}
+
+	start := time.Now()
+	for i := 1; i < maxDetailAttempts; i++ {
+		result := &PollResult{Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		p.pollDetails(context.Background(), state, detailer, testONUs(), result)
+		require.NotNil(t, state.detail, "cycle resumes after %d failed reads", i)
+		assert.Contains(t, result.GroupErrors[GroupONUOptical].Error(), "1 PON ports left")
+	}
+
+	// The last attempt gives up on the port and ends the cycle
+	result := &PollResult{Timestamp: start.Add(maxDetailAttempts * time.Minute)}
+	p.pollDetails(context.Background(), state, detailer, testONUs(), result)
+	assert.Nil(t, state.detail, "cycle ends")
+	assert.Contains(t, result.GroupErrors[GroupONUOptical].Error(), "failed for PON ports 0/2")
+
+	// The next cycle reads every port again
+	detailer.failPort = ""
+	result = &PollResult{Timestamp: start.Add(2 * time.Hour)}
+	p.pollDetails(context.Background(), state, detailer, testONUs(), result)
+	assert.True(t, result.hasDetails("A"))
+	assert.True(t, result.hasDetails("C"))
+	assert.Empty(t, result.GroupErrors)
+	assert.Nil(t, state.detail)
+}
+
+func TestPollDetailsEndsCycleAfterDetailedInterval(t *testing.T) {
+	var active, peak int32
+	p := New(nil, nil, nil, nil)
+	state := &OLTState{Config: OLTConfig{ID: "olt-1", Name: "OLT 1", Polling: OLTPollingConfig{
+		Enabled:          true,
+		Interval:         60,
+		DetailedInterval: 300,
+	}}}
+	detailer := fakeDetailer{failPort: "0/2", active: &active, peak: &peak}
+
+	start := time.Now()
+	p.pollDetails(context.Background(), state, detailer, testONUs(), &PollResult{Timestamp: start})
+	require.NotNil(t, state.detail)
+
+	result := &PollResult{Timestamp: start.Add(5 * time.Minute)}
+	p.pollDetails(context.Background(), state, detailer, testONUs(), result)
+	assert.Nil(t, state.detail, "a cycle older than the detailed interval ends")
+	assert.Contains(t, result.GroupErrors[GroupONUOptical].Error(), "failed for PON ports 0/2")
+}
@@ -0,0 +1,227 @@
+package poller
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetricGroup is a set of data the poller collects from an OLT. Groups are
+// selected per OLT through OLTPollingConfig.Metrics and each one is collected
+// on its own interval.
+type MetricGroup string
+
+const (
+	GroupONUStatus   MetricGroup = "onu_status"   // ONU list: status, profiles, distance
+	GroupONUOptical  MetricGroup = "onu_optical"  // ONU Rx/Tx power, temperature, voltage, bias current
+	GroupONUTraffic  MetricGroup = "onu_traffic"  // ONU byte/packet counters and rates
+	GroupOLTSystem   MetricGroup = "olt_system"   // OLT CPU, memory, temperature, uptime
+	GroupPONPorts    MetricGroup = "pon_ports"    // PON port state, optical power and ONU counts
+	GroupUplinkPorts MetricGroup = "uplink_ports" // uplink port state and counters
+	GroupAlarms      MetricGroup = "alarms"       // active OLT alarms
+)
+
+// allMetricGroups lists every known metric group in collection order.
+var allMetricGroups = []MetricGroup{
+	GroupONUStatus,
+	GroupONUOptical,
+	GroupONUTraffic,
+	GroupOLTSystem,
+	GroupPONPorts,
+	GroupUplinkPorts,
+	GroupAlarms,
+}
+
+// metricGroupAliases maps the metric names older control planes send to
+// metric groups.
+var metricGroupAliases = map[string]MetricGroup{
+	"onu-status":    GroupONUStatus,
+	"optical-power": GroupONUOptical,
+	"optical":       GroupONUOptical,
+	"traffic":       GroupONUTraffic,
+	"system":        GroupOLTSystem,
+	"telemetry":     GroupOLTSystem,
+}
+
+// defaultMetricGroups are collected when an OLT does not list any metrics.
+// They match what the poller collected before metric groups existed.
+var defaultMetricGroups = []MetricGroup{
+	GroupONUStatus,
+	GroupONUOptical,
+	GroupONUTraffic,
+	GroupOLTSystem,
+	GroupPONPorts,
+}
+
+const (
+	// defaultPollInterval is used when an OLT does not set a poll interval.
+	defaultPollInterval = 5 * time.Minute
+
+	// defaultDetailedInterval is used when an OLT does not set a detailed
+	// poll interval. Per-ONU optical and traffic data is expensive to read.
+	defaultDetailedInterval = 10 * time.Minute
+)
+
+// ParseMetricGroup returns the metric group named by s. Legacy metric names
+// such as "optical-power" are accepted. ok is false for unknown names.
+func ParseMetricGroup(s string) (MetricGroup, bool) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if g, ok := metricGroupAliases[name]; ok {
+		return g, true
+	}
+	g := MetricGroup(strings.ReplaceAll(name, "-", "_"))
+	for _, known := range allMetricGroups {
+		if g == known {
+			return g, true
+		}
+	}
+	return "", false
+}
+
+// metricGroups returns the groups an OLT collects. Unknown names are
+// ignored; a list without any known group selects the default groups.
+func metricGroups(cfg OLTPollingConfig) []MetricGroup {
+	if len(cfg.Metrics) == 0 {
+		return defaultMetricGroups
+	}
+	seen := make(map[MetricGroup]bool, len(cfg.Metrics))
+	groups := make([]MetricGroup, 0, len(cfg.Metrics))
+	for _, name := range cfg.Metrics {
+		g, ok := ParseMetricGroup(name)
+		if !ok || seen[g] {
+			continue
+		}
+		seen[g] = true
+		groups = append(groups, g)
+	}
+	if len(groups) == 0 {
+		return defaultMetricGroups
+	}
+	sort.Slice(groups, func(i, j int) bool { return groupOrder(groups[i]) < groupOrder(groups[j]) })
+	return groups
+}
+
+// groupOrder returns the position of g in allMetricGroups.
+func groupOrder(g MetricGroup) int {
+	for i, known := range allMetricGroups {
+		if g == known {
+			return i
+		}
+	}
+	return len(allMetricGroups)
+}
+
+// groupInterval returns how often a metric group is collected on an OLT.
+// An entry in MetricIntervals wins; otherwise per-ONU optical and traffic
+// data follow DetailedInterval and everything else follows Interval.
+func groupInterval(cfg OLTPollingConfig, g MetricGroup) time.Duration {
+	if seconds := cfg.MetricIntervals[string(g)]; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	switch g {
+	case GroupONUOptical, GroupONUTraffic:
+		if cfg.DetailedInterval > 0 {
+			return time.Duration(cfg.DetailedInterval) * time.Second
+		}
+		return defaultDetailedInterval
+	default:
+		if cfg.Interval > 0 {
+			return time.Duration(cfg.Interval) * time.Second
+		}
+		return defaultPollInterval
+	}
+}
+
+// minGroupInterval returns the shortest interval of the groups an OLT collects.
+func minGroupInterval(cfg OLTPollingConfig) time.Duration {
+	var shortest time.Duration
+	for _, g := range metricGroups(cfg) {
+		if interval := groupInterval(cfg, g); shortest == 0 || interval < shortest {
+			shortest = interval
+		}
+	}
+	if shortest == 0 {
+		return defaultPollInterval
+	}
+	return shortest
+}
+
+// groupDueAt returns when a metric group of an OLT is next due: its
+// interval, stretched under load and shifted by the OLT's jitter, after it
+// was last collected. Groups never collected are due immediately.
+// Callers hold p.mu.
+func groupDueAt(state *OLTState, g MetricGroup) time.Time {
+	last, ok := state.LastCollected[g]
+	if !ok {
+		return time.Time{}
+	}
+	interval := groupInterval(state.Config.Polling, g)
+	if state.detail != nil && (g == GroupONUOptical || g == GroupONUTraffic) {
+		// An unfinished detailed poll cycle resumes on the next fast poll
+		if fast := groupInterval(state.Config.Polling, GroupONUStatus); fast < interval {
+			interval = fast
+		}
+	}
+	if state.Stretch > 1 {
+		interval = time.Duration(float64(interval) * state.Stretch)
+	}
+	return last.Add(interval + state.jitter)
+}
+
+// dueGroups returns the groups of an OLT that are due at now.
+// Callers hold p.mu.
+func dueGroups(state *OLTState, now time.Time) []MetricGroup {
+	var due []MetricGroup
+	for _, g := range metricGroups(state.Config.Polling) {
+		if !groupDueAt(state, g).After(now) {
+			due = append(due, g)
+		}
+	}
+	return due
+}
+
+// nextDue returns when the earliest metric group of an OLT is due.
+// Callers hold p.mu.
+func nextDue(state *OLTState) time.Time {
+	var next time.Time
+	for i, g := range metricGroups(state.Config.Polling) {
+		if at := groupDueAt(state, g); i == 0 || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// groupSet is a set of metric groups.
+type groupSet map[MetricGroup]bool
+
+// newGroupSet returns a set holding groups.
+func newGroupSet(groups []MetricGroup) groupSet {
+	set := make(groupSet, len(groups))
+	for _, g := range groups {
+		set[g] = true
+	}
+	return set
+}
+
+// needsONUList reports whether any group in the set is read from the ONU list.
+func (s groupSet) needsONUList() bool {
+	return s[GroupONUStatus] || s[GroupONUOptical] || s[GroupONUTraffic] || s[GroupPONPorts]
+}
+
+// needsONUDetails reports whether any group in the set needs per-ONU details.
+func (s groupSet) needsONUDetails() bool {
+	return s[GroupONUOptical] || s[GroupONUTraffic]
+}
+
+// joinGroups formats metric groups for log messages.
+func joinGroups(groups []MetricGroup) string {
+	if len(groups) == 0 {
+		return "none"
+	}
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = string(g)
+	}
+	return strings.Join(names, ",")
+}
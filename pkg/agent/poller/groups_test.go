@@ -0,0 +1,123 @@
+package poller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics []string
+		want    []MetricGroup
+	}{
+		{"empty selects defaults", nil, defaultMetricGroups},
+		{"known groups in collection order", []string{"alarms", "onu_status"}, []MetricGroup{GroupONUStatus, GroupAlarms}},
+		{"legacy names", []string{"onu-status", "optical-power", "traffic", "errors"}, []MetricGroup{GroupONUStatus, GroupONUOptical, GroupONUTraffic}},
+		{"duplicates collapse", []string{"pon_ports", "PON-PORTS"}, []MetricGroup{GroupPONPorts}},
+		{"nothing known selects defaults", []string{"errors"}, defaultMetricGroups},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, metricGroups(OLTPollingConfig{Metrics: tt.metrics}))
+		})
+	}
+}
+
+func TestGroupInterval(t *testing.T) {
+	cfg := OLTPollingConfig{
+		Interval:         60,
+		DetailedInterval: 900,
+		MetricIntervals:  map[string]int{"alarms": 30},
+	}
+
+	assert.Equal(t, time.Minute, groupInterval(cfg, GroupONUStatus))
+	assert.Equal(t, 15*time.Minute, groupInterval(cfg, GroupONUOptical))
+	assert.Equal(t, 15*time.Minute, groupInterval(cfg, GroupONUTraffic))
+	assert.Equal(t, 30*time.Second, groupInterval(cfg, GroupAlarms))
+	assert.Equal(t, defaultPollInterval, groupInterval(OLTPollingConfig{}, GroupOLTSystem))
+	assert.Equal(t, defaultDetailedInterval, groupInterval(OLTPollingConfig{}, GroupONUOptical))
+}
+
+func TestDueGroups(t *testing.T) {
+	now := time.Now()
+	state := &OLTState{Config: OLTConfig{Polling: OLTPollingConfig{
+		Interval:         60,
+		DetailedInterval: 600,
+		Metrics:          []string{"onu_status", "onu_optical", "olt_system"},
+	}}}
+
+	assert.Equal(t, []MetricGroup{GroupONUStatus, GroupONUOptical, GroupOLTSystem}, dueGroups(state, now))
+
+	state.LastCollected = map[MetricGroup]time.Time{
+		GroupONUStatus:  now.Add(-2 * time.Minute),
+		GroupONUOptical: now.Add(-2 * time.Minute),
+		GroupOLTSystem:  now.Add(-30 * time.Second),
+	}
+	assert.Equal(t, []MetricGroup{GroupONUStatus}, dueGroups(state, now))
+}
+
+func TestONUDataDropsUnselectedGroups(t *testing.T) {
+	onu := types.ONUInfo{
+		Serial:     "HWTC12345678",
+		PONPort:    "0/1/0",
+		IsOnline:   true,
+		RxPowerDBm: -21.5,
+		BytesUp:    1024,
+	}
+
+	data := onuData(onu, newGroupSet([]MetricGroup{GroupONUStatus, GroupONUOptical}))
+	assert.Equal(t, "online", data.Status)
+	assert.Equal(t, -21.5, data.RxPower)
+	assert.Zero(t, data.BytesUp)
+
+	data = onuData(onu, newGroupSet([]MetricGroup{GroupONUStatus, GroupONUTraffic}))
+	assert.Zero(t, data.RxPower)
+	assert.Equal(t, uint64(1024), data.BytesUp)
+}
+
+func TestBuildMetricsBatchHonorsGroups(t *testing.T) {
+	p := New(nil, nil, nil, nil)
+	result := &PollResult{
+		OLTID: "olt-1",
+		ONUs: []ONUData{
+			{Serial: "HWTC12345678", PONPort: "0/1/0", RxPower: -21.5, BytesUp: 1024},
+		},
+		Telemetry: &TelemetryData{CPUPercent: 12},
+		Ports:     []PortData{{Port: "0/1/0", Type: PortTypePON, ONUCount: 1}},
+		Alarms: []types.OLTAlarm{
+			{ID: "1", Severity: "Critical"},
+			{ID: "2", Severity: "minor", ClearedAt: &time.Time{}},
+		},
+	}
+
+	names := func(batch *MetricsBatch) map[string]int {
+		counts := make(map[string]int)
+		for _, m := range batch.Metrics {
+			counts[m.Name]++
+		}
+		return counts
+	}
+
+	result.Groups = []MetricGroup{GroupONUStatus}
+	assert.Empty(t, p.buildMetricsBatch(result, "OLT 1").Metrics)
+
+	result.Groups = []MetricGroup{GroupONUOptical, GroupOLTSystem, GroupPONPorts, GroupAlarms}
+	batch := p.buildMetricsBatch(result, "OLT 1")
+	got := names(batch)
+	assert.Equal(t, map[string]int{
+		"olt_cpu_percent":    1,
+		"onu_rx_power_dbm":   1,
+		"pon_port_onu_count": 1,
+		"olt_active_alarms":  1,
+	}, got)
+	for _, m := range batch.Metrics {
+		if m.Name == "olt_active_alarms" {
+			require.Equal(t, "critical", m.Labels["severity"])
+		}
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/nanoncore/nano-agent/pkg/snmp"
 	"github.com/nanoncore/nano-southbound"
 	"github.com/nanoncore/nano-southbound/types"
 )
@@ -27,6 +29,26 @@ type MetricsPusher interface {
 	PushMetrics(batch *MetricsBatch) (*PushMetricsResponse, error)
 }
 
+// AutofindPusher is the interface for pushing autofind discovery results to the control plane.
+type AutofindPusher interface {
+	PushAutofind(oltID string, report *AutofindReport) (*PushAutofindResponse, error)
+}
+
+// ResultSink receives the results of successful polls alongside the
+// control plane pushers. Submit is called on the result handler and must
+// not block; sinks buffer and write records on their own.
+type ResultSink interface {
+	Name() string
+	Submit(record *SinkRecord)
+}
+
+// DiscoveryHandler is called with ONUs that newly appeared in an OLT's
+// autofind list since the previous discovery.
+type DiscoveryHandler func(oltID string, discoveries []types.ONUDiscovery)
+
+// defaultDiscoveryInterval is used when an OLT enables discovery without an interval.
+const defaultDiscoveryInterval = 5 * time.Minute
+
 // Poller manages OLT polling with a worker pool.
 type Poller struct {
 	mu sync.RWMutex
@@ -36,6 +58,9 @@ type Poller struct {
 	checkInterval  time.Duration
 	maxBackoff     time.Duration
 	connectTimeout time.Duration
+	jitterFraction float64
+	maxStretch     float64
+	staleAfter     int
 
 	// State
 	oltStates map[string]*OLTState
@@ -45,9 +70,13 @@ type Poller struct {
 	pusher          ONUPusher
 	telemetryPusher TelemetryPusher
 	metricsPusher   MetricsPusher
+	autofindPusher  AutofindPusher
+	portPusher      PortPusher
+	sinks           []ResultSink
+	onDiscovery     DiscoveryHandler
 
-	// Channels
-	jobChan    chan *OLTState
+	// Work queue and channels
+	queue      *jobQueue
 	resultChan chan *PollResult
 	stopChan   chan struct{}
 	doneChan   chan struct{}
@@ -70,6 +99,19 @@ type Config struct {
 	// ConnectTimeout is the timeout for connecting to OLTs (default: 30s)
 	ConnectTimeout time.Duration
 
+	// JitterFraction spreads each OLT's polls by up to this fraction of its
+	// shortest interval (default: 0.1)
+	JitterFraction float64
+
+	// MaxStretch is the most an OLT's intervals are stretched when its polls
+	// take nearly as long as the interval (default: 4)
+	MaxStretch float64
+
+	// StaleAfterIntervals is how many intervals a metric group may go
+	// without fresh data before the OLT's telemetry is reported stale
+	// (default: 3)
+	StaleAfterIntervals int
+
 	// LogPrefix is prepended to log messages (default: "[poller]")
 	LogPrefix string
 }
@@ -77,11 +119,14 @@ type Config struct {
 // DefaultConfig returns the default poller configuration.
 func DefaultConfig() *Config {
 	return &Config{
-		WorkerCount:    5,
-		CheckInterval:  10 * time.Second,
-		MaxBackoff:     5 * time.Minute,
-		ConnectTimeout: 30 * time.Second,
-		LogPrefix:      "[poller]",
+		WorkerCount:         5,
+		CheckInterval:       10 * time.Second,
+		MaxBackoff:          5 * time.Minute,
+		ConnectTimeout:      30 * time.Second,
+		JitterFraction:      0.1,
+		MaxStretch:          4,
+		StaleAfterIntervals: defaultStaleAfterIntervals,
+		LogPrefix:           "[poller]",
 	}
 }
 
@@ -102,6 +147,15 @@ func New(pusher ONUPusher, telemetryPusher TelemetryPusher, metricsPusher Metric
 	if cfg.ConnectTimeout <= 0 {
 		cfg.ConnectTimeout = 30 * time.Second
 	}
+	if cfg.JitterFraction <= 0 || cfg.JitterFraction > 0.5 {
+		cfg.JitterFraction = 0.1
+	}
+	if cfg.MaxStretch < 1 {
+		cfg.MaxStretch = 4
+	}
+	if cfg.StaleAfterIntervals <= 0 {
+		cfg.StaleAfterIntervals = defaultStaleAfterIntervals
+	}
 	if cfg.LogPrefix == "" {
 		cfg.LogPrefix = "[poller]"
 	}
@@ -111,6 +165,9 @@ func New(pusher ONUPusher, telemetryPusher TelemetryPusher, metricsPusher Metric
 		checkInterval:   cfg.CheckInterval,
 		maxBackoff:      cfg.MaxBackoff,
 		connectTimeout:  cfg.ConnectTimeout,
+		jitterFraction:  cfg.JitterFraction,
+		maxStretch:      cfg.MaxStretch,
+		staleAfter:      cfg.StaleAfterIntervals,
 		oltStates:       make(map[string]*OLTState),
 		pusher:          pusher,
 		telemetryPusher: telemetryPusher,
@@ -119,7 +176,7 @@ func New(pusher ONUPusher, telemetryPusher TelemetryPusher, metricsPusher Metric
 	}
 }
 
-// UpdateOLTs updates the list of OLTs to poll.
+// UpdateOLTs updates the list of OLTs to poll and to run autofind discovery on.
 func (p *Poller) UpdateOLTs(olts []OLTConfig) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -130,22 +187,28 @@ func (p *Poller) UpdateOLTs(olts []OLTConfig) {
 	for _, olt := range olts {
 		seen[olt.ID] = true
 
-		// Skip OLTs with polling disabled
-		if !olt.Polling.Enabled {
+		// Skip OLTs with both polling and discovery disabled
+		if !olt.Polling.Enabled && !olt.Discovery.Enabled {
 			delete(p.oltStates, olt.ID)
 			continue
 		}
 
 		// Update or create state
-		if state, exists := p.oltStates[olt.ID]; exists {
+		state, exists := p.oltStates[olt.ID]
+		if exists {
 			// Update config but preserve state
 			state.Config = olt
 		} else {
 			// New OLT - add to state
-			p.oltStates[olt.ID] = &OLTState{
-				Config: olt,
+			state = &OLTState{
+				Config:  olt,
+				Stretch: 1,
+				AddedAt: time.Now(),
+				busy:    make(map[jobKind]bool),
 			}
+			p.oltStates[olt.ID] = state
 		}
+		state.jitter = oltJitter(olt.ID, minGroupInterval(olt.Polling), p.jitterFraction)
 	}
 
 	// Remove OLTs that are no longer in the config
@@ -158,6 +221,35 @@ func (p *Poller) UpdateOLTs(olts []OLTConfig) {
 	p.log("Updated OLT list: %d OLTs configured for polling", len(p.oltStates))
 }
 
+// SetAutofindPusher sets where autofind discovery results are pushed.
+func (p *Poller) SetAutofindPusher(pusher AutofindPusher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.autofindPusher = pusher
+}
+
+// SetPortPusher sets where PON and uplink port data is pushed.
+func (p *Poller) SetPortPusher(pusher PortPusher) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.portPusher = pusher
+}
+
+// AddSink adds an output that receives the results of successful polls.
+func (p *Poller) AddSink(sink ResultSink) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sinks = append(p.sinks, sink)
+}
+
+// SetDiscoveryHandler reports ONUs that newly appear in autofind to handler.
+// Discovery only runs on OLTs whose configuration enables it.
+func (p *Poller) SetDiscoveryHandler(handler DiscoveryHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onDiscovery = handler
+}
+
 // Start begins the polling loop.
 func (p *Poller) Start(ctx context.Context) {
 	p.mu.Lock()
@@ -167,8 +259,8 @@ func (p *Poller) Start(ctx context.Context) {
 	}
 	p.running = true
 
-	// Initialize channels
-	p.jobChan = make(chan *OLTState, p.workerCount*2)
+	// Initialize queue and channels
+	p.queue = newJobQueue()
 	p.resultChan = make(chan *PollResult, p.workerCount*2)
 	p.stopChan = make(chan struct{})
 	p.doneChan = make(chan struct{})
@@ -192,7 +284,7 @@ func (p *Poller) Start(ctx context.Context) {
 	// Wait for stop signal
 	go func() {
 		<-p.stopChan
-		close(p.jobChan)
+		p.queue.close()
 		wg.Wait()
 		close(p.resultChan)
 		close(p.doneChan)
@@ -231,32 +323,31 @@ func (p *Poller) scheduler(ctx context.Context) {
 			return
 		case <-ticker.C:
 			p.schedulePolls()
+			p.checkStaleness()
 		}
 	}
 }
 
 // scheduleInitialPolls staggers the initial polls to avoid thundering herd.
 func (p *Poller) scheduleInitialPolls() {
-	p.mu.RLock()
+	p.mu.Lock()
 	olts := make([]*OLTState, 0, len(p.oltStates))
 	for _, state := range p.oltStates {
-		olts = append(olts, state)
+		if state.Config.Polling.Enabled {
+			olts = append(olts, state)
+		}
 	}
-	p.mu.RUnlock()
-
 	if len(olts) == 0 {
+		p.mu.Unlock()
 		return
 	}
 
 	// Calculate stagger interval
 	// For 30 OLTs with 5min polling, stagger = 5min / 30 = 10s between each
-	minInterval := 300 * time.Second // Default 5 min polling
+	minInterval := defaultPollInterval
 	for _, state := range olts {
-		if state.Config.Polling.Interval > 0 {
-			interval := time.Duration(state.Config.Polling.Interval) * time.Second
-			if interval < minInterval {
-				minInterval = interval
-			}
+		if interval := minGroupInterval(state.Config.Polling); interval < minInterval {
+			minInterval = interval
 		}
 	}
 	stagger := minInterval / time.Duration(len(olts))
@@ -267,80 +358,158 @@ func (p *Poller) scheduleInitialPolls() {
 		stagger = 30 * time.Second
 	}
 
-	p.log("Scheduling initial polls with %s stagger for %d OLTs", stagger, len(olts))
+	// Hold back each OLT's first poll by its place in the stagger
+	now := time.Now()
+	sort.Slice(olts, func(i, j int) bool { return olts[i].Config.ID < olts[j].Config.ID })
+	for i, state := range olts {
+		state.notBefore = now.Add(time.Duration(i) * stagger)
+	}
+	p.mu.Unlock()
 
-	// Schedule with stagger
-	go func() {
-		for i, state := range olts {
-			select {
-			case <-p.stopChan:
-				return
-			case p.jobChan <- state:
-				p.log("Queued initial poll for %s (%d/%d)", state.Config.Name, i+1, len(olts))
-			default:
-				p.log("Job queue full, skipping initial poll for %s", state.Config.Name)
-			}
-			time.Sleep(stagger)
-		}
-	}()
+	p.log("Scheduling initial polls with %s stagger for %d OLTs", stagger, len(olts))
+	p.schedulePolls()
 }
 
-// schedulePolls checks which OLTs need polling and queues them.
+// schedulePolls queues polls and autofind discoveries of OLTs that are due.
+// An OLT whose previous job is still queued or running is not queued again;
+// the skipped poll is counted and reported in its metrics.
 func (p *Poller) schedulePolls() {
-	p.mu.RLock()
+	p.mu.Lock()
 	now := time.Now()
-	var toSchedule []*OLTState
+	var toSchedule []*job
 
 	for _, state := range p.oltStates {
-		// Skip if in backoff
-		if now.Before(state.BackoffUntil) {
+		if state.busy == nil {
+			state.busy = make(map[jobKind]bool)
+		}
+
+		// Skip if in backoff or held back by the initial stagger
+		if now.Before(state.BackoffUntil) || now.Before(state.notBefore) {
 			continue
 		}
 
-		// Check if poll interval has elapsed
-		interval := time.Duration(state.Config.Polling.Interval) * time.Second
-		if interval <= 0 {
-			interval = 5 * time.Minute // Default
+		// Check if any metric group is due
+		if state.Config.Polling.Enabled && len(dueGroups(state, now)) > 0 {
+			if state.busy[jobPoll] {
+				if !state.skipCounted {
+					state.SkippedPolls++
+					state.skipCounted = true
+					p.log("Poll of %s is due but the previous one has not finished, skipping", state.Config.Name)
+				}
+			} else {
+				due := nextDue(state)
+				if due.IsZero() || due.Before(state.notBefore) {
+					due = state.notBefore
+				}
+				state.busy[jobPoll] = true
+				toSchedule = append(toSchedule, &job{state: state, kind: jobPoll, priority: pollPriority(state, now), due: due})
+			}
 		}
 
-		if now.Sub(state.LastPoll) >= interval {
-			toSchedule = append(toSchedule, state)
+		// Check if discovery interval has elapsed
+		if state.Config.Discovery.Enabled && !state.busy[jobDiscovery] && now.Sub(state.LastDiscovery) >= discoveryInterval(state.Config) {
+			state.busy[jobDiscovery] = true
+			toSchedule = append(toSchedule, &job{state: state, kind: jobDiscovery, due: now})
 		}
 	}
-	p.mu.RUnlock()
+	p.mu.Unlock()
 
 	// Queue jobs
-	for _, state := range toSchedule {
-		select {
-		case p.jobChan <- state:
-			// Queued successfully
-		default:
-			// Queue full, skip this cycle
-			p.log("Job queue full, skipping poll for %s", state.Config.Name)
+	for _, j := range toSchedule {
+		if !p.queue.push(j) {
+			p.finishJob(j)
 		}
 	}
 }
 
-// worker processes polling jobs from the job channel.
+// finishJob marks a job's OLT free for the next job of the same kind.
+func (p *Poller) finishJob(j *job) {
+	if j.done != nil {
+		return
+	}
+	p.mu.Lock()
+	j.state.busy[j.kind] = false
+	if j.kind == jobPoll {
+		j.state.skipCounted = false
+	}
+	p.mu.Unlock()
+}
+
+// discoveryInterval returns how often an OLT's autofind list is read.
+func discoveryInterval(cfg OLTConfig) time.Duration {
+	if cfg.Discovery.Interval <= 0 {
+		return defaultDiscoveryInterval
+	}
+	return time.Duration(cfg.Discovery.Interval) * time.Second
+}
+
+// worker processes jobs from the queue, highest priority first.
 func (p *Poller) worker(ctx context.Context, id int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	for state := range p.jobChan {
+	for {
+		j, ok := p.queue.pop()
+		if !ok {
+			return
+		}
 		select {
 		case <-ctx.Done():
+			p.finishJob(j)
 			return
 		default:
-			result := p.pollOLT(ctx, state)
-			select {
-			case p.resultChan <- result:
-			case <-ctx.Done():
-				return
+		}
+
+		if j.kind == jobDiscovery {
+			// Discovery results only touch autofind state, so they are
+			// handled on the worker
+			p.handleDiscoveryResult(p.discoverOLT(ctx, j.state))
+			p.finishJob(j)
+			continue
+		}
+
+		p.recordLateness(j)
+		result := p.pollOLT(ctx, j.state)
+		p.finishJob(j)
+
+		if j.done != nil {
+			// Manual probes are handled on the worker so the caller sees the
+			// pushed result; failures do not back off the schedule
+			if result.Error == nil {
+				p.handleResult(result)
 			}
+			j.done <- result
+			continue
 		}
+
+		select {
+		case p.resultChan <- result:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// recordLateness records how long after it was due a poll started. Polls
+// starting more than two scheduler checks late are counted as late.
+func (p *Poller) recordLateness(j *job) {
+	if j.done != nil || j.due.IsZero() {
+		return
+	}
+	lateness := time.Since(j.due)
+	if lateness < 0 {
+		lateness = 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	j.state.LastLateness = lateness
+	if lateness > 2*p.checkInterval {
+		j.state.LatePolls++
 	}
 }
 
-// pollOLT polls a single OLT and returns the result.
+// pollOLT polls a single OLT for the metric groups that are due and returns
+// the result.
 func (p *Poller) pollOLT(ctx context.Context, state *OLTState) *PollResult {
 	start := time.Now()
 	result := &PollResult{
@@ -348,27 +517,174 @@ func (p *Poller) pollOLT(ctx context.Context, state *OLTState) *PollResult {
 		Timestamp: start,
 	}
 
-	// Determine if we need a detailed poll
-	detailedInterval := time.Duration(state.Config.Polling.DetailedInterval) * time.Second
-	if detailedInterval <= 0 {
-		detailedInterval = 10 * time.Minute // Default: 10 minutes
-	}
-	needsDetailedPoll := time.Since(state.LastDetailedPoll) >= detailedInterval
-
-	// Update last poll time
+	// Determine which metric groups are due and mark them collected
 	p.mu.Lock()
 	state.LastPoll = start
+	groups := dueGroups(state, start)
+	if state.LastCollected == nil {
+		state.LastCollected = make(map[MetricGroup]time.Time)
+	}
+	for _, g := range groups {
+		state.LastCollected[g] = start
+	}
+	selected := newGroupSet(metricGroups(state.Config.Polling))
 	p.mu.Unlock()
 
-	// Create driver
-	vendor := types.Vendor(strings.ToLower(state.Config.Vendor))
-	protocol := p.determineProtocol(state.Config)
+	result.Groups = groups
+	due := newGroupSet(groups)
+
+	driverV2, disconnect, err := p.connect(ctx, state.Config, p.determineProtocol(state.Config))
+	if err != nil {
+		result.Error = err
+		result.Unreachable = true
+		result.Duration = time.Since(start)
+		return result
+	}
+	defer disconnect()
+
+	if due.needsONUList() {
+		// Get ONU list (fast poll - basic status)
+		onus, err := driverV2.GetONUList(ctx, nil)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to get ONU list: %w", snmp.ExplainError(err))
+			result.Duration = time.Since(start)
+			return result
+		}
+
+		// If optical or traffic data is due, fetch details for each ONU
+		if due.needsONUDetails() && len(onus) > 0 {
+			result.DetailedPoll = true
+
+			// Check if driver supports detailed polling
+			if detailer, ok := driverV2.(onuDetailer); ok {
+				onus = p.pollDetails(ctx, state, detailer, onus, result)
+			}
+
+			// Update last detailed poll time
+			p.mu.Lock()
+			state.LastDetailedPoll = start
+			p.mu.Unlock()
+		}
+
+		// Convert to ONUData
+		result.ONUs = make([]ONUData, len(onus))
+		for i, onu := range onus {
+			result.ONUs[i] = onuData(onu, selected)
+		}
+	}
+
+	if due[GroupPONPorts] {
+		// Get PON port state, optical power and ONU counts
+		statuses, err := driverV2.ListPorts(ctx)
+		if err != nil {
+			p.log("Warning: failed to list PON ports for %s: %v (using ONU list)", state.Config.Name, err)
+			result.groupFailed(GroupPONPorts, err)
+		}
+		result.Ports = append(result.Ports, ponPorts(statuses, result.ONUs)...)
+	}
+
+	if due[GroupUplinkPorts] {
+		uplinks, err := p.collectUplinks(ctx, state.Config)
+		if err != nil {
+			p.log("Warning: failed to collect uplink ports for %s: %v", state.Config.Name, err)
+			result.groupFailed(GroupUplinkPorts, err)
+		} else {
+			result.Ports = append(result.Ports, uplinks...)
+		}
+	}
+
+	if due[GroupOLTSystem] {
+		// Get OLT status for telemetry (CPU, Memory, Temperature)
+		oltStatus, err := driverV2.GetOLTStatus(ctx)
+		if err != nil {
+			// Log but don't fail - ONU data is still valid
+			p.log("Warning: failed to get OLT status for %s: %v", state.Config.Name, err)
+			result.groupFailed(GroupOLTSystem, err)
+		} else if oltStatus != nil {
+			result.Telemetry = &TelemetryData{
+				CPUPercent:    oltStatus.CPUPercent,
+				MemoryPercent: oltStatus.MemoryPercent,
+				Temperature:   oltStatus.Temperature,
+				Uptime:        oltStatus.UptimeSeconds,
+				IsReachable:   oltStatus.IsReachable,
+				IsHealthy:     oltStatus.IsHealthy,
+				Firmware:      oltStatus.Firmware,
+				SerialNumber:  oltStatus.SerialNumber,
+			}
+		}
+	}
+
+	if due[GroupAlarms] {
+		alarms, err := driverV2.GetAlarms(ctx)
+		if err != nil {
+			p.log("Warning: failed to get alarms for %s: %v", state.Config.Name, err)
+			result.groupFailed(GroupAlarms, err)
+		} else {
+			result.Alarms = alarms
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// onuData converts an ONU from the driver into the control plane format.
+// Optical and traffic fields are dropped unless their group is selected.
+func onuData(onu types.ONUInfo, selected groupSet) ONUData {
+	status := "offline"
+	if onu.IsOnline {
+		status = "online"
+	} else if onu.AdminState == "disabled" || onu.OperState == "suspended" {
+		// ONU is administratively suspended
+		status = "suspended"
+	} else if onu.OperState == "los" {
+		status = "los"
+	} else if onu.OperState == "discovered" {
+		status = "discovered"
+	}
+
+	data := ONUData{
+		Serial:         onu.Serial,
+		PONPort:        onu.PONPort,
+		ONUID:          onu.ONUID,
+		Status:         status,
+		Distance:       onu.DistanceM,
+		Model:          onu.Model,
+		Vendor:         onu.Vendor,
+		ONUProfile:     onu.ONUProfile,
+		LineProfile:    onu.LineProfile,
+		ServiceProfile: onu.ServiceProfile,
+		VLAN:           onu.VLAN,
+	}
+	if selected[GroupONUOptical] {
+		data.RxPower = onu.RxPowerDBm
+		data.TxPower = onu.TxPowerDBm
+		data.Temperature = onu.Temperature
+		data.Voltage = onu.Voltage
+		data.BiasCurrent = onu.BiasCurrent
+	}
+	if selected[GroupONUTraffic] {
+		data.BytesUp = onu.BytesUp
+		data.BytesDown = onu.BytesDown
+		data.PacketsUp = onu.PacketsUp
+		data.PacketsDown = onu.PacketsDown
+		data.InputRateBps = onu.InputRateBps
+		data.OutputRateBps = onu.OutputRateBps
+		data.UptimeSeconds = onu.UptimeSeconds
+	}
+	return data
+}
+
+// connect creates a driver for an OLT over the given protocol and connects
+// it. The returned function disconnects the driver.
+func (p *Poller) connect(ctx context.Context, cfg OLTConfig, protocol types.Protocol) (types.DriverV2, func(), error) {
+	vendor := types.Vendor(strings.ToLower(cfg.Vendor))
 
 	// Build config based on protocol
 	config := &types.EquipmentConfig{
-		Name:          state.Config.ID,
+		Name:          cfg.ID,
 		Vendor:        vendor,
-		Address:       state.Config.Address,
+		Address:       cfg.Address,
 		Protocol:      protocol,
 		TLSEnabled:    false,
 		TLSSkipVerify: true,
@@ -376,145 +692,143 @@ func (p *Poller) pollOLT(ctx context.Context, state *OLTState) *PollResult {
 		Metadata:      make(map[string]string),
 	}
 
-	// Set protocol-specific configuration
+	// Set protocol-specific configuration. The driver is created with the
+	// CLI login only; vendor adapters open secondary CLI sessions with it
+	connectConfig := config
 	if protocol == types.ProtocolSNMP {
-		config.Port = state.Config.Protocols.SNMP.Port
-		// SNMP driver reads community and version from Metadata
-		config.Metadata["snmp_community"] = state.Config.Protocols.SNMP.Community
-		config.Metadata["snmp_version"] = state.Config.Protocols.SNMP.Version
-
-		// Also pass CLI credentials if available - needed for metrics that
-		// aren't available via SNMP (e.g., V-SOL CPU/Memory)
-		if state.Config.Protocols.SSH.Enabled {
-			config.Username = state.Config.Protocols.SSH.Username
-			config.Password = state.Config.Protocols.SSH.Password
-			config.Metadata["cli_host"] = state.Config.Address
-			config.Metadata["cli_port"] = fmt.Sprintf("%d", state.Config.Protocols.SSH.Port)
+		snmpCfg, err := snmp.NewDeviceConfig(cfg.ID, cfg.Address, snmp.Settings(cfg.Protocols.SNMP), p.connectTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		// SNMP driver reads its settings from Metadata
+		metadata := snmpCfg.DriverMetadata()
+		for k, v := range metadata {
+			config.Metadata[k] = v
+		}
+		config.Port = cfg.Protocols.SNMP.Port
+		config.SNMPCommunity = snmpCfg.Community
+		config.SNMPVersion = metadata["snmp_version"]
+
+		if cfg.Protocols.SSH.Enabled {
+			// Also pass CLI credentials if available - needed for metrics that
+			// aren't available via SNMP (e.g., V-SOL CPU/Memory)
+			config.Username = cfg.Protocols.SSH.Username
+			config.Password = cfg.Protocols.SSH.Password
+			config.Metadata["cli_host"] = cfg.Address
+			config.Metadata["cli_port"] = fmt.Sprintf("%d", cfg.Protocols.SSH.Port)
+		}
+
+		if snmpCfg.Version == snmp.SNMPv3 {
+			// The SNMP driver authenticates SNMPv3 with the equipment
+			// credentials of the config it is connected with
+			v3Config := *config
+			v3Config.Username, v3Config.Password, err = snmpCfg.DriverCredentials()
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid SNMP credentials for %s: %w", cfg.ID, err)
+			}
+			connectConfig = &v3Config
 		}
 	} else {
-		config.Port = state.Config.Protocols.SSH.Port
-		config.Username = state.Config.Protocols.SSH.Username
-		config.Password = state.Config.Protocols.SSH.Password
+		config.Port = cfg.Protocols.SSH.Port
+		config.Username = cfg.Protocols.SSH.Username
+		config.Password = cfg.Protocols.SSH.Password
 	}
 
 	driver, err := southbound.NewDriver(vendor, protocol, config)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to create driver: %w", err)
-		result.Duration = time.Since(start)
-		return result
+		return nil, nil, fmt.Errorf("failed to create driver: %w", err)
 	}
 
 	// Connect with timeout
 	connectCtx, cancel := context.WithTimeout(ctx, p.connectTimeout)
-	defer cancel()
 
-	if err := driver.Connect(connectCtx, config); err != nil {
-		result.Error = fmt.Errorf("failed to connect: %w", err)
-		result.Duration = time.Since(start)
-		return result
+	if err := driver.Connect(connectCtx, connectConfig); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("failed to connect: %w", snmp.ExplainError(err))
+	}
+	disconnect := func() {
+		_ = driver.Disconnect(ctx)
+		cancel()
 	}
-	defer func() { _ = driver.Disconnect(ctx) }()
 
 	// Check if driver supports DriverV2
 	driverV2, ok := driver.(types.DriverV2)
 	if !ok {
-		result.Error = fmt.Errorf("driver for vendor %s does not support ONU listing", state.Config.Vendor)
+		disconnect()
+		return nil, nil, fmt.Errorf("driver for vendor %s does not support ONU listing", cfg.Vendor)
+	}
+
+	return driverV2, disconnect, nil
+}
+
+// discoverOLT reads an OLT's autofind list, limited to the configured PON ports.
+func (p *Poller) discoverOLT(ctx context.Context, state *OLTState) *DiscoveryResult {
+	start := time.Now()
+	result := &DiscoveryResult{
+		OLTID:     state.Config.ID,
+		Timestamp: start,
+	}
+
+	// Update last discovery time
+	p.mu.Lock()
+	state.LastDiscovery = start
+	cfg := state.Config
+	p.mu.Unlock()
+
+	driverV2, disconnect, err := p.connect(ctx, cfg, p.discoveryProtocol(cfg))
+	if err != nil {
+		result.Error = err
 		result.Duration = time.Since(start)
 		return result
 	}
+	defer disconnect()
 
-	// Get ONU list (fast poll - basic status)
-	onus, err := driverV2.GetONUList(ctx, nil)
+	discoveries, err := driverV2.DiscoverONUs(ctx, cfg.Discovery.PONPorts)
 	if err != nil {
-		result.Error = fmt.Errorf("failed to get ONU list: %w", err)
+		result.Error = fmt.Errorf("failed to discover ONUs: %w", err)
 		result.Duration = time.Since(start)
 		return result
 	}
 
-	// If detailed poll is needed, fetch optical/traffic data for each ONU
-	if needsDetailedPoll && len(onus) > 0 {
-		p.log("Running detailed poll for %s (%d ONUs)", state.Config.Name, len(onus))
-		result.DetailedPoll = true
-
-		// Check if driver supports detailed polling
-		if detailProvider, ok := driverV2.(interface {
-			GetAllONUDetails(ctx context.Context, onus []types.ONUInfo) ([]types.ONUInfo, error)
-		}); ok {
-			detailedONUs, err := detailProvider.GetAllONUDetails(ctx, onus)
-			if err != nil {
-				p.log("Warning: detailed poll failed for %s: %v (using basic data)", state.Config.Name, err)
-			} else {
-				onus = detailedONUs
-			}
+	// Not every driver filters by port, so filter again here
+	result.Discoveries = make([]types.ONUDiscovery, 0, len(discoveries))
+	for _, d := range discoveries {
+		if portSelected(cfg.Discovery.PONPorts, d.PONPort) {
+			result.Discoveries = append(result.Discoveries, d)
 		}
-
-		// Update last detailed poll time
-		p.mu.Lock()
-		state.LastDetailedPoll = start
-		p.mu.Unlock()
 	}
 
-	// Convert to ONUData
-	result.ONUs = make([]ONUData, len(onus))
-	for i, onu := range onus {
-		status := "offline"
-		if onu.IsOnline {
-			status = "online"
-		} else if onu.AdminState == "disabled" || onu.OperState == "suspended" {
-			// ONU is administratively suspended
-			status = "suspended"
-		} else if onu.OperState == "los" {
-			status = "los"
-		} else if onu.OperState == "discovered" {
-			status = "discovered"
-		}
-
-		result.ONUs[i] = ONUData{
-			Serial:         onu.Serial,
-			PONPort:        onu.PONPort,
-			ONUID:          onu.ONUID,
-			Status:         status,
-			Distance:       onu.DistanceM,
-			RxPower:        onu.RxPowerDBm,
-			TxPower:        onu.TxPowerDBm,
-			Model:          onu.Model,
-			Temperature:    onu.Temperature,
-			Voltage:        onu.Voltage,
-			BiasCurrent:    onu.BiasCurrent,
-			BytesUp:        onu.BytesUp,
-			BytesDown:      onu.BytesDown,
-			PacketsUp:      onu.PacketsUp,
-			PacketsDown:    onu.PacketsDown,
-			InputRateBps:   onu.InputRateBps,
-			OutputRateBps:  onu.OutputRateBps,
-			Vendor:         onu.Vendor,
-			ONUProfile:     onu.ONUProfile,
-			LineProfile:    onu.LineProfile,
-			ServiceProfile: onu.ServiceProfile,
-			VLAN:           onu.VLAN,
-		}
-	}
-
-	// Get OLT status for telemetry (CPU, Memory, Temperature)
-	oltStatus, err := driverV2.GetOLTStatus(ctx)
-	if err != nil {
-		// Log but don't fail - ONU data is still valid
-		p.log("Warning: failed to get OLT status for %s: %v", state.Config.Name, err)
-	} else if oltStatus != nil {
-		result.Telemetry = &TelemetryData{
-			CPUPercent:    oltStatus.CPUPercent,
-			MemoryPercent: oltStatus.MemoryPercent,
-			Temperature:   oltStatus.Temperature,
-			Uptime:        oltStatus.UptimeSeconds,
-			IsReachable:   oltStatus.IsReachable,
-			IsHealthy:     oltStatus.IsHealthy,
-			Firmware:      oltStatus.Firmware,
-			SerialNumber:  oltStatus.SerialNumber,
+	result.Duration = time.Since(start)
+	return result
+}
+
+// portSelected reports whether a PON port is in ports. An empty list selects all ports.
+func portSelected(ports []string, port string) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		if p == port {
+			return true
 		}
 	}
+	return false
+}
 
-	result.Duration = time.Since(start)
-	return result
+// discoveryProtocol returns the protocol used for autofind discovery. The
+// configured protocol wins; otherwise CLI is preferred because most vendors
+// only expose the autofind list there.
+func (p *Poller) discoveryProtocol(cfg OLTConfig) types.Protocol {
+	switch strings.ToLower(cfg.Discovery.Protocol) {
+	case "snmp":
+		return types.ProtocolSNMP
+	case "cli", "ssh":
+		return types.ProtocolCLI
+	}
+	if cfg.Protocols.SSH.Enabled {
+		return types.ProtocolCLI
+	}
+	return p.determineProtocol(cfg)
 }
 
 // determineProtocol determines the best protocol to use for polling an OLT.
@@ -566,22 +880,13 @@ func (p *Poller) handleResult(result *PollResult) {
 	}
 
 	if result.Error != nil {
-		// Handle error with exponential backoff
+		// Handle error with jittered exponential backoff
 		state.LastError = result.Error
 		state.ErrorCount++
+		state.LastDuration = result.Duration
+		state.Unreachable = result.Unreachable
 
-		// Calculate backoff: min(2^errorCount * 10s, maxBackoff)
-		// Cap error count to prevent overflow (max 30 gives ~10 billion seconds)
-		var expCount uint = 1
-		if state.ErrorCount > 0 && state.ErrorCount <= 30 {
-			expCount = uint(state.ErrorCount) // #nosec G115 - bounds checked above
-		} else if state.ErrorCount > 30 {
-			expCount = 30
-		}
-		backoff := time.Duration(1<<expCount) * 10 * time.Second
-		if backoff > p.maxBackoff {
-			backoff = p.maxBackoff
-		}
+		backoff := backoffDelay(state.ErrorCount, p.maxBackoff)
 		state.BackoffUntil = time.Now().Add(backoff)
 
 		p.mu.Unlock()
@@ -590,22 +895,45 @@ func (p *Poller) handleResult(result *PollResult) {
 		return
 	}
 
-	// Success - reset error state
+	// Success - reset error state. An OLT recovering from errors or whose
+	// ONUs changed state is polled with priority for a while.
+	if state.ErrorCount > 0 || onuStatesChanged(state.LastONUs, result.ONUs) {
+		state.LastChange = result.Timestamp
+	}
+	p.adaptInterval(state, result.Duration)
 	state.LastSuccess = result.Timestamp
 	state.LastError = nil
 	state.ErrorCount = 0
 	state.BackoffUntil = time.Time{}
+	state.Unreachable = false
+	recordGroupResults(state, result)
+	computeRates(state, result)
+	readONUs := result.Collected(GroupONUStatus) || result.Collected(GroupONUOptical) || result.Collected(GroupONUTraffic)
+	if readONUs || result.Collected(GroupPONPorts) {
+		state.LastONUs = result.ONUs
+		state.LastONUsAt = result.Timestamp
+	}
 	oltName := state.Config.Name
+	portPusher := p.portPusher
+	sinks := p.sinks
+	schedMetrics := schedulerMetrics(state, time.Now().UnixMilli())
+	if result.Telemetry != nil {
+		// Other metric groups may still be stale
+		state.LastTelemetry = result.Telemetry
+		state.ReportedStale = staleGroups(state, time.Now(), p.staleAfter)
+		annotateStaleness(result.Telemetry, state, state.ReportedStale)
+	}
 	p.mu.Unlock()
 
 	pollType := "fast"
 	if result.DetailedPoll {
 		pollType = "detailed"
 	}
-	p.log("Poll succeeded for %s: %d ONUs in %s (%s poll)", oltName, len(result.ONUs), result.Duration, pollType)
+	p.log("Poll succeeded for %s: %d ONUs in %s (%s poll, groups: %s)",
+		oltName, len(result.ONUs), result.Duration, pollType, joinGroups(result.Groups))
 
 	// Push ONUs to control plane
-	if p.pusher != nil && len(result.ONUs) > 0 {
+	if p.pusher != nil && readONUs && len(result.ONUs) > 0 {
 		resp, err := p.pusher.PushONUs(result.OLTID, result.ONUs)
 		if err != nil {
 			p.log("Failed to push ONUs for %s: %v", oltName, err)
@@ -626,47 +954,193 @@ func (p *Poller) handleResult(result *PollResult) {
 		}
 	}
 
+	// Push PON and uplink ports to control plane
+	if portPusher != nil && len(result.Ports) > 0 {
+		if _, err := portPusher.PushPorts(result.OLTID, result.Ports); err != nil {
+			p.log("Failed to push ports for %s: %v", oltName, err)
+		}
+	}
+
+	if p.metricsPusher == nil && len(sinks) == 0 {
+		return
+	}
+	batch := p.buildMetricsBatch(result, oltName)
+	batch.Metrics = append(batch.Metrics, schedMetrics...)
+
+	// Hand the result to the sinks, which write it in the background
+	if len(sinks) > 0 {
+		record := &SinkRecord{
+			OLTID:     result.OLTID,
+			OLTName:   oltName,
+			Timestamp: result.Timestamp,
+			Groups:    result.Groups,
+			Telemetry: result.Telemetry,
+			Ports:     result.Ports,
+			Metrics:   batch.Metrics,
+		}
+		if readONUs {
+			record.ONUs = result.ONUs
+		}
+		for _, sink := range sinks {
+			sink.Submit(record)
+		}
+	}
+
 	// Push metrics to control plane for time-series storage
-	if p.metricsPusher != nil {
-		batch := p.buildMetricsBatch(result, oltName)
-		if len(batch.Metrics) > 0 {
-			resp, err := p.metricsPusher.PushMetrics(batch)
-			if err != nil {
-				p.log("Failed to push metrics for %s: %v", oltName, err)
-			} else if resp != nil && resp.Success {
-				p.log("Pushed %d metrics for %s", resp.Count, oltName)
-			}
+	if p.metricsPusher != nil && len(batch.Metrics) > 0 {
+		resp, err := p.metricsPusher.PushMetrics(batch)
+		if err != nil {
+			p.log("Failed to push metrics for %s: %v", oltName, err)
+		} else if resp != nil && resp.Success {
+			p.log("Pushed %d metrics for %s", resp.Count, oltName)
 		}
 	}
 }
 
+// handleDiscoveryResult pushes the changes to an OLT's autofind list and
+// hands newly discovered ONUs to the discovery handler.
+func (p *Poller) handleDiscoveryResult(result *DiscoveryResult) {
+	p.mu.Lock()
+	state, exists := p.oltStates[result.OLTID]
+	if !exists {
+		p.mu.Unlock()
+		return
+	}
+	oltName := state.Config.Name
+
+	if result.Error != nil {
+		// The next discovery runs at the normal interval; polling errors
+		// already back off unreachable OLTs
+		p.mu.Unlock()
+		p.log("Autofind discovery failed for %s: %v", oltName, result.Error)
+		return
+	}
+
+	report, added := p.trackAutofind(state, result.Discoveries, result.Timestamp)
+	onDiscovery := p.onDiscovery
+	autofindPusher := p.autofindPusher
+	p.mu.Unlock()
+
+	p.log("Autofind discovery for %s: %d new, %d persisting, %d vanished in %s",
+		oltName, len(report.New), len(report.Persisting), len(report.Vanished), result.Duration)
+
+	// Push autofind changes to control plane
+	if autofindPusher != nil && len(report.New)+len(report.Persisting)+len(report.Vanished) > 0 {
+		if _, err := autofindPusher.PushAutofind(result.OLTID, report); err != nil {
+			p.log("Failed to push autofind entries for %s: %v", oltName, err)
+		}
+	}
+
+	// Hand newly discovered ONUs to the discovery handler
+	if onDiscovery != nil && len(added) > 0 {
+		onDiscovery(result.OLTID, added)
+	}
+}
+
+// trackAutofind replaces the OLT's autofind entries with the latest
+// discovery and reports what changed. It also returns the discoveries that
+// were not in the list before. Callers hold p.mu.
+func (p *Poller) trackAutofind(state *OLTState, discoveries []types.ONUDiscovery, now time.Time) (*AutofindReport, []types.ONUDiscovery) {
+	report := &AutofindReport{DiscoveredAt: now}
+	current := make(map[string]AutofindEntry, len(discoveries))
+	var added []types.ONUDiscovery
+
+	for _, d := range discoveries {
+		if _, dup := current[d.Serial]; dup || d.Serial == "" {
+			continue
+		}
+		entry := AutofindEntry{
+			Serial:    d.Serial,
+			PONPort:   d.PONPort,
+			Model:     d.Model,
+			Vendor:    d.Vendor,
+			MAC:       d.MAC,
+			Distance:  d.DistanceM,
+			RxPower:   d.RxPowerDBm,
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+		if previous, ok := state.Autofind[d.Serial]; ok {
+			entry.FirstSeen = previous.FirstSeen
+			report.Persisting = append(report.Persisting, entry)
+		} else {
+			report.New = append(report.New, entry)
+			added = append(added, d)
+		}
+		current[d.Serial] = entry
+	}
+
+	for serial, entry := range state.Autofind {
+		if _, ok := current[serial]; !ok {
+			report.Vanished = append(report.Vanished, entry)
+		}
+	}
+	sort.Slice(report.Vanished, func(i, j int) bool { return report.Vanished[i].Serial < report.Vanished[j].Serial })
+
+	state.Autofind = current
+	return report, added
+}
+
 // TriggerDetailedPoll triggers an immediate detailed poll for a specific OLT.
+// While the poller runs, the probe is queued ahead of scheduled polls.
 // Returns the poll result or an error if the OLT is not found.
 func (p *Poller) TriggerDetailedPoll(ctx context.Context, oltID string) (*PollResult, error) {
-	p.mu.RLock()
+	p.mu.Lock()
 	state, exists := p.oltStates[oltID]
-	p.mu.RUnlock()
-
 	if !exists {
+		p.mu.Unlock()
 		return nil, fmt.Errorf("OLT %s not found", oltID)
 	}
 
-	p.log("Manual probe triggered for %s", state.Config.Name)
-
-	// Force detailed poll by setting LastDetailedPoll to zero
-	p.mu.Lock()
+	// Force a poll of every metric group by forgetting when they were collected
 	state.LastDetailedPoll = time.Time{}
+	state.LastCollected = nil
+	running := p.running
+	queue := p.queue
 	p.mu.Unlock()
 
-	// Run the poll synchronously
-	result := p.pollOLT(ctx, state)
+	p.log("Manual probe triggered for %s", state.Config.Name)
 
-	// Process the result (push data)
-	if result.Error == nil {
-		p.handleResult(result)
+	if !running {
+		// Run the poll synchronously
+		result := p.pollOLT(ctx, state)
+
+		// Process the result (push data)
+		if result.Error == nil {
+			p.handleResult(result)
+		}
+		return result, result.Error
 	}
 
-	return result, result.Error
+	j := &job{state: state, kind: jobPoll, priority: priorityManual, due: time.Now(), done: make(chan *PollResult, 1)}
+	if !queue.push(j) {
+		return nil, fmt.Errorf("poller stopped")
+	}
+	select {
+	case result, ok := <-j.done:
+		if !ok || result == nil {
+			return nil, fmt.Errorf("poller stopped")
+		}
+		return result, result.Error
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ONUSnapshot returns a copy of the ONUs seen by the latest successful poll
+// of an OLT and when that poll ran. ok is false until the OLT has been polled.
+func (p *Poller) ONUSnapshot(oltID string) (onus []ONUData, at time.Time, ok bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	state, exists := p.oltStates[oltID]
+	if !exists || state.LastONUsAt.IsZero() {
+		return nil, time.Time{}, false
+	}
+
+	onus = make([]ONUData, len(state.LastONUs))
+	copy(onus, state.LastONUs)
+	return onus, state.LastONUsAt, true
 }
 
 // GetStats returns current polling statistics.
@@ -679,16 +1153,29 @@ func (p *Poller) GetStats() map[string]interface{} {
 		"worker_count": p.workerCount,
 		"olt_count":    len(p.oltStates),
 	}
+	if p.queue != nil {
+		stats["queued_jobs"] = p.queue.len()
+	}
 
 	oltStats := make([]map[string]interface{}, 0, len(p.oltStates))
 	for id, state := range p.oltStates {
 		oltStat := map[string]interface{}{
-			"id":                 id,
-			"name":               state.Config.Name,
-			"last_poll":          state.LastPoll,
-			"last_detailed_poll": state.LastDetailedPoll,
-			"last_success":       state.LastSuccess,
-			"error_count":        state.ErrorCount,
+			"id":                  id,
+			"name":                state.Config.Name,
+			"last_poll":           state.LastPoll,
+			"last_detailed_poll":  state.LastDetailedPoll,
+			"last_success":        state.LastSuccess,
+			"error_count":         state.ErrorCount,
+			"last_discovery":      state.LastDiscovery,
+			"autofind_count":      len(state.Autofind),
+			"metric_groups":       metricGroups(state.Config.Polling),
+			"interval_stretch":    state.Stretch,
+			"skipped_polls":       state.SkippedPolls,
+			"late_polls":          state.LatePolls,
+			"last_lateness":       state.LastLateness.String(),
+			"detail_cycle_active": state.detail != nil,
+			"unreachable":         state.Unreachable,
+			"stale_groups":        staleGroups(state, time.Now(), p.staleAfter),
 		}
 		if state.LastError != nil {
 			oltStat["last_error"] = state.LastError.Error()
@@ -700,7 +1187,8 @@ func (p *Poller) GetStats() map[string]interface{} {
 	return stats
 }
 
-// buildMetricsBatch converts a poll result into a metrics batch for time-series storage.
+// buildMetricsBatch converts a poll result into a metrics batch for
+// time-series storage. Only the metric groups collected by the poll are included.
 func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsBatch {
 	now := time.Now().UnixMilli()
 	metrics := make([]MetricSample, 0)
@@ -711,7 +1199,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 	}
 
 	// OLT telemetry metrics
-	if result.Telemetry != nil {
+	if result.Collected(GroupOLTSystem) && result.Telemetry != nil {
 		if result.Telemetry.CPUPercent > 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "olt_cpu_percent",
@@ -739,7 +1227,12 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 	}
 
 	// ONU metrics
+	optical := result.Collected(GroupONUOptical)
+	traffic := result.Collected(GroupONUTraffic)
 	for _, onu := range result.ONUs {
+		if !optical && !traffic {
+			break
+		}
 		onuLabels := map[string]string{
 			"olt_id":     result.OLTID,
 			"olt_name":   oltName,
@@ -748,7 +1241,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 		}
 
 		// Optical power metrics
-		if onu.RxPower != 0 {
+		if optical && onu.RxPower != 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_rx_power_dbm",
 				Value:     onu.RxPower,
@@ -756,7 +1249,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
-		if onu.TxPower != 0 {
+		if optical && onu.TxPower != 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_tx_power_dbm",
 				Value:     onu.TxPower,
@@ -766,7 +1259,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 		}
 
 		// Thermal metrics (from detailed poll)
-		if onu.Temperature != 0 {
+		if optical && onu.Temperature != 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_temperature_celsius",
 				Value:     onu.Temperature,
@@ -774,7 +1267,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
-		if onu.Voltage != 0 {
+		if optical && onu.Voltage != 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_voltage_volts",
 				Value:     onu.Voltage,
@@ -782,7 +1275,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
-		if onu.BiasCurrent != 0 {
+		if optical && onu.BiasCurrent != 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_bias_current_ma",
 				Value:     onu.BiasCurrent,
@@ -792,7 +1285,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 		}
 
 		// Traffic metrics (as counters, from detailed poll)
-		if onu.BytesUp > 0 {
+		if traffic && onu.BytesUp > 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_bytes_up_total",
 				Value:     float64(onu.BytesUp),
@@ -800,7 +1293,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
-		if onu.BytesDown > 0 {
+		if traffic && onu.BytesDown > 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_bytes_down_total",
 				Value:     float64(onu.BytesDown),
@@ -808,7 +1301,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
-		if onu.PacketsUp > 0 {
+		if traffic && onu.PacketsUp > 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_packets_up_total",
 				Value:     float64(onu.PacketsUp),
@@ -816,7 +1309,7 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
-		if onu.PacketsDown > 0 {
+		if traffic && onu.PacketsDown > 0 {
 			metrics = append(metrics, MetricSample{
 				Name:      "onu_packets_down_total",
 				Value:     float64(onu.PacketsDown),
@@ -824,28 +1317,54 @@ func (p *Poller) buildMetricsBatch(result *PollResult, oltName string) *MetricsB
 				Labels:    onuLabels,
 			})
 		}
+
+		// Traffic rates (from counter deltas)
+		if traffic {
+			for _, rate := range result.rates[onuRateKey(onu.Serial)] {
+				metrics = append(metrics, MetricSample{
+					Name:      rate.Name,
+					Value:     rate.Value,
+					Timestamp: now,
+					Labels:    onuLabels,
+				})
+			}
+		}
 	}
 
-	// Aggregate ONU counts by PON port (vendor-safe: skip empty PONPort)
-	ponPortCounts := make(map[string]int)
-	for _, onu := range result.ONUs {
-		if onu.PONPort != "" {
-			ponPortCounts[onu.PONPort]++
-		}
-	}
-
-	// Add PON port ONU count metrics
-	for ponPort, count := range ponPortCounts {
-		metrics = append(metrics, MetricSample{
-			Name:      "pon_port_onu_count",
-			Value:     float64(count),
-			Timestamp: now,
-			Labels: map[string]string{
-				"olt_id":   result.OLTID,
-				"olt_name": oltName,
-				"pon_port": ponPort,
-			},
-		})
+	// PON and uplink port metrics
+	for _, port := range result.Ports {
+		if port.Type == PortTypeUplink && !result.Collected(GroupUplinkPorts) ||
+			port.Type == PortTypePON && !result.Collected(GroupPONPorts) {
+			continue
+		}
+		metrics = append(metrics, portMetrics(port, result.rates[uplinkRateKey(port.Port)], result.OLTID, oltName, now)...)
+	}
+
+	// Active alarm counts by severity
+	if result.Collected(GroupAlarms) {
+		alarmCounts := make(map[string]int)
+		for _, alarm := range result.Alarms {
+			if alarm.ClearedAt != nil {
+				continue
+			}
+			severity := strings.ToLower(alarm.Severity)
+			if severity == "" {
+				severity = "unknown"
+			}
+			alarmCounts[severity]++
+		}
+		for severity, count := range alarmCounts {
+			metrics = append(metrics, MetricSample{
+				Name:      "olt_active_alarms",
+				Value:     float64(count),
+				Timestamp: now,
+				Labels: map[string]string{
+					"olt_id":   result.OLTID,
+					"olt_name": oltName,
+					"severity": severity,
+				},
+			})
+		}
 	}
 
 	return &MetricsBatch{Metrics: metrics}
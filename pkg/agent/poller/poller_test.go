@@ -0,0 +1,111 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	records []*SinkRecord
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Submit(record *SinkRecord) { s.records = append(s.records, record) }
+
+func TestHandleResultSubmitsToSinks(t *testing.T) {
+	p := New(nil, nil, nil, nil)
+	first, second := &recordingSink{}, &recordingSink{}
+	p.AddSink(first)
+	p.AddSink(second)
+	p.UpdateOLTs([]OLTConfig{{ID: "olt-1", Name: "OLT 1", Polling: OLTPollingConfig{Enabled: true, Interval: 60}}})
+
+	p.handleResult(&PollResult{
+		OLTID:     "olt-1",
+		Timestamp: time.Now(),
+		Groups:    []MetricGroup{GroupONUStatus, GroupOLTSystem},
+		ONUs:      []ONUData{{Serial: "HWTC12345678", Status: "online"}},
+		Telemetry: &TelemetryData{CPUPercent: 30, IsReachable: true},
+	})
+
+	require.Len(t, first.records, 1)
+	require.Len(t, second.records, 1)
+	record := first.records[0]
+	assert.Equal(t, "OLT 1", record.OLTName)
+	assert.Len(t, record.ONUs, 1)
+	assert.Equal(t, 30.0, record.Telemetry.CPUPercent)
+	assert.NotEmpty(t, record.Metrics)
+
+	// Failed polls are not written
+	p.handleResult(&PollResult{OLTID: "olt-1", Error: assert.AnError})
+	assert.Len(t, first.records, 1)
+}
+
+func TestConvertOLTConfigsSNMPv3(t *testing.T) {
+	configs := ConvertOLTConfigs([]agent.OLTConfig{{
+		ID: "olt-1",
+		Protocols: agent.OLTProtocols{SNMP: agent.SNMPConfig{
+			Enabled:      true,
+			Version:      "3",
+			Username:     "nms",
+			AuthProtocol: "SHA",
+			AuthPassword: "authpass1",
+			PrivProtocol: "AES",
+			PrivPassword: "privpass1",
+		}},
+	}})
+	require.Len(t, configs, 1)
+	snmpCfg := configs[0].Protocols.SNMP
+	assert.Equal(t, "nms", snmpCfg.Username)
+	assert.Equal(t, "SHA", snmpCfg.AuthProtocol)
+	assert.Equal(t, "authpass1", snmpCfg.AuthPassword)
+	assert.Equal(t, "AES", snmpCfg.PrivProtocol)
+	assert.Equal(t, "privpass1", snmpCfg.PrivPassword)
+}
+
+func TestConnectRejectsBadSNMPv3Credentials(t *testing.T) {
+	p := New(nil, nil, nil, nil)
+	cfg := OLTConfig{
+		ID:      "olt-1",
+		Vendor:  "huawei",
+		Address: "192.0.2.1",
+		Protocols: OLTProtocols{SNMP: SNMPConfig{
+			Enabled:      true,
+			Port:         161,
+			Version:      "3",
+			AuthProtocol: "SHA",
+			AuthPassword: "authpass1",
+		}},
+	}
+
+	_, _, err := p.connect(context.Background(), cfg, types.ProtocolSNMP)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid SNMP credentials for olt-1")
+	assert.Contains(t, err.Error(), "requires a username")
+
+	// Uplinks are read with the same credentials
+	_, err = p.collectUplinks(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a username")
+
+	// The OLT driver only speaks SHA/AES with one password
+	cfg.Protocols.SNMP.Username = "nms"
+	_, _, err = p.connect(context.Background(), cfg, types.ProtocolSNMP)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SHA/none")
+}
+
+func TestGroupErrorsExplainSNMPv3Failures(t *testing.T) {
+	result := &PollResult{}
+	result.groupFailed(GroupPONPorts, fmt.Errorf("SNMP query failed: %w", gosnmp.ErrUnknownUsername))
+	assert.ErrorIs(t, result.GroupErrors[GroupPONPorts], gosnmp.ErrUnknownUsername)
+	assert.Contains(t, result.GroupErrors[GroupPONPorts].Error(), "SNMPv3 user is not configured on the device")
+}
@@ -0,0 +1,216 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nanoncore/nano-agent/pkg/snmp"
+	"github.com/nanoncore/nano-southbound/types"
+)
+
+// PortPusher is the interface for pushing PON and uplink port data to the control plane.
+type PortPusher interface {
+	PushPorts(oltID string, ports []PortData) (*PushPortsResponse, error)
+}
+
+// uplinkNamePrefixes are interface name prefixes of Ethernet ports, which
+// are the uplinks on an OLT.
+var uplinkNamePrefixes = []string{
+	"eth", "ge", "xge", "10ge", "25ge", "40ge", "100ge",
+	"gigabit", "tengig", "fortygig", "hundredgig", "uplink",
+}
+
+// nonUplinkNameParts mark interfaces that are never uplinks, even when
+// they share a prefix with one (e.g. "eth-mgmt" or "gpon-olt").
+var nonUplinkNameParts = []string{"pon", "onu", "ont", "mgmt", "meth", "vlan", "loop", "null"}
+
+// ponPorts converts the driver's PON port list into port data. ONU counts the
+// driver does not report are taken from the ONU list, and ports that only
+// appear in the ONU list are added.
+func ponPorts(statuses []*types.PONPortStatus, onus []ONUData) []PortData {
+	onuCounts := make(map[string]int)
+	for _, onu := range onus {
+		if onu.PONPort != "" {
+			onuCounts[onu.PONPort]++
+		}
+	}
+
+	ports := make([]PortData, 0, len(statuses))
+	seen := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		if status == nil || status.Port == "" || seen[status.Port] {
+			continue
+		}
+		seen[status.Port] = true
+
+		onuCount := status.ONUCount
+		if onuCount == 0 {
+			onuCount = onuCounts[status.Port]
+		}
+		ports = append(ports, PortData{
+			Port:        status.Port,
+			Type:        PortTypePON,
+			AdminState:  status.AdminState,
+			OperState:   status.OperState,
+			Description: status.Description,
+			ONUCount:    onuCount,
+			MaxONUs:     status.MaxONUs,
+			TxPower:     status.TxPowerDBm,
+			RxPower:     status.RxPowerDBm,
+		})
+	}
+
+	for port, count := range onuCounts {
+		if !seen[port] {
+			ports = append(ports, PortData{Port: port, Type: PortTypePON, ONUCount: count})
+		}
+	}
+
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Port < ports[j].Port })
+	return ports
+}
+
+// uplinkPorts converts IF-MIB interfaces into port data. Only the named
+// interfaces are kept; without names, Ethernet interfaces are detected by name.
+func uplinkPorts(interfaces []snmp.InterfaceStats, names []string) []PortData {
+	ports := make([]PortData, 0)
+	for _, iface := range interfaces {
+		if len(names) > 0 {
+			if !portSelected(names, iface.Name) {
+				continue
+			}
+		} else if !isUplinkInterface(iface.Name) {
+			continue
+		}
+
+		ports = append(ports, PortData{
+			Port:        iface.Name,
+			Type:        PortTypeUplink,
+			AdminState:  iface.AdminStatus,
+			OperState:   iface.OperStatus,
+			Description: iface.Alias,
+			SpeedMbps:   iface.SpeedMbps,
+			BytesIn:     iface.InOctets,
+			BytesOut:    iface.OutOctets,
+			PacketsIn:   iface.InPackets,
+			PacketsOut:  iface.OutPackets,
+			ErrorsIn:    iface.InErrors,
+			ErrorsOut:   iface.OutErrors,
+			DiscardsIn:  iface.InDiscards,
+			DiscardsOut: iface.OutDiscards,
+			Counters32:  iface.Counters32,
+		})
+	}
+	return ports
+}
+
+// isUplinkInterface reports whether an interface name looks like an Ethernet uplink.
+func isUplinkInterface(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, part := range nonUplinkNameParts {
+		if strings.Contains(name, part) {
+			return false
+		}
+	}
+	for _, prefix := range uplinkNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectUplinks reads uplink interface state and counters from IF-MIB.
+// Uplinks are read over SNMP for every vendor, so SNMP must be enabled.
+func (p *Poller) collectUplinks(ctx context.Context, cfg OLTConfig) ([]PortData, error) {
+	if !cfg.Protocols.SNMP.Enabled {
+		return nil, fmt.Errorf("uplink ports require SNMP")
+	}
+
+	device, err := snmp.NewDeviceConfig(cfg.ID, cfg.Address, snmp.Settings(cfg.Protocols.SNMP), p.connectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	collector := snmp.NewBaseCollector(device)
+	if err := collector.Connect(); err != nil {
+		return nil, err
+	}
+	defer collector.Close()
+
+	interfaces, err := collector.CollectInterfaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return uplinkPorts(interfaces, cfg.Polling.UplinkPorts), nil
+}
+
+// portMetrics converts a port and its traffic rates into metric samples for
+// time-series storage.
+func portMetrics(port PortData, rates []counterRate, oltID, oltName string, now int64) []MetricSample {
+	labels := map[string]string{
+		"olt_id":   oltID,
+		"olt_name": oltName,
+	}
+	prefix := "pon_port_"
+	if port.Type == PortTypeUplink {
+		prefix = "uplink_port_"
+		labels["port"] = port.Port
+	} else {
+		labels["pon_port"] = port.Port
+	}
+
+	metrics := make([]MetricSample, 0)
+	add := func(name string, value float64) {
+		metrics = append(metrics, MetricSample{
+			Name:      prefix + name,
+			Value:     value,
+			Timestamp: now,
+			Labels:    labels,
+		})
+	}
+
+	if port.OperState != "" {
+		up := 0.0
+		if strings.EqualFold(port.OperState, "up") || strings.EqualFold(port.OperState, "online") {
+			up = 1
+		}
+		add("oper_up", up)
+	}
+
+	if port.Type == PortTypeUplink {
+		if port.SpeedMbps > 0 {
+			add("speed_mbps", float64(port.SpeedMbps))
+		}
+		add("bytes_in_total", float64(port.BytesIn))
+		add("bytes_out_total", float64(port.BytesOut))
+		add("packets_in_total", float64(port.PacketsIn))
+		add("packets_out_total", float64(port.PacketsOut))
+		add("errors_in_total", float64(port.ErrorsIn))
+		add("errors_out_total", float64(port.ErrorsOut))
+		add("discards_in_total", float64(port.DiscardsIn))
+		add("discards_out_total", float64(port.DiscardsOut))
+		for _, rate := range rates {
+			metrics = append(metrics, MetricSample{
+				Name:      rate.Name,
+				Value:     rate.Value,
+				Timestamp: now,
+				Labels:    labels,
+			})
+		}
+		return metrics
+	}
+
+	add("onu_count", float64(port.ONUCount))
+	if port.MaxONUs > 0 {
+		add("utilization_ratio", float64(port.ONUCount)/float64(port.MaxONUs))
+	}
+	if port.TxPower != 0 {
+		add("tx_power_dbm", port.TxPower)
+	}
+	if port.RxPower != 0 {
+		add("rx_power_dbm", port.RxPower)
+	}
+	return metrics
+}
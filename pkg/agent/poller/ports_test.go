@@ -0,0 +1,82 @@
+package poller
+
+import (
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/snmp"
+	"github.com/nanoncore/nano-southbound/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPONPorts(t *testing.T) {
+	statuses := []*types.PONPortStatus{
+		{Port: "0/1/0", OperState: "up", ONUCount: 5, MaxONUs: 128, TxPowerDBm: 4.2},
+		{Port: "0/1/1", OperState: "up"},
+		nil,
+	}
+	onus := []ONUData{
+		{Serial: "A", PONPort: "0/1/1"},
+		{Serial: "B", PONPort: "0/1/1"},
+		{Serial: "C", PONPort: "0/1/2"},
+	}
+
+	ports := ponPorts(statuses, onus)
+	require.Len(t, ports, 3)
+	assert.Equal(t, PortData{Port: "0/1/0", Type: PortTypePON, OperState: "up", ONUCount: 5, MaxONUs: 128, TxPower: 4.2}, ports[0])
+	assert.Equal(t, 2, ports[1].ONUCount, "ONU count falls back to the ONU list")
+	assert.Equal(t, PortData{Port: "0/1/2", Type: PortTypePON, ONUCount: 1}, ports[2])
+
+	// Without a port list, ports come from the ONU list alone
+	assert.Len(t, ponPorts(nil, onus), 2)
+}
+
+func TestUplinkPorts(t *testing.T) {
+	interfaces := []snmp.InterfaceStats{
+		{Index: 1, Name: "GPON 0/1/0"},
+		{Index: 2, Name: "xge0/9/0", OperStatus: "up", SpeedMbps: 10000, InOctets: 100, InErrors: 2},
+		{Index: 3, Name: "ethernet0/9/1", OperStatus: "down", Counters32: true},
+		{Index: 4, Name: "meth0"},
+		{Index: 5, Name: "vlanif100"},
+	}
+
+	ports := uplinkPorts(interfaces, nil)
+	require.Len(t, ports, 2)
+	assert.Equal(t, "xge0/9/0", ports[0].Port)
+	assert.Equal(t, PortTypeUplink, ports[0].Type)
+	assert.Equal(t, uint64(100), ports[0].BytesIn)
+	assert.Equal(t, uint64(2), ports[0].ErrorsIn)
+	assert.False(t, ports[0].Counters32)
+	assert.Equal(t, "ethernet0/9/1", ports[1].Port)
+	assert.True(t, ports[1].Counters32, "32-bit ifTable counters are marked")
+
+	ports = uplinkPorts(interfaces, []string{"ethernet0/9/1"})
+	require.Len(t, ports, 1)
+	assert.Equal(t, "ethernet0/9/1", ports[0].Port)
+}
+
+func TestPortMetrics(t *testing.T) {
+	names := func(samples []MetricSample) map[string]float64 {
+		values := make(map[string]float64)
+		for _, s := range samples {
+			values[s.Name] = s.Value
+		}
+		return values
+	}
+
+	pon := portMetrics(PortData{Port: "0/1/0", Type: PortTypePON, OperState: "up", ONUCount: 32, MaxONUs: 128, TxPower: 4.2}, nil, "olt-1", "OLT 1", 0)
+	assert.Equal(t, map[string]float64{
+		"pon_port_oper_up":           1,
+		"pon_port_onu_count":         32,
+		"pon_port_utilization_ratio": 0.25,
+		"pon_port_tx_power_dbm":      4.2,
+	}, names(pon))
+	assert.Equal(t, "0/1/0", pon[0].Labels["pon_port"])
+
+	uplink := portMetrics(PortData{Port: "xge0/9/0", Type: PortTypeUplink, OperState: "down", ErrorsIn: 7}, []counterRate{{Name: "uplink_port_bytes_in_per_second", Value: 125}}, "olt-1", "OLT 1", 0)
+	values := names(uplink)
+	assert.Equal(t, 0.0, values["uplink_port_oper_up"])
+	assert.Equal(t, 7.0, values["uplink_port_errors_in_total"])
+	assert.Equal(t, 125.0, values["uplink_port_bytes_in_per_second"])
+	assert.Equal(t, "xge0/9/0", uplink[0].Labels["port"])
+}
@@ -0,0 +1,247 @@
+package poller
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// maxByteRate and maxPacketRate bound what a single counter can
+	// plausibly move per second (100 Gbps line rate). A 32-bit counter that
+	// went backwards is only treated as a wrap if the wrapped delta stays
+	// within these bounds; otherwise it was reset by a reboot.
+	maxByteRate   = 100e9 / 8
+	maxPacketRate = 150e6
+)
+
+// counterWidth is the width of a cumulative counter in bits.
+type counterWidth int
+
+const (
+	counter32 counterWidth = 32 // e.g. IF-MIB ifTable counters
+	counter64 counterWidth = 64
+)
+
+// counterSample is a reading of a cumulative counter.
+type counterSample struct {
+	Value uint64
+	At    time.Time
+}
+
+// counterDelta returns how far a cumulative counter of the given width
+// moved from prev to cur. ok is false for resets, where no delta can be
+// computed. 64-bit counters cannot wrap in a polling interval, so for them a
+// decrease is always a reset; a 32-bit counter that went backwards wrapped,
+// unless the wrapped delta is implausible. Resets the counter climbed back
+// from are detected by uptime, see computeRates.
+func counterDelta(prev, cur uint64, width counterWidth, elapsed time.Duration, maxRate float64) (delta uint64, ok bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	if width != counter32 || prev > math.MaxUint32 {
+		return 0, false
+	}
+	delta = math.MaxUint32 - prev + cur + 1
+	if float64(delta)/elapsed.Seconds() > maxRate {
+		return 0, false
+	}
+	return delta, true
+}
+
+// counterRate is a per-second rate computed from two counter samples.
+type counterRate struct {
+	// Name is the rate metric name, e.g. onu_bytes_up_per_second
+	Name  string
+	Value float64
+}
+
+// rateTracker computes per-second rates from successive samples of the
+// cumulative counters of an OLT. Callers hold p.mu.
+type rateTracker struct {
+	samples map[string]counterSample
+}
+
+// observe records a counter sample under key and returns the rate since
+// the previous sample. ok is false for the first sample, a counter reset, or
+// samples taken at the same time.
+func (t *rateTracker) observe(key string, value uint64, width counterWidth, at time.Time, maxRate float64) (rate float64, ok bool) {
+	if t.samples == nil {
+		t.samples = make(map[string]counterSample)
+	}
+	prev, exists := t.samples[key]
+	t.samples[key] = counterSample{Value: value, At: at}
+	if !exists {
+		return 0, false
+	}
+
+	elapsed := at.Sub(prev.At)
+	if elapsed <= 0 {
+		return 0, false
+	}
+	delta, ok := counterDelta(prev.Value, value, width, elapsed, maxRate)
+	if !ok {
+		return 0, false
+	}
+	return float64(delta) / elapsed.Seconds(), true
+}
+
+// forget drops the samples whose key starts with prefix, so the next sample
+// starts a new baseline.
+func (t *rateTracker) forget(prefix string) {
+	for key := range t.samples {
+		if strings.HasPrefix(key, prefix) {
+			delete(t.samples, key)
+		}
+	}
+}
+
+// forgetBefore drops the samples whose key starts with prefix and that were
+// taken before cutoff, such as those from before a device restarted.
+func (t *rateTracker) forgetBefore(prefix string, cutoff time.Time) {
+	for key, sample := range t.samples {
+		if strings.HasPrefix(key, prefix) && sample.At.Before(cutoff) {
+			delete(t.samples, key)
+		}
+	}
+}
+
+// restartedAt returns when a device with the given uptime in seconds last
+// started, or the zero time if the uptime is unknown.
+func restartedAt(at time.Time, uptime int64) time.Time {
+	if uptime <= 0 {
+		return time.Time{}
+	}
+	return at.Add(-time.Duration(uptime) * time.Second)
+}
+
+// prune forgets samples taken before cutoff, such as those of removed ONUs.
+func (t *rateTracker) prune(cutoff time.Time) {
+	for key, sample := range t.samples {
+		if sample.At.Before(cutoff) {
+			delete(t.samples, key)
+		}
+	}
+}
+
+// counterRateTTL returns how long counter samples of an OLT are kept
+// without a new reading: three intervals of the slowest counter group.
+func counterRateTTL(cfg OLTPollingConfig) time.Duration {
+	ttl := groupInterval(cfg, GroupONUTraffic)
+	if uplink := groupInterval(cfg, GroupUplinkPorts); uplink > ttl {
+		ttl = uplink
+	}
+	return 3 * ttl
+}
+
+// computeRates computes traffic rates for the ONUs and uplink ports of a
+// poll result from the counters of the previous poll. Rates the vendor
+// already reported are kept in ONUData, but rate metrics are always computed
+// from counters so they are consistent across vendors. Callers hold p.mu.
+func computeRates(state *OLTState, result *PollResult) {
+	at := result.Timestamp
+	t := &state.Counters
+	result.rates = make(map[string][]counterRate)
+
+	// An OLT that rebooted since the previous sample reset all its counters
+	if result.Telemetry != nil {
+		t.forgetBefore("", restartedAt(at, result.Telemetry.Uptime))
+	}
+
+	if result.Collected(GroupONUTraffic) {
+		for i := range result.ONUs {
+			onu := &result.ONUs[i]
+			if onu.Serial == "" {
+				continue
+			}
+			key := onuRateKey(onu.Serial)
+
+			// Counters of ONUs left for the next detailed poll were not read
+			if !result.hasDetails(onu.Serial) {
+				continue
+			}
+
+			// Counters of an ONU that is down are reset when it comes back,
+			// and so are those of an ONU that restarted since its last sample
+			if onu.Status != "online" {
+				t.forget(key + "/")
+				continue
+			}
+			t.forgetBefore(key+"/", restartedAt(at, onu.UptimeSeconds))
+
+			// Drivers report ONU counters as 64-bit values
+			var rates []counterRate
+			if rate, ok := t.observe(key+"/bytes_up", onu.BytesUp, counter64, at, maxByteRate); ok {
+				rates = append(rates, counterRate{Name: "onu_bytes_up_per_second", Value: rate})
+				if onu.OutputRateBps == 0 {
+					onu.OutputRateBps = uint64(rate)
+				}
+			}
+			if rate, ok := t.observe(key+"/bytes_down", onu.BytesDown, counter64, at, maxByteRate); ok {
+				rates = append(rates, counterRate{Name: "onu_bytes_down_per_second", Value: rate})
+				if onu.InputRateBps == 0 {
+					onu.InputRateBps = uint64(rate)
+				}
+			}
+			if rate, ok := t.observe(key+"/packets_up", onu.PacketsUp, counter64, at, maxPacketRate); ok {
+				rates = append(rates, counterRate{Name: "onu_packets_up_per_second", Value: rate})
+			}
+			if rate, ok := t.observe(key+"/packets_down", onu.PacketsDown, counter64, at, maxPacketRate); ok {
+				rates = append(rates, counterRate{Name: "onu_packets_down_per_second", Value: rate})
+			}
+			result.rates[key] = rates
+		}
+	}
+
+	if result.Collected(GroupUplinkPorts) {
+		for i := range result.Ports {
+			port := &result.Ports[i]
+			if port.Type != PortTypeUplink {
+				continue
+			}
+			key := uplinkRateKey(port.Port)
+			width := counter64
+			if port.Counters32 {
+				width = counter32
+			}
+			// IF-MIB only has 32-bit error and discard counters
+			counters := []struct {
+				name    string
+				value   uint64
+				width   counterWidth
+				maxRate float64
+			}{
+				{"bytes_in", port.BytesIn, width, maxByteRate},
+				{"bytes_out", port.BytesOut, width, maxByteRate},
+				{"packets_in", port.PacketsIn, width, maxPacketRate},
+				{"packets_out", port.PacketsOut, width, maxPacketRate},
+				{"errors_in", port.ErrorsIn, counter32, maxPacketRate},
+				{"errors_out", port.ErrorsOut, counter32, maxPacketRate},
+				{"discards_in", port.DiscardsIn, counter32, maxPacketRate},
+				{"discards_out", port.DiscardsOut, counter32, maxPacketRate},
+			}
+			var rates []counterRate
+			for _, c := range counters {
+				rate, ok := t.observe(key+"/"+c.name, c.value, c.width, at, c.maxRate)
+				if !ok {
+					continue
+				}
+				rates = append(rates, counterRate{Name: "uplink_port_" + c.name + "_per_second", Value: rate})
+				switch c.name {
+				case "bytes_in":
+					port.InputRateBps = uint64(rate)
+				case "bytes_out":
+					port.OutputRateBps = uint64(rate)
+				}
+			}
+			result.rates[key] = rates
+		}
+	}
+
+	t.prune(at.Add(-counterRateTTL(state.Config.Polling)))
+}
+
+// onuRateKey and uplinkRateKey identify the counters of an ONU or uplink port.
+func onuRateKey(serial string) string { return "onu/" + serial }
+
+func uplinkRateKey(port string) string { return "uplink/" + port }
@@ -0,0 +1,149 @@
+package poller
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur uint64
+		width     counterWidth
+		want      uint64
+		ok        bool
+	}{
+		{"increase", 100, 250, counter64, 150, true},
+		{"unchanged", 100, 100, counter32, 0, true},
+		{"32-bit wrap", math.MaxUint32 - 99, 50, counter32, 150, true},
+		{"64-bit counter below 2^32 does not wrap", math.MaxUint32 - 99, 50, counter64, 0, false},
+		{"reset from low 32-bit value", 1000, 10, counter32, 0, false},
+		{"64-bit counter reset", 1 << 40, 10, counter64, 0, false},
+		{"32-bit counter above 2^32", 1 << 40, 10, counter32, 0, false},
+		{"implausible 32-bit wrap", math.MaxUint32/2 + 1, math.MaxUint32 / 4, counter32, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, ok := counterDelta(tt.prev, tt.cur, tt.width, time.Second, maxPacketRate)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, delta)
+		})
+	}
+}
+
+func TestRateTrackerObserve(t *testing.T) {
+	var tracker rateTracker
+	start := time.Now()
+
+	_, ok := tracker.observe("k", 1000, counter64, start, maxByteRate)
+	assert.False(t, ok, "first sample has no rate")
+
+	rate, ok := tracker.observe("k", 7000, counter64, start.Add(60*time.Second), maxByteRate)
+	require.True(t, ok)
+	assert.Equal(t, 100.0, rate)
+
+	_, ok = tracker.observe("k", 10, counter64, start.Add(120*time.Second), maxByteRate)
+	assert.False(t, ok, "reset has no rate")
+
+	rate, ok = tracker.observe("k", 610, counter64, start.Add(180*time.Second), maxByteRate)
+	require.True(t, ok, "sample after a reset is the new baseline")
+	assert.Equal(t, 10.0, rate)
+
+	tracker.prune(start.Add(time.Hour))
+	assert.Empty(t, tracker.samples)
+}
+
+func TestComputeRates(t *testing.T) {
+	start := time.Now()
+	state := &OLTState{}
+	poll := func(at time.Time, bytesUp, bytesIn uint64, status string, uptime int64) *PollResult {
+		result := &PollResult{
+			Timestamp: at,
+			Groups:    []MetricGroup{GroupONUTraffic, GroupUplinkPorts, GroupOLTSystem},
+			ONUs:      []ONUData{{Serial: "HWTC12345678", Status: status, BytesUp: bytesUp}},
+			Ports:     []PortData{{Port: "xge0/9/0", Type: PortTypeUplink, BytesIn: bytesIn}},
+			Telemetry: &TelemetryData{Uptime: uptime},
+		}
+		computeRates(state, result)
+		return result
+	}
+
+	first := poll(start, 1000, 5000, "online", 3600)
+	assert.Zero(t, first.ONUs[0].OutputRateBps)
+	assert.Empty(t, first.rates[onuRateKey("HWTC12345678")])
+
+	second := poll(start.Add(100*time.Second), 11000, 105000, "online", 3700)
+	assert.Equal(t, uint64(100), second.ONUs[0].OutputRateBps)
+	assert.Equal(t, uint64(1000), second.Ports[0].InputRateBps)
+	assert.Contains(t, second.rates[onuRateKey("HWTC12345678")], counterRate{Name: "onu_bytes_up_per_second", Value: 100})
+	assert.Contains(t, second.rates[uplinkRateKey("xge0/9/0")], counterRate{Name: "uplink_port_bytes_in_per_second", Value: 1000})
+
+	// An offline ONU starts a new baseline
+	offline := poll(start.Add(200*time.Second), 11000, 205000, "offline", 3800)
+	assert.Empty(t, offline.rates[onuRateKey("HWTC12345678")])
+	back := poll(start.Add(300*time.Second), 50, 305000, "online", 3900)
+	assert.Empty(t, back.rates[onuRateKey("HWTC12345678")])
+
+	// An OLT reboot resets every counter
+	rebooted := poll(start.Add(400*time.Second), 60, 2000000, "online", 30)
+	assert.Empty(t, rebooted.rates[onuRateKey("HWTC12345678")])
+	assert.Empty(t, rebooted.rates[uplinkRateKey("xge0/9/0")])
+}
+
+func TestComputeRatesCounterWidth(t *testing.T) {
+	start := time.Now()
+	state := &OLTState{}
+	poll := func(at time.Time, bytesIn uint64, counters32 bool) *PollResult {
+		result := &PollResult{
+			Timestamp: at,
+			Groups:    []MetricGroup{GroupUplinkPorts},
+			Ports:     []PortData{{Port: "ge0/1", Type: PortTypeUplink, BytesIn: bytesIn, Counters32: counters32}},
+		}
+		computeRates(state, result)
+		return result
+	}
+
+	// A 32-bit counter that went backwards wrapped
+	poll(start, math.MaxUint32-999, true)
+	wrapped := poll(start.Add(10*time.Second), 9000, true)
+	assert.Equal(t, uint64(1000), wrapped.Ports[0].InputRateBps)
+
+	// A 64-bit counter that went backwards was reset, wherever it was
+	state = &OLTState{}
+	poll(start, math.MaxUint32-999, false)
+	reset := poll(start.Add(10*time.Second), 9000, false)
+	assert.Zero(t, reset.Ports[0].InputRateBps)
+	for _, rate := range reset.rates[uplinkRateKey("ge0/1")] {
+		assert.NotEqual(t, "uplink_port_bytes_in_per_second", rate.Name)
+	}
+}
+
+func TestComputeRatesONUUptime(t *testing.T) {
+	start := time.Now()
+	state := &OLTState{}
+	poll := func(at time.Time, bytesUp uint64, uptime int64) *PollResult {
+		result := &PollResult{
+			Timestamp: at,
+			Groups:    []MetricGroup{GroupONUTraffic},
+			ONUs:      []ONUData{{Serial: "HWTC12345678", Status: "online", BytesUp: bytesUp, UptimeSeconds: uptime}},
+		}
+		computeRates(state, result)
+		return result
+	}
+
+	poll(start, 1000, 3600)
+	second := poll(start.Add(100*time.Second), 11000, 3700)
+	assert.Equal(t, uint64(100), second.ONUs[0].OutputRateBps)
+
+	// The ONU restarted and its counter climbed past the previous sample
+	restarted := poll(start.Add(200*time.Second), 20000, 50)
+	assert.Zero(t, restarted.ONUs[0].OutputRateBps)
+	assert.Empty(t, restarted.rates[onuRateKey("HWTC12345678")])
+
+	after := poll(start.Add(300*time.Second), 30000, 150)
+	assert.Equal(t, uint64(100), after.ONUs[0].OutputRateBps)
+}
@@ -0,0 +1,279 @@
+package poller
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// jobKind identifies the work a job does on an OLT.
+type jobKind int
+
+const (
+	jobPoll      jobKind = iota // ONU list, telemetry and metrics
+	jobDiscovery                // autofind discovery
+)
+
+// jobPriority orders queued jobs. Higher priorities run first; jobs of the
+// same priority run in the order they became due.
+type jobPriority int
+
+const (
+	priorityNormal  jobPriority = iota
+	priorityChanged             // OLT whose state changed recently
+	priorityManual              // manual probe
+)
+
+const (
+	// recentChangeWindow is how long an OLT keeps priorityChanged after its
+	// ONUs changed state or it recovered from errors.
+	recentChangeWindow = 15 * time.Minute
+
+	// stretchHighWater and stretchLowWater are the poll duration, as a
+	// fraction of the OLT's shortest interval, above which intervals are
+	// stretched and below which they shrink back.
+	stretchHighWater = 0.8
+	stretchLowWater  = 0.4
+
+	// stretchStep is the factor intervals are stretched or shrunk by.
+	stretchStep = 1.5
+)
+
+// job is a unit of work for the worker pool.
+type job struct {
+	state    *OLTState
+	kind     jobKind
+	priority jobPriority
+	due      time.Time // when the job became due
+
+	// done receives the result of a manual probe
+	done chan *PollResult
+
+	index int // position in the heap
+}
+
+// jobHeap implements heap.Interface over queued jobs.
+type jobHeap []*job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].due.Before(h[j].due)
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	j := x.(*job)
+	j.index = len(*h)
+	*h = append(*h, j)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	j.index = -1
+	*h = old[:n-1]
+	return j
+}
+
+// jobQueue is a priority queue of jobs shared by the workers. Workers block
+// in pop until a job is queued or the queue is closed.
+type jobQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	jobs   jobHeap
+	closed bool
+}
+
+// newJobQueue creates an empty job queue.
+func newJobQueue() *jobQueue {
+	q := &jobQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues a job. It returns false if the queue is closed.
+func (q *jobQueue) push(j *job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	heap.Push(&q.jobs, j)
+	q.cond.Signal()
+	return true
+}
+
+// pop removes the highest priority job, waiting for one if the queue is
+// empty. ok is false once the queue is closed.
+func (q *jobQueue) pop() (j *job, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil, false
+	}
+	return heap.Pop(&q.jobs).(*job), true
+}
+
+// close wakes all waiting workers and drops queued jobs. Manual probes
+// still waiting for a result are released with nil.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	for _, j := range q.jobs {
+		if j.done != nil {
+			close(j.done)
+		}
+	}
+	q.jobs = nil
+	q.cond.Broadcast()
+}
+
+// len returns the number of queued jobs.
+func (q *jobQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// oltJitter returns a stable offset for an OLT in [-fraction, +fraction) of
+// interval, so OLTs with the same interval do not poll in lockstep.
+func oltJitter(oltID string, interval time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || interval <= 0 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(oltID))
+	unit := float64(h.Sum32())/float64(1<<32)*2 - 1 // [-1, 1)
+	return time.Duration(unit * fraction * float64(interval))
+}
+
+// backoffDelay returns how long to wait before polling an OLT again after
+// errorCount consecutive failures: exponential from 10s, capped at max, with
+// jitter so failing OLTs do not retry together.
+func backoffDelay(errorCount int, max time.Duration) time.Duration {
+	// Cap error count to prevent overflow
+	exp := errorCount
+	if exp < 1 {
+		exp = 1
+	} else if exp > 30 {
+		exp = 30
+	}
+	backoff := time.Duration(1<<uint(exp)) * 10 * time.Second // #nosec G115 - bounds checked above
+	if backoff > max || backoff <= 0 {
+		backoff = max
+	}
+	// Equal jitter: between half and all of the backoff
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1)) // #nosec G404 - jitter does not need crypto randomness
+}
+
+// adjustStretch stretches an OLT's intervals when its polls take close to
+// the shortest interval and shrinks them back once polls are fast again.
+// It returns the new stretch factor.
+func adjustStretch(stretch float64, duration, interval time.Duration, maxStretch float64) float64 {
+	if stretch < 1 {
+		stretch = 1
+	}
+	effective := time.Duration(float64(interval) * stretch)
+	switch {
+	case duration >= time.Duration(float64(effective)*stretchHighWater):
+		stretch *= stretchStep
+		if stretch > maxStretch {
+			stretch = maxStretch
+		}
+	case duration <= time.Duration(float64(effective)*stretchLowWater) && stretch > 1:
+		stretch /= stretchStep
+		if stretch < 1 {
+			stretch = 1
+		}
+	}
+	return stretch
+}
+
+// pollPriority returns the priority of a scheduled poll of an OLT.
+// Callers hold p.mu.
+func pollPriority(state *OLTState, now time.Time) jobPriority {
+	if !state.LastChange.IsZero() && now.Sub(state.LastChange) < recentChangeWindow {
+		return priorityChanged
+	}
+	return priorityNormal
+}
+
+// adaptInterval records a successful poll's duration and stretches or
+// shrinks the OLT's intervals to match. Callers hold p.mu.
+func (p *Poller) adaptInterval(state *OLTState, duration time.Duration) {
+	state.LastDuration = duration
+	stretch := adjustStretch(state.Stretch, duration, minGroupInterval(state.Config.Polling), p.maxStretch)
+	if stretch != state.Stretch {
+		p.log("Poll of %s took %s, stretching intervals by %.2fx", state.Config.Name, duration.Round(time.Second), stretch)
+	}
+	state.Stretch = stretch
+}
+
+// onuStatesChanged reports whether any ONU appeared, disappeared or changed
+// status between two polls. The first poll of an OLT is not a change, and
+// neither is a poll that did not read the ONU list.
+func onuStatesChanged(previous, current []ONUData) bool {
+	if len(previous) == 0 || current == nil {
+		return false
+	}
+	if len(previous) != len(current) {
+		return true
+	}
+	statuses := make(map[string]string, len(previous))
+	for _, onu := range previous {
+		statuses[onu.Serial] = onu.Status
+	}
+	for _, onu := range current {
+		if status, ok := statuses[onu.Serial]; !ok || status != onu.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// schedulerMetrics reports how an OLT's polls keep up with their schedule.
+// Callers hold p.mu.
+func schedulerMetrics(state *OLTState, now int64) []MetricSample {
+	labels := map[string]string{
+		"olt_id":   state.Config.ID,
+		"olt_name": state.Config.Name,
+	}
+	values := []struct {
+		name  string
+		value float64
+	}{
+		{"poller_skipped_polls_total", float64(state.SkippedPolls)},
+		{"poller_late_polls_total", float64(state.LatePolls)},
+		{"poller_poll_lateness_seconds", state.LastLateness.Seconds()},
+		{"poller_poll_duration_seconds", state.LastDuration.Seconds()},
+		{"poller_interval_stretch", state.Stretch},
+	}
+	metrics := make([]MetricSample, len(values))
+	for i, v := range values {
+		metrics[i] = MetricSample{
+			Name:      v.name,
+			Value:     v.value,
+			Timestamp: now,
+			Labels:    labels,
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,95 @@
+package poller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobQueueOrder(t *testing.T) {
+	q := newJobQueue()
+	now := time.Now()
+
+	q.push(&job{state: &OLTState{Config: OLTConfig{ID: "late"}}, due: now.Add(-time.Minute)})
+	q.push(&job{state: &OLTState{Config: OLTConfig{ID: "normal"}}, due: now})
+	q.push(&job{state: &OLTState{Config: OLTConfig{ID: "changed"}}, priority: priorityChanged, due: now})
+	q.push(&job{state: &OLTState{Config: OLTConfig{ID: "manual"}}, priority: priorityManual, due: now.Add(time.Minute)})
+
+	var order []string
+	for q.len() > 0 {
+		j, ok := q.pop()
+		require.True(t, ok)
+		order = append(order, j.state.Config.ID)
+	}
+	assert.Equal(t, []string{"manual", "changed", "late", "normal"}, order)
+
+	done := make(chan *PollResult, 1)
+	q.push(&job{state: &OLTState{}, done: done})
+	q.close()
+	_, ok := q.pop()
+	assert.False(t, ok)
+	_, open := <-done
+	assert.False(t, open, "waiting probes are released on close")
+}
+
+func TestOLTJitter(t *testing.T) {
+	interval := 5 * time.Minute
+	a := oltJitter("olt-a", interval, 0.1)
+	assert.Equal(t, a, oltJitter("olt-a", interval, 0.1), "jitter is stable per OLT")
+	assert.NotEqual(t, a, oltJitter("olt-b", interval, 0.1))
+	for _, id := range []string{"olt-a", "olt-b", "olt-c", "olt-d"} {
+		j := oltJitter(id, interval, 0.1)
+		assert.True(t, j >= -30*time.Second && j < 30*time.Second, "jitter %s out of range", j)
+	}
+	assert.Zero(t, oltJitter("olt-a", interval, 0))
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		d := backoffDelay(1, 5*time.Minute)
+		assert.True(t, d >= 10*time.Second && d <= 20*time.Second, "backoff %s out of range", d)
+
+		d = backoffDelay(40, 5*time.Minute)
+		assert.True(t, d >= 150*time.Second && d <= 5*time.Minute, "capped backoff %s out of range", d)
+	}
+}
+
+func TestAdjustStretch(t *testing.T) {
+	interval := time.Minute
+	assert.Equal(t, 1.0, adjustStretch(1, 10*time.Second, interval, 4))
+	assert.Equal(t, 1.5, adjustStretch(1, 50*time.Second, interval, 4))
+	assert.Equal(t, 4.0, adjustStretch(3, 3*time.Minute, interval, 4))
+	assert.Equal(t, 1.0, adjustStretch(1.5, 20*time.Second, interval, 4))
+}
+
+func TestONUStatesChanged(t *testing.T) {
+	before := []ONUData{{Serial: "A", Status: "online"}, {Serial: "B", Status: "online"}}
+
+	assert.False(t, onuStatesChanged(nil, before), "first poll")
+	assert.False(t, onuStatesChanged(before, nil), "ONU list not read")
+	assert.False(t, onuStatesChanged(before, []ONUData{{Serial: "B", Status: "online"}, {Serial: "A", Status: "online"}}))
+	assert.True(t, onuStatesChanged(before, []ONUData{{Serial: "A", Status: "online"}, {Serial: "B", Status: "los"}}))
+	assert.True(t, onuStatesChanged(before, []ONUData{{Serial: "A", Status: "online"}}))
+}
+
+func TestSchedulePollsCountsSkippedPolls(t *testing.T) {
+	p := New(nil, nil, nil, nil)
+	p.queue = newJobQueue()
+	p.UpdateOLTs([]OLTConfig{{ID: "olt-1", Name: "OLT 1", Polling: OLTPollingConfig{Enabled: true, Interval: 60}}})
+
+	p.schedulePolls()
+	p.schedulePolls()
+	p.schedulePolls()
+
+	assert.Equal(t, 1, p.queue.len(), "a busy OLT is not queued twice")
+	state := p.oltStates["olt-1"]
+	assert.Equal(t, 1, state.SkippedPolls, "a skip is counted once per due poll")
+
+	j, ok := p.queue.pop()
+	require.True(t, ok)
+	p.finishJob(j)
+	p.schedulePolls()
+	assert.Equal(t, 1, p.queue.len(), "a finished OLT is queued again while due")
+}
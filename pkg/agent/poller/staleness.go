@@ -0,0 +1,168 @@
+package poller
+
+import (
+	"time"
+)
+
+// defaultStaleAfterIntervals is how many intervals a metric group may go
+// without fresh data before an OLT's telemetry is reported stale.
+const defaultStaleAfterIntervals = 3
+
+// recordGroupResults records which of the metric groups collected by a
+// successful poll returned fresh data. Callers hold p.mu.
+func recordGroupResults(state *OLTState, result *PollResult) {
+	if state.LastGroupSuccess == nil {
+		state.LastGroupSuccess = make(map[MetricGroup]time.Time)
+	}
+	if state.GroupErrors == nil {
+		state.GroupErrors = make(map[MetricGroup]error)
+	}
+	for _, g := range result.Groups {
+		if err, failed := result.GroupErrors[g]; failed {
+			state.GroupErrors[g] = err
+			continue
+		}
+		state.LastGroupSuccess[g] = result.Timestamp
+		delete(state.GroupErrors, g)
+	}
+}
+
+// groupStaleAfter returns how long metric group g may go without fresh data
+// before it is stale: n of its intervals, stretched while polls are slow.
+func groupStaleAfter(state *OLTState, g MetricGroup, n int) time.Duration {
+	interval := groupInterval(state.Config.Polling, g)
+	if state.Stretch > 1 {
+		interval = time.Duration(float64(interval) * state.Stretch)
+	}
+	return time.Duration(n)*interval + state.jitter
+}
+
+// staleGroups returns the selected metric groups of an OLT that have had no
+// fresh data for n intervals. Groups never collected are measured from when
+// the OLT was added. Callers hold p.mu.
+func staleGroups(state *OLTState, now time.Time, n int) []MetricGroup {
+	if !state.Config.Polling.Enabled {
+		return nil
+	}
+	var stale []MetricGroup
+	for _, g := range metricGroups(state.Config.Polling) {
+		since, ok := state.LastGroupSuccess[g]
+		if !ok {
+			since = state.AddedAt
+		}
+		if since.IsZero() {
+			continue
+		}
+		if now.Sub(since) > groupStaleAfter(state, g, n) {
+			stale = append(stale, g)
+		}
+	}
+	return stale
+}
+
+// sameGroups reports whether two ordered lists of metric groups are equal.
+func sameGroups(a, b []MetricGroup) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// staleError returns the error to report for an OLT with stale groups: the
+// last poll error, or else the last error of a stale group.
+func staleError(state *OLTState, stale []MetricGroup) error {
+	if state.LastError != nil {
+		return state.LastError
+	}
+	for _, g := range stale {
+		if err := state.GroupErrors[g]; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotateStaleness adds an OLT's staleness to telemetry. Callers hold p.mu.
+func annotateStaleness(telemetry *TelemetryData, state *OLTState, stale []MetricGroup) {
+	telemetry.Stale = len(stale) > 0
+	telemetry.StaleGroups = nil
+	for _, g := range stale {
+		telemetry.StaleGroups = append(telemetry.StaleGroups, string(g))
+	}
+	telemetry.LastError = ""
+	if err := staleError(state, stale); err != nil && telemetry.Stale {
+		telemetry.LastError = err.Error()
+	}
+	telemetry.LastSuccessAt = nil
+	if !state.LastSuccess.IsZero() {
+		lastSuccess := state.LastSuccess
+		telemetry.LastSuccessAt = &lastSuccess
+	}
+	telemetry.ConsecutiveFailures = state.ErrorCount
+	if telemetry.Stale {
+		telemetry.IsHealthy = false
+	}
+}
+
+// statusTelemetry builds the telemetry pushed when an OLT's data goes stale
+// or becomes fresh again: the last telemetry read from the OLT with its
+// current reachability and staleness. Callers hold p.mu.
+func statusTelemetry(state *OLTState, stale []MetricGroup) *TelemetryData {
+	telemetry := &TelemetryData{}
+	if state.LastTelemetry != nil {
+		*telemetry = *state.LastTelemetry
+	}
+	telemetry.IsReachable = !state.Unreachable
+	annotateStaleness(telemetry, state, stale)
+	return telemetry
+}
+
+// checkStaleness reports OLTs whose data went stale or became fresh again
+// since the last check. A stale OLT is reported with the last error, so the
+// control plane can tell an unreachable OLT from one that is not polled.
+func (p *Poller) checkStaleness() {
+	type staleUpdate struct {
+		oltID     string
+		oltName   string
+		stale     []MetricGroup
+		telemetry *TelemetryData
+	}
+
+	now := time.Now()
+	var updates []staleUpdate
+	p.mu.Lock()
+	for id, state := range p.oltStates {
+		stale := staleGroups(state, now, p.staleAfter)
+		if sameGroups(stale, state.ReportedStale) {
+			continue
+		}
+		state.ReportedStale = stale
+		updates = append(updates, staleUpdate{
+			oltID:     id,
+			oltName:   state.Config.Name,
+			stale:     stale,
+			telemetry: statusTelemetry(state, stale),
+		})
+	}
+	p.mu.Unlock()
+
+	for _, u := range updates {
+		if len(u.stale) > 0 {
+			p.log("Telemetry for %s is stale (groups: %s, reachable: %v): %s",
+				u.oltName, joinGroups(u.stale), u.telemetry.IsReachable, u.telemetry.LastError)
+		} else {
+			p.log("Telemetry for %s is fresh again", u.oltName)
+		}
+		if p.telemetryPusher == nil {
+			continue
+		}
+		if _, err := p.telemetryPusher.PushTelemetry(u.oltID, u.telemetry); err != nil {
+			p.log("Failed to push telemetry status for %s: %v", u.oltName, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package poller
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTelemetryPusher struct {
+	mu     sync.Mutex
+	pushed []*TelemetryData
+}
+
+func (r *recordingTelemetryPusher) PushTelemetry(oltID string, telemetry *TelemetryData) (*PushTelemetryResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pushed = append(r.pushed, telemetry)
+	return &PushTelemetryResponse{Success: true}, nil
+}
+
+func TestStaleGroups(t *testing.T) {
+	now := time.Now()
+	state := &OLTState{
+		Config: OLTConfig{Polling: OLTPollingConfig{
+			Enabled:  true,
+			Interval: 60,
+			Metrics:  []string{"onu_status", "olt_system"},
+		}},
+		AddedAt: now.Add(-10 * time.Minute),
+		LastGroupSuccess: map[MetricGroup]time.Time{
+			GroupONUStatus: now.Add(-2 * time.Minute),
+			GroupOLTSystem: now.Add(-4 * time.Minute),
+		},
+	}
+
+	assert.Equal(t, []MetricGroup{GroupOLTSystem}, staleGroups(state, now, 3))
+	assert.Empty(t, staleGroups(state, now, 5))
+
+	state.Stretch = 2
+	assert.Empty(t, staleGroups(state, now, 3), "stretched intervals allow older data")
+
+	state.Stretch = 1
+	delete(state.LastGroupSuccess, GroupONUStatus)
+	assert.Equal(t, []MetricGroup{GroupONUStatus, GroupOLTSystem}, staleGroups(state, now, 3),
+		"groups never collected are measured from when the OLT was added")
+
+	state.Config.Polling.Enabled = false
+	assert.Empty(t, staleGroups(state, now, 3))
+}
+
+func TestRecordGroupResults(t *testing.T) {
+	now := time.Now()
+	state := &OLTState{}
+	result := &PollResult{
+		Timestamp: now,
+		Groups:    []MetricGroup{GroupONUStatus, GroupOLTSystem},
+	}
+	result.groupFailed(GroupOLTSystem, errors.New("snmp timeout"))
+	recordGroupResults(state, result)
+
+	assert.Equal(t, now, state.LastGroupSuccess[GroupONUStatus])
+	assert.NotContains(t, state.LastGroupSuccess, GroupOLTSystem)
+	assert.EqualError(t, state.GroupErrors[GroupOLTSystem], "snmp timeout")
+
+	recordGroupResults(state, &PollResult{Timestamp: now.Add(time.Minute), Groups: []MetricGroup{GroupOLTSystem}})
+	assert.Equal(t, now.Add(time.Minute), state.LastGroupSuccess[GroupOLTSystem])
+	assert.Empty(t, state.GroupErrors)
+}
+
+func TestCheckStalenessReportsTransitions(t *testing.T) {
+	pusher := &recordingTelemetryPusher{}
+	p := New(nil, pusher, nil, &Config{StaleAfterIntervals: 3})
+	p.UpdateOLTs([]OLTConfig{{ID: "olt-1", Name: "OLT 1", Polling: OLTPollingConfig{
+		Enabled:  true,
+		Interval: 60,
+		Metrics:  []string{"olt_system"},
+	}}})
+
+	state := p.oltStates["olt-1"]
+	lastSuccess := time.Now().Add(-5 * time.Minute)
+	state.LastSuccess = lastSuccess
+	state.LastGroupSuccess = map[MetricGroup]time.Time{GroupOLTSystem: lastSuccess}
+	state.LastTelemetry = &TelemetryData{CPUPercent: 12, IsReachable: true, IsHealthy: true}
+	state.LastError = errors.New("dial tcp 10.0.0.1:161: connect: no route to host")
+	state.ErrorCount = 4
+	state.Unreachable = true
+
+	p.checkStaleness()
+	p.checkStaleness()
+	require.Len(t, pusher.pushed, 1, "staleness is reported once per transition")
+	stale := pusher.pushed[0]
+	assert.True(t, stale.Stale)
+	assert.False(t, stale.IsReachable)
+	assert.False(t, stale.IsHealthy)
+	assert.Equal(t, []string{"olt_system"}, stale.StaleGroups)
+	assert.Equal(t, "dial tcp 10.0.0.1:161: connect: no route to host", stale.LastError)
+	assert.Equal(t, 4, stale.ConsecutiveFailures)
+	assert.Equal(t, 12.0, stale.CPUPercent, "last telemetry is kept")
+	require.NotNil(t, stale.LastSuccessAt)
+	assert.Equal(t, lastSuccess, *stale.LastSuccessAt)
+
+	// The OLT answers again
+	state.LastGroupSuccess[GroupOLTSystem] = time.Now()
+	state.LastError = nil
+	state.ErrorCount = 0
+	state.Unreachable = false
+
+	p.checkStaleness()
+	require.Len(t, pusher.pushed, 2)
+	fresh := pusher.pushed[1]
+	assert.False(t, fresh.Stale)
+	assert.True(t, fresh.IsReachable)
+	assert.True(t, fresh.IsHealthy)
+	assert.Empty(t, fresh.LastError)
+}
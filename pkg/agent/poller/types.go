@@ -3,6 +3,9 @@ package poller
 
 import (
 	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/snmp"
+	"github.com/nanoncore/nano-southbound/types"
 )
 
 // OLTConfig represents an OLT configuration from the control plane.
@@ -29,6 +32,13 @@ type SNMPConfig struct {
 	Port      int    `json:"port"`
 	Community string `json:"community"`
 	Version   string `json:"version"`
+
+	// SNMPv3 user-based security, used when Version is "3".
+	Username     string `json:"username,omitempty"`
+	AuthProtocol string `json:"authProtocol,omitempty"` // MD5, SHA, SHA224, SHA256, SHA384, SHA512
+	AuthPassword string `json:"authPassword,omitempty"`
+	PrivProtocol string `json:"privProtocol,omitempty"` // DES, AES, AES192, AES256
+	PrivPassword string `json:"privPassword,omitempty"`
 }
 
 // SSHConfig contains SSH configuration.
@@ -42,9 +52,27 @@ type SSHConfig struct {
 // OLTPollingConfig contains polling configuration.
 type OLTPollingConfig struct {
 	Enabled          bool     `json:"enabled"`
-	Interval         int      `json:"interval"`         // seconds (fast poll interval, default 60)
+	Interval         int      `json:"interval"`         // seconds (fast poll interval, default 300)
 	DetailedInterval int      `json:"detailedInterval"` // seconds (detailed poll interval, default 600 = 10 min)
-	Metrics          []string `json:"metrics"`
+	Metrics          []string `json:"metrics"`          // metric groups to collect (default: all but uplink_ports and alarms)
+
+	// MetricIntervals overrides the interval of individual metric groups, in
+	// seconds. Groups without an entry use DetailedInterval for onu_optical
+	// and onu_traffic and Interval for everything else.
+	MetricIntervals map[string]int `json:"metricIntervals,omitempty"`
+
+	// UplinkPorts names the interfaces reported by the uplink_ports group.
+	// When empty, Ethernet interfaces are detected by name.
+	UplinkPorts []string `json:"uplinkPorts,omitempty"`
+
+	// DetailConcurrency is how many PON ports are read in parallel during a
+	// detailed poll, each over its own connection (default 2).
+	DetailConcurrency int `json:"detailConcurrency,omitempty"`
+
+	// DetailTimeout bounds the ONU detail reads of a poll, in seconds
+	// (default: 80% of Interval). Ports not read in time are read by the
+	// following polls.
+	DetailTimeout int `json:"detailTimeout,omitempty"`
 }
 
 // OLTDiscoveryConfig contains discovery configuration.
@@ -87,6 +115,9 @@ type ONUData struct {
 	InputRateBps  uint64 `json:"inputRateBps,omitempty"`
 	OutputRateBps uint64 `json:"outputRateBps,omitempty"`
 
+	// UptimeSeconds is the ONU session uptime, used to detect counter resets
+	UptimeSeconds int64 `json:"uptimeSeconds,omitempty"`
+
 	// Additional
 	Vendor string `json:"vendor,omitempty"` // ONU vendor (detected from serial)
 
@@ -123,6 +154,14 @@ type TelemetryData struct {
 	IsHealthy     bool    `json:"isHealthy"`
 	Firmware      string  `json:"firmware,omitempty"`
 	SerialNumber  string  `json:"serialNumber,omitempty"`
+
+	// Staleness, reported when the agent has had no fresh data from the OLT
+	// for several polling intervals
+	Stale               bool       `json:"stale,omitempty"`
+	StaleGroups         []string   `json:"staleGroups,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"`
 }
 
 // PushTelemetryResponse is the response from pushing telemetry.
@@ -131,6 +170,49 @@ type PushTelemetryResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// Port types reported in PortData.
+const (
+	PortTypePON    = "pon"
+	PortTypeUplink = "uplink"
+)
+
+// PortData represents a PON or uplink port to be pushed to the control plane.
+type PortData struct {
+	Port        string `json:"port"`
+	Type        string `json:"type"` // pon, uplink
+	AdminState  string `json:"adminState,omitempty"`
+	OperState   string `json:"operState,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// PON ports
+	ONUCount int     `json:"onuCount,omitempty"`
+	MaxONUs  int     `json:"maxOnus,omitempty"`
+	TxPower  float64 `json:"txPower,omitempty"` // dBm
+	RxPower  float64 `json:"rxPower,omitempty"` // dBm
+
+	// Uplink ports (cumulative counters)
+	SpeedMbps   uint64 `json:"speedMbps,omitempty"`
+	BytesIn     uint64 `json:"bytesIn,omitempty"`
+	BytesOut    uint64 `json:"bytesOut,omitempty"`
+	PacketsIn   uint64 `json:"packetsIn,omitempty"`
+	PacketsOut  uint64 `json:"packetsOut,omitempty"`
+	ErrorsIn    uint64 `json:"errorsIn,omitempty"`
+	ErrorsOut   uint64 `json:"errorsOut,omitempty"`
+	DiscardsIn  uint64 `json:"discardsIn,omitempty"`
+	DiscardsOut uint64 `json:"discardsOut,omitempty"`
+	Counters32  bool   `json:"-"` // counters are 32-bit and wrap
+
+	// Uplink ports (computed from counter deltas, bytes per second)
+	InputRateBps  uint64 `json:"inputRateBps,omitempty"`
+	OutputRateBps uint64 `json:"outputRateBps,omitempty"`
+}
+
+// PushPortsResponse is the response from pushing port data.
+type PushPortsResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
 // MetricSample represents a single metric data point for time-series storage.
 type MetricSample struct {
 	Name      string            `json:"name"`
@@ -144,6 +226,18 @@ type MetricsBatch struct {
 	Metrics []MetricSample `json:"metrics"`
 }
 
+// SinkRecord is the result of a successful poll as written to result sinks.
+type SinkRecord struct {
+	OLTID     string         `json:"oltId"`
+	OLTName   string         `json:"oltName"`
+	Timestamp time.Time      `json:"timestamp"`
+	Groups    []MetricGroup  `json:"groups"`
+	ONUs      []ONUData      `json:"onus,omitempty"`
+	Telemetry *TelemetryData `json:"telemetry,omitempty"`
+	Ports     []PortData     `json:"ports,omitempty"`
+	Metrics   []MetricSample `json:"metrics,omitempty"`
+}
+
 // PushMetricsResponse is the response from pushing metrics.
 type PushMetricsResponse struct {
 	Success bool   `json:"success"`
@@ -155,11 +249,46 @@ type PushMetricsResponse struct {
 type OLTState struct {
 	Config           OLTConfig
 	LastPoll         time.Time
-	LastDetailedPoll time.Time // Last time detailed ONU data was fetched
+	LastDetailedPoll time.Time                 // Last time detailed ONU data was fetched
+	LastCollected    map[MetricGroup]time.Time // Last time each metric group was collected
 	LastSuccess      time.Time
 	LastError        error
 	ErrorCount       int
 	BackoffUntil     time.Time
+
+	// Latest ONU snapshot from a successful poll
+	LastONUs   []ONUData
+	LastONUsAt time.Time
+
+	// Autofind discovery
+	LastDiscovery time.Time                // Last time the autofind list was read
+	Autofind      map[string]AutofindEntry // serial -> entry in the autofind list at the last discovery
+
+	// Previous ONU and uplink counter samples, for traffic rates
+	Counters rateTracker
+
+	// Staleness
+	AddedAt          time.Time                 // When the OLT was added to the poller
+	LastGroupSuccess map[MetricGroup]time.Time // Last time each metric group was collected without error
+	GroupErrors      map[MetricGroup]error     // Last error collecting each metric group, until it succeeds again
+	Unreachable      bool                      // The last poll could not connect to the OLT
+	ReportedStale    []MetricGroup             // Stale groups last reported to the control plane
+	LastTelemetry    *TelemetryData            // Telemetry of the last poll that collected the system group
+
+	// Scheduling
+	Stretch      float64       // Factor intervals are stretched by while polls are slow (1 = none)
+	LastChange   time.Time     // Last time ONU states changed or the OLT recovered from errors
+	LastDuration time.Duration // Duration of the last poll
+	LastLateness time.Duration // How long after it was due the last poll started
+	SkippedPolls int           // Due polls not started because the previous one was still queued or running
+	LatePolls    int           // Polls started more than the late threshold after they were due
+
+	jitter      time.Duration    // stable per-OLT offset added to due times
+	notBefore   time.Time        // initial stagger: not polled before this time
+	busy        map[jobKind]bool // a job of this kind is queued or running
+	skipCounted bool             // a skip was counted for the poll currently due
+
+	detail *detailCheckpoint // detailed poll cycle in progress, if any
 }
 
 // PollResult contains the result of polling an OLT.
@@ -171,4 +300,84 @@ type PollResult struct {
 	Duration     time.Duration
 	Timestamp    time.Time
 	DetailedPoll bool // Whether this poll included detailed ONU data (optical, traffic)
+
+	Groups []MetricGroup    // Metric groups collected by this poll
+	Alarms []types.OLTAlarm // Active alarms (alarms group only)
+	Ports  []PortData       // PON and uplink ports (pon_ports and uplink_ports groups)
+
+	// Unreachable is set when the poll failed because the agent could not
+	// connect to the OLT
+	Unreachable bool
+
+	// GroupErrors holds the errors of metric groups that failed in an
+	// otherwise successful poll
+	GroupErrors map[MetricGroup]error
+
+	// detailed holds the serials of ONUs whose optical and traffic details
+	// were read by this poll. nil means all ONUs have fresh details.
+	detailed map[string]bool
+
+	// rates holds traffic rates computed from counter deltas, keyed by
+	// onuRateKey or uplinkRateKey
+	rates map[string][]counterRate
+}
+
+// Collected reports whether the poll collected metric group g.
+func (r *PollResult) Collected(g MetricGroup) bool {
+	for _, collected := range r.Groups {
+		if collected == g {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDetails reports whether the poll read fresh optical and traffic
+// details of an ONU.
+func (r *PollResult) hasDetails(serial string) bool {
+	return r.detailed == nil || r.detailed[serial]
+}
+
+// groupFailed records that metric group g could not be collected.
+func (r *PollResult) groupFailed(g MetricGroup, err error) {
+	if r.GroupErrors == nil {
+		r.GroupErrors = make(map[MetricGroup]error)
+	}
+	r.GroupErrors[g] = snmp.ExplainError(err)
+}
+
+// AutofindEntry is an unprovisioned ONU seen in an OLT's autofind list.
+type AutofindEntry struct {
+	Serial    string    `json:"serialNumber"`
+	PONPort   string    `json:"ponPort"`
+	Model     string    `json:"model,omitempty"`
+	Vendor    string    `json:"vendor,omitempty"`
+	MAC       string    `json:"mac,omitempty"`
+	Distance  int       `json:"distance,omitempty"`
+	RxPower   float64   `json:"rxPower,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// AutofindReport is an OLT's autofind list compared with the previous discovery.
+type AutofindReport struct {
+	New          []AutofindEntry `json:"new"`
+	Persisting   []AutofindEntry `json:"persisting"`
+	Vanished     []AutofindEntry `json:"vanished"`
+	DiscoveredAt time.Time       `json:"discoveredAt"`
+}
+
+// PushAutofindResponse is the response from pushing autofind entries.
+type PushAutofindResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// DiscoveryResult contains the result of reading an OLT's autofind list.
+type DiscoveryResult struct {
+	OLTID       string
+	Discoveries []types.ONUDiscovery
+	Error       error
+	Duration    time.Duration
+	Timestamp   time.Time
 }
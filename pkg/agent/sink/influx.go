@@ -0,0 +1,156 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+)
+
+// influxWriter writes the metrics of records to InfluxDB in line protocol.
+// InfluxDB 2.x is used when a bucket is set, 1.x when a database is set.
+type influxWriter struct {
+	writeURL   string
+	token      string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func newInfluxWriter(cfg agent.SinkConfig) (*influxWriter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("influxdb sink needs a url")
+	}
+	base, err := url.Parse(strings.TrimRight(cfg.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid influxdb url: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("precision", "ms")
+	switch {
+	case cfg.Bucket != "":
+		base.Path += "/api/v2/write"
+		query.Set("bucket", cfg.Bucket)
+		if cfg.Org != "" {
+			query.Set("org", cfg.Org)
+		}
+	case cfg.Database != "":
+		base.Path += "/write"
+		query.Set("db", cfg.Database)
+	default:
+		return nil, fmt.Errorf("influxdb sink needs a bucket (2.x) or database (1.x)")
+	}
+	base.RawQuery = query.Encode()
+
+	return &influxWriter{
+		writeURL: base.String(),
+		token:    cfg.Token,
+		username: cfg.Username,
+		password: cfg.Password,
+		httpClient: &http.Client{
+			Timeout: writeTimeout,
+		},
+	}, nil
+}
+
+// Write posts the metrics of the records as one line protocol body.
+func (w *influxWriter) Write(ctx context.Context, records []*poller.SinkRecord) error {
+	var body bytes.Buffer
+	for _, record := range records {
+		for _, m := range record.Metrics {
+			writeLine(&body, m)
+		}
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.writeURL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	} else if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("write failed (HTTP %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// Close is a no-op; the writer holds no connection.
+func (w *influxWriter) Close() error {
+	return nil
+}
+
+// writeLine appends a metric sample as a line protocol point: the metric
+// name as measurement, its labels as tags and its value as the value field.
+func writeLine(buf *bytes.Buffer, m poller.MetricSample) {
+	// Line protocol has no NaN or infinite floats
+	if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+		return
+	}
+	buf.WriteString(escapeLP(m.Name, ", "))
+
+	keys := make([]string, 0, len(m.Labels))
+	for k, v := range m.Labels {
+		// Line protocol has no empty tag values
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(',')
+		buf.WriteString(escapeLP(k, ",= "))
+		buf.WriteByte('=')
+		buf.WriteString(escapeLP(m.Labels[k], ",= "))
+	}
+
+	buf.WriteString(" value=")
+	buf.WriteString(strconv.FormatFloat(m.Value, 'g', -1, 64))
+	buf.WriteByte(' ')
+	ts := m.Timestamp
+	if ts == 0 {
+		ts = time.Now().UnixMilli()
+	}
+	buf.WriteString(strconv.FormatInt(ts, 10))
+	buf.WriteByte('\n')
+}
+
+// escapeLP backslash-escapes the characters in special.
+func escapeLP(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLine(t *testing.T) {
+	var buf bytes.Buffer
+	writeLine(&buf, poller.MetricSample{
+		Name:      "onu_rx_power_dbm",
+		Value:     -21.5,
+		Timestamp: 1700000000000,
+		Labels: map[string]string{
+			"olt_name":   "Main OLT, rack=2",
+			"onu_serial": "HWTC12345678",
+			"pon_port":   "",
+		},
+	})
+	assert.Equal(t, `onu_rx_power_dbm,olt_name=Main\ OLT\,\ rack\=2,onu_serial=HWTC12345678 value=-21.5 1700000000000`+"\n", buf.String())
+
+	buf.Reset()
+	writeLine(&buf, poller.MetricSample{Name: "olt_cpu_percent", Value: math.NaN()})
+	assert.Empty(t, buf.String(), "NaN has no line protocol representation")
+}
+
+func TestInfluxWriter(t *testing.T) {
+	var gotPath, gotQuery, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotQuery, gotAuth, gotBody = r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w, err := newInfluxWriter(agent.SinkConfig{URL: srv.URL, Org: "isp", Bucket: "olts", Token: "secret"})
+	require.NoError(t, err)
+
+	err = w.Write(context.Background(), []*poller.SinkRecord{{
+		OLTID: "olt-1",
+		Metrics: []poller.MetricSample{
+			{Name: "olt_cpu_percent", Value: 12, Timestamp: 1700000000000, Labels: map[string]string{"olt_id": "olt-1"}},
+		},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v2/write", gotPath)
+	assert.Equal(t, "bucket=olts&org=isp&precision=ms", gotQuery)
+	assert.Equal(t, "Token secret", gotAuth)
+	assert.Equal(t, "olt_cpu_percent,olt_id=olt-1 value=12 1700000000000\n", gotBody)
+
+	v1, err := newInfluxWriter(agent.SinkConfig{URL: srv.URL + "/", Database: "telemetry"})
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+"/write?db=telemetry&precision=ms", v1.writeURL)
+}
+
+func TestInfluxWriterReportsErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":"unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	w, err := newInfluxWriter(agent.SinkConfig{URL: srv.URL, Database: "telemetry"})
+	require.NoError(t, err)
+	err = w.Write(context.Background(), []*poller.SinkRecord{{Metrics: []poller.MetricSample{{Name: "x", Value: 1}}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+}
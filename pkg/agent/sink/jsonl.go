@@ -0,0 +1,60 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+)
+
+// jsonlWriter appends records to a local file, one JSON object per line.
+type jsonlWriter struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+func newJSONLWriter(cfg agent.SinkConfig) (*jsonlWriter, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("jsonl sink needs a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create jsonl sink directory: %w", err)
+	}
+	file, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open jsonl sink file: %w", err)
+	}
+	return &jsonlWriter{path: cfg.Path, file: file}, nil
+}
+
+// Write appends the records and syncs the file.
+func (w *jsonlWriter) Write(ctx context.Context, records []*poller.SinkRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	buf := bufio.NewWriter(w.file)
+	enc := json.NewEncoder(buf)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", w.path, err)
+	}
+	return w.file.Sync()
+}
+
+// Close closes the file.
+func (w *jsonlWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
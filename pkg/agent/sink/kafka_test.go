@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaWriter(t *testing.T) {
+	var gotPath, gotType string
+	var got kafkaProduceRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotType = r.URL.Path, r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":41},{"partition":1,"offset":7}]}`))
+	}))
+	defer srv.Close()
+
+	w, err := newKafkaWriter(agent.SinkConfig{URL: srv.URL, Topic: "olt.polls"})
+	require.NoError(t, err)
+
+	err = w.Write(context.Background(), []*poller.SinkRecord{{OLTID: "olt-1"}, {OLTID: "olt-2"}})
+	require.NoError(t, err)
+	assert.Equal(t, "/topics/olt.polls", gotPath)
+	assert.Equal(t, "application/vnd.kafka.json.v2+json", gotType)
+	require.Len(t, got.Records, 2)
+	assert.Equal(t, "olt-1", got.Records[0].Key)
+	assert.Equal(t, "olt-2", got.Records[1].Value.OLTID)
+}
+
+func TestKafkaWriterReportsRecordErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"Kafka error: topic authorization failed"}]}`))
+	}))
+	defer srv.Close()
+
+	w, err := newKafkaWriter(agent.SinkConfig{URL: srv.URL, Topic: "olt.polls"})
+	require.NoError(t, err)
+	err = w.Write(context.Background(), []*poller.SinkRecord{{OLTID: "olt-1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "topic authorization failed")
+}
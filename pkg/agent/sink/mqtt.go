@@ -0,0 +1,322 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+)
+
+// MQTT 3.1.1 control packet types, in the high nibble of the first byte.
+const (
+	mqttConnect    = 1
+	mqttConnAck    = 2
+	mqttPublish    = 3
+	mqttPubAck     = 4
+	mqttDisconnect = 14
+)
+
+const (
+	// defaultMQTTTopic is used when an MQTT sink does not set a topic.
+	defaultMQTTTopic = "nano-agent/{olt_id}/poll"
+
+	// mqttKeepAlive is the keep alive announced to the broker. The
+	// connection is reopened when it was idle for longer.
+	mqttKeepAlive = 60 * time.Second
+)
+
+// mqttConnectErrors describes CONNACK return codes.
+var mqttConnectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// mqttWriter publishes each record as JSON to an MQTT broker with QoS 1.
+// It speaks just enough MQTT 3.1.1 to connect and publish.
+type mqttWriter struct {
+	mu       sync.Mutex
+	address  string
+	useTLS   bool
+	host     string
+	topic    string
+	clientID string
+	username string
+	password string
+
+	conn     net.Conn
+	reader   *bufio.Reader
+	lastUsed time.Time
+	packetID uint16
+}
+
+func newMQTTWriter(cfg agent.SinkConfig) (*mqttWriter, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("mqtt sink needs a broker url")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mqtt broker url: %w", err)
+	}
+
+	w := &mqttWriter{
+		host:     u.Hostname(),
+		topic:    cfg.Topic,
+		clientID: cfg.ClientID,
+		username: cfg.Username,
+		password: cfg.Password,
+	}
+	port := u.Port()
+	switch u.Scheme {
+	case "mqtt", "tcp":
+		if port == "" {
+			port = "1883"
+		}
+	case "mqtts", "ssl", "tls":
+		w.useTLS = true
+		if port == "" {
+			port = "8883"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported mqtt url scheme %q (want mqtt or mqtts)", u.Scheme)
+	}
+	if w.host == "" {
+		return nil, fmt.Errorf("mqtt broker url has no host")
+	}
+	w.address = net.JoinHostPort(w.host, port)
+
+	if w.topic == "" {
+		w.topic = defaultMQTTTopic
+	}
+	if w.clientID == "" {
+		hostname, _ := os.Hostname()
+		w.clientID = "nano-agent-" + hostname
+	}
+	if u.User != nil && w.username == "" {
+		w.username = u.User.Username()
+		w.password, _ = u.User.Password()
+	}
+	return w, nil
+}
+
+// Write publishes the records, connecting first if needed. A failed
+// publish drops the connection so the next write reconnects.
+func (w *mqttWriter) Write(ctx context.Context, records []*poller.SinkRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil && time.Since(w.lastUsed) > mqttKeepAlive {
+		w.closeConn()
+	}
+	if w.conn == nil {
+		if err := w.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		topic := strings.ReplaceAll(w.topic, "{olt_id}", record.OLTID)
+		if err := w.publish(ctx, topic, payload); err != nil {
+			w.closeConn()
+			return fmt.Errorf("publish to %s failed: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// connect opens the connection and sends CONNECT. Callers hold w.mu.
+func (w *mqttWriter) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: writeTimeout}
+	var conn net.Conn
+	var err error
+	if w.useTLS {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: w.host, MinVersion: tls.VersionTLS12}}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", w.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", w.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to mqtt broker %s: %w", w.address, err)
+	}
+	w.conn = conn
+	w.reader = bufio.NewReader(conn)
+	w.setDeadline(ctx)
+
+	// Variable header: protocol name, level 4 (3.1.1), flags, keep alive
+	var flags byte = 0x02 // clean session
+	if w.username != "" {
+		flags |= 0x80
+		if w.password != "" {
+			flags |= 0x40
+		}
+	}
+	packet := mqttString("MQTT")
+	packet = append(packet, 4, flags)
+	packet = binary.BigEndian.AppendUint16(packet, uint16(mqttKeepAlive/time.Second))
+	packet = append(packet, mqttString(w.clientID)...)
+	if w.username != "" {
+		packet = append(packet, mqttString(w.username)...)
+		if w.password != "" {
+			packet = append(packet, mqttString(w.password)...)
+		}
+	}
+
+	if err := w.send(mqttConnect<<4, packet); err != nil {
+		w.closeConn()
+		return fmt.Errorf("failed to send mqtt connect: %w", err)
+	}
+	kind, body, err := w.receive()
+	if err != nil {
+		w.closeConn()
+		return fmt.Errorf("failed to read mqtt connack: %w", err)
+	}
+	if kind != mqttConnAck || len(body) != 2 {
+		w.closeConn()
+		return fmt.Errorf("unexpected mqtt packet type %d instead of connack", kind)
+	}
+	if code := body[1]; code != 0 {
+		w.closeConn()
+		reason, ok := mqttConnectErrors[code]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", code)
+		}
+		return fmt.Errorf("mqtt broker refused connection: %s", reason)
+	}
+	w.lastUsed = time.Now()
+	return nil
+}
+
+// publish sends a QoS 1 PUBLISH and waits for its PUBACK. Callers hold w.mu.
+func (w *mqttWriter) publish(ctx context.Context, topic string, payload []byte) error {
+	w.packetID++
+	if w.packetID == 0 {
+		w.packetID = 1
+	}
+	w.setDeadline(ctx)
+
+	packet := mqttString(topic)
+	packet = binary.BigEndian.AppendUint16(packet, w.packetID)
+	packet = append(packet, payload...)
+	if err := w.send(mqttPublish<<4|0x02, packet); err != nil {
+		return err
+	}
+
+	for {
+		kind, body, err := w.receive()
+		if err != nil {
+			return err
+		}
+		// Other packets, such as PINGRESP, are ignored
+		if kind == mqttPubAck && len(body) == 2 && binary.BigEndian.Uint16(body) == w.packetID {
+			w.lastUsed = time.Now()
+			return nil
+		}
+	}
+}
+
+// setDeadline bounds the next reads and writes by the context deadline.
+func (w *mqttWriter) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(writeTimeout)
+	}
+	_ = w.conn.SetDeadline(deadline)
+}
+
+// send writes a control packet.
+func (w *mqttWriter) send(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = append(packet, mqttLength(len(body))...)
+	packet = append(packet, body...)
+	_, err := w.conn.Write(packet)
+	return err
+}
+
+// receive reads a control packet and returns its type and body.
+func (w *mqttWriter) receive() (kind byte, body []byte, err error) {
+	header, err := w.reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed mqtt remaining length")
+		}
+		b, err := w.reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	body = make([]byte, length)
+	if _, err := io.ReadFull(w.reader, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// closeConn sends DISCONNECT and closes the connection. Callers hold w.mu.
+func (w *mqttWriter) closeConn() {
+	if w.conn == nil {
+		return
+	}
+	_ = w.conn.SetDeadline(time.Now().Add(time.Second))
+	_ = w.send(mqttDisconnect<<4, nil)
+	_ = w.conn.Close()
+	w.conn = nil
+	w.reader = nil
+}
+
+// Close disconnects from the broker.
+func (w *mqttWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closeConn()
+	return nil
+}
+
+// mqttString encodes a length-prefixed UTF-8 string.
+func mqttString(s string) []byte {
+	b := binary.BigEndian.AppendUint16(nil, uint16(len(s))) // #nosec G115 - topics, IDs and credentials are short
+	return append(b, s...)
+}
+
+// mqttLength encodes a remaining length as a variable byte integer.
+func mqttLength(n int) []byte {
+	var b []byte
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
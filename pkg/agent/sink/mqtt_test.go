@@ -0,0 +1,103 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// published is a PUBLISH packet received by the fake broker.
+type published struct {
+	topic   string
+	payload []byte
+}
+
+// fakeBroker accepts one connection, answers CONNECT with returnCode and
+// acknowledges every PUBLISH.
+func fakeBroker(t *testing.T, returnCode byte) (addr string, connect <-chan []byte, publishes <-chan published) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	connects := make(chan []byte, 1)
+	pubs := make(chan published, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// The broker side reuses the writer's packet framing
+		peer := &mqttWriter{conn: conn, reader: bufio.NewReader(conn)}
+
+		kind, body, err := peer.receive()
+		if err != nil || kind != mqttConnect {
+			return
+		}
+		connects <- body
+		if err := peer.send(mqttConnAck<<4, []byte{0, returnCode}); err != nil || returnCode != 0 {
+			return
+		}
+		for {
+			kind, body, err := peer.receive()
+			if err != nil || kind != mqttPublish {
+				return
+			}
+			topicLen := int(binary.BigEndian.Uint16(body))
+			topic := string(body[2 : 2+topicLen])
+			packetID := body[2+topicLen : 4+topicLen]
+			pubs <- published{topic: topic, payload: body[4+topicLen:]}
+			if err := peer.send(mqttPubAck<<4, packetID); err != nil {
+				return
+			}
+		}
+	}()
+	return ln.Addr().String(), connects, pubs
+}
+
+func TestMQTTWriterPublishes(t *testing.T) {
+	addr, connects, pubs := fakeBroker(t, 0)
+	w, err := newMQTTWriter(agent.SinkConfig{URL: "mqtt://" + addr, ClientID: "agent-1", Username: "poller", Password: "secret"})
+	require.NoError(t, err)
+	defer w.Close()
+
+	err = w.Write(context.Background(), []*poller.SinkRecord{{OLTID: "olt-1"}, {OLTID: "olt-2"}})
+	require.NoError(t, err)
+
+	connect := <-connects
+	assert.Equal(t, "MQTT", string(connect[2:6]))
+	assert.Equal(t, byte(0xC2), connect[7], "clean session with user name and password")
+
+	first := <-pubs
+	assert.Equal(t, "nano-agent/olt-1/poll", first.topic)
+	var record poller.SinkRecord
+	require.NoError(t, json.Unmarshal(first.payload, &record))
+	assert.Equal(t, "olt-1", record.OLTID)
+	assert.Equal(t, "nano-agent/olt-2/poll", (<-pubs).topic)
+}
+
+func TestMQTTWriterReportsRefusedConnection(t *testing.T) {
+	addr, _, _ := fakeBroker(t, 4)
+	w, err := newMQTTWriter(agent.SinkConfig{URL: "tcp://" + addr, Username: "poller", Password: "wrong"})
+	require.NoError(t, err)
+
+	err = w.Write(context.Background(), []*poller.SinkRecord{{OLTID: "olt-1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad user name or password")
+	assert.Nil(t, w.conn, "the next write reconnects")
+}
+
+func TestMQTTLength(t *testing.T) {
+	assert.Equal(t, []byte{0x00}, mqttLength(0))
+	assert.Equal(t, []byte{0x7f}, mqttLength(127))
+	assert.Equal(t, []byte{0x80, 0x01}, mqttLength(128))
+	assert.Equal(t, []byte{0xff, 0xff, 0x7f}, mqttLength(2097151))
+}
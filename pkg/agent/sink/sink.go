@@ -0,0 +1,272 @@
+// Package sink writes OLT poll results to outputs other than the control
+// plane: local JSONL files, InfluxDB, MQTT brokers and Kafka topics. Each
+// sink buffers records independently, so a slow or unreachable output does
+// not hold back the poller or the other sinks.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/nanoncore/nano-agent/pkg/agent/resilience"
+)
+
+// Sink types accepted in agent.SinkConfig.
+const (
+	TypeJSONL    = "jsonl"
+	TypeInfluxDB = "influxdb"
+	TypeMQTT     = "mqtt"
+	TypeKafka    = "kafka"
+)
+
+const (
+	defaultBufferSize    = 1000
+	defaultMaxAge        = 15 * time.Minute
+	defaultBatchSize     = 100
+	defaultFlushInterval = 10 * time.Second
+
+	// maxRetryBackoff caps how long a failing sink waits between writes.
+	maxRetryBackoff = 5 * time.Minute
+
+	// writeTimeout bounds a single write to an output.
+	writeTimeout = 30 * time.Second
+)
+
+// Writer writes batches of records to an output.
+type Writer interface {
+	Write(ctx context.Context, records []*poller.SinkRecord) error
+	Close() error
+}
+
+// Sink buffers poll records and writes them to a Writer in the background.
+// It implements poller.ResultSink.
+type Sink struct {
+	name          string
+	writer        Writer
+	buffer        *resilience.MetricsBuffer
+	batchSize     int
+	flushInterval time.Duration
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu          sync.Mutex
+	backoff     time.Duration
+	nextAttempt time.Time
+	written     int64
+	failures    int64
+	lastError   error
+}
+
+// Stats contains statistics about a sink.
+type Stats struct {
+	Name       string
+	Written    int64
+	Failures   int64
+	BufferSize int
+	LastError  string
+}
+
+// New creates the sink described by cfg and starts writing in the background.
+func New(cfg agent.SinkConfig) (*Sink, error) {
+	writer, err := newWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Type
+	}
+	return newSink(name, writer, cfg), nil
+}
+
+// newWriter creates the writer for a sink type.
+func newWriter(cfg agent.SinkConfig) (Writer, error) {
+	switch strings.ToLower(cfg.Type) {
+	case TypeJSONL:
+		return newJSONLWriter(cfg)
+	case TypeInfluxDB:
+		return newInfluxWriter(cfg)
+	case TypeMQTT:
+		return newMQTTWriter(cfg)
+	case TypeKafka:
+		return newKafkaWriter(cfg)
+	case "":
+		return nil, fmt.Errorf("sink type is required")
+	default:
+		return nil, fmt.Errorf("unknown sink type %q (want %s, %s, %s or %s)", cfg.Type, TypeJSONL, TypeInfluxDB, TypeMQTT, TypeKafka)
+	}
+}
+
+// newSink wraps a writer with buffering and starts its write loop.
+func newSink(name string, writer Writer, cfg agent.SinkConfig) *Sink {
+	bufferCfg := resilience.MetricsBufferConfig{
+		MaxSize: cfg.BufferSize,
+		MaxAge:  time.Duration(cfg.MaxAge) * time.Second,
+	}
+	if bufferCfg.MaxSize <= 0 {
+		bufferCfg.MaxSize = defaultBufferSize
+	}
+	if bufferCfg.MaxAge <= 0 {
+		bufferCfg.MaxAge = defaultMaxAge
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	flushInterval := time.Duration(cfg.FlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Sink{
+		name:          name,
+		writer:        writer,
+		buffer:        resilience.NewMetricsBuffer(bufferCfg),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		wake:          make(chan struct{}, 1),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Name returns the sink name.
+func (s *Sink) Name() string {
+	return s.name
+}
+
+// Submit buffers a record for writing. When the buffer is full the oldest
+// record is dropped.
+func (s *Sink) Submit(record *poller.SinkRecord) {
+	s.buffer.Add(record)
+	if s.buffer.Size() >= s.batchSize {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run writes buffered records every flush interval, or sooner once a full
+// batch is buffered.
+func (s *Sink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.flush(s.ctx)
+		case <-s.wake:
+			s.flush(s.ctx)
+		}
+	}
+}
+
+// flush writes buffered records batch by batch until the buffer is empty
+// or a write fails. A failed batch is requeued and the sink backs off.
+func (s *Sink) flush(ctx context.Context) {
+	s.mu.Lock()
+	waiting := time.Now().Before(s.nextAttempt)
+	s.mu.Unlock()
+	if waiting {
+		return
+	}
+
+	for ctx.Err() == nil {
+		batches := s.buffer.DrainN(s.batchSize)
+		if len(batches) == 0 {
+			return
+		}
+		records := make([]*poller.SinkRecord, 0, len(batches))
+		for _, b := range batches {
+			if record, ok := b.Data.(*poller.SinkRecord); ok {
+				records = append(records, record)
+			}
+		}
+
+		writeCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+		err := s.writer.Write(writeCtx, records)
+		cancel()
+
+		s.mu.Lock()
+		if err != nil {
+			s.failures++
+			s.lastError = err
+			s.backoff *= 2
+			if s.backoff == 0 {
+				s.backoff = s.flushInterval
+			}
+			if s.backoff > maxRetryBackoff {
+				s.backoff = maxRetryBackoff
+			}
+			s.nextAttempt = time.Now().Add(s.backoff)
+			backoff := s.backoff
+			s.mu.Unlock()
+
+			s.buffer.Requeue(batches)
+			s.log("Write of %d records failed, retrying in %s: %v", len(records), backoff, err)
+			return
+		}
+		s.written += int64(len(records))
+		s.backoff = 0
+		s.nextAttempt = time.Time{}
+		s.mu.Unlock()
+	}
+}
+
+// Close stops the write loop, writes what is still buffered and closes the
+// output.
+func (s *Sink) Close() error {
+	s.cancel()
+	s.wg.Wait()
+
+	// Last attempt for buffered records, regardless of backoff
+	s.mu.Lock()
+	s.nextAttempt = time.Time{}
+	s.mu.Unlock()
+	s.flush(context.Background())
+	if n := s.buffer.Size(); n > 0 {
+		s.log("Dropping %d unwritten records", n)
+	}
+	return s.writer.Close()
+}
+
+// Stats returns current statistics.
+func (s *Sink) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		Name:       s.name,
+		Written:    s.written,
+		Failures:   s.failures,
+		BufferSize: s.buffer.Size(),
+	}
+	if s.lastError != nil {
+		stats.LastError = s.lastError.Error()
+	}
+	return stats
+}
+
+// log outputs a log message with the sink name.
+func (s *Sink) log(format string, args ...interface{}) {
+	log.Printf("%s [sink:%s] "+format, append([]interface{}{time.Now().Format("15:04:05"), s.name}, args...)...)
+}
@@ -0,0 +1,141 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nanoncore/nano-agent/pkg/agent"
+	"github.com/nanoncore/nano-agent/pkg/agent/poller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyWriter fails while failing is set and records what it wrote.
+type flakyWriter struct {
+	mu      sync.Mutex
+	failing bool
+	written []string
+	closed  bool
+}
+
+func (w *flakyWriter) Write(ctx context.Context, records []*poller.SinkRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failing {
+		return errors.New("connection refused")
+	}
+	for _, r := range records {
+		w.written = append(w.written, r.OLTID)
+	}
+	return nil
+}
+
+func (w *flakyWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  agent.SinkConfig
+		err  string
+	}{
+		{"missing type", agent.SinkConfig{}, "sink type is required"},
+		{"unknown type", agent.SinkConfig{Type: "syslog"}, `unknown sink type "syslog"`},
+		{"jsonl without path", agent.SinkConfig{Type: TypeJSONL}, "jsonl sink needs a path"},
+		{"influxdb without bucket", agent.SinkConfig{Type: TypeInfluxDB, URL: "http://influx:8086"}, "bucket (2.x) or database (1.x)"},
+		{"mqtt with http url", agent.SinkConfig{Type: TypeMQTT, URL: "http://broker"}, "unsupported mqtt url scheme"},
+		{"kafka without topic", agent.SinkConfig{Type: TypeKafka, URL: "http://proxy:8082"}, "kafka sink needs a topic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestSinkRetriesAfterFailure(t *testing.T) {
+	w := &flakyWriter{failing: true}
+	s := newSink("test", w, agent.SinkConfig{BatchSize: 2, FlushInterval: 3600})
+	s.cancel() // drive flushes by hand
+	s.wg.Wait()
+
+	s.Submit(&poller.SinkRecord{OLTID: "olt-1"})
+	s.Submit(&poller.SinkRecord{OLTID: "olt-2"})
+	s.Submit(&poller.SinkRecord{OLTID: "olt-3"})
+
+	s.flush(context.Background())
+	stats := s.Stats()
+	assert.Equal(t, int64(1), stats.Failures)
+	assert.Equal(t, 3, stats.BufferSize, "failed records stay buffered")
+	assert.Equal(t, "connection refused", stats.LastError)
+
+	// Backing off: no write is attempted
+	w.mu.Lock()
+	w.failing = false
+	w.mu.Unlock()
+	s.flush(context.Background())
+	assert.Equal(t, 3, s.Stats().BufferSize)
+
+	s.mu.Lock()
+	s.nextAttempt = time.Time{}
+	s.mu.Unlock()
+	s.flush(context.Background())
+	assert.Equal(t, []string{"olt-1", "olt-2", "olt-3"}, w.written, "records are written in order")
+	assert.Equal(t, int64(3), s.Stats().Written)
+
+	require.NoError(t, s.Close())
+	assert.True(t, w.closed)
+}
+
+func TestSinkDropsOldestWhenFull(t *testing.T) {
+	w := &flakyWriter{}
+	s := newSink("test", w, agent.SinkConfig{BufferSize: 2, BatchSize: 10, FlushInterval: 3600})
+	s.Submit(&poller.SinkRecord{OLTID: "olt-1"})
+	s.Submit(&poller.SinkRecord{OLTID: "olt-2"})
+	s.Submit(&poller.SinkRecord{OLTID: "olt-3"})
+
+	require.NoError(t, s.Close())
+	assert.Equal(t, []string{"olt-2", "olt-3"}, w.written, "buffered records are written on close")
+}
+
+func TestJSONLSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results", "polls.jsonl")
+	s, err := New(agent.SinkConfig{Type: TypeJSONL, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, TypeJSONL, s.Name())
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.Submit(&poller.SinkRecord{OLTID: "olt-1", OLTName: "OLT 1", Timestamp: at, Groups: []poller.MetricGroup{poller.GroupONUStatus}})
+	s.Submit(&poller.SinkRecord{OLTID: "olt-2", Timestamp: at})
+	require.NoError(t, s.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []poller.SinkRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r poller.SinkRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "OLT 1", records[0].OLTName)
+	assert.Equal(t, at, records[0].Timestamp)
+	assert.Equal(t, []poller.MetricGroup{poller.GroupONUStatus}, records[0].Groups)
+	assert.Equal(t, "olt-2", records[1].OLTID)
+}
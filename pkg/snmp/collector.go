@@ -48,6 +48,10 @@ func (c *BaseCollector) Connect() error {
 		return nil // Already connected
 	}
 
+	if err := c.config.Validate(); err != nil {
+		return err
+	}
+
 	client := &gosnmp.GoSNMP{
 		Target:    c.config.Host,
 		Port:      c.config.Port,
@@ -57,17 +61,22 @@ func (c *BaseCollector) Connect() error {
 	}
 
 	switch c.config.Version {
+	case SNMPv1:
+		client.Version = gosnmp.Version1
 	case SNMPv2c:
 		client.Version = gosnmp.Version2c
 	case SNMPv3:
+		// Protocols were checked by Validate
+		authProtocol, _ := parseAuthProtocol(c.config.AuthProtocol)
+		privProtocol, _ := parsePrivProtocol(c.config.PrivProtocol)
 		client.Version = gosnmp.Version3
 		client.SecurityModel = gosnmp.UserSecurityModel
-		client.MsgFlags = gosnmp.AuthPriv
+		client.MsgFlags = msgFlags(c.config.SecurityLevel())
 		client.SecurityParameters = &gosnmp.UsmSecurityParameters{
 			UserName:                 c.config.Username,
-			AuthenticationProtocol:   parseAuthProtocol(c.config.AuthProtocol),
+			AuthenticationProtocol:   authProtocol,
 			AuthenticationPassphrase: c.config.AuthPassword,
-			PrivacyProtocol:          parsePrivProtocol(c.config.PrivProtocol),
+			PrivacyProtocol:          privProtocol,
 			PrivacyPassphrase:        c.config.PrivPassword,
 		}
 	default:
@@ -109,7 +118,8 @@ func (c *BaseCollector) Get(oids []string) (*gosnmp.SnmpPacket, error) {
 		return nil, fmt.Errorf("not connected")
 	}
 
-	return c.client.Get(oids)
+	packet, err := c.client.Get(oids)
+	return packet, ExplainError(err)
 }
 
 // Walk performs an SNMP walk operation.
@@ -121,7 +131,7 @@ func (c *BaseCollector) Walk(rootOid string, walkFn gosnmp.WalkFunc) error {
 		return fmt.Errorf("not connected")
 	}
 
-	return c.client.Walk(rootOid, walkFn)
+	return ExplainError(c.client.Walk(rootOid, walkFn))
 }
 
 // BulkWalk performs an SNMP bulk walk operation (more efficient).
@@ -133,7 +143,7 @@ func (c *BaseCollector) BulkWalk(rootOid string, walkFn gosnmp.WalkFunc) error {
 		return fmt.Errorf("not connected")
 	}
 
-	return c.client.BulkWalk(rootOid, walkFn)
+	return ExplainError(c.client.BulkWalk(rootOid, walkFn))
 }
 
 // Helper functions for parsing SNMP values
@@ -273,33 +283,41 @@ func ConvertMWtoDBm(mw float64) float64 {
 
 // Protocol parsing helpers
 
-func parseAuthProtocol(proto string) gosnmp.SnmpV3AuthProtocol {
-	switch strings.ToUpper(proto) {
+func parseAuthProtocol(proto string) (gosnmp.SnmpV3AuthProtocol, error) {
+	switch strings.ToUpper(strings.ReplaceAll(proto, "-", "")) {
+	case "":
+		return gosnmp.NoAuth, nil
 	case "MD5":
-		return gosnmp.MD5
-	case "SHA":
-		return gosnmp.SHA
+		return gosnmp.MD5, nil
+	case "SHA", "SHA1":
+		return gosnmp.SHA, nil
+	case "SHA224":
+		return gosnmp.SHA224, nil
 	case "SHA256":
-		return gosnmp.SHA256
+		return gosnmp.SHA256, nil
+	case "SHA384":
+		return gosnmp.SHA384, nil
 	case "SHA512":
-		return gosnmp.SHA512
+		return gosnmp.SHA512, nil
 	default:
-		return gosnmp.NoAuth
+		return gosnmp.NoAuth, fmt.Errorf("unsupported SNMPv3 auth protocol %q (use MD5, SHA, SHA224, SHA256, SHA384 or SHA512)", proto)
 	}
 }
 
-func parsePrivProtocol(proto string) gosnmp.SnmpV3PrivProtocol {
-	switch strings.ToUpper(proto) {
+func parsePrivProtocol(proto string) (gosnmp.SnmpV3PrivProtocol, error) {
+	switch strings.ToUpper(strings.ReplaceAll(proto, "-", "")) {
+	case "":
+		return gosnmp.NoPriv, nil
 	case "DES":
-		return gosnmp.DES
-	case "AES":
-		return gosnmp.AES
+		return gosnmp.DES, nil
+	case "AES", "AES128":
+		return gosnmp.AES, nil
 	case "AES192":
-		return gosnmp.AES192
+		return gosnmp.AES192, nil
 	case "AES256":
-		return gosnmp.AES256
+		return gosnmp.AES256, nil
 	default:
-		return gosnmp.NoPriv
+		return gosnmp.NoPriv, fmt.Errorf("unsupported SNMPv3 privacy protocol %q (use DES, AES, AES192 or AES256)", proto)
 	}
 }
 
@@ -0,0 +1,241 @@
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// Standard IF-MIB OIDs (RFC 2863), supported by all vendors
+const (
+	IfTable  = "1.3.6.1.2.1.2.2.1"
+	IfXTable = "1.3.6.1.2.1.31.1.1.1"
+)
+
+// IF-MIB interface columns
+var ifOIDs = struct {
+	Descr        string
+	AdminStatus  string
+	OperStatus   string
+	InOctets     string
+	InUcastPkts  string
+	OutOctets    string
+	OutUcastPkts string
+	InDiscards   string
+	InErrors     string
+	OutDiscards  string
+	OutErrors    string
+	Name         string
+	HCInOctets   string
+	HCInPkts     string
+	HCOutOctets  string
+	HCOutPkts    string
+	HighSpeed    string
+	Alias        string
+}{
+	Descr:        IfTable + ".2",
+	AdminStatus:  IfTable + ".7",
+	OperStatus:   IfTable + ".8",
+	InOctets:     IfTable + ".10",
+	InUcastPkts:  IfTable + ".11",
+	OutOctets:    IfTable + ".16",
+	OutUcastPkts: IfTable + ".17",
+	InDiscards:   IfTable + ".13",
+	InErrors:     IfTable + ".14",
+	OutDiscards:  IfTable + ".19",
+	OutErrors:    IfTable + ".20",
+	Name:         IfXTable + ".1",
+	HCInOctets:   IfXTable + ".6",
+	HCInPkts:     IfXTable + ".7",
+	HCOutOctets:  IfXTable + ".10",
+	HCOutPkts:    IfXTable + ".11",
+	HighSpeed:    IfXTable + ".15",
+	Alias:        IfXTable + ".18",
+}
+
+// InterfaceStats represents IF-MIB state and counters of an interface.
+type InterfaceStats struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Alias       string `json:"alias,omitempty"`
+	AdminStatus string `json:"admin_status"` // up, down, testing
+	OperStatus  string `json:"oper_status"`  // up, down, testing, unknown, dormant, not_present, lower_layer_down
+	SpeedMbps   uint64 `json:"speed_mbps"`
+	InOctets    uint64 `json:"in_octets"`
+	OutOctets   uint64 `json:"out_octets"`
+	InPackets   uint64 `json:"in_packets"`
+	OutPackets  uint64 `json:"out_packets"`
+	InErrors    uint64 `json:"in_errors"`
+	OutErrors   uint64 `json:"out_errors"`
+	InDiscards  uint64 `json:"in_discards"`
+	OutDiscards uint64 `json:"out_discards"`
+
+	// Counters32 is set when the octet and packet counters are the 32-bit
+	// ifTable counters, which wrap within minutes on fast links
+	Counters32 bool `json:"counters_32,omitempty"`
+}
+
+// CollectInterfaces gathers state and counters for every interface in IF-MIB.
+// Counters are the 64-bit ifXTable counters where the device supports them;
+// interfaces without them get the 32-bit ifTable counters and Counters32.
+func (c *BaseCollector) CollectInterfaces(ctx context.Context) ([]InterfaceStats, error) {
+	ifMap := make(map[int]*InterfaceStats)
+	hcCounters := make(map[int]bool) // interfaces with ifXTable counters
+
+	columns := []string{
+		ifOIDs.Descr, ifOIDs.AdminStatus, ifOIDs.OperStatus,
+		ifOIDs.InDiscards, ifOIDs.InErrors, ifOIDs.OutDiscards, ifOIDs.OutErrors,
+		ifOIDs.Name, ifOIDs.HCInOctets, ifOIDs.HCInPkts, ifOIDs.HCOutOctets, ifOIDs.HCOutPkts,
+		ifOIDs.HighSpeed, ifOIDs.Alias,
+	}
+	for _, column := range columns {
+		err := c.BulkWalk(column, func(pdu gosnmp.SnmpPDU) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			indices := ExtractIndex(pdu.Name, column)
+			if len(indices) != 1 {
+				return nil
+			}
+
+			stats, exists := ifMap[indices[0]]
+			if !exists {
+				stats = &InterfaceStats{Index: indices[0]}
+				ifMap[indices[0]] = stats
+			}
+			applyInterfaceColumn(stats, column, pdu.Value)
+			if isHCCounterColumn(column) {
+				hcCounters[indices[0]] = true
+			}
+			return nil
+		})
+		if err != nil {
+			// ifTable is mandatory; ifXTable columns are optional
+			if strings.HasPrefix(column, IfTable+".") {
+				return nil, fmt.Errorf("failed to collect interfaces: %w", err)
+			}
+		}
+	}
+
+	// Fall back to the 32-bit ifTable counters for interfaces the ifXTable
+	// does not cover, such as on SNMPv1-only devices
+	if len(hcCounters) < len(ifMap) {
+		for _, column := range []string{ifOIDs.InOctets, ifOIDs.InUcastPkts, ifOIDs.OutOctets, ifOIDs.OutUcastPkts} {
+			err := c.BulkWalk(column, func(pdu gosnmp.SnmpPDU) error {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+				}
+
+				indices := ExtractIndex(pdu.Name, column)
+				if len(indices) != 1 || hcCounters[indices[0]] {
+					return nil
+				}
+				if stats, exists := ifMap[indices[0]]; exists {
+					applyInterfaceColumn(stats, column, pdu.Value)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to collect interface counters: %w", err)
+			}
+		}
+	}
+
+	interfaces := make([]InterfaceStats, 0, len(ifMap))
+	for _, stats := range ifMap {
+		if stats.Name == "" {
+			stats.Name = stats.Description
+		}
+		interfaces = append(interfaces, *stats)
+	}
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].Index < interfaces[j].Index })
+
+	return interfaces, nil
+}
+
+// applyInterfaceColumn stores the value of an IF-MIB column in stats.
+func applyInterfaceColumn(stats *InterfaceStats, column string, value interface{}) {
+	switch column {
+	case ifOIDs.Descr:
+		stats.Description = ParseString(value)
+	case ifOIDs.Name:
+		stats.Name = ParseString(value)
+	case ifOIDs.Alias:
+		stats.Alias = ParseString(value)
+	case ifOIDs.AdminStatus:
+		stats.AdminStatus = parseIfStatus(ParseInt64(value))
+	case ifOIDs.OperStatus:
+		stats.OperStatus = parseIfStatus(ParseInt64(value))
+	case ifOIDs.HighSpeed:
+		stats.SpeedMbps = ParseUint64(value)
+	case ifOIDs.InOctets:
+		stats.InOctets = ParseUint64(value)
+		stats.Counters32 = true
+	case ifOIDs.OutOctets:
+		stats.OutOctets = ParseUint64(value)
+		stats.Counters32 = true
+	case ifOIDs.InUcastPkts:
+		stats.InPackets = ParseUint64(value)
+		stats.Counters32 = true
+	case ifOIDs.OutUcastPkts:
+		stats.OutPackets = ParseUint64(value)
+		stats.Counters32 = true
+	case ifOIDs.HCInOctets:
+		stats.InOctets = ParseUint64(value)
+	case ifOIDs.HCOutOctets:
+		stats.OutOctets = ParseUint64(value)
+	case ifOIDs.HCInPkts:
+		stats.InPackets = ParseUint64(value)
+	case ifOIDs.HCOutPkts:
+		stats.OutPackets = ParseUint64(value)
+	case ifOIDs.InErrors:
+		stats.InErrors = ParseUint64(value)
+	case ifOIDs.OutErrors:
+		stats.OutErrors = ParseUint64(value)
+	case ifOIDs.InDiscards:
+		stats.InDiscards = ParseUint64(value)
+	case ifOIDs.OutDiscards:
+		stats.OutDiscards = ParseUint64(value)
+	}
+}
+
+// isHCCounterColumn reports whether an IF-MIB column is a 64-bit ifXTable
+// octet or packet counter.
+func isHCCounterColumn(column string) bool {
+	switch column {
+	case ifOIDs.HCInOctets, ifOIDs.HCInPkts, ifOIDs.HCOutOctets, ifOIDs.HCOutPkts:
+		return true
+	}
+	return false
+}
+
+// parseIfStatus converts an IF-MIB ifAdminStatus/ifOperStatus value to a string.
+func parseIfStatus(status int64) string {
+	switch status {
+	case 1:
+		return "up"
+	case 2:
+		return "down"
+	case 3:
+		return "testing"
+	case 4:
+		return "unknown"
+	case 5:
+		return "dormant"
+	case 6:
+		return "not_present"
+	case 7:
+		return "lower_layer_down"
+	default:
+		return fmt.Sprintf("unknown(%d)", status)
+	}
+}
@@ -0,0 +1,71 @@
+package snmp
+
+import "testing"
+
+func TestParseIfStatus(t *testing.T) {
+	tests := []struct {
+		status   int64
+		expected string
+	}{
+		{1, "up"},
+		{2, "down"},
+		{7, "lower_layer_down"},
+		{42, "unknown(42)"},
+	}
+
+	for _, tt := range tests {
+		if result := parseIfStatus(tt.status); result != tt.expected {
+			t.Errorf("parseIfStatus(%d) = %s, want %s", tt.status, result, tt.expected)
+		}
+	}
+}
+
+func TestApplyInterfaceColumn(t *testing.T) {
+	stats := &InterfaceStats{Index: 7}
+
+	applyInterfaceColumn(stats, ifOIDs.Name, []byte("xge0/9/0"))
+	applyInterfaceColumn(stats, ifOIDs.OperStatus, 1)
+	applyInterfaceColumn(stats, ifOIDs.HighSpeed, uint32(10000))
+	applyInterfaceColumn(stats, ifOIDs.HCInOctets, uint64(1<<40))
+	applyInterfaceColumn(stats, ifOIDs.InErrors, uint32(3))
+
+	if stats.Name != "xge0/9/0" {
+		t.Errorf("Name = %q, want xge0/9/0", stats.Name)
+	}
+	if stats.OperStatus != "up" {
+		t.Errorf("OperStatus = %q, want up", stats.OperStatus)
+	}
+	if stats.SpeedMbps != 10000 {
+		t.Errorf("SpeedMbps = %d, want 10000", stats.SpeedMbps)
+	}
+	if stats.InOctets != 1<<40 {
+		t.Errorf("InOctets = %d, want %d", stats.InOctets, uint64(1<<40))
+	}
+	if stats.InErrors != 3 {
+		t.Errorf("InErrors = %d, want 3", stats.InErrors)
+	}
+}
+
+func TestApplyInterfaceColumn32BitCounters(t *testing.T) {
+	stats := &InterfaceStats{Index: 3}
+
+	applyInterfaceColumn(stats, ifOIDs.InOctets, uint32(4000000000))
+	applyInterfaceColumn(stats, ifOIDs.OutUcastPkts, uint32(12))
+
+	if stats.InOctets != 4000000000 {
+		t.Errorf("InOctets = %d, want 4000000000", stats.InOctets)
+	}
+	if stats.OutPackets != 12 {
+		t.Errorf("OutPackets = %d, want 12", stats.OutPackets)
+	}
+	if !stats.Counters32 {
+		t.Error("Counters32 = false, want true for ifTable counters")
+	}
+
+	if !isHCCounterColumn(ifOIDs.HCOutOctets) {
+		t.Error("HCOutOctets is a 64-bit counter column")
+	}
+	if isHCCounterColumn(ifOIDs.OutOctets) || isHCCounterColumn(ifOIDs.HighSpeed) {
+		t.Error("ifOutOctets and ifHighSpeed are not 64-bit counter columns")
+	}
+}
@@ -0,0 +1,198 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// SNMPv3 security levels (RFC 3414).
+const (
+	SecurityNoAuthNoPriv = "noAuthNoPriv"
+	SecurityAuthNoPriv   = "authNoPriv"
+	SecurityAuthPriv     = "authPriv"
+)
+
+// minPassphraseLength is the shortest SNMPv3 passphrase RFC 3414 allows.
+const minPassphraseLength = 8
+
+// ParseVersion parses a configured SNMP version such as "2c" or "3". An
+// empty version is SNMPv2c.
+func ParseVersion(version string) (SNMPVersion, error) {
+	switch strings.ToLower(strings.TrimSpace(version)) {
+	case "1", "v1":
+		return SNMPv1, nil
+	case "", "2", "2c", "v2", "v2c":
+		return SNMPv2c, nil
+	case "3", "v3":
+		return SNMPv3, nil
+	default:
+		return "", fmt.Errorf("unsupported SNMP version %q (use 1, 2c or 3)", version)
+	}
+}
+
+// Settings are the SNMP settings of an OLT as configured. The fields match
+// the agent and poller SNMP configs one for one, so those convert directly.
+type Settings struct {
+	Enabled   bool
+	Port      int
+	Community string
+	Version   string
+
+	Username     string
+	AuthProtocol string
+	AuthPassword string
+	PrivProtocol string
+	PrivPassword string
+}
+
+// NewDeviceConfig returns the device config for the OLT name at host,
+// rejecting an unknown version or bad SNMPv3 credentials before anything is
+// sent to the OLT.
+func NewDeviceConfig(name, host string, settings Settings, timeout time.Duration) (DeviceConfig, error) {
+	version, err := ParseVersion(settings.Version)
+	if err != nil {
+		return DeviceConfig{}, fmt.Errorf("invalid SNMP settings for %s: %w", name, err)
+	}
+	device := DeviceConfig{
+		Host:         host,
+		Port:         uint16(settings.Port), // #nosec G115 - SNMP ports fit in uint16
+		Community:    settings.Community,
+		Version:      version,
+		Timeout:      timeout,
+		Username:     settings.Username,
+		AuthProtocol: settings.AuthProtocol,
+		AuthPassword: settings.AuthPassword,
+		PrivProtocol: settings.PrivProtocol,
+		PrivPassword: settings.PrivPassword,
+	}
+	if err := device.Validate(); err != nil {
+		return DeviceConfig{}, fmt.Errorf("invalid SNMP credentials for %s: %w", name, err)
+	}
+	return device, nil
+}
+
+// SecurityLevel returns the SNMPv3 security level implied by the configured
+// protocols.
+func (c DeviceConfig) SecurityLevel() string {
+	switch {
+	case c.AuthProtocol == "":
+		return SecurityNoAuthNoPriv
+	case c.PrivProtocol == "":
+		return SecurityAuthNoPriv
+	default:
+		return SecurityAuthPriv
+	}
+}
+
+// Validate checks the SNMPv3 settings, so bad credentials are reported
+// before anything is sent to the device. Other versions always pass.
+func (c DeviceConfig) Validate() error {
+	if c.Version != SNMPv3 {
+		return nil
+	}
+	if c.Username == "" {
+		return fmt.Errorf("SNMPv3 requires a username")
+	}
+	if _, err := parseAuthProtocol(c.AuthProtocol); err != nil {
+		return err
+	}
+	if _, err := parsePrivProtocol(c.PrivProtocol); err != nil {
+		return err
+	}
+	if c.AuthProtocol == "" {
+		if c.PrivProtocol != "" {
+			return fmt.Errorf("SNMPv3 privacy protocol %s requires an auth protocol", c.PrivProtocol)
+		}
+		return nil
+	}
+	if len(c.AuthPassword) < minPassphraseLength {
+		return fmt.Errorf("SNMPv3 auth password for user %q must be at least %d characters", c.Username, minPassphraseLength)
+	}
+	if c.PrivProtocol != "" && len(c.PrivPassword) < minPassphraseLength {
+		return fmt.Errorf("SNMPv3 privacy password for user %q must be at least %d characters", c.Username, minPassphraseLength)
+	}
+	return nil
+}
+
+// msgFlags returns the gosnmp message flags for a security level.
+func msgFlags(level string) gosnmp.SnmpV3MsgFlags {
+	switch level {
+	case SecurityAuthPriv:
+		return gosnmp.AuthPriv
+	case SecurityAuthNoPriv:
+		return gosnmp.AuthNoPriv
+	default:
+		return gosnmp.NoAuthNoPriv
+	}
+}
+
+// ExplainError turns the SNMPv3 errors a device reports for bad credentials
+// into errors that say what to check. Other errors are returned unchanged.
+func ExplainError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gosnmp.ErrUnknownUsername):
+		return fmt.Errorf("SNMPv3 user is not configured on the device: %w", err)
+	case errors.Is(err, gosnmp.ErrWrongDigest):
+		return fmt.Errorf("SNMPv3 authentication failed, check the auth protocol and password: %w", err)
+	case errors.Is(err, gosnmp.ErrDecryption):
+		return fmt.Errorf("SNMPv3 decryption failed, check the privacy protocol and password: %w", err)
+	case errors.Is(err, gosnmp.ErrUnknownSecurityLevel):
+		return fmt.Errorf("device does not accept the SNMPv3 security level of this user: %w", err)
+	default:
+		return err
+	}
+}
+
+// DriverMetadata returns the equipment metadata the southbound SNMP driver
+// reads its settings from.
+func (c DeviceConfig) DriverMetadata() map[string]string {
+	metadata := map[string]string{
+		"snmp_community": c.Community,
+		"snmp_version":   string(c.Version),
+	}
+	if c.Version != SNMPv3 {
+		return metadata
+	}
+	metadata["snmp_version"] = "3"
+	metadata["snmp_username"] = c.Username
+	metadata["snmp_security_level"] = c.SecurityLevel()
+	metadata["snmp_auth_protocol"] = c.AuthProtocol
+	metadata["snmp_auth_password"] = c.AuthPassword
+	metadata["snmp_priv_protocol"] = c.PrivProtocol
+	metadata["snmp_priv_password"] = c.PrivPassword
+	return metadata
+}
+
+// DriverCredentials returns the username and password the southbound SNMP
+// driver authenticates SNMPv3 with. That driver always uses SHA
+// authentication and AES privacy keyed by the equipment password, so other
+// settings are rejected here rather than failing on the device.
+//
+// Vendor adapters log in to the CLI with the equipment credentials they were
+// created with, so these belong only in the config passed to Connect.
+func (c DeviceConfig) DriverCredentials() (username, password string, err error) {
+	if err := c.Validate(); err != nil {
+		return "", "", err
+	}
+	auth, _ := parseAuthProtocol(c.AuthProtocol)
+	priv, _ := parsePrivProtocol(c.PrivProtocol)
+	if auth != gosnmp.SHA || priv != gosnmp.AES || c.AuthPassword != c.PrivPassword {
+		return "", "", fmt.Errorf("SNMPv3 user %q uses %s/%s: OLT drivers support SHA authentication with AES privacy and one password for both",
+			c.Username, protocolName(c.AuthProtocol), protocolName(c.PrivProtocol))
+	}
+	return c.Username, c.AuthPassword, nil
+}
+
+// protocolName names a configured protocol for error messages.
+func protocolName(proto string) string {
+	if proto == "" {
+		return "none"
+	}
+	return strings.ToUpper(proto)
+}
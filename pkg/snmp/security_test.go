@@ -0,0 +1,149 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected SNMPVersion
+	}{
+		{"", SNMPv2c},
+		{"2c", SNMPv2c},
+		{"v2c", SNMPv2c},
+		{"1", SNMPv1},
+		{"3", SNMPv3},
+		{"V3", SNMPv3},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if err != nil || got != tt.expected {
+			t.Errorf("ParseVersion(%q) = %q, %v; want %q", tt.in, got, err, tt.expected)
+		}
+	}
+	if _, err := ParseVersion("4"); err == nil {
+		t.Error("ParseVersion(\"4\") should fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := DeviceConfig{
+		Version:      SNMPv3,
+		Username:     "nms",
+		AuthProtocol: "SHA256",
+		AuthPassword: "authpass1",
+		PrivProtocol: "AES",
+		PrivPassword: "privpass1",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if level := valid.SecurityLevel(); level != SecurityAuthPriv {
+		t.Errorf("SecurityLevel() = %s, want %s", level, SecurityAuthPriv)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*DeviceConfig)
+		want   string
+	}{
+		{"no user", func(c *DeviceConfig) { c.Username = "" }, "requires a username"},
+		{"unknown auth", func(c *DeviceConfig) { c.AuthProtocol = "SHA3" }, "unsupported SNMPv3 auth protocol"},
+		{"unknown priv", func(c *DeviceConfig) { c.PrivProtocol = "3DES" }, "unsupported SNMPv3 privacy protocol"},
+		{"priv without auth", func(c *DeviceConfig) { c.AuthProtocol = "" }, "requires an auth protocol"},
+		{"short auth password", func(c *DeviceConfig) { c.AuthPassword = "short" }, "auth password"},
+		{"short priv password", func(c *DeviceConfig) { c.PrivPassword = "" }, "privacy password"},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Validate() = %v, want error containing %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Community-based versions have nothing to validate
+	if err := (DeviceConfig{Version: SNMPv2c}).Validate(); err != nil {
+		t.Errorf("v2c Validate() = %v", err)
+	}
+}
+
+func TestNewDeviceConfig(t *testing.T) {
+	settings := Settings{
+		Port:         161,
+		Version:      "3",
+		Username:     "nms",
+		AuthProtocol: "SHA",
+		AuthPassword: "authpass1",
+		PrivProtocol: "AES",
+		PrivPassword: "privpass1",
+	}
+	device, err := NewDeviceConfig("olt-1", "192.0.2.1", settings, 0)
+	if err != nil {
+		t.Fatalf("NewDeviceConfig() = %v", err)
+	}
+	if device.Version != SNMPv3 || device.Host != "192.0.2.1" || device.Port != 161 || device.SecurityLevel() != SecurityAuthPriv {
+		t.Errorf("NewDeviceConfig() = %+v", device)
+	}
+
+	bad := settings
+	bad.AuthPassword = "short"
+	if _, err := NewDeviceConfig("olt-1", "192.0.2.1", bad, 0); err == nil || !strings.Contains(err.Error(), "invalid SNMP credentials for olt-1") {
+		t.Errorf("NewDeviceConfig(short password) = %v", err)
+	}
+	bad = settings
+	bad.Version = "4"
+	if _, err := NewDeviceConfig("olt-1", "192.0.2.1", bad, 0); err == nil || !strings.Contains(err.Error(), "invalid SNMP settings for olt-1") {
+		t.Errorf("NewDeviceConfig(version 4) = %v", err)
+	}
+}
+
+func TestExplainError(t *testing.T) {
+	err := ExplainError(fmt.Errorf("walk failed: %w", gosnmp.ErrWrongDigest))
+	if !errors.Is(err, gosnmp.ErrWrongDigest) || !strings.Contains(err.Error(), "check the auth protocol and password") {
+		t.Errorf("ExplainError(wrong digest) = %v", err)
+	}
+	other := errors.New("request timeout")
+	if got := ExplainError(other); got != other {
+		t.Errorf("ExplainError(other) = %v, want it unchanged", got)
+	}
+	if ExplainError(nil) != nil {
+		t.Error("ExplainError(nil) should be nil")
+	}
+}
+
+func TestDriverSettings(t *testing.T) {
+	cfg := DeviceConfig{
+		Version:      SNMPv3,
+		Username:     "nms",
+		AuthProtocol: "sha",
+		AuthPassword: "secretpass",
+		PrivProtocol: "aes",
+		PrivPassword: "secretpass",
+	}
+	metadata := cfg.DriverMetadata()
+	if metadata["snmp_version"] != "3" || metadata["snmp_username"] != "nms" || metadata["snmp_security_level"] != SecurityAuthPriv {
+		t.Errorf("DriverMetadata() = %v", metadata)
+	}
+	user, password, err := cfg.DriverCredentials()
+	if err != nil || user != "nms" || password != "secretpass" {
+		t.Errorf("DriverCredentials() = %q, %q, %v", user, password, err)
+	}
+
+	cfg.PrivPassword = "otherpass"
+	if _, _, err := cfg.DriverCredentials(); err == nil {
+		t.Error("DriverCredentials() should reject different auth and privacy passwords")
+	}
+	cfg.PrivPassword = cfg.AuthPassword
+	cfg.AuthProtocol = "MD5"
+	if _, _, err := cfg.DriverCredentials(); err == nil || !strings.Contains(err.Error(), "MD5/AES") {
+		t.Errorf("DriverCredentials() = %v, want MD5 rejected", err)
+	}
+}
@@ -22,6 +22,7 @@ const (
 type SNMPVersion string
 
 const (
+	SNMPv1  SNMPVersion = "1"
 	SNMPv2c SNMPVersion = "2c"
 	SNMPv3  SNMPVersion = "v3"
 )
@@ -39,9 +40,9 @@ type DeviceConfig struct {
 
 	// SNMPv3 fields
 	Username     string `json:"username,omitempty"`
-	AuthProtocol string `json:"auth_protocol,omitempty"` // MD5, SHA
+	AuthProtocol string `json:"auth_protocol,omitempty"` // MD5, SHA, SHA224, SHA256, SHA384, SHA512; empty for noAuthNoPriv
 	AuthPassword string `json:"auth_password,omitempty"`
-	PrivProtocol string `json:"priv_protocol,omitempty"` // DES, AES
+	PrivProtocol string `json:"priv_protocol,omitempty"` // DES, AES, AES192, AES256; empty for authNoPriv
 	PrivPassword string `json:"priv_password,omitempty"`
 
 	// Custom offline reason code overrides (merges with vendor defaults)
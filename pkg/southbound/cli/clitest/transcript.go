@@ -0,0 +1,240 @@
+// Package clitest replays recorded OLT CLI sessions so vendor drivers and
+// command handlers can be tested against real device output without SSH.
+//
+// A transcript is a text file of commands and the output the device printed
+// for each. Lines starting with ">>> " are commands; the lines that follow,
+// up to the next command, are its output. Lines starting with "#" before the
+// first command are comments. A command whose output is a single
+// "!!! <message>" line fails with that message, as a dropped session would.
+//
+//	# Huawei MA5800, ONT 0/1/1 5
+//	>>> enable
+//	>>> display ont info 0/1/1 5
+//	  F/S/P                   : 0/1/1
+//	  ONT-ID                  : 5
+//	...
+//
+// Enabling and disabling the pager are session setup and are not recorded.
+package clitest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+)
+
+const (
+	commandPrefix = ">>> "
+	failurePrefix = "!!! "
+)
+
+// Exchange is one command and the device's response.
+type Exchange struct {
+	Command string
+	Output  string
+	// Err is set when the command failed at the transport level.
+	Err string
+}
+
+// Transcript is a recorded CLI session.
+type Transcript struct {
+	Name      string
+	Exchanges []Exchange
+}
+
+// LoadTranscript reads a transcript file.
+func LoadTranscript(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	return ParseTranscript(filepath.Base(path), f)
+}
+
+// ParseTranscript parses a transcript from r.
+func ParseTranscript(name string, r io.Reader) (*Transcript, error) {
+	transcript := &Transcript{Name: name}
+
+	var current *Exchange
+	var output []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		text := strings.Join(output, "\n")
+		if len(output) == 1 && strings.HasPrefix(strings.TrimSpace(text), failurePrefix) {
+			current.Err = strings.TrimPrefix(strings.TrimSpace(text), failurePrefix)
+		} else {
+			current.Output = text
+		}
+		transcript.Exchanges = append(transcript.Exchanges, *current)
+		current, output = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasPrefix(line, commandPrefix) {
+			flush()
+			command := strings.TrimSpace(strings.TrimPrefix(line, commandPrefix))
+			if command == "" {
+				return nil, fmt.Errorf("%s:%d: empty command", name, lineNum)
+			}
+			current = &Exchange{Command: command}
+			continue
+		}
+
+		if current == nil {
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: output before the first command", name, lineNum)
+		}
+		output = append(output, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript %s: %w", name, err)
+	}
+	flush()
+
+	if len(transcript.Exchanges) == 0 {
+		return nil, fmt.Errorf("transcript %s has no commands", name)
+	}
+	return transcript, nil
+}
+
+// Replay plays a transcript back in order. Every session it dials shares the
+// same position, so one transcript can cover several driver connections.
+// A command that differs from the next recorded one fails and stops the
+// replay.
+type Replay struct {
+	mu         sync.Mutex
+	transcript *Transcript
+	next       int
+	err        error
+	dials      int
+}
+
+// NewReplay creates a replay of a transcript.
+func NewReplay(transcript *Transcript) *Replay {
+	return &Replay{transcript: transcript}
+}
+
+// Dial opens a session on the replay. It matches cli.CLIConfig.Dial.
+func (r *Replay) Dial(ctx context.Context, config cli.CLIConfig) (cli.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.dials++
+	return &replaySession{replay: r}, nil
+}
+
+// Dials returns the number of sessions opened.
+func (r *Replay) Dials() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dials
+}
+
+// Remaining returns the recorded commands that were not sent.
+func (r *Replay) Remaining() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var commands []string
+	for _, exchange := range r.transcript.Exchanges[r.next:] {
+		commands = append(commands, exchange.Command)
+	}
+	return commands
+}
+
+// Err returns the first mismatch, or an error listing recorded commands that
+// were never sent.
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	if r.next < len(r.transcript.Exchanges) {
+		return fmt.Errorf("transcript %s: %d recorded command(s) not sent, next %q",
+			r.transcript.Name, len(r.transcript.Exchanges)-r.next, r.transcript.Exchanges[r.next].Command)
+	}
+	return nil
+}
+
+// execute returns the recorded output for the next command.
+func (r *Replay) execute(command string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return "", r.err
+	}
+	if r.next >= len(r.transcript.Exchanges) {
+		r.err = fmt.Errorf("transcript %s: unexpected command %q after the end of the transcript",
+			r.transcript.Name, command)
+		return "", r.err
+	}
+
+	exchange := r.transcript.Exchanges[r.next]
+	if exchange.Command != strings.TrimSpace(command) {
+		r.err = fmt.Errorf("transcript %s: command %d: got %q, recorded %q",
+			r.transcript.Name, r.next+1, command, exchange.Command)
+		return "", r.err
+	}
+	r.next++
+
+	if exchange.Err != "" {
+		return "", fmt.Errorf("%s", exchange.Err)
+	}
+	// Recorded output goes through the same error detection as a live session
+	output := strings.TrimSpace(exchange.Output)
+	if err := cli.CheckCLIErrors(output); err != nil {
+		return output, err
+	}
+	return output, nil
+}
+
+// replaySession is a cli.Session backed by a Replay.
+type replaySession struct {
+	replay *Replay
+	closed bool
+}
+
+func (s *replaySession) Execute(command string) (string, error) {
+	if s.closed {
+		return "", fmt.Errorf("session closed")
+	}
+	return s.replay.execute(command)
+}
+
+func (s *replaySession) ExecuteEnableWithPassword(password string) (string, error) {
+	return s.Execute("enable")
+}
+
+func (s *replaySession) DisablePager() error {
+	return nil
+}
+
+func (s *replaySession) Close() error {
+	s.closed = true
+	return nil
+}
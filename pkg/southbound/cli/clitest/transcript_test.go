@@ -0,0 +1,101 @@
+package clitest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nanoncore/nano-agent/pkg/southbound/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `# comment
+>>> enable
+>>> show vlan all
+Created VLANs:
+            1   701   702
+>>> show onu info 9
+% Invalid input detected
+>>> show version
+!!! connection reset by peer
+`
+
+func TestParseTranscript(t *testing.T) {
+	transcript, err := ParseTranscript("sample", strings.NewReader(sample))
+	require.NoError(t, err)
+	require.Len(t, transcript.Exchanges, 4)
+
+	assert.Equal(t, Exchange{Command: "enable"}, transcript.Exchanges[0])
+	assert.Equal(t, "show vlan all", transcript.Exchanges[1].Command)
+	assert.Equal(t, "Created VLANs:\n            1   701   702", transcript.Exchanges[1].Output)
+	assert.Equal(t, "connection reset by peer", transcript.Exchanges[3].Err)
+
+	_, err = ParseTranscript("bad", strings.NewReader("output first\n>>> enable\n"))
+	assert.ErrorContains(t, err, "bad:1: output before the first command")
+
+	_, err = ParseTranscript("empty", strings.NewReader("# nothing\n"))
+	assert.ErrorContains(t, err, "has no commands")
+}
+
+func TestReplay(t *testing.T) {
+	transcript, err := ParseTranscript("sample", strings.NewReader(sample))
+	require.NoError(t, err)
+	replay := NewReplay(transcript)
+
+	driver := cli.NewBaseCLIDriver(cli.CLIConfig{Host: "olt", Dial: replay.Dial})
+	require.NoError(t, driver.Connect(context.Background()))
+	assert.True(t, driver.IsConnected())
+
+	_, err = driver.ExecuteEnableWithPassword(context.Background(), "secret")
+	require.NoError(t, err)
+	require.NoError(t, driver.DisablePager())
+
+	output, err := driver.Execute(context.Background(), "show vlan all")
+	require.NoError(t, err)
+	assert.Contains(t, output, "701")
+
+	_, err = driver.Execute(context.Background(), "show onu info 9")
+	assert.ErrorContains(t, err, "CLI error detected")
+
+	_, err = driver.Execute(context.Background(), "show version")
+	assert.ErrorContains(t, err, "connection reset by peer")
+
+	require.NoError(t, driver.Close())
+	assert.False(t, driver.IsConnected())
+	assert.NoError(t, replay.Err())
+	assert.Equal(t, 1, replay.Dials())
+}
+
+func TestReplay_Mismatch(t *testing.T) {
+	transcript, err := ParseTranscript("sample", strings.NewReader(sample))
+	require.NoError(t, err)
+	replay := NewReplay(transcript)
+
+	session, err := replay.Dial(context.Background(), cli.CLIConfig{})
+	require.NoError(t, err)
+
+	_, err = session.Execute("enable")
+	require.NoError(t, err)
+	_, err = session.Execute("show vlan 701")
+	assert.ErrorContains(t, err, `command 2: got "show vlan 701", recorded "show vlan all"`)
+
+	// The replay stays failed so the mismatch is reported at the end
+	_, err = session.Execute("show vlan all")
+	assert.Error(t, err)
+	assert.ErrorContains(t, replay.Err(), "show vlan 701")
+}
+
+func TestReplay_UnsentCommands(t *testing.T) {
+	transcript, err := ParseTranscript("sample", strings.NewReader(sample))
+	require.NoError(t, err)
+	replay := NewReplay(transcript)
+
+	session, err := replay.Dial(context.Background(), cli.CLIConfig{})
+	require.NoError(t, err)
+	_, err = session.Execute("enable")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"show vlan all", "show onu info 9", "show version"}, replay.Remaining())
+	assert.ErrorContains(t, replay.Err(), `3 recorded command(s) not sent, next "show vlan all"`)
+}
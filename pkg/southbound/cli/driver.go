@@ -150,12 +150,50 @@ type CLIDriver interface {
 	SaveConfig(ctx context.Context) error
 }
 
+// SerialReplacer is implemented by drivers that can re-bind an existing ONU
+// entry to a new serial number in place, keeping its ONU ID, profiles and
+// service ports.
+type SerialReplacer interface {
+	// ReplaceONUSerial changes the serial number bound to an ONU entry.
+	ReplaceONUSerial(ctx context.Context, ponPort string, onuID int, serial string) error
+}
+
+// ONUHealthReader is implemented by drivers that read an ONU's device health
+// and connectivity on their own, so diagnostics can report each section as
+// soon as it is read instead of waiting for GetONUDiagnostics.
+type ONUHealthReader interface {
+	// GetONUHealth retrieves device health information for an ONU.
+	GetONUHealth(ctx context.Context, ponPort string, onuID int) (*DeviceHealth, error)
+
+	// GetONUConnectivity retrieves connectivity information for an ONU.
+	GetONUConnectivity(ctx context.Context, ponPort string, onuID int) (*ConnectivityInfo, error)
+}
+
+// Session is an interactive CLI session on a device. ExpectSession implements
+// it over SSH; tests can dial sessions that replay recorded output instead
+// (see CLIConfig.Dial).
+type Session interface {
+	// Execute sends a command and returns its output.
+	Execute(command string) (string, error)
+	// ExecuteEnableWithPassword enters privileged mode, answering the
+	// password prompt if the device shows one.
+	ExecuteEnableWithPassword(password string) (string, error)
+	// DisablePager turns off output paging.
+	DisablePager() error
+	// Close ends the session.
+	Close() error
+}
+
 // BaseCLIDriver provides common SSH functionality for vendor drivers.
 type BaseCLIDriver struct {
-	config        CLIConfig
-	client        *ssh.Client
-	expectSession *ExpectSession
-	mu            sync.Mutex
+	config  CLIConfig
+	client  *ssh.Client
+	session Session
+	mu      sync.Mutex
+
+	// Dry-run state (see SetDryRun)
+	dryRun  bool
+	planned []PlannedCommand
 }
 
 // NewBaseCLIDriver creates a new base CLI driver.
@@ -176,10 +214,19 @@ func (d *BaseCLIDriver) Connect(ctx context.Context) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.client != nil {
+	if d.session != nil {
 		return nil // Already connected
 	}
 
+	if d.config.Dial != nil {
+		session, err := d.config.Dial(ctx, d.config)
+		if err != nil {
+			return fmt.Errorf("failed to open session to %s: %w", d.config.Host, err)
+		}
+		d.session = session
+		return nil
+	}
+
 	// Build auth methods based on vendor
 	// V-SOL OLTs have a non-compliant SSH implementation that sends
 	// SSH_MSG_USERAUTH_FAILURE (type 51) instead of SSH_MSG_USERAUTH_INFO_REQUEST
@@ -238,7 +285,7 @@ func (d *BaseCLIDriver) Connect(ctx context.Context) error {
 	}
 
 	d.client = client
-	d.expectSession = expectSession
+	d.session = expectSession
 
 	return nil
 }
@@ -250,11 +297,11 @@ func (d *BaseCLIDriver) Close() error {
 
 	var errs []error
 	// Close expect session first
-	if d.expectSession != nil {
-		if err := d.expectSession.Close(); err != nil {
+	if d.session != nil {
+		if err := d.session.Close(); err != nil {
 			errs = append(errs, err)
 		}
-		d.expectSession = nil
+		d.session = nil
 	}
 	// Then close SSH client
 	if d.client != nil {
@@ -275,12 +322,20 @@ func (d *BaseCLIDriver) Execute(ctx context.Context, cmd string) (string, error)
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.expectSession == nil {
+	if d.dryRun {
+		readOnly := IsReadOnlyCommand(cmd)
+		d.planned = append(d.planned, PlannedCommand{Command: cmd, Executed: readOnly})
+		if !readOnly {
+			return "", nil
+		}
+	}
+
+	if d.session == nil {
 		return "", fmt.Errorf("not connected")
 	}
 
 	// Use the interactive expect session to execute the command
-	output, err := d.expectSession.Execute(cmd)
+	output, err := d.session.Execute(cmd)
 	if err != nil {
 		return output, fmt.Errorf("command failed: %w", err)
 	}
@@ -293,11 +348,11 @@ func (d *BaseCLIDriver) Config() CLIConfig {
 	return d.config
 }
 
-// IsConnected returns true if the driver is connected with an active expect session.
+// IsConnected returns true if the driver has an active CLI session.
 func (d *BaseCLIDriver) IsConnected() bool {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	return d.client != nil && d.expectSession != nil
+	return d.session != nil
 }
 
 // ExecuteEnableWithPassword handles the enable command with password prompt.
@@ -306,11 +361,11 @@ func (d *BaseCLIDriver) ExecuteEnableWithPassword(ctx context.Context, password
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.expectSession == nil {
+	if d.session == nil {
 		return "", fmt.Errorf("not connected")
 	}
 
-	output, err := d.expectSession.ExecuteEnableWithPassword(password)
+	output, err := d.session.ExecuteEnableWithPassword(password)
 	if err != nil {
 		return output, fmt.Errorf("enable with password failed: %w", err)
 	}
@@ -324,9 +379,9 @@ func (d *BaseCLIDriver) DisablePager() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.expectSession == nil {
+	if d.session == nil {
 		return fmt.Errorf("not connected")
 	}
 
-	return d.expectSession.DisablePager()
+	return d.session.DisablePager()
 }
@@ -0,0 +1,76 @@
+package cli
+
+import "strings"
+
+// PlannedCommand is a CLI command captured while a driver is in dry-run mode.
+type PlannedCommand struct {
+	Command string `json:"command"`
+	// Executed is true for read-only and navigation commands, which still run
+	// so handlers can collect pre-state. Mutating commands are held back.
+	Executed bool `json:"executed"`
+}
+
+// DryRunner is implemented by drivers that can hold back mutating commands
+// and report the command sequence they would have sent.
+type DryRunner interface {
+	// SetDryRun enables or disables dry-run mode. Enabling it clears any
+	// previously planned commands.
+	SetDryRun(enabled bool)
+
+	// PlannedCommands returns the commands captured since dry-run was enabled.
+	PlannedCommands() []PlannedCommand
+}
+
+// readOnlyKeywords are leading keywords of commands that never change the
+// device configuration: show commands and CLI mode navigation.
+var readOnlyKeywords = map[string]bool{
+	"show":          true,
+	"display":       true,
+	"enable":        true,
+	"config":        true,
+	"configure":     true,
+	"interface":     true,
+	"quit":          true,
+	"exit":          true,
+	"end":           true,
+	"terminal":      true,
+	"screen-length": true,
+	"scroll":        true,
+}
+
+// IsReadOnlyCommand returns true if every line of cmd starts with a read-only
+// or navigation keyword. Empty commands are considered read-only.
+func IsReadOnlyCommand(cmd string) bool {
+	for _, line := range strings.Split(cmd, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !readOnlyKeywords[strings.ToLower(fields[0])] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetDryRun enables or disables dry-run mode on the driver.
+// While enabled, Execute records every command and only sends read-only ones.
+func (d *BaseCLIDriver) SetDryRun(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.dryRun = enabled
+	if enabled {
+		d.planned = nil
+	}
+}
+
+// PlannedCommands returns the commands captured since dry-run was enabled.
+func (d *BaseCLIDriver) PlannedCommands() []PlannedCommand {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	planned := make([]PlannedCommand, len(d.planned))
+	copy(planned, d.planned)
+	return planned
+}
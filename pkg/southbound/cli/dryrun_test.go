@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReadOnlyCommand(t *testing.T) {
+	tests := []struct {
+		cmd      string
+		readOnly bool
+	}{
+		{"display ont info 0 1", true},
+		{"show onu info all", true},
+		{"interface gpon 0/1\n quit", true},
+		{"", true},
+		{"ont add 1 5 sn-auth HWTC12345678 omci", false},
+		{"interface gpon 0/1\n ont deactivate 1 ont-id 5\n quit", false},
+		{"undo vlan 100", false},
+		{"save", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			assert.Equal(t, tt.readOnly, IsReadOnlyCommand(tt.cmd))
+		})
+	}
+}
+
+func TestBaseCLIDriverDryRun(t *testing.T) {
+	d := NewBaseCLIDriver(CLIConfig{Host: "127.0.0.1", Vendor: "huawei"})
+	d.SetDryRun(true)
+
+	// Mutating commands are held back and never need a session
+	out, err := d.Execute(context.Background(), "vlan 100 smart")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	// Read-only commands still go to the device
+	_, err = d.Execute(context.Background(), "display vlan all")
+	assert.Error(t, err)
+
+	assert.Equal(t, []PlannedCommand{
+		{Command: "vlan 100 smart", Executed: false},
+		{Command: "display vlan all", Executed: true},
+	}, d.PlannedCommands())
+
+	// Re-enabling clears the previous plan
+	d.SetDryRun(true)
+	assert.Empty(t, d.PlannedCommands())
+
+	d.SetDryRun(false)
+	_, err = d.Execute(context.Background(), "vlan 100 smart")
+	assert.Error(t, err)
+	assert.Empty(t, d.PlannedCommands())
+}
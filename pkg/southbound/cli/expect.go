@@ -215,6 +215,12 @@ func (s *ExpectSession) Execute(command string) (string, error) {
 
 // checkCLIErrors checks the output for common CLI error patterns
 func (s *ExpectSession) checkCLIErrors(output string) error {
+	return CheckCLIErrors(output)
+}
+
+// CheckCLIErrors returns an error if the output contains one of
+// CLIErrorPatterns.
+func CheckCLIErrors(output string) error {
 	outputLower := strings.ToLower(output)
 	for _, pattern := range CLIErrorPatterns {
 		if strings.Contains(outputLower, pattern) {
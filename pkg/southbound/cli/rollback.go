@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AppliedStep records a configuration step applied during a multi-step operation.
+type AppliedStep struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// Undo is the compensating command sequence. It is empty for steps that
+	// are removed together with an earlier step (e.g. ONT settings go away
+	// when the ONT itself is deleted).
+	Undo []string `json:"undo,omitempty"`
+}
+
+// PartialApplyError is returned when a multi-step operation fails after some
+// of its steps were applied to the device.
+type PartialApplyError struct {
+	Operation string
+	Applied   []AppliedStep
+	Err       error
+}
+
+// Error implements the error interface.
+func (e *PartialApplyError) Error() string {
+	return fmt.Sprintf("%s failed after %d applied steps: %v", e.Operation, len(e.Applied), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *PartialApplyError) Unwrap() error {
+	return e.Err
+}
+
+// AppliedSteps returns the steps applied before err occurred, or nil if err
+// does not carry them.
+func AppliedSteps(err error) []AppliedStep {
+	var partial *PartialApplyError
+	if errors.As(err, &partial) {
+		return partial.Applied
+	}
+	return nil
+}
+
+// RollbackResult reports the outcome of compensating actions.
+type RollbackResult struct {
+	Success bool           `json:"success"`
+	Steps   []RollbackStep `json:"steps"`
+}
+
+// RollbackStep reports the outcome of undoing a single applied step.
+type RollbackStep struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Rollback undoes applied steps in reverse order using exec.
+// A failed command aborts the rest of its step, but later steps are still
+// attempted so as much as possible is cleaned up.
+func Rollback(ctx context.Context, exec func(ctx context.Context, cmd string) (string, error), steps []AppliedStep) *RollbackResult {
+	result := &RollbackResult{Success: true, Steps: []RollbackStep{}}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if len(step.Undo) == 0 {
+			continue
+		}
+
+		rs := RollbackStep{Name: step.Name, Commands: step.Undo}
+		for _, cmd := range step.Undo {
+			if _, err := exec(ctx, cmd); err != nil {
+				rs.Error = fmt.Sprintf("%s: %v", cmd, err)
+				result.Success = false
+				break
+			}
+		}
+		result.Steps = append(result.Steps, rs)
+	}
+
+	return result
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppliedSteps(t *testing.T) {
+	steps := []AppliedStep{{Name: "ont_add", Command: "ont add 1 5 sn-auth HWTC12345678 omci"}}
+	partial := &PartialApplyError{Operation: "ONT add", Applied: steps, Err: errors.New("timeout")}
+
+	assert.Equal(t, steps, AppliedSteps(partial))
+	assert.Equal(t, steps, AppliedSteps(fmt.Errorf("failed to provision ONU: %w", partial)))
+	assert.Nil(t, AppliedSteps(errors.New("plain error")))
+	assert.Contains(t, partial.Error(), "ONT add failed after 1 applied steps")
+}
+
+func TestRollback(t *testing.T) {
+	steps := []AppliedStep{
+		{Name: "onu_add", Undo: []string{"interface gpon 0/1", "no onu 5", "exit"}},
+		{Name: "line_profile"},
+		{Name: "service_port", Undo: []string{"interface gpon 0/1", "no onu 5 service-port 1", "exit"}},
+	}
+
+	t.Run("undoes steps in reverse order", func(t *testing.T) {
+		var executed []string
+		exec := func(ctx context.Context, cmd string) (string, error) {
+			executed = append(executed, cmd)
+			return "", nil
+		}
+
+		result := Rollback(context.Background(), exec, steps)
+		require.True(t, result.Success)
+		require.Len(t, result.Steps, 2)
+		assert.Equal(t, "service_port", result.Steps[0].Name)
+		assert.Equal(t, "onu_add", result.Steps[1].Name)
+		assert.Equal(t, []string{
+			"interface gpon 0/1", "no onu 5 service-port 1", "exit",
+			"interface gpon 0/1", "no onu 5", "exit",
+		}, executed)
+	})
+
+	t.Run("continues after a failed step", func(t *testing.T) {
+		var executed []string
+		exec := func(ctx context.Context, cmd string) (string, error) {
+			executed = append(executed, cmd)
+			if cmd == "no onu 5 service-port 1" {
+				return "", errors.New("command failed")
+			}
+			return "", nil
+		}
+
+		result := Rollback(context.Background(), exec, steps)
+		assert.False(t, result.Success)
+		require.Len(t, result.Steps, 2)
+		assert.Contains(t, result.Steps[0].Error, "command failed")
+		assert.Empty(t, result.Steps[1].Error)
+		// The failed step's trailing "exit" is skipped, the next step still runs
+		assert.Equal(t, []string{
+			"interface gpon 0/1", "no onu 5 service-port 1",
+			"interface gpon 0/1", "no onu 5", "exit",
+		}, executed)
+	})
+}
@@ -1,6 +1,9 @@
 package cli
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // CLIConfig holds SSH connection configuration.
 type CLIConfig struct {
@@ -11,6 +14,10 @@ type CLIConfig struct {
 	PrivateKeyPath string        `json:"private_key_path,omitempty"`
 	Timeout        time.Duration `json:"timeout"`
 	Vendor         string        `json:"vendor"`
+
+	// Dial opens the CLI session instead of connecting over SSH. Tests use
+	// it to replay recorded device output (see package clitest).
+	Dial func(ctx context.Context, config CLIConfig) (Session, error) `json:"-"`
 }
 
 // ONUProvisionRequest contains parameters for adding an ONU.
@@ -48,6 +55,7 @@ type ONUCLIInfo struct {
 	Description    string    `json:"description,omitempty"`
 	LineProfile    string    `json:"line_profile,omitempty"`
 	ServiceProfile string    `json:"service_profile,omitempty"`
+	ONUProfile     string    `json:"onu_profile,omitempty"` // V-SOL hardware profile
 	Distance       int       `json:"distance,omitempty"`
 	RxPower        float64   `json:"rx_power,omitempty"`
 	LastOnline     time.Time `json:"last_online,omitempty"`
@@ -114,13 +114,30 @@ func (d *HuaweiCLIDriver) AddONU(ctx context.Context, req *cli.ONUProvisionReque
 		return fmt.Errorf("ONT add failed: %s", output)
 	}
 
+	// Record applied steps so callers can roll back a partial provision.
+	// Deleting the ONT also removes its port settings.
+	applied := []cli.AppliedStep{{
+		Name:    "ont_add",
+		Command: cmd,
+		Undo: []string{
+			fmt.Sprintf("interface gpon %s", req.PonPort),
+			fmt.Sprintf("ont delete %d %d", portNum, req.OnuID),
+			"quit",
+		},
+	}}
+	partial := func(err error) error {
+		_, _ = d.Execute(ctx, "quit") // Leave the interface before rollback
+		return &cli.PartialApplyError{Operation: "ONT add", Applied: applied, Err: err}
+	}
+
 	// Configure service ports if specified
 	for _, sp := range req.ServicePorts {
 		cmd = fmt.Sprintf("ont port native-vlan %d eth %d vlan %d",
 			req.OnuID, sp.Index, sp.VLAN)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to configure service port: %w", err)
+			return partial(fmt.Errorf("failed to configure service port: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "native_vlan", Command: cmd})
 	}
 
 	// Exit interface
@@ -163,6 +180,43 @@ func (d *HuaweiCLIDriver) DeleteONU(ctx context.Context, ponPort string, onuID i
 	return nil
 }
 
+// ReplaceONUSerial re-binds an ONT to a new serial number in place, keeping
+// its ONT ID, profiles and service ports.
+func (d *HuaweiCLIDriver) ReplaceONUSerial(ctx context.Context, ponPort string, onuID int, serial string) error {
+	if serial == "" {
+		return fmt.Errorf("serial number is required")
+	}
+
+	// Extract port number from PonPort (e.g., "0/0/1" -> 1)
+	portNum, err := extractPortNumber(ponPort)
+	if err != nil {
+		return fmt.Errorf("invalid PON port format: %w", err)
+	}
+
+	cmd := fmt.Sprintf("interface gpon %s", ponPort)
+	if _, err := d.Execute(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to enter interface: %w", err)
+	}
+
+	// ont modify {port} {ont_id} sn {serial}
+	cmd = fmt.Sprintf("ont modify %d %d sn %s", portNum, onuID, serial)
+	output, err := d.Execute(ctx, cmd)
+	if err == nil && (strings.Contains(strings.ToLower(output), "error") ||
+		strings.Contains(strings.ToLower(output), "failure")) {
+		err = fmt.Errorf("%s", output)
+	}
+	if err != nil {
+		_, _ = d.Execute(ctx, "quit") // Leave the interface before returning
+		return fmt.Errorf("failed to modify ONT serial: %w", err)
+	}
+
+	if _, err := d.Execute(ctx, "quit"); err != nil {
+		return fmt.Errorf("failed to exit interface: %w", err)
+	}
+
+	return nil
+}
+
 // GetONUInfo retrieves ONT information via CLI.
 func (d *HuaweiCLIDriver) GetONUInfo(ctx context.Context, ponPort string, onuID int) (*cli.ONUCLIInfo, error) {
 	cmd := fmt.Sprintf("display ont info %s %d", ponPort, onuID)
@@ -781,24 +835,53 @@ func (d *HuaweiCLIDriver) GetONUDiagnostics(ctx context.Context, ponPort string,
 	}
 
 	// Get device health
-	cmd := fmt.Sprintf("display ont version 0/%s %d", ponPort, onuID)
-	output, err := d.Execute(ctx, cmd)
+	health, err := d.GetONUHealth(ctx, ponPort, onuID)
 	if err == nil {
-		// Parse firmware version
-		fwRegex := regexp.MustCompile(`(?i)software[- ]version\s*:\s*(\S+)`)
-		if matches := fwRegex.FindStringSubmatch(output); len(matches) > 1 {
-			diag.Health.FirmwareVer = matches[1]
-		}
-		// Parse hardware version
-		hwRegex := regexp.MustCompile(`(?i)hardware[- ]version\s*:\s*(\S+)`)
-		if matches := hwRegex.FindStringSubmatch(output); len(matches) > 1 {
-			diag.Health.HardwareVer = matches[1]
-		}
+		diag.Health = *health
 	}
 
 	return diag, nil
 }
 
+// GetONUHealth retrieves the firmware and hardware versions of an ONT.
+func (d *HuaweiCLIDriver) GetONUHealth(ctx context.Context, ponPort string, onuID int) (*cli.DeviceHealth, error) {
+	cmd := fmt.Sprintf("display ont version 0/%s %d", ponPort, onuID)
+	output, err := d.Execute(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ONT version: %w", err)
+	}
+
+	health := &cli.DeviceHealth{}
+
+	// Parse firmware version
+	fwRegex := regexp.MustCompile(`(?i)software[- ]version\s*:\s*(\S+)`)
+	if matches := fwRegex.FindStringSubmatch(output); len(matches) > 1 {
+		health.FirmwareVer = matches[1]
+	}
+	// Parse hardware version
+	hwRegex := regexp.MustCompile(`(?i)hardware[- ]version\s*:\s*(\S+)`)
+	if matches := hwRegex.FindStringSubmatch(output); len(matches) > 1 {
+		health.HardwareVer = matches[1]
+	}
+
+	return health, nil
+}
+
+// GetONUConnectivity retrieves the distance and offline reason of an ONT.
+func (d *HuaweiCLIDriver) GetONUConnectivity(ctx context.Context, ponPort string, onuID int) (*cli.ConnectivityInfo, error) {
+	info, err := d.GetONUInfo(ctx, ponPort, onuID)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, fmt.Errorf("ONT %s %d not found", ponPort, onuID)
+	}
+	return &cli.ConnectivityInfo{
+		Distance:      info.Distance,
+		OfflineReason: info.OfflineReason,
+	}, nil
+}
+
 // GetONUCounters retrieves performance counters for an ONT.
 func (d *HuaweiCLIDriver) GetONUCounters(ctx context.Context, ponPort string, onuID int) (*cli.PerformanceCounters, error) {
 	cmd := fmt.Sprintf("display ont traffic 0/%s %d", ponPort, onuID)
@@ -184,3 +184,10 @@ func TestDriverNotConnected(t *testing.T) {
 		t.Error("IsConnected() = true, want false for new driver")
 	}
 }
+
+func TestHuaweiReplacesSerialInPlace(t *testing.T) {
+	var driver cli.CLIDriver = NewHuaweiCLIDriver(cli.CLIConfig{Host: "192.168.1.1"})
+	if _, ok := driver.(cli.SerialReplacer); !ok {
+		t.Fatal("HuaweiCLIDriver should implement cli.SerialReplacer")
+	}
+}
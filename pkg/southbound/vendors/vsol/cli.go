@@ -91,6 +91,7 @@ func (d *VSOLCLIDriver) AddONU(ctx context.Context, req *cli.ONUProvisionRequest
 
 	var output string
 	var err error
+	onuIDKnown := true
 
 	// If ONU ID is 0 or not specified, use "onu confirm" to auto-provision from auto-find
 	// NAN-241: Profiles are optional when using onu confirm (auto-provision)
@@ -116,6 +117,7 @@ func (d *VSOLCLIDriver) AddONU(ctx context.Context, req *cli.ONUProvisionRequest
 		if req.OnuID <= 0 {
 			// Default to 1 if we can't determine
 			req.OnuID = 1
+			onuIDKnown = false
 		}
 	} else {
 		// Use explicit ONU ID with onu add command (requires profile)
@@ -139,20 +141,38 @@ func (d *VSOLCLIDriver) AddONU(ctx context.Context, req *cli.ONUProvisionRequest
 		return fmt.Errorf("ONU add failed: %s", output)
 	}
 
+	// Record applied steps so callers can roll back a partial provision.
+	// Deleting the ONU also removes its profile, TCONT, GEM and service ports.
+	// A guessed ONU ID is never deleted, as it may belong to another ONU.
+	applied := []cli.AppliedStep{{Name: "onu_add", Command: cmd}}
+	if onuIDKnown {
+		applied[0].Undo = []string{
+			fmt.Sprintf("interface gpon %s", req.PonPort),
+			fmt.Sprintf("no onu %d", req.OnuID),
+			"exit",
+		}
+	}
+	partial := func(err error) error {
+		_, _ = d.Execute(ctx, "exit") // Leave the interface before rollback
+		return &cli.PartialApplyError{Operation: "ONU add", Applied: applied, Err: err}
+	}
+
 	// Bind line profile if provided (V-SOL two-tier profile system)
 	if req.LineProfile != "" {
 		cmd = fmt.Sprintf("onu %d profile line name %s", req.OnuID, req.LineProfile)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to set line profile: %w", err)
+			return partial(fmt.Errorf("failed to set line profile: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "line_profile", Command: cmd})
 	}
 
 	// Configure description if provided
 	if req.Description != "" {
 		cmd = fmt.Sprintf("onu %d description %s", req.OnuID, req.Description)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to set description: %w", err)
+			return partial(fmt.Errorf("failed to set description: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "description", Command: cmd})
 	}
 
 	// Configure native VLAN if specified
@@ -162,34 +182,39 @@ func (d *VSOLCLIDriver) AddONU(ctx context.Context, req *cli.ONUProvisionRequest
 		// Create TCONT (traffic container)
 		cmd = fmt.Sprintf("onu %d tcont 1", req.OnuID)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to create TCONT: %w", err)
+			return partial(fmt.Errorf("failed to create TCONT: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "tcont", Command: cmd})
 
 		// Create GEMPORT linked to TCONT
 		cmd = fmt.Sprintf("onu %d gemport 1 tcont 1", req.OnuID)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to create GEMPORT: %w", err)
+			return partial(fmt.Errorf("failed to create GEMPORT: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "gemport", Command: cmd})
 
 		// Create service-port with VLAN (this populates SNMP VLAN table)
 		cmd = fmt.Sprintf("onu %d service-port 1 gemport 1 uservlan %d vlan %d new_cos 0", req.OnuID, req.NativeVLAN, req.NativeVLAN)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to configure service-port VLAN: %w", err)
+			return partial(fmt.Errorf("failed to configure service-port VLAN: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "service_port_vlan", Command: cmd})
 
 		// Also configure port VLAN for traffic tagging
 		cmd = fmt.Sprintf("onu %d portvlan eth 1 mode tag vlan %d", req.OnuID, req.NativeVLAN)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to configure port VLAN: %w", err)
+			return partial(fmt.Errorf("failed to configure port VLAN: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "port_vlan", Command: cmd})
 	}
 
 	// Configure service ports (for more complex VLAN setups)
 	for _, sp := range req.ServicePorts {
 		cmd = fmt.Sprintf("onu %d service-port %d vlan %d", req.OnuID, sp.Index, sp.VLAN)
 		if _, err := d.Execute(ctx, cmd); err != nil {
-			return fmt.Errorf("failed to configure service port: %w", err)
+			return partial(fmt.Errorf("failed to configure service port: %w", err))
 		}
+		applied = append(applied, cli.AppliedStep{Name: "service_port", Command: cmd})
 	}
 
 	// Exit interface
@@ -411,6 +436,12 @@ func parseVSOLONUInfo(output string, ponPort string, onuID int) (*cli.ONUCLIInfo
 		info.Type = matches[1]
 	}
 
+	// Parse ONU hardware profile; anchored so line/service profiles don't match
+	profileRegex := regexp.MustCompile(`(?im)^\s*(?:ONU\s*)?Profile(?:\s*name)?\s*:[ \t]*(\S+)`)
+	if matches := profileRegex.FindStringSubmatch(output); len(matches) > 1 {
+		info.ONUProfile = matches[1]
+	}
+
 	// Parse distance
 	distRegex := regexp.MustCompile(`(?i)Distance\s*:[\s\x00-\x1F]*(\d+)`)
 	if matches := distRegex.FindStringSubmatch(output); len(matches) > 1 {
@@ -1363,13 +1394,13 @@ func (d *VSOLCLIDriver) GetONUDiagnostics(ctx context.Context, ponPort string, o
 	}
 
 	// Get health info
-	health, err := d.getONUHealth(ctx, ponPort, onuID)
+	health, err := d.GetONUHealth(ctx, ponPort, onuID)
 	if err == nil {
 		diag.Health = *health
 	}
 
 	// Get connectivity info
-	connectivity, err := d.getONUConnectivity(ctx, ponPort, onuID)
+	connectivity, err := d.GetONUConnectivity(ctx, ponPort, onuID)
 	if err == nil {
 		diag.Connectivity = *connectivity
 	}
@@ -1377,8 +1408,8 @@ func (d *VSOLCLIDriver) GetONUDiagnostics(ctx context.Context, ponPort string, o
 	return diag, nil
 }
 
-// getONUHealth retrieves ONU health information.
-func (d *VSOLCLIDriver) getONUHealth(ctx context.Context, ponPort string, onuID int) (*cli.DeviceHealth, error) {
+// GetONUHealth retrieves ONU health information.
+func (d *VSOLCLIDriver) GetONUHealth(ctx context.Context, ponPort string, onuID int) (*cli.DeviceHealth, error) {
 	// Enter GPON interface first
 	cmd := fmt.Sprintf("interface gpon %s", ponPort)
 	if _, err := d.Execute(ctx, cmd); err != nil {
@@ -1433,8 +1464,8 @@ func (d *VSOLCLIDriver) getONUHealth(ctx context.Context, ponPort string, onuID
 	return health, nil
 }
 
-// getONUConnectivity retrieves ONU connectivity information.
-func (d *VSOLCLIDriver) getONUConnectivity(ctx context.Context, ponPort string, onuID int) (*cli.ConnectivityInfo, error) {
+// GetONUConnectivity retrieves ONU connectivity information.
+func (d *VSOLCLIDriver) GetONUConnectivity(ctx context.Context, ponPort string, onuID int) (*cli.ConnectivityInfo, error) {
 	// Enter GPON interface first
 	cmd := fmt.Sprintf("interface gpon %s", ponPort)
 	if _, err := d.Execute(ctx, cmd); err != nil {
@@ -41,12 +41,15 @@ ONU Information:
   Distance      : 2000
   RX Power      : -23.5
   Description   : Home-User-1
+  Line Profile  : line_vlan_100
+  ONU Profile   : AN5506-04-F1
 `,
 			want: &cli.ONUCLIInfo{
 				SerialNumber: "VSOL12345678",
 				MAC:          "aa:bb:cc:dd:ee:ff",
 				Status:       "online",
 				Type:         "V2801",
+				ONUProfile:   "AN5506-04-F1",
 				Distance:     2000,
 				RxPower:      -23.5,
 				Description:  "Home-User-1",
@@ -84,6 +87,9 @@ ONU Information:
 			if got.Type != tt.want.Type {
 				t.Errorf("Type = %s, want %s", got.Type, tt.want.Type)
 			}
+			if got.ONUProfile != tt.want.ONUProfile {
+				t.Errorf("ONUProfile = %s, want %s", got.ONUProfile, tt.want.ONUProfile)
+			}
 		})
 	}
 }